@@ -0,0 +1,262 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openark/golib/log"
+)
+
+// Components logging under their own tag, whose log level can be set apart from the general log level
+const (
+	ApplierLogComponent   = "applier"
+	InspectorLogComponent = "inspector"
+	MigratorLogComponent  = "migrator"
+	ServerLogComponent    = "server"
+	StreamerLogComponent  = "streamer"
+	ThrottlerLogComponent = "throttler"
+)
+
+var LogComponents = []string{
+	ApplierLogComponent,
+	InspectorLogComponent,
+	MigratorLogComponent,
+	ServerLogComponent,
+	StreamerLogComponent,
+	ThrottlerLogComponent,
+}
+
+// logRepeatSummaryInterval is how often a message that keeps repeating is logged again, along with the
+// number of times it repeated
+const logRepeatSummaryInterval = time.Minute
+
+// LogLevels holds the general log level, and the levels of components overriding it
+type LogLevels struct {
+	mutex      *sync.Mutex
+	general    log.LogLevel
+	components map[string]log.LogLevel
+}
+
+func NewLogLevels() *LogLevels {
+	return &LogLevels{
+		mutex:      &sync.Mutex{},
+		general:    log.INFO,
+		components: make(map[string]log.LogLevel),
+	}
+}
+
+func (this *LogLevels) setGeneral(level log.LogLevel) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.general = level
+}
+
+// set overrides the general log level for given component; a nil level removes the override
+func (this *LogLevels) set(component string, level *log.LogLevel) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if level == nil {
+		delete(this.components, component)
+	} else {
+		this.components[component] = *level
+	}
+}
+
+// enabled returns whether given component logs messages of given level
+func (this *LogLevels) enabled(component string, level log.LogLevel) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if componentLevel, ok := this.components[component]; ok {
+		return level <= componentLevel
+	}
+	return level <= this.general
+}
+
+// mostVerbose returns the most verbose of all levels, which the underlying logger filters by
+func (this *LogLevels) mostVerbose() log.LogLevel {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	level := this.general
+	for _, componentLevel := range this.components {
+		level = max(level, componentLevel)
+	}
+	return level
+}
+
+// applyTo has the logger underlying given logger filter by the most verbose of all levels, leaving
+// filtering by component to component loggers
+func (this *LogLevels) applyTo(logger Logger) {
+	if componentLogger, ok := logger.(*componentLogger); ok {
+		logger = componentLogger.parent
+	}
+	logger.SetLevel(this.mostVerbose())
+}
+
+// String returns the general level followed by component overrides, e.g. "general=info,applier=debug"
+func (this *LogLevels) String() string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	levels := []string{fmt.Sprintf("general=%s", strings.ToLower(this.general.String()))}
+	components := make([]string, 0, len(this.components))
+	for component := range this.components {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		levels = append(levels, fmt.Sprintf("%s=%s", component, strings.ToLower(this.components[component].String())))
+	}
+	return strings.Join(levels, ",")
+}
+
+// componentLogger tags messages with a component name, filters them by the component's log level, and
+// suppresses consecutive repeats of a message. A message that keeps repeating is logged again once per
+// logRepeatSummaryInterval, and a message following repeats is preceded by their count.
+type componentLogger struct {
+	parent    Logger
+	component string
+	levels    *LogLevels
+
+	mutex        *sync.Mutex
+	lastLevel    log.LogLevel
+	lastMessage  string
+	lastLoggedAt time.Time
+	repeats      int64
+}
+
+func newComponentLogger(parent Logger, component string, levels *LogLevels) *componentLogger {
+	return &componentLogger{
+		parent:    parent,
+		component: component,
+		levels:    levels,
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (this *componentLogger) tag(message string) string {
+	if this.component == "" {
+		return message
+	}
+	return fmt.Sprintf("[%s] %s", this.component, message)
+}
+
+// shouldLog returns whether a message is to be logged, and logs the count of repeats of the previous one
+func (this *componentLogger) shouldLog(level log.LogLevel, message string) bool {
+	if !this.levels.enabled(this.component, level) {
+		return false
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if level == this.lastLevel && message == this.lastMessage && time.Since(this.lastLoggedAt) < logRepeatSummaryInterval {
+		this.repeats++
+		return false
+	}
+	if this.repeats > 0 {
+		this.logAt(this.lastLevel, fmt.Sprintf("last message repeated %d times", this.repeats))
+	}
+	this.lastLevel = level
+	this.lastMessage = message
+	this.lastLoggedAt = time.Now()
+	this.repeats = 0
+	return true
+}
+
+func (this *componentLogger) logAt(level log.LogLevel, message string) error {
+	message = this.tag(message)
+	switch level {
+	case log.DEBUG:
+		this.parent.Debugf("%s", message)
+	case log.INFO, log.NOTICE:
+		this.parent.Infof("%s", message)
+	case log.WARNING:
+		return this.parent.Warningf("%s", message)
+	default:
+		return this.parent.Errorf("%s", message)
+	}
+	return nil
+}
+
+// logError logs given message unless suppressed, and returns it as an error either way
+func (this *componentLogger) logError(level log.LogLevel, message string) error {
+	if !this.shouldLog(level, message) {
+		return errors.New(this.tag(message))
+	}
+	return this.logAt(level, message)
+}
+
+func (this *componentLogger) Debug(args ...interface{}) {
+	this.Debugf("%s", fmt.Sprint(args...))
+}
+
+func (this *componentLogger) Debugf(format string, args ...interface{}) {
+	if message := fmt.Sprintf(format, args...); this.shouldLog(log.DEBUG, message) {
+		this.logAt(log.DEBUG, message)
+	}
+}
+
+func (this *componentLogger) Info(args ...interface{}) {
+	this.Infof("%s", fmt.Sprint(args...))
+}
+
+func (this *componentLogger) Infof(format string, args ...interface{}) {
+	if message := fmt.Sprintf(format, args...); this.shouldLog(log.INFO, message) {
+		this.logAt(log.INFO, message)
+	}
+}
+
+func (this *componentLogger) Warning(args ...interface{}) error {
+	return this.Warningf("%s", fmt.Sprint(args...))
+}
+
+func (this *componentLogger) Warningf(format string, args ...interface{}) error {
+	return this.logError(log.WARNING, fmt.Sprintf(format, args...))
+}
+
+func (this *componentLogger) Error(args ...interface{}) error {
+	return this.Errorf("%s", fmt.Sprint(args...))
+}
+
+func (this *componentLogger) Errorf(format string, args ...interface{}) error {
+	return this.logError(log.ERROR, fmt.Sprintf(format, args...))
+}
+
+func (this *componentLogger) Errore(err error) error {
+	if err == nil {
+		return nil
+	}
+	if this.shouldLog(log.ERROR, err.Error()) {
+		this.parent.Errore(fmt.Errorf("%s", this.tag(fmt.Sprintf("%+v", err))))
+	}
+	return err
+}
+
+func (this *componentLogger) Fatal(args ...interface{}) error {
+	return this.parent.Fatalf("%s", this.tag(fmt.Sprint(args...)))
+}
+
+func (this *componentLogger) Fatalf(format string, args ...interface{}) error {
+	return this.parent.Fatalf("%s", this.tag(fmt.Sprintf(format, args...)))
+}
+
+func (this *componentLogger) Fatale(err error) error {
+	return this.parent.Fatalf("%s", this.tag(fmt.Sprintf("%+v", err)))
+}
+
+// SetLevel sets the general log level
+func (this *componentLogger) SetLevel(level log.LogLevel) {
+	this.levels.setGeneral(level)
+	this.levels.applyTo(this.parent)
+}
+
+func (this *componentLogger) SetPrintStackTrace(printStackTraceFlag bool) {
+	this.parent.SetPrintStackTrace(printStackTraceFlag)
+}
@@ -0,0 +1,139 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openark/golib/log"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger records the messages it is given, and the level it is set to
+type recordingLogger struct {
+	level    log.LogLevel
+	messages []string
+}
+
+func (this *recordingLogger) record(level string, message string) error {
+	this.messages = append(this.messages, fmt.Sprintf("%s: %s", level, message))
+	return fmt.Errorf("%s", message)
+}
+
+func (this *recordingLogger) Debug(args ...interface{}) { this.record("DEBUG", fmt.Sprint(args...)) }
+func (this *recordingLogger) Debugf(format string, args ...interface{}) {
+	this.record("DEBUG", fmt.Sprintf(format, args...))
+}
+func (this *recordingLogger) Info(args ...interface{}) { this.record("INFO", fmt.Sprint(args...)) }
+func (this *recordingLogger) Infof(format string, args ...interface{}) {
+	this.record("INFO", fmt.Sprintf(format, args...))
+}
+func (this *recordingLogger) Warning(args ...interface{}) error {
+	return this.record("WARNING", fmt.Sprint(args...))
+}
+func (this *recordingLogger) Warningf(format string, args ...interface{}) error {
+	return this.record("WARNING", fmt.Sprintf(format, args...))
+}
+func (this *recordingLogger) Error(args ...interface{}) error {
+	return this.record("ERROR", fmt.Sprint(args...))
+}
+func (this *recordingLogger) Errorf(format string, args ...interface{}) error {
+	return this.record("ERROR", fmt.Sprintf(format, args...))
+}
+func (this *recordingLogger) Errore(err error) error { return this.record("ERROR", err.Error()) }
+func (this *recordingLogger) Fatal(args ...interface{}) error {
+	return this.record("FATAL", fmt.Sprint(args...))
+}
+func (this *recordingLogger) Fatalf(format string, args ...interface{}) error {
+	return this.record("FATAL", fmt.Sprintf(format, args...))
+}
+func (this *recordingLogger) Fatale(err error) error      { return this.record("FATAL", err.Error()) }
+func (this *recordingLogger) SetLevel(level log.LogLevel) { this.level = level }
+func (this *recordingLogger) SetPrintStackTrace(bool)     {}
+
+func newRecordingContext() (*MigrationContext, *recordingLogger) {
+	migrationContext := NewMigrationContext()
+	recorder := &recordingLogger{}
+	migrationContext.Log = newComponentLogger(recorder, "", migrationContext.LogLevels)
+	return migrationContext, recorder
+}
+
+func TestComponentLoggerLevels(t *testing.T) {
+	migrationContext, recorder := newRecordingContext()
+	applierLog := migrationContext.NewComponentLogger(ApplierLogComponent)
+	throttlerLog := migrationContext.NewComponentLogger(ThrottlerLogComponent)
+
+	migrationContext.Log.SetLevel(log.INFO)
+	require.Equal(t, log.INFO, recorder.level)
+	migrationContext.Log.Infof("general %d", 1)
+	applierLog.Debugf("applier %d", 1)
+	applierLog.Infof("applier %d", 2)
+	throttlerLog.Warning("throttler 1")
+	require.Equal(t, []string{
+		"INFO: general 1",
+		"INFO: [applier] applier 2",
+		"WARNING: [throttler] throttler 1",
+	}, recorder.messages)
+
+	require.NoError(t, migrationContext.SetComponentLogLevels("applier=debug, throttler=error"))
+	require.Equal(t, log.DEBUG, recorder.level)
+	require.Equal(t, "general=info,applier=debug,throttler=error", migrationContext.LogLevels.String())
+	recorder.messages = nil
+	migrationContext.Log.Debugf("general %d", 2)
+	applierLog.Debugf("applier %d", 3)
+	throttlerLog.Warning("throttler 2")
+	require.Error(t, throttlerLog.Errorf("throttler %d", 3))
+	require.Equal(t, []string{
+		"DEBUG: [applier] applier 3",
+		"ERROR: [throttler] throttler 3",
+	}, recorder.messages)
+
+	require.NoError(t, migrationContext.SetComponentLogLevels("applier=default"))
+	require.Equal(t, log.INFO, recorder.level)
+	require.Equal(t, "general=info,throttler=error", migrationContext.LogLevels.String())
+}
+
+func TestComponentLoggerRepeats(t *testing.T) {
+	migrationContext, recorder := newRecordingContext()
+	throttlerLog := newComponentLogger(recorder, ThrottlerLogComponent, migrationContext.LogLevels)
+
+	for i := 0; i < 5; i++ {
+		err := throttlerLog.Warningf("throttled by %s", "max-load")
+		require.EqualError(t, err, "[throttler] throttled by max-load")
+	}
+	throttlerLog.Info("resumed")
+	throttlerLog.Info("resumed")
+	require.Equal(t, []string{
+		"WARNING: [throttler] throttled by max-load",
+		"WARNING: [throttler] last message repeated 4 times",
+		"INFO: [throttler] resumed",
+	}, recorder.messages)
+
+	// a repeating message is logged again once the summary interval elapses
+	throttlerLog.lastLoggedAt = time.Now().Add(-logRepeatSummaryInterval)
+	throttlerLog.Info("resumed")
+	require.Equal(t, []string{
+		"INFO: [throttler] last message repeated 1 times",
+		"INFO: [throttler] resumed",
+	}, recorder.messages[3:])
+}
+
+func TestSetComponentLogLevels(t *testing.T) {
+	migrationContext, _ := newRecordingContext()
+	require.NoError(t, migrationContext.SetComponentLogLevels(""))
+	require.Equal(t, "general=info", migrationContext.LogLevels.String())
+
+	require.ErrorContains(t, migrationContext.SetComponentLogLevels("applier"), "expected component=level")
+	require.ErrorContains(t, migrationContext.SetComponentLogLevels("copier=debug"), `Unknown log component "copier"`)
+	require.Error(t, migrationContext.SetComponentLogLevels("applier=debug,streamer=chatty"))
+	// nothing is applied when any of the levels is invalid
+	require.Equal(t, "general=info", migrationContext.LogLevels.String())
+
+	require.NoError(t, migrationContext.SetComponentLogLevels("streamer=Warning"))
+	require.Equal(t, "general=info,streamer=warning", migrationContext.LogLevels.String())
+}
@@ -10,6 +10,7 @@ import (
 	"math"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -50,13 +51,24 @@ const (
 )
 
 const (
-	HTTPStatusOK       = 200
-	MaxEventsBatchSize = 1000
-	ETAUnknown         = math.MinInt64
+	// PasswordEnvVariable and MasterPasswordEnvVariable are read when no password is given on the command line
+	PasswordEnvVariable       = "GH_OST_PASSWORD"
+	MasterPasswordEnvVariable = "GH_OST_MASTER_PASSWORD"
+)
+
+const (
+	HTTPStatusOK          = 200
+	MinChunkSize          = 10
+	MaxChunkSize          = 100000
+	MaxEventsBatchSize    = 1000
+	MaxApplierParallelism = 64
+	ETAUnknown            = math.MinInt64
 )
 
 var (
 	envVariableRegexp = regexp.MustCompile("[$][{](.*)[}]")
+	// timeZoneOffsetRegexp matches a time_zone given as an offset from UTC, e.g. +05:30
+	timeZoneOffsetRegexp = regexp.MustCompile(`^[+-]([0-9]{2}):([0-9]{2})$`)
 )
 
 type ThrottleCheckResult struct {
@@ -73,6 +85,27 @@ func NewThrottleCheckResult(throttle bool, reason string, reasonHint ThrottleRea
 	}
 }
 
+// throttleState is an immutable snapshot of the current throttle decision,
+// replaced as a whole whenever the throttler re-evaluates
+type throttleState struct {
+	throttled  bool
+	reason     string
+	reasonHint ThrottleReasonHint
+}
+
+// heartbeatHistorySize is the number of most recent heartbeats remembered for measuring replication lag;
+// at the minimal heartbeat interval it covers over 100 seconds
+const heartbeatHistorySize = 1024
+
+// queuedEventsHistorySize bounds the number of binlog events queued and not yet applied: a full apply queue,
+// a batch dequeued from it and in flight, and one event about to be queued.
+const queuedEventsHistorySize = 2*MaxEventsBatchSize + 1
+
+type heartbeatInjection struct {
+	sequence   int64
+	injectedAt time.Time
+}
+
 // MigrationContext has the general, global state of migration. It is used by
 // all components throughout the migration process.
 type MigrationContext struct {
@@ -80,6 +113,7 @@ type MigrationContext struct {
 
 	DatabaseName          string
 	OriginalTableName     string
+	GhostDatabaseName     string
 	AlterStatement        string
 	AlterStatementOptions string // anything following the 'ALTER TABLE [schema.]table' from AlterStatement
 
@@ -95,6 +129,9 @@ type MigrationContext struct {
 	SkipStrictMode           bool
 	AllowZeroInDate          bool
 	NullableUniqueKeyAllowed bool
+	AllowTempIndex           bool
+	TempIndexMaxRows         int64
+	AllowLossyMigration      bool
 	ApproveRenamedColumns    bool
 	SkipRenamedColumns       bool
 	IsTungsten               bool
@@ -102,10 +139,16 @@ type MigrationContext struct {
 	AliyunRDS                bool
 	GoogleCloudPlatform      bool
 	AzureMySQL               bool
+	ProxyCompat              bool
+	connectionPortTrusted    int64
 	AttemptInstantDDL        bool
+	AttemptInplaceIndexDDL   bool
 	Resume                   bool
 	Revert                   bool
 	OldTableName             string
+	Cleanup                  bool
+	Observe                  bool
+	ObserveDuration          time.Duration
 
 	// SkipPortValidation allows skipping the port validation in `ValidateConnection`
 	// This is useful when connecting to a MySQL instance where the external port
@@ -129,7 +172,10 @@ type MigrationContext struct {
 	HeartbeatIntervalMilliseconds       int64
 	defaultNumRetries                   int64
 	ChunkSize                           int64
-	niceRatio                           float64
+	ChunkIndex                          string
+	QueryMaxExecutionTimeMillis         int64
+	chunkCopyOptimizerHints             string
+	niceRatioBits                       uint64
 	MaxLagMillisecondsThrottleThreshold int64
 	throttleControlReplicaKeys          *mysql.InstanceKeyMap
 	ThrottleFlagFile                    string
@@ -150,22 +196,41 @@ type MigrationContext struct {
 	ForceNamedCutOverCommand            bool
 	ForceNamedPanicCommand              bool
 	PanicFlagFile                       string
+	SummaryFile                         string
+	ReportFile                          string
+	CommandLineFlags                    map[string]string
 	HooksPath                           string
 	HooksHintMessage                    string
 	HooksHintOwner                      string
 	HooksHintToken                      string
 	HooksStatusIntervalSec              int64
 	PanicOnWarnings                     bool
+	CopyConflictStrategy                sql.CopyConflictStrategy
 	Checkpoint                          bool
 	CheckpointIntervalSeconds           int64
-
-	DropServeSocket bool
-	ServeSocketFile string
-	ServeTCPPort    int64
+	StallTimeoutSeconds                 int64
+	AbortOnStall                        bool
+	maxRuntime                          int64
+	MaxApplierLag                       time.Duration
+	MaxRuntimeAction                    string
+	WarmUpSeconds                       int64
+	VerifyRowCountThreshold             int64
+	MaxConcurrentMigrations             int64
+	CoordinationTable                   string
+	CoordinationStaleSeconds            int64
+	MaxBacklogMemoryMB                  int64
+	MaxMemoryMB                         int64
+
+	DropServeSocket     bool
+	ServeSocketFile     string
+	ServeTCPPort        int64
+	StatusListenAddress string
 
 	Noop                         bool
 	TestOnReplica                bool
 	MigrateOnReplica             bool
+	ReplicaPromotionPlanFile     string
+	AwaitReplicaPromotion        bool
 	TestOnReplicaSkipReplicaStop bool
 	OkToDropTable                bool
 	InitiallyDropOldTable        bool
@@ -174,8 +239,13 @@ type MigrationContext struct {
 	CutOverType                  CutOver
 	ReplicaServerId              uint
 
-	Hostname                               string
-	AssumeMasterHostname                   string
+	Hostname             string
+	AssumeMasterHostname string
+	BinlogHostname       string
+	// ProxyProbeQuery is run through the proxy, with --proxy-compat, to read the backend's binlog_format
+	ProxyProbeQuery string
+	// TimeZone is the session time_zone of all connections, via --time-zone; empty for the servers' defaults
+	TimeZone                               string
 	ApplierTimeZone                        string
 	ApplierWaitTimeout                     int64
 	TableEngine                            string
@@ -189,6 +259,7 @@ type MigrationContext struct {
 	InspectorMySQLVersion                  string
 	ApplierConnectionConfig                *mysql.ConnectionConfig
 	ApplierMySQLVersion                    string
+	BinlogConnectionConfig                 *mysql.ConnectionConfig
 	StartTime                              time.Time
 	RowCopyStartTime                       time.Time
 	RowCopyEndTime                         time.Time
@@ -199,6 +270,19 @@ type MigrationContext struct {
 	pointOfInterestTimeMutex               *sync.Mutex
 	lastHeartbeatOnChangelogTime           time.Time
 	lastHeartbeatOnChangelogMutex          *sync.Mutex
+	heartbeatMutex                         *sync.Mutex
+	heartbeatSequence                      int64
+	heartbeatHistory                       [heartbeatHistorySize]heartbeatInjection
+	lastRowCopyProgressNano                int64
+	lastDMLApplyProgressNano               int64
+	queuedEventNanos                       [queuedEventsHistorySize]int64
+	queuedEventsCount                      int64
+	appliedEventsCount                     int64
+	Stats                                  *MigrationStats
+	binlogClockSkewNano                    int64
+	applierLagHighWaterMarkNano            int64
+	binlogCatchUpNano                      int64
+	postponedCutOverNano                   int64
 	CurrentLag                             int64
 	currentProgress                        uint64
 	etaNanoseonds                          int64
@@ -210,17 +294,27 @@ type MigrationContext struct {
 	TotalRowsCopied                        int64
 	TotalDMLEventsApplied                  int64
 	DMLBatchSize                           int64
-	isThrottled                            bool
-	throttleReason                         string
-	throttleReasonHint                     ThrottleReasonHint
-	throttleGeneralCheckResult             ThrottleCheckResult
+	ApplierParallelism                     int64
+	applierParallelismRestriction          atomic.Pointer[string]
+	throttleState                          atomic.Pointer[throttleState]
+	throttleGeneralCheckResult             atomic.Pointer[ThrottleCheckResult]
 	throttleMutex                          *sync.Mutex
 	throttleHTTPMutex                      *sync.Mutex
 	IsPostponingCutOver                    int64
+	IsQueuedFlag                           int64
 	CountingRowsFlag                       int64
 	AllEventsUpToLockProcessedInjectedFlag int64
 	CleanupImminentFlag                    int64
 	UserCommandedUnpostponeFlag            int64
+	UserCommandedSkipWarmUpFlag            int64
+	IsAwaitingReplicaPromotionFlag         int64
+	UserCommandedPromoteFlag               int64
+	IsWarmingUpFlag                        int64
+	IsRunningInplaceIndexDDLFlag           int64
+	IsBackpressuredFlag                    int64
+	BacklogMemoryBytes                     int64
+	WarmUpIndexesTotal                     int64
+	WarmUpIndexesDone                      int64
 	CutOverCompleteFlag                    int64
 	InCutOverCriticalSectionFlag           int64
 	PanicAbort                             chan error
@@ -230,6 +324,7 @@ type MigrationContext struct {
 	OriginalTableVirtualColumns      *sql.ColumnList
 	OriginalTableUniqueKeys          [](*sql.UniqueKey)
 	OriginalTableAutoIncrement       uint64
+	OriginalTablePartitioning        *sql.TablePartitioning
 	GhostTableColumns                *sql.ColumnList
 	GhostTableVirtualColumns         *sql.ColumnList
 	GhostTableUniqueKeys             [](*sql.UniqueKey)
@@ -237,15 +332,27 @@ type MigrationContext struct {
 	SharedColumns                    *sql.ColumnList
 	ColumnRenameMap                  map[string]string
 	DroppedColumnsMap                map[string]bool
+	ModifiedColumnsMap               map[string]bool
+	IgnoredColumnsMap                map[string]bool
+	ColumnTransforms                 []*sql.ColumnTransform
 	MappedSharedColumns              *sql.ColumnList
 	MigrationLastInsertSQLWarnings   []string
+	NarrowedColumnsFindings          []string
 	MigrationRangeMinValues          *sql.ColumnValues
 	MigrationRangeMaxValues          *sql.ColumnValues
 	Iteration                        int64
 	MigrationIterationRangeMinValues *sql.ColumnValues
 	MigrationIterationRangeMaxValues *sql.ColumnValues
+	IterationPartitions              []string
+	IterationPartitionIndex          int
 	InitialStreamerCoords            mysql.BinlogCoordinates
-	ForceTmpTableName                string
+	// Binary log coordinates at migration start, row-copy completion and cut-over, reported upon success
+	StartBinlogCoordinates           mysql.BinlogCoordinates
+	RowCopyCompleteBinlogCoordinates mysql.BinlogCoordinates
+	CutOverBinlogCoordinates         mysql.BinlogCoordinates
+	// Statement left for the user to issue after a successful migration, when gh-ost failed to, reported upon success
+	PendingCleanupStatement string
+	ForceTmpTableName       string
 
 	IncludeTriggers     bool
 	RemoveTriggerSuffix bool
@@ -259,7 +366,8 @@ type MigrationContext struct {
 	SkipMetadataLockCheck             bool
 	IsOpenMetadataLockInstruments     bool
 
-	Log Logger
+	Log       Logger
+	LogLevels *LogLevels
 }
 
 type Logger interface {
@@ -293,8 +401,10 @@ type ContextConfig struct {
 }
 
 func NewMigrationContext() *MigrationContext {
+	logLevels := NewLogLevels()
 	return &MigrationContext{
 		Uuid:                                uuid.NewString(),
+		Stats:                               NewMigrationStats(),
 		defaultNumRetries:                   60,
 		ChunkSize:                           1000,
 		InspectorConnectionConfig:           mysql.NewConnectionConfig(),
@@ -302,6 +412,8 @@ func NewMigrationContext() *MigrationContext {
 		MaxLagMillisecondsThrottleThreshold: 1500,
 		CutOverLockTimeoutSeconds:           3,
 		DMLBatchSize:                        10,
+		ApplierParallelism:                  1,
+		CopyConflictStrategy:                sql.CopyConflictIgnore,
 		etaNanoseonds:                       ETAUnknown,
 		maxLoad:                             NewLoadMap(),
 		criticalLoad:                        NewLoadMap(),
@@ -311,9 +423,12 @@ func NewMigrationContext() *MigrationContext {
 		configMutex:                         &sync.Mutex{},
 		pointOfInterestTimeMutex:            &sync.Mutex{},
 		lastHeartbeatOnChangelogMutex:       &sync.Mutex{},
+		heartbeatMutex:                      &sync.Mutex{},
 		ColumnRenameMap:                     make(map[string]string),
+		IgnoredColumnsMap:                   make(map[string]bool),
 		PanicAbort:                          make(chan error),
-		Log:                                 NewDefaultLogger(),
+		LogLevels:                           logLevels,
+		Log:                                 newComponentLogger(NewDefaultLogger(), "", logLevels),
 	}
 }
 
@@ -339,6 +454,27 @@ func (this *MigrationContext) SetConnectionCharset(charset string) {
 	this.ApplierConnectionConfig.Charset = charset
 }
 
+// SetConnectionTimeZone sets the session time_zone of all connections, unless empty. Given either as
+// an offset from UTC, within MySQL's -13:59 to +14:00 range, or as a named time zone, which the servers
+// validate upon connecting; see ValidateTimeZone.
+func (this *MigrationContext) SetConnectionTimeZone(timeZone string) error {
+	if timeZone == "" {
+		return nil
+	}
+	if submatch := timeZoneOffsetRegexp.FindStringSubmatch(timeZone); len(submatch) > 0 {
+		offset := submatch[1] + submatch[2]
+		if submatch[2] >= "60" || (timeZone[0] == '+' && offset > "1400") || (timeZone[0] == '-' && offset > "1359") {
+			return fmt.Errorf("--time-zone=%s: offset out of range, expecting -13:59 to +14:00", timeZone)
+		}
+	} else if strings.ContainsAny(timeZone[:1], "+-0123456789") {
+		return fmt.Errorf("--time-zone=%s: expecting an offset such as +05:30, or a named time zone such as UTC", timeZone)
+	}
+	this.TimeZone = timeZone
+	this.InspectorConnectionConfig.TimeZone = timeZone
+	this.ApplierConnectionConfig.TimeZone = timeZone
+	return nil
+}
+
 func getSafeTableName(baseName string, suffix string) string {
 	name := fmt.Sprintf("_%s_%s", baseName, suffix)
 	if len(name) <= mysql.MaxTableNameLength {
@@ -362,6 +498,33 @@ func (this *MigrationContext) GetGhostTableName() string {
 	}
 }
 
+// GetGhostDatabaseName returns the schema the ghost table lives in: --ghost-database, or else the migrated schema.
+// When reverting, the "ghost" table is the _del table, which lives in the migrated schema.
+func (this *MigrationContext) GetGhostDatabaseName() string {
+	if this.GhostDatabaseName == "" || this.Revert {
+		return this.DatabaseName
+	}
+	return this.GhostDatabaseName
+}
+
+// GetFullRowUniqueKey returns the full row key a table without PRIMARY nor UNIQUE key is migrated by
+// (see --allow-temp-index), or nil
+func (this *MigrationContext) GetFullRowUniqueKey() *sql.UniqueKey {
+	if len(this.OriginalTableUniqueKeys) == 1 && this.OriginalTableUniqueKeys[0].IsFullRow {
+		return this.OriginalTableUniqueKeys[0]
+	}
+	return nil
+}
+
+// GetChangelogDatabaseName returns the schema the changelog and checkpoint tables live in: --ghost-database,
+// or else the migrated schema
+func (this *MigrationContext) GetChangelogDatabaseName() string {
+	if this.GhostDatabaseName == "" {
+		return this.DatabaseName
+	}
+	return this.GhostDatabaseName
+}
+
 // GetOldTableName generates the name of the "old" table, into which the original table is renamed.
 func (this *MigrationContext) GetOldTableName() string {
 	var tableName string
@@ -404,6 +567,15 @@ func (this *MigrationContext) GetCheckpointTableName() string {
 	}
 }
 
+// GetCoordinationTable returns the schema and name of the table concurrent migrations register on.
+// An unqualified --coordination-table lives in the migrated schema.
+func (this *MigrationContext) GetCoordinationTable() (databaseName, tableName string) {
+	if databaseName, tableName, found := strings.Cut(this.CoordinationTable, "."); found {
+		return databaseName, tableName
+	}
+	return this.DatabaseName, this.CoordinationTable
+}
+
 // GetVoluntaryLockName returns a name of a voluntary lock to be used throughout
 // the swap-tables process.
 func (this *MigrationContext) GetVoluntaryLockName() string {
@@ -437,6 +609,15 @@ func (this *MigrationContext) GetInspectorHostname() string {
 	return this.InspectorConnectionConfig.ImpliedKey.Hostname
 }
 
+// GetBinlogConnectionConfig returns the connection config of the server whose binary logs are streamed:
+// the --binlog-host when given, otherwise the inspected server.
+func (this *MigrationContext) GetBinlogConnectionConfig() *mysql.ConnectionConfig {
+	if this.BinlogConnectionConfig != nil {
+		return this.BinlogConnectionConfig
+	}
+	return this.InspectorConnectionConfig
+}
+
 // InspectorIsAlsoApplier is `true` when the both inspector and applier are the
 // same database instance. This would be true when running directly on master or when
 // testing on replica.
@@ -444,12 +625,47 @@ func (this *MigrationContext) InspectorIsAlsoApplier() bool {
 	return this.InspectorConnectionConfig.Equals(this.ApplierConnectionConfig)
 }
 
+// MarkConnectionPortTrusted notes a server does not report the port connected to, as on some managed platforms,
+// and is to be identified by the connection port
+func (this *MigrationContext) MarkConnectionPortTrusted() {
+	atomic.StoreInt64(&this.connectionPortTrusted, 1)
+}
+
+// TrustsConnectionPort is `true` when servers cannot be identified by the port they report,
+// and are rather identified by the port connected to
+func (this *MigrationContext) TrustsConnectionPort() bool {
+	return this.ProxyCompat || atomic.LoadInt64(&this.connectionPortTrusted) > 0
+}
+
+// GetManagedPlatformFlag returns the managed platform flag given, if any. Such a flag does not change what
+// gh-ost detects of the servers, but acknowledges a server reporting a port other than the connection port.
+func (this *MigrationContext) GetManagedPlatformFlag() string {
+	switch {
+	case this.AliyunRDS:
+		return "--aliyun-rds"
+	case this.GoogleCloudPlatform:
+		return "--gcp"
+	case this.AzureMySQL:
+		return "--azure"
+	}
+	return ""
+}
+
 // HasMigrationRange tells us whether there's a range to iterate for copying rows.
 // It will be `false` if the table is initially empty
 func (this *MigrationContext) HasMigrationRange() bool {
 	return this.MigrationRangeMinValues != nil && this.MigrationRangeMaxValues != nil
 }
 
+// GetIterationPartition returns the partition currently iterated by rowcopy, or an empty
+// string when rowcopy does not iterate partition by partition
+func (this *MigrationContext) GetIterationPartition() string {
+	if this.IterationPartitionIndex < len(this.IterationPartitions) {
+		return this.IterationPartitions[this.IterationPartitionIndex]
+	}
+	return ""
+}
+
 func (this *MigrationContext) SetCutOverLockTimeoutSeconds(timeoutSeconds int64) error {
 	if timeoutSeconds < 1 {
 		return fmt.Errorf("Minimal timeout is 1sec. Timeout remains at %d", this.CutOverLockTimeoutSeconds)
@@ -470,18 +686,13 @@ func (this *MigrationContext) SetExponentialBackoffMaxInterval(intervalSeconds i
 }
 
 func (this *MigrationContext) SetDefaultNumRetries(retries int64) {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
 	if retries > 0 {
-		this.defaultNumRetries = retries
+		atomic.StoreInt64(&this.defaultNumRetries, retries)
 	}
 }
 
 func (this *MigrationContext) MaxRetries() int64 {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-	retries := this.defaultNumRetries
-	return retries
+	return atomic.LoadInt64(&this.defaultNumRetries)
 }
 
 func (this *MigrationContext) IsTransactionalTable() bool {
@@ -640,6 +851,206 @@ func (this *MigrationContext) GetLastHeartbeatOnChangelogTime() time.Time {
 	return this.lastHeartbeatOnChangelogTime
 }
 
+// NewComponentLogger returns a logger for given component, see LogComponents. It logs through this
+// context's Log, tagging messages with the component name and filtering them by the component's log level.
+func (this *MigrationContext) NewComponentLogger(component string) Logger {
+	parent := this.Log
+	if logger, ok := parent.(*componentLogger); ok {
+		parent = logger.parent
+	}
+	return newComponentLogger(parent, component, this.LogLevels)
+}
+
+// SetComponentLogLevels overrides the general log level for components, given as a comma separated list
+// of component=level, e.g. "throttler=warning,applier=debug". A level of "default" removes a component's
+// override, making it follow the general log level again.
+func (this *MigrationContext) SetComponentLogLevels(componentLevels string) error {
+	type componentLevel struct {
+		component string
+		level     *log.LogLevel
+	}
+	var parsed []componentLevel
+	for _, token := range strings.Split(componentLevels, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		component, levelName, ok := strings.Cut(token, "=")
+		if !ok {
+			return fmt.Errorf("Invalid log level %q: expected component=level", token)
+		}
+		component = strings.TrimSpace(component)
+		if !slices.Contains(LogComponents, component) {
+			return fmt.Errorf("Unknown log component %q; known components are: %s", component, strings.Join(LogComponents, ", "))
+		}
+		levelName = strings.TrimSpace(levelName)
+		if strings.EqualFold(levelName, "default") {
+			parsed = append(parsed, componentLevel{component: component})
+			continue
+		}
+		level, err := log.LogLevelFromString(strings.ToUpper(levelName))
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, componentLevel{component: component, level: &level})
+	}
+	for _, componentLevel := range parsed {
+		this.LogLevels.set(componentLevel.component, componentLevel.level)
+	}
+	this.LogLevels.applyTo(this.Log)
+	return nil
+}
+
+// NextHeartbeatSequence returns the sequence number of the next heartbeat to write onto the changelog table
+func (this *MigrationContext) NextHeartbeatSequence() int64 {
+	this.heartbeatMutex.Lock()
+	defer this.heartbeatMutex.Unlock()
+
+	this.heartbeatSequence++
+	return this.heartbeatSequence
+}
+
+// MarkHeartbeatInjected records a heartbeat was written onto the changelog table, with the time it carries
+func (this *MigrationContext) MarkHeartbeatInjected(sequence int64, injectedAt time.Time) {
+	this.heartbeatMutex.Lock()
+	defer this.heartbeatMutex.Unlock()
+
+	this.heartbeatHistory[sequence%heartbeatHistorySize] = heartbeatInjection{sequence: sequence, injectedAt: injectedAt}
+}
+
+// getRecentHeartbeatInjection returns the injection of given heartbeat, if it is one of the recent heartbeats
+// of this process. Heartbeats are told apart by their time, too, as a previous process may have used the
+// same sequence numbers.
+func (this *MigrationContext) getRecentHeartbeatInjection(sequence int64, heartbeatTime time.Time) (injection heartbeatInjection, found bool) {
+	injection = this.heartbeatHistory[sequence%heartbeatHistorySize]
+	return injection, sequence > 0 && injection.sequence == sequence && injection.injectedAt.Equal(heartbeatTime)
+}
+
+// GetHeartbeatInjectionTime returns the time given heartbeat was injected at, as read on the monotonic
+// clock for recent heartbeats of this process, and as written otherwise.
+func (this *MigrationContext) GetHeartbeatInjectionTime(sequence int64, heartbeatTime time.Time) time.Time {
+	this.heartbeatMutex.Lock()
+	defer this.heartbeatMutex.Unlock()
+
+	if injection, found := this.getRecentHeartbeatInjection(sequence, heartbeatTime); found {
+		return injection.injectedAt
+	}
+	return heartbeatTime
+}
+
+// GetHeartbeatLag returns the replication lag of a server whose latest heartbeat is the given one: the
+// time since that heartbeat was injected. This is an upper bound of the lag, exceeding it by up to a
+// heartbeat interval. Lag is read on the monotonic clock; for heartbeats other than recent heartbeats of
+// this process, it is the time since the given heartbeat was written.
+func (this *MigrationContext) GetHeartbeatLag(sequence int64, heartbeatTime time.Time) time.Duration {
+	return time.Since(this.GetHeartbeatInjectionTime(sequence, heartbeatTime))
+}
+
+// MarkRowCopyProgress records a chunk of rows has just been copied
+func (this *MigrationContext) MarkRowCopyProgress() {
+	atomic.StoreInt64(&this.lastRowCopyProgressNano, time.Now().UnixNano())
+}
+
+// GetLastRowCopyProgressTime returns the time a chunk was last copied, or zero time if none was
+func (this *MigrationContext) GetLastRowCopyProgressTime() time.Time {
+	return unixNanoToTime(atomic.LoadInt64(&this.lastRowCopyProgressNano))
+}
+
+// MarkDMLApplyProgress records a batch of DML events has just been applied
+func (this *MigrationContext) MarkDMLApplyProgress() {
+	atomic.StoreInt64(&this.lastDMLApplyProgressNano, time.Now().UnixNano())
+}
+
+// GetLastDMLApplyProgressTime returns the time DML events were last applied, or zero time if none were
+func (this *MigrationContext) GetLastDMLApplyProgressTime() time.Time {
+	return unixNanoToTime(atomic.LoadInt64(&this.lastDMLApplyProgressNano))
+}
+
+// SetBinlogClockSkew records how far ahead of gh-ost's clock binlog event timestamps are
+func (this *MigrationContext) SetBinlogClockSkew(skew time.Duration) {
+	atomic.StoreInt64(&this.binlogClockSkewNano, int64(skew))
+}
+
+func (this *MigrationContext) GetBinlogClockSkew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.binlogClockSkewNano))
+}
+
+// MarkBinlogEventQueued records the timestamp, translated to gh-ost's clock, of a binlog event queued for
+// applying. Events are queued by a single goroutine, and are applied in the order they were queued.
+func (this *MigrationContext) MarkBinlogEventQueued(eventTimestamp time.Time) {
+	var eventNano int64
+	if !eventTimestamp.IsZero() {
+		eventNano = eventTimestamp.Add(-this.GetBinlogClockSkew()).UnixNano()
+	}
+	queued := atomic.LoadInt64(&this.queuedEventsCount)
+	atomic.StoreInt64(&this.queuedEventNanos[queued%queuedEventsHistorySize], eventNano)
+	atomic.StoreInt64(&this.queuedEventsCount, queued+1)
+}
+
+// MarkBinlogEventsApplied records the given number of queued binlog events as applied
+func (this *MigrationContext) MarkBinlogEventsApplied(count int64) {
+	atomic.AddInt64(&this.appliedEventsCount, count)
+}
+
+// GetOldestUnappliedEventTime returns the timestamp, by gh-ost's clock, of the oldest binlog event queued
+// and not yet applied, or zero time if there is none
+func (this *MigrationContext) GetOldestUnappliedEventTime() time.Time {
+	applied := atomic.LoadInt64(&this.appliedEventsCount)
+	if applied >= atomic.LoadInt64(&this.queuedEventsCount) {
+		return time.Time{}
+	}
+	return unixNanoToTime(atomic.LoadInt64(&this.queuedEventNanos[applied%queuedEventsHistorySize]))
+}
+
+// RecordApplierLag updates the applier lag high-water mark
+func (this *MigrationContext) RecordApplierLag(lag time.Duration) {
+	for {
+		highWaterMark := atomic.LoadInt64(&this.applierLagHighWaterMarkNano)
+		if int64(lag) <= highWaterMark || atomic.CompareAndSwapInt64(&this.applierLagHighWaterMarkNano, highWaterMark, int64(lag)) {
+			return
+		}
+	}
+}
+
+// GetApplierLagHighWaterMark returns the highest applier lag seen throughout the migration
+func (this *MigrationContext) GetApplierLagHighWaterMark() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.applierLagHighWaterMarkNano))
+}
+
+// AddBinlogCatchUpTime counts time cut-over waited for gh-ost to catch up with the binary logs
+func (this *MigrationContext) AddBinlogCatchUpTime(duration time.Duration) {
+	atomic.AddInt64(&this.binlogCatchUpNano, int64(duration))
+}
+
+func (this *MigrationContext) GetBinlogCatchUpTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.binlogCatchUpNano))
+}
+
+// AddPostponedCutOverTime counts time cut-over was postponed, by flag file or --max-runtime
+func (this *MigrationContext) AddPostponedCutOverTime(duration time.Duration) {
+	atomic.AddInt64(&this.postponedCutOverNano, int64(duration))
+}
+
+func (this *MigrationContext) GetPostponedCutOverTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.postponedCutOverNano))
+}
+
+// GetCutOverLockDuration returns how long the most recent cut-over attempt locked the original table for,
+// until tables were renamed, or 0 if that attempt did not rename tables
+func (this *MigrationContext) GetCutOverLockDuration() time.Duration {
+	if this.LockTablesStartTime.IsZero() || this.RenameTablesEndTime.Before(this.LockTablesStartTime) {
+		return 0
+	}
+	return this.RenameTablesEndTime.Sub(this.LockTablesStartTime)
+}
+
+func unixNanoToTime(nano int64) time.Time {
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nano)
+}
+
 func (this *MigrationContext) SetHeartbeatIntervalMilliseconds(heartbeatIntervalMilliseconds int64) {
 	if heartbeatIntervalMilliseconds < 100 {
 		heartbeatIntervalMilliseconds = 100
@@ -657,12 +1068,40 @@ func (this *MigrationContext) SetMaxLagMillisecondsThrottleThreshold(maxLagMilli
 	atomic.StoreInt64(&this.MaxLagMillisecondsThrottleThreshold, maxLagMillisecondsThrottleThreshold)
 }
 
+// SetMaxRuntime sets the migration deadline, relative to the migration start time. 0 disables the deadline.
+func (this *MigrationContext) SetMaxRuntime(maxRuntime time.Duration) {
+	atomic.StoreInt64(&this.maxRuntime, int64(maxRuntime))
+}
+
+func (this *MigrationContext) GetMaxRuntime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.maxRuntime))
+}
+
+// GetRuntimeRemaining returns the time left until the --max-runtime deadline; negative once
+// the deadline has passed. ok is false when there is no deadline.
+func (this *MigrationContext) GetRuntimeRemaining() (remaining time.Duration, ok bool) {
+	maxRuntime := this.GetMaxRuntime()
+	if maxRuntime <= 0 {
+		return 0, false
+	}
+	if this.StartTime.IsZero() {
+		return maxRuntime, true
+	}
+	return maxRuntime - this.ElapsedTime(), true
+}
+
+// IsPastDeadline returns true when the --max-runtime deadline has passed
+func (this *MigrationContext) IsPastDeadline() bool {
+	remaining, ok := this.GetRuntimeRemaining()
+	return ok && remaining <= 0
+}
+
 func (this *MigrationContext) SetChunkSize(chunkSize int64) {
-	if chunkSize < 10 {
-		chunkSize = 10
+	if chunkSize < MinChunkSize {
+		chunkSize = MinChunkSize
 	}
-	if chunkSize > 100000 {
-		chunkSize = 100000
+	if chunkSize > MaxChunkSize {
+		chunkSize = MaxChunkSize
 	}
 	atomic.StoreInt64(&this.ChunkSize, chunkSize)
 }
@@ -677,32 +1116,63 @@ func (this *MigrationContext) SetDMLBatchSize(batchSize int64) {
 	atomic.StoreInt64(&this.DMLBatchSize, batchSize)
 }
 
+// GetApplierParallelism returns the number of applier workers applying binlog events
+func (this *MigrationContext) GetApplierParallelism() int64 {
+	return atomic.LoadInt64(&this.ApplierParallelism)
+}
+
+func (this *MigrationContext) SetApplierParallelism(parallelism int64) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > MaxApplierParallelism {
+		parallelism = MaxApplierParallelism
+	}
+	atomic.StoreInt64(&this.ApplierParallelism, parallelism)
+}
+
+// RestrictApplierParallelism sets a single applier worker, which may then not be changed, for the given reason
+func (this *MigrationContext) RestrictApplierParallelism(reason string) {
+	this.applierParallelismRestriction.Store(&reason)
+	this.SetApplierParallelism(1)
+}
+
+// GetApplierParallelismRestriction returns the reason applier parallelism is restricted, if any
+func (this *MigrationContext) GetApplierParallelismRestriction() string {
+	if reason := this.applierParallelismRestriction.Load(); reason != nil {
+		return *reason
+	}
+	return ""
+}
+
+// SetThrottleGeneralCheckResult publishes the result of the throttler's general metrics checks.
+// The result is stored as a copy, so that readers never see a partial update.
 func (this *MigrationContext) SetThrottleGeneralCheckResult(checkResult *ThrottleCheckResult) *ThrottleCheckResult {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-	this.throttleGeneralCheckResult = *checkResult
+	result := *checkResult
+	this.throttleGeneralCheckResult.Store(&result)
 	return checkResult
 }
 
+// GetThrottleGeneralCheckResult returns a copy of the most recently published general check result
 func (this *MigrationContext) GetThrottleGeneralCheckResult() *ThrottleCheckResult {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-	result := this.throttleGeneralCheckResult
-	return &result
+	if checkResult := this.throttleGeneralCheckResult.Load(); checkResult != nil {
+		result := *checkResult
+		return &result
+	}
+	return &ThrottleCheckResult{}
 }
 
 func (this *MigrationContext) SetThrottled(throttle bool, reason string, reasonHint ThrottleReasonHint) {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-	this.isThrottled = throttle
-	this.throttleReason = reason
-	this.throttleReasonHint = reasonHint
+	this.throttleState.Store(&throttleState{
+		throttled:  throttle,
+		reason:     reason,
+		reasonHint: reasonHint,
+	})
 }
 
+// IsThrottled is called on every chunk and every DML batch, and therefore reads
+// a lock-free snapshot of the throttle state rather than taking throttleMutex
 func (this *MigrationContext) IsThrottled() (bool, string, ThrottleReasonHint) {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-
 	// we don't throttle when cutting over. We _do_ throttle:
 	// - during copy phase
 	// - just before cut-over
@@ -712,7 +1182,11 @@ func (this *MigrationContext) IsThrottled() (bool, string, ThrottleReasonHint) {
 	if atomic.LoadInt64(&this.InCutOverCriticalSectionFlag) > 0 {
 		return false, "critical section", NoThrottleReasonHint
 	}
-	return this.isThrottled, this.throttleReason, this.throttleReasonHint
+	state := this.throttleState.Load()
+	if state == nil {
+		return false, "", NoThrottleReasonHint
+	}
+	return state.throttled, state.reason, state.reasonHint
 }
 
 func (this *MigrationContext) GetThrottleQuery() string {
@@ -767,10 +1241,7 @@ func (this *MigrationContext) GetCriticalLoad() LoadMap {
 }
 
 func (this *MigrationContext) GetNiceRatio() float64 {
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-
-	return this.niceRatio
+	return math.Float64frombits(atomic.LoadUint64(&this.niceRatioBits))
 }
 
 func (this *MigrationContext) SetNiceRatio(newRatio float64) {
@@ -781,9 +1252,7 @@ func (this *MigrationContext) SetNiceRatio(newRatio float64) {
 		newRatio = 100.0
 	}
 
-	this.throttleMutex.Lock()
-	defer this.throttleMutex.Unlock()
-	this.niceRatio = newRatio
+	atomic.StoreUint64(&this.niceRatioBits, math.Float64bits(newRatio))
 }
 
 func (this *MigrationContext) GetRecentBinlogCoordinates() mysql.BinlogCoordinates {
@@ -856,6 +1325,17 @@ func (this *MigrationContext) GetThrottleControlReplicaKeys() *mysql.InstanceKey
 	return keys
 }
 
+// ReadIgnoredColumns parses the `--ignore-columns` flag, a comma delimited list of column names
+// to exclude from the copy
+func (this *MigrationContext) ReadIgnoredColumns(ignoredColumnsList string) {
+	this.IgnoredColumnsMap = make(map[string]bool)
+	for _, columnName := range strings.Split(ignoredColumnsList, ",") {
+		if columnName = strings.TrimSpace(columnName); columnName != "" {
+			this.IgnoredColumnsMap[columnName] = true
+		}
+	}
+}
+
 func (this *MigrationContext) ReadThrottleControlReplicaKeys(throttleControlReplicas string) error {
 	keys := mysql.NewInstanceKeyMap()
 	if err := keys.ReadCommaDelimitedList(throttleControlReplicas); err != nil {
@@ -877,6 +1357,25 @@ func (this *MigrationContext) AddThrottleControlReplicaKey(key mysql.InstanceKey
 	return nil
 }
 
+func (this *MigrationContext) GetChunkCopyOptimizerHints() string {
+	this.configMutex.Lock()
+	defer this.configMutex.Unlock()
+
+	return this.chunkCopyOptimizerHints
+}
+
+// SetChunkCopyOptimizerHints sets optimizer hints (e.g. `INDEX(tbl idx)`) injected into the rowcopy SELECT
+func (this *MigrationContext) SetChunkCopyOptimizerHints(hints string) error {
+	if err := sql.ValidateOptimizerHints(hints); err != nil {
+		return err
+	}
+	this.configMutex.Lock()
+	defer this.configMutex.Unlock()
+
+	this.chunkCopyOptimizerHints = hints
+	return nil
+}
+
 // ApplyCredentials sorts out the credentials between the config file and the CLI flags
 func (this *MigrationContext) ApplyCredentials() {
 	this.configMutex.Lock()
@@ -898,6 +1397,31 @@ func (this *MigrationContext) ApplyCredentials() {
 	}
 }
 
+// ReadPasswords populates passwords not given on the command line, from the given password files or
+// else from the GH_OST_PASSWORD and GH_OST_MASTER_PASSWORD environment variables. Passwords in the
+// config file take lowest precedence, see ApplyCredentials().
+func (this *MigrationContext) ReadPasswords(passwordFile, masterPasswordFile string) (err error) {
+	if this.CliPassword == "" {
+		if passwordFile != "" {
+			if this.CliPassword, err = ReadPasswordFile(passwordFile); err != nil {
+				return fmt.Errorf("Unable to read --password-file: %w", err)
+			}
+		} else {
+			this.CliPassword = os.Getenv(PasswordEnvVariable)
+		}
+	}
+	if this.CliMasterPassword == "" {
+		if masterPasswordFile != "" {
+			if this.CliMasterPassword, err = ReadPasswordFile(masterPasswordFile); err != nil {
+				return fmt.Errorf("Unable to read --master-password-file: %w", err)
+			}
+		} else {
+			this.CliMasterPassword = os.Getenv(MasterPasswordEnvVariable)
+		}
+	}
+	return nil
+}
+
 func (this *MigrationContext) SetupTLS() error {
 	if this.UseTLS {
 		return this.InspectorConnectionConfig.UseTLS(this.TLSCACertificate, this.TLSCertificate, this.TLSKey, this.TLSAllowInsecure)
@@ -926,6 +1450,16 @@ func (this *MigrationContext) ReadConfigFile() error {
 		this.config.Client.Password = cfg.Section("client").Key("password").String()
 	}
 
+	if cfg.Section("client").HasKey("password_file") {
+		if cfg.Section("client").HasKey("password") {
+			return fmt.Errorf("Config file may specify either password or password_file, not both")
+		}
+		passwordFile := cfg.Section("client").Key("password_file").String()
+		if this.config.Client.Password, err = ReadPasswordFile(passwordFile); err != nil {
+			return fmt.Errorf("Unable to read client password_file: %w", err)
+		}
+	}
+
 	if cfg.Section("osc").HasKey("chunk_size") {
 		this.config.Osc.Chunk_Size, err = cfg.Section("osc").Key("chunk_size").Int64()
 		if err != nil {
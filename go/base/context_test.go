@@ -7,10 +7,14 @@ package base
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/github/gh-ost/go/mysql"
 	"github.com/openark/golib/log"
 	"github.com/stretchr/testify/require"
 )
@@ -59,6 +63,22 @@ func TestGetTableNames(t *testing.T) {
 	}
 }
 
+func TestGetGhostDatabaseName(t *testing.T) {
+	context := NewMigrationContext()
+	context.DatabaseName = "test"
+	require.Equal(t, "test", context.GetGhostDatabaseName())
+	require.Equal(t, "test", context.GetChangelogDatabaseName())
+
+	context.GhostDatabaseName = "scratch"
+	require.Equal(t, "scratch", context.GetGhostDatabaseName())
+	require.Equal(t, "scratch", context.GetChangelogDatabaseName())
+
+	// When reverting, the "ghost" table is the _del table of the migrated schema
+	context.Revert = true
+	require.Equal(t, "test", context.GetGhostDatabaseName())
+	require.Equal(t, "scratch", context.GetChangelogDatabaseName())
+}
+
 func TestGetTriggerNames(t *testing.T) {
 	{
 		context := NewMigrationContext()
@@ -181,4 +201,361 @@ func TestReadConfigFile(t *testing.T) {
 			t.Fatalf("Expected osc 'max_load' %q, got %q", "10", context.config.Osc.Max_Load)
 		}
 	}
+	{
+		passwordFile := filepath.Join(t.TempDir(), "password")
+		require.NoError(t, os.WriteFile(passwordFile, []byte("123456\n"), 0600))
+		f, err := os.CreateTemp("", t.Name())
+		if err != nil {
+			t.Fatalf("Failed to create tmp file: %v", err)
+		}
+		defer os.Remove(f.Name())
+
+		f.Write([]byte("[client]\nuser=test\npassword_file=" + passwordFile))
+		context := NewMigrationContext()
+		context.ConfigFile = f.Name()
+		if err := context.ReadConfigFile(); err != nil {
+			t.Fatalf(".ReadConfigFile() failed: %v", err)
+		}
+		if context.config.Client.Password != "123456" {
+			t.Fatalf("Expected client password %q, got %q", "123456", context.config.Client.Password)
+		}
+	}
+	{
+		f, err := os.CreateTemp("", t.Name())
+		if err != nil {
+			t.Fatalf("Failed to create tmp file: %v", err)
+		}
+		defer os.Remove(f.Name())
+
+		f.Write([]byte("[client]\npassword=123456\npassword_file=/does/not/exist"))
+		context := NewMigrationContext()
+		context.ConfigFile = f.Name()
+		if err := context.ReadConfigFile(); err == nil {
+			t.Fatal("Expected .ReadConfigFile() to return an error, got nil")
+		}
+	}
+}
+
+func TestReadPasswords(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from-file\n"), 0600))
+	t.Setenv(PasswordEnvVariable, "from-env")
+	t.Setenv(MasterPasswordEnvVariable, "master-from-env")
+
+	t.Run("cli", func(t *testing.T) {
+		context := NewMigrationContext()
+		context.CliPassword = "from-cli"
+		require.NoError(t, context.ReadPasswords("", ""))
+		require.Equal(t, "from-cli", context.CliPassword)
+		require.Equal(t, "master-from-env", context.CliMasterPassword)
+	})
+	t.Run("file", func(t *testing.T) {
+		context := NewMigrationContext()
+		require.NoError(t, context.ReadPasswords(passwordFile, passwordFile))
+		require.Equal(t, "from-file", context.CliPassword)
+		require.Equal(t, "from-file", context.CliMasterPassword)
+	})
+	t.Run("env", func(t *testing.T) {
+		context := NewMigrationContext()
+		context.config.Client.Password = "from-config"
+		require.NoError(t, context.ReadPasswords("", ""))
+		context.ApplyCredentials()
+		require.Equal(t, "from-env", context.InspectorConnectionConfig.Password)
+	})
+	t.Run("config", func(t *testing.T) {
+		t.Setenv(PasswordEnvVariable, "")
+		context := NewMigrationContext()
+		context.config.Client.Password = "from-config"
+		require.NoError(t, context.ReadPasswords("", ""))
+		context.ApplyCredentials()
+		require.Equal(t, "from-config", context.InspectorConnectionConfig.Password)
+	})
+	t.Run("missing-file", func(t *testing.T) {
+		context := NewMigrationContext()
+		require.Error(t, context.ReadPasswords("/does/not/exist", ""))
+	})
+}
+
+func TestSetChunkCopyOptimizerHints(t *testing.T) {
+	context := NewMigrationContext()
+	require.NoError(t, context.SetChunkCopyOptimizerHints("INDEX(tbl idx)"))
+	require.Equal(t, "INDEX(tbl idx)", context.GetChunkCopyOptimizerHints())
+	require.Error(t, context.SetChunkCopyOptimizerHints("INDEX(tbl idx) */ sleep(1) /*"))
+	require.Equal(t, "INDEX(tbl idx)", context.GetChunkCopyOptimizerHints())
+	require.NoError(t, context.SetChunkCopyOptimizerHints(""))
+	require.Equal(t, "", context.GetChunkCopyOptimizerHints())
+}
+
+func TestSetApplierParallelism(t *testing.T) {
+	context := NewMigrationContext()
+	require.Equal(t, int64(1), context.GetApplierParallelism())
+	context.SetApplierParallelism(8)
+	require.Equal(t, int64(8), context.GetApplierParallelism())
+	context.SetApplierParallelism(0)
+	require.Equal(t, int64(1), context.GetApplierParallelism())
+	context.SetApplierParallelism(MaxApplierParallelism + 1)
+	require.Equal(t, int64(MaxApplierParallelism), context.GetApplierParallelism())
+}
+
+func TestSetThrottledConcurrentAccess(t *testing.T) {
+	context := NewMigrationContext()
+
+	isThrottled, reason, reasonHint := context.IsThrottled()
+	require.False(t, isThrottled)
+	require.Equal(t, "", reason)
+	require.Equal(t, NoThrottleReasonHint, reasonHint)
+
+	// require must not be called from spawned goroutines: readings are collected, and asserted once these are done
+	type throttleReading struct {
+		reason     string
+		reasonHint ThrottleReasonHint
+		niceRatio  float64
+	}
+	readings := []throttleReading{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			context.SetThrottled(i%2 == 0, "commanded by user", UserCommandThrottleReasonHint)
+			context.SetNiceRatio(float64(i % 10))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			reading := throttleReading{reason: "commanded by user", reasonHint: UserCommandThrottleReasonHint}
+			if isThrottled, reason, reasonHint := context.IsThrottled(); isThrottled {
+				reading.reason, reading.reasonHint = reason, reasonHint
+			}
+			reading.niceRatio = context.GetNiceRatio()
+			readings = append(readings, reading)
+		}
+	}()
+	wg.Wait()
+	require.Len(t, readings, 1000)
+	for _, reading := range readings {
+		require.Equal(t, "commanded by user", reading.reason)
+		require.Equal(t, UserCommandThrottleReasonHint, reading.reasonHint)
+		require.GreaterOrEqual(t, reading.niceRatio, 0.0)
+	}
+
+	context.SetThrottled(true, "lag", NoThrottleReasonHint)
+	isThrottled, reason, _ = context.IsThrottled()
+	require.True(t, isThrottled)
+	require.Equal(t, "lag", reason)
+
+	atomic.StoreInt64(&context.InCutOverCriticalSectionFlag, 1)
+	isThrottled, reason, _ = context.IsThrottled()
+	require.False(t, isThrottled)
+	require.Equal(t, "critical section", reason)
+}
+
+// BenchmarkAppliedEventsWithStatusPoller measures the context reads made per
+// applied DML event while a concurrent poller reads status as fast as it can
+func BenchmarkAppliedEventsWithStatusPoller(b *testing.B) {
+	context := NewMigrationContext()
+	context.SetNiceRatio(0.5)
+	context.SetThrottled(false, "", NoThrottleReasonHint)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			context.IsThrottled()
+			context.GetNiceRatio()
+			context.MaxRetries()
+			context.GetTotalRowsCopied()
+			context.GetCurrentLagDuration()
+			context.GetThrottleGeneralCheckResult()
+			context.ElapsedRowCopyTime()
+		}
+	}()
+
+	b.ResetTimer()
+	start := time.Now().Add(-time.Second)
+	for i := 0; i < b.N; i++ {
+		context.IsThrottled()
+		context.GetNiceRatio()
+		context.MaxRetries()
+		atomic.AddInt64(&context.TotalDMLEventsApplied, 1)
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "events/sec")
+	b.StopTimer()
+
+	close(done)
+	wg.Wait()
+}
+
+func TestReadIgnoredColumns(t *testing.T) {
+	context := NewMigrationContext()
+	context.ReadIgnoredColumns("")
+	require.Empty(t, context.IgnoredColumnsMap)
+
+	context.ReadIgnoredColumns("a, b,,c ")
+	require.Equal(t, map[string]bool{"a": true, "b": true, "c": true}, context.IgnoredColumnsMap)
+}
+
+func TestProgressTimes(t *testing.T) {
+	context := NewMigrationContext()
+	require.True(t, context.GetLastRowCopyProgressTime().IsZero())
+	require.True(t, context.GetLastDMLApplyProgressTime().IsZero())
+
+	before := time.Now()
+	context.MarkRowCopyProgress()
+	require.False(t, context.GetLastRowCopyProgressTime().Before(before))
+	require.True(t, context.GetLastDMLApplyProgressTime().IsZero())
+
+	context.MarkDMLApplyProgress()
+	require.False(t, context.GetLastDMLApplyProgressTime().Before(before))
+}
+
+func TestGetRuntimeRemaining(t *testing.T) {
+	context := NewMigrationContext()
+	_, ok := context.GetRuntimeRemaining()
+	require.False(t, ok)
+	require.False(t, context.IsPastDeadline())
+
+	context.SetMaxRuntime(time.Hour)
+	remaining, ok := context.GetRuntimeRemaining()
+	require.True(t, ok)
+	require.Equal(t, time.Hour, remaining)
+
+	context.StartTime = time.Now().Add(-30 * time.Minute)
+	remaining, _ = context.GetRuntimeRemaining()
+	require.InDelta(t, (30 * time.Minute).Seconds(), remaining.Seconds(), 1)
+	require.False(t, context.IsPastDeadline())
+
+	context.StartTime = time.Now().Add(-2 * time.Hour)
+	require.True(t, context.IsPastDeadline())
+
+	context.SetMaxRuntime(0)
+	require.False(t, context.IsPastDeadline())
+}
+
+func TestApplierLagTracking(t *testing.T) {
+	context := NewMigrationContext()
+	require.True(t, context.GetOldestUnappliedEventTime().IsZero())
+
+	eventTimestamp := time.Unix(1700000000, 0)
+	context.MarkBinlogEventQueued(eventTimestamp)
+	// binlog events are timestamped 3 seconds ahead of gh-ost's clock
+	context.SetBinlogClockSkew(3 * time.Second)
+	context.MarkBinlogEventQueued(eventTimestamp.Add(5 * time.Second))
+	require.Equal(t, eventTimestamp, context.GetOldestUnappliedEventTime())
+
+	context.MarkBinlogEventsApplied(1)
+	require.Equal(t, eventTimestamp.Add(2*time.Second), context.GetOldestUnappliedEventTime())
+	context.MarkBinlogEventsApplied(1)
+	require.True(t, context.GetOldestUnappliedEventTime().IsZero())
+
+	// the history wraps around
+	for i := 0; i < 3*queuedEventsHistorySize; i++ {
+		context.MarkBinlogEventQueued(eventTimestamp.Add(time.Duration(i) * time.Second))
+		context.MarkBinlogEventsApplied(1)
+	}
+	context.MarkBinlogEventQueued(eventTimestamp)
+	require.Equal(t, eventTimestamp.Add(-3*time.Second), context.GetOldestUnappliedEventTime())
+
+	context.RecordApplierLag(2 * time.Second)
+	context.RecordApplierLag(5 * time.Second)
+	context.RecordApplierLag(time.Second)
+	require.Equal(t, 5*time.Second, context.GetApplierLagHighWaterMark())
+}
+
+func TestTrustsConnectionPort(t *testing.T) {
+	context := NewMigrationContext()
+	require.False(t, context.TrustsConnectionPort())
+	context.MarkConnectionPortTrusted()
+	require.True(t, context.TrustsConnectionPort())
+
+	// Managed platform flags acknowledge what is detected, rather than trusting the connection port outright
+	context = NewMigrationContext()
+	context.AzureMySQL = true
+	require.False(t, context.TrustsConnectionPort())
+	require.Equal(t, "--azure", context.GetManagedPlatformFlag())
+
+	context = NewMigrationContext()
+	context.ProxyCompat = true
+	require.True(t, context.TrustsConnectionPort())
+	require.Equal(t, "", context.GetManagedPlatformFlag())
+}
+
+func TestGetCoordinationTable(t *testing.T) {
+	context := NewMigrationContext()
+	context.DatabaseName = "test"
+
+	context.CoordinationTable = "_gh_ost_migrations"
+	databaseName, tableName := context.GetCoordinationTable()
+	require.Equal(t, "test", databaseName)
+	require.Equal(t, "_gh_ost_migrations", tableName)
+
+	context.CoordinationTable = "meta.migrations"
+	databaseName, tableName = context.GetCoordinationTable()
+	require.Equal(t, "meta", databaseName)
+	require.Equal(t, "migrations", tableName)
+}
+
+func TestGetBinlogConnectionConfig(t *testing.T) {
+	context := NewMigrationContext()
+	context.InspectorConnectionConfig.Key = mysql.InstanceKey{Hostname: "proxy", Port: 6033}
+	require.Equal(t, context.InspectorConnectionConfig, context.GetBinlogConnectionConfig())
+
+	context.BinlogConnectionConfig = context.InspectorConnectionConfig.DuplicateCredentials(mysql.InstanceKey{Hostname: "backend", Port: 3306})
+	require.Equal(t, "backend:3306", context.GetBinlogConnectionConfig().Key.String())
+	require.Equal(t, "proxy:6033", context.InspectorConnectionConfig.Key.String())
+}
+
+func TestSetConnectionTimeZone(t *testing.T) {
+	// Named time zones are validated by the servers
+	for _, timeZone := range []string{"", "UTC", "+00:00", "+05:30", "-13:59", "+14:00", "America/New_York", "SYSTEM", "Mars/Olympus_Mons"} {
+		context := NewMigrationContext()
+		require.NoError(t, context.SetConnectionTimeZone(timeZone), timeZone)
+		require.Equal(t, timeZone, context.TimeZone)
+		require.Equal(t, timeZone, context.InspectorConnectionConfig.TimeZone)
+		require.Equal(t, timeZone, context.ApplierConnectionConfig.TimeZone)
+	}
+	for _, timeZone := range []string{"+14:01", "-14:00", "+05:60", "+5:30", "05:30"} {
+		context := NewMigrationContext()
+		require.Error(t, context.SetConnectionTimeZone(timeZone), timeZone)
+		require.Empty(t, context.InspectorConnectionConfig.TimeZone)
+	}
+}
+
+func TestHeartbeatLag(t *testing.T) {
+	context := NewMigrationContext()
+	start := time.Now().Add(-time.Second)
+	heartbeatTimes := make(map[int64]time.Time)
+	for i := 0; i < 5; i++ {
+		sequence := context.NextHeartbeatSequence()
+		heartbeatTimes[sequence] = start.Add(time.Duration(i) * 100 * time.Millisecond)
+		if sequence == 3 {
+			// failed to write
+			continue
+		}
+		context.MarkHeartbeatInjected(sequence, heartbeatTimes[sequence])
+	}
+	// As read back from the changelog table: without monotonic clock reading
+	readBack := func(sequence int64) time.Time {
+		return heartbeatTimes[sequence].Round(0)
+	}
+
+	for _, sequence := range []int64{5, 4, 2, 1} {
+		require.InDelta(t, time.Since(heartbeatTimes[sequence]), context.GetHeartbeatLag(sequence, readBack(sequence)), float64(50*time.Millisecond))
+	}
+
+	// Heartbeats not injected by this process, or by an earlier version without sequence numbers
+	earlier := time.Now().Add(-time.Minute)
+	require.InDelta(t, time.Minute, context.GetHeartbeatLag(5, earlier), float64(time.Second))
+	require.InDelta(t, time.Minute, context.GetHeartbeatLag(0, earlier), float64(time.Second))
+
+	require.Equal(t, heartbeatTimes[2], context.GetHeartbeatInjectionTime(2, readBack(2)))
+	require.Equal(t, earlier, context.GetHeartbeatInjectionTime(2, earlier))
 }
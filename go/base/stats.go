@@ -0,0 +1,136 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// chunkCopyDurationsWindow is the number of most recent chunk copies chunk copy percentiles are computed over
+const chunkCopyDurationsWindow = 1000
+
+// MigrationStats breaks down the work done by a migration. It is kept on the migration context rather than
+// on the applier's connections, and so survives reconnects.
+type MigrationStats struct {
+	insertsApplied int64
+	updatesApplied int64
+	deletesApplied int64
+	// uniqueKeyUpdatesApplied are the updates that modified the migration's unique key, and were applied
+	// as a delete and an insert
+	uniqueKeyUpdatesApplied int64
+	retries                 int64
+
+	mutex                  *sync.Mutex
+	chunksCopied           int64
+	chunkCopyDurations     []time.Duration
+	totalChunkCopyDuration time.Duration
+	rowsCopiedByChunkSize  map[int64]int64
+	throttledByReason      map[string]time.Duration
+}
+
+// MigrationStatsSnapshot is a point in time copy of MigrationStats
+type MigrationStatsSnapshot struct {
+	InsertsApplied          int64           `json:"inserts_applied"`
+	UpdatesApplied          int64           `json:"updates_applied"`
+	DeletesApplied          int64           `json:"deletes_applied"`
+	UniqueKeyUpdatesApplied int64           `json:"unique_key_updates_applied"`
+	ChunksCopied            int64           `json:"chunks_copied"`
+	ChunkCopyAvgMillis      float64         `json:"chunk_copy_avg_millis"`
+	ChunkCopyP50Millis      float64         `json:"chunk_copy_p50_millis"`
+	ChunkCopyP95Millis      float64         `json:"chunk_copy_p95_millis"`
+	ChunkCopyP99Millis      float64         `json:"chunk_copy_p99_millis"`
+	RowsCopiedByChunkSize   map[int64]int64 `json:"rows_copied_by_chunk_size"`
+	Retries                 int64           `json:"retries"`
+
+	ThrottledSecondsByReason map[string]float64 `json:"throttled_seconds_by_reason"`
+}
+
+func NewMigrationStats() *MigrationStats {
+	return &MigrationStats{
+		mutex:                 &sync.Mutex{},
+		chunkCopyDurations:    make([]time.Duration, 0, chunkCopyDurationsWindow),
+		rowsCopiedByChunkSize: make(map[int64]int64),
+		throttledByReason:     make(map[string]time.Duration),
+	}
+}
+
+// AddAppliedDML counts binlog DML events applied onto the ghost table. uniqueKeyUpdates are the updates,
+// of those counted, that modified the migration's unique key.
+func (this *MigrationStats) AddAppliedDML(inserts, updates, deletes, uniqueKeyUpdates int64) {
+	atomic.AddInt64(&this.insertsApplied, inserts)
+	atomic.AddInt64(&this.updatesApplied, updates)
+	atomic.AddInt64(&this.deletesApplied, deletes)
+	atomic.AddInt64(&this.uniqueKeyUpdatesApplied, uniqueKeyUpdates)
+}
+
+// MarkRetry counts a retried operation
+func (this *MigrationStats) MarkRetry() {
+	atomic.AddInt64(&this.retries, 1)
+}
+
+// MarkChunkCopied counts a chunk of rows copied onto the ghost table, under the chunk-size in effect
+func (this *MigrationStats) MarkChunkCopied(chunkSize, rowsCopied int64, duration time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if len(this.chunkCopyDurations) < chunkCopyDurationsWindow {
+		this.chunkCopyDurations = append(this.chunkCopyDurations, duration)
+	} else {
+		this.chunkCopyDurations[this.chunksCopied%chunkCopyDurationsWindow] = duration
+	}
+	this.chunksCopied++
+	this.totalChunkCopyDuration += duration
+	this.rowsCopiedByChunkSize[chunkSize] += rowsCopied
+}
+
+// AddThrottledTime counts time spent throttled, by the kind of reason throttled for
+func (this *MigrationStats) AddThrottledTime(reason string, duration time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.throttledByReason[reason] += duration
+}
+
+// GetSnapshot returns the current stats. Chunk copy percentiles cover the most recent chunk copies.
+func (this *MigrationStats) GetSnapshot() MigrationStatsSnapshot {
+	snapshot := MigrationStatsSnapshot{
+		InsertsApplied:          atomic.LoadInt64(&this.insertsApplied),
+		UpdatesApplied:          atomic.LoadInt64(&this.updatesApplied),
+		DeletesApplied:          atomic.LoadInt64(&this.deletesApplied),
+		UniqueKeyUpdatesApplied: atomic.LoadInt64(&this.uniqueKeyUpdatesApplied),
+		RowsCopiedByChunkSize:   make(map[int64]int64),
+		Retries:                 atomic.LoadInt64(&this.retries),
+
+		ThrottledSecondsByReason: make(map[string]float64),
+	}
+
+	this.mutex.Lock()
+	durations := append([]time.Duration{}, this.chunkCopyDurations...)
+	totalDuration := this.totalChunkCopyDuration
+	for chunkSize, rowsCopied := range this.rowsCopiedByChunkSize {
+		snapshot.RowsCopiedByChunkSize[chunkSize] = rowsCopied
+	}
+	for reason, duration := range this.throttledByReason {
+		snapshot.ThrottledSecondsByReason[reason] = duration.Seconds()
+	}
+	snapshot.ChunksCopied = this.chunksCopied
+	this.mutex.Unlock()
+
+	if len(durations) == 0 {
+		return snapshot
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentileMillis := func(percentile int) float64 {
+		index := (len(durations)*percentile+99)/100 - 1
+		return float64(durations[index]) / float64(time.Millisecond)
+	}
+	snapshot.ChunkCopyAvgMillis = float64(totalDuration) / float64(snapshot.ChunksCopied) / float64(time.Millisecond)
+	snapshot.ChunkCopyP50Millis = percentileMillis(50)
+	snapshot.ChunkCopyP95Millis = percentileMillis(95)
+	snapshot.ChunkCopyP99Millis = percentileMillis(99)
+	return snapshot
+}
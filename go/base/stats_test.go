@@ -0,0 +1,66 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationStats(t *testing.T) {
+	stats := NewMigrationStats()
+	snapshot := stats.GetSnapshot()
+	require.Zero(t, snapshot.ChunksCopied)
+	require.Zero(t, snapshot.ChunkCopyP99Millis)
+	require.Empty(t, snapshot.RowsCopiedByChunkSize)
+	require.Empty(t, snapshot.ThrottledSecondsByReason)
+
+	stats.AddAppliedDML(3, 2, 1, 1)
+	stats.AddAppliedDML(1, 0, 0, 0)
+	stats.MarkRetry()
+	stats.AddThrottledTime("lag", time.Second)
+	stats.AddThrottledTime("max-load", 500*time.Millisecond)
+	stats.AddThrottledTime("lag", 2*time.Second)
+	for i := 1; i <= 100; i++ {
+		chunkSize := int64(1000)
+		if i > 60 {
+			chunkSize = 2000
+		}
+		stats.MarkChunkCopied(chunkSize, 10, time.Duration(i)*time.Millisecond)
+	}
+
+	snapshot = stats.GetSnapshot()
+	require.Equal(t, int64(4), snapshot.InsertsApplied)
+	require.Equal(t, int64(2), snapshot.UpdatesApplied)
+	require.Equal(t, int64(1), snapshot.DeletesApplied)
+	require.Equal(t, int64(1), snapshot.UniqueKeyUpdatesApplied)
+	require.Equal(t, int64(1), snapshot.Retries)
+	require.Equal(t, int64(100), snapshot.ChunksCopied)
+	require.Equal(t, 50.5, snapshot.ChunkCopyAvgMillis)
+	require.Equal(t, 50.0, snapshot.ChunkCopyP50Millis)
+	require.Equal(t, 95.0, snapshot.ChunkCopyP95Millis)
+	require.Equal(t, 99.0, snapshot.ChunkCopyP99Millis)
+	require.Equal(t, map[int64]int64{1000: 600, 2000: 400}, snapshot.RowsCopiedByChunkSize)
+	require.Equal(t, map[string]float64{"lag": 3, "max-load": 0.5}, snapshot.ThrottledSecondsByReason)
+}
+
+func TestMigrationStatsChunkCopyWindow(t *testing.T) {
+	stats := NewMigrationStats()
+	for i := 0; i < chunkCopyDurationsWindow; i++ {
+		stats.MarkChunkCopied(1000, 1000, time.Second)
+	}
+	for i := 0; i < chunkCopyDurationsWindow; i++ {
+		stats.MarkChunkCopied(1000, 1000, time.Millisecond)
+	}
+
+	// percentiles cover recent chunk copies only, the average covers all
+	snapshot := stats.GetSnapshot()
+	require.Equal(t, 1.0, snapshot.ChunkCopyP99Millis)
+	require.Equal(t, 500.5, snapshot.ChunkCopyAvgMillis)
+	require.Equal(t, int64(2*chunkCopyDurationsWindow), snapshot.ChunksCopied)
+}
@@ -61,6 +61,33 @@ func StringContainsAll(s string, substrings ...string) bool {
 	return nonEmptyStringsFound
 }
 
+// ValidateTimeZone validates that the server knows --time-zone; a named time zone requires the server's
+// time zone tables. A connection with an unknown session time_zone fails, and so this validates on a
+// connection of its own, in the server's default time_zone.
+func ValidateTimeZone(connectionConfig *mysql.ConnectionConfig, migrationContext *MigrationContext, name string) error {
+	if migrationContext.TimeZone == "" {
+		return nil
+	}
+	config := connectionConfig.Duplicate()
+	config.TimeZone = ""
+	db, err := gosql.Open("mysql", config.GetDBUri("information_schema"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var converted gosql.NullString
+	query := `select /* gh-ost */ convert_tz(now(), @@session.time_zone, ?)`
+	if err := db.QueryRow(query, migrationContext.TimeZone).Scan(&converted); err != nil {
+		return err
+	}
+	if !converted.Valid {
+		return fmt.Errorf("--time-zone=%s is unknown to %s; a named time zone requires the server's time zone tables to be loaded", migrationContext.TimeZone, name)
+	}
+	migrationContext.Log.Infof("%s time zone validated: %s", name, migrationContext.TimeZone)
+	return nil
+}
+
 func ValidateConnection(db *gosql.DB, connectionConfig *mysql.ConnectionConfig, migrationContext *MigrationContext, name string) (string, error) {
 	versionQuery := `select @@global.version`
 
@@ -80,25 +107,50 @@ func ValidateConnection(db *gosql.DB, connectionConfig *mysql.ConnectionConfig,
 		// swallow this error. not all servers support extra_port
 	}
 
-	// AliyunRDS set users port to "NULL", replace it by gh-ost param
-	// GCP set users port to "NULL", replace it by gh-ost param
-	// Azure MySQL set users port to a different value by design, replace it by gh-ost para
+	// Some managed platforms, such as Aliyun RDS and GCP, report a NULL port, and others, such as Azure,
+	// report a port other than the one connected to. A proxy reports the port of whichever backend it
+	// routed to. In these cases servers are identified by the connection port.
 	var port int
-	if migrationContext.AliyunRDS || migrationContext.GoogleCloudPlatform || migrationContext.AzureMySQL {
+	if migrationContext.TrustsConnectionPort() {
 		port = connectionConfig.Key.Port
 	} else {
+		var reportedPort gosql.NullInt64
 		portQuery := `select @@global.port`
-		if err := db.QueryRow(portQuery).Scan(&port); err != nil {
+		if err := db.QueryRow(portQuery).Scan(&reportedPort); err != nil {
 			return "", err
 		}
+		if reportedPort.Valid {
+			port = int(reportedPort.Int64)
+		} else {
+			migrationContext.Log.Warningf("%s does not report its port, as is the case on some managed platforms. Trusting connection port %d", name, connectionConfig.Key.Port)
+			migrationContext.MarkConnectionPortTrusted()
+			port = connectionConfig.Key.Port
+		}
 	}
 
 	if connectionConfig.Key.Port == port || (extraPort > 0 && connectionConfig.Key.Port == extraPort) {
 		migrationContext.Log.Infof("%s connection validated on %+v", name, connectionConfig.Key)
 		return version, nil
+	} else if platformFlag := migrationContext.GetManagedPlatformFlag(); platformFlag != "" {
+		migrationContext.Log.Warningf("%s reports port %d rather than connection port %d, which %s acknowledges. Trusting connection port", name, port, connectionConfig.Key.Port, platformFlag)
+		migrationContext.MarkConnectionPortTrusted()
+		return version, nil
 	} else if extraPort == 0 {
-		return "", fmt.Errorf("Unexpected database port reported: %+v", port)
+		return "", fmt.Errorf("Unexpected database port reported: %+v. On a managed platform reporting another port than the one connected to, such as Azure, use --azure", port)
 	} else {
 		return "", fmt.Errorf("Unexpected database port reported: %+v / extra_port: %+v", port, extraPort)
 	}
 }
+
+// ReadPasswordFile returns the content of a password file, without trailing newline
+func ReadPasswordFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("Password file %s is empty", path)
+	}
+	return password, nil
+}
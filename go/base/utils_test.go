@@ -6,6 +6,8 @@
 package base
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/openark/golib/log"
@@ -27,3 +29,24 @@ func TestStringContainsAll(t *testing.T) {
 	require.True(t, StringContainsAll(s, "insert", ""))
 	require.True(t, StringContainsAll(s, "insert", "update", "delete"))
 }
+
+func TestReadPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	{
+		path := filepath.Join(dir, "password")
+		require.NoError(t, os.WriteFile(path, []byte("s3cr3t \n"), 0600))
+		password, err := ReadPasswordFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t ", password)
+	}
+	{
+		path := filepath.Join(dir, "empty")
+		require.NoError(t, os.WriteFile(path, []byte("\n"), 0600))
+		_, err := ReadPasswordFile(path)
+		require.Error(t, err)
+	}
+	{
+		_, err := ReadPasswordFile(filepath.Join(dir, "does-not-exist"))
+		require.Error(t, err)
+	}
+}
@@ -7,6 +7,7 @@ package binlog
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/github/gh-ost/go/mysql"
 )
@@ -15,6 +16,10 @@ import (
 type BinlogEntry struct {
 	Coordinates mysql.BinlogCoordinates
 	DmlEvent    *BinlogDMLEvent
+	// Size approximates the memory held by this entry: its share of the originating binlog event's size
+	Size int64
+	// Timestamp is when the originating binlog event was written, at second resolution, by the clock of the server it was first written on
+	Timestamp time.Time
 }
 
 // NewBinlogEntryAt creates an empty, ready to go BinlogEntry object
@@ -5,9 +5,17 @@
 
 package binlog
 
+import (
+	"github.com/github/gh-ost/go/mysql"
+)
+
 // BinlogReader is a general interface whose implementations can choose their methods of reading
 // a binary log file and parsing it into binlog entries
 type BinlogReader interface {
+	ConnectBinlogStreamer(coordinates mysql.BinlogCoordinates) error
+	GetCurrentBinlogCoordinates() mysql.BinlogCoordinates
+	// GetLastTrxCoordinates returns the coordinates of the last transaction completely read, or nil
+	GetLastTrxCoordinates() mysql.BinlogCoordinates
 	StreamEvents(canStopStreaming func() bool, entriesChannel chan<- *BinlogEntry) error
-	Reconnect() error
+	Close() error
 }
@@ -8,6 +8,7 @@ package binlog
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/github/gh-ost/go/base"
 	"github.com/github/gh-ost/go/mysql"
@@ -21,8 +22,11 @@ import (
 	"golang.org/x/net/context"
 )
 
+const backpressureSleepInterval = 100 * time.Millisecond
+
 type GoMySQLReader struct {
 	migrationContext        *base.MigrationContext
+	log                     base.Logger
 	connectionConfig        *mysql.ConnectionConfig
 	binlogSyncer            *replication.BinlogSyncer
 	binlogStreamer          *replication.BinlogStreamer
@@ -33,10 +37,17 @@ type GoMySQLReader struct {
 	LastTrxCoords mysql.BinlogCoordinates
 }
 
-func NewGoMySQLReader(migrationContext *base.MigrationContext) *GoMySQLReader {
-	connectionConfig := migrationContext.InspectorConnectionConfig
+// NewGoMySQLReader creates a reader streaming row events of the tables accepted by tableFilter. Row images
+// of other tables are not decoded. A nil tableFilter streams row events of all tables.
+func NewGoMySQLReader(migrationContext *base.MigrationContext, tableFilter func(databaseName, tableName string) bool) *GoMySQLReader {
+	connectionConfig := migrationContext.GetBinlogConnectionConfig()
+	var rowsEventDecodeFunc func(*replication.RowsEvent, []byte) error
+	if tableFilter != nil {
+		rowsEventDecodeFunc = newRowsEventDecodeFunc(tableFilter)
+	}
 	return &GoMySQLReader{
 		migrationContext:        migrationContext,
+		log:                     migrationContext.NewComponentLogger(base.StreamerLogComponent),
 		connectionConfig:        connectionConfig,
 		currentCoordinatesMutex: &sync.Mutex{},
 		binlogSyncer: replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
@@ -50,20 +61,38 @@ func NewGoMySQLReader(migrationContext *base.MigrationContext) *GoMySQLReader {
 			UseDecimal:              true,
 			TimestampStringLocation: time.UTC,
 			MaxReconnectAttempts:    migrationContext.BinlogSyncerMaxReconnectAttempts,
+			RowsEventDecodeFunc:     rowsEventDecodeFunc,
 		}),
 	}
 }
 
+// newRowsEventDecodeFunc returns a rows event decoder which only decodes the header of events on tables
+// rejected by tableFilter. Decoding row images is the bulk of the parsing cost on busy servers, where the
+// migrated table is typically a small fraction of the writes. The table map, GTID, XID and rotate events
+// the coordinates rely on are still fully decoded.
+func newRowsEventDecodeFunc(tableFilter func(databaseName, tableName string) bool) func(*replication.RowsEvent, []byte) error {
+	return func(rowsEvent *replication.RowsEvent, data []byte) error {
+		pos, err := rowsEvent.DecodeHeader(data)
+		if err != nil {
+			return err
+		}
+		if !tableFilter(string(rowsEvent.Table.Schema), string(rowsEvent.Table.Table)) {
+			return nil
+		}
+		return rowsEvent.DecodeData(pos, data)
+	}
+}
+
 // ConnectBinlogStreamer
 func (this *GoMySQLReader) ConnectBinlogStreamer(coordinates mysql.BinlogCoordinates) (err error) {
 	if coordinates.IsEmpty() {
-		return this.migrationContext.Log.Errorf("Empty coordinates at ConnectBinlogStreamer()")
+		return this.log.Errorf("Empty coordinates at ConnectBinlogStreamer()")
 	}
 
 	this.currentCoordinatesMutex.Lock()
 	defer this.currentCoordinatesMutex.Unlock()
 	this.currentCoordinates = coordinates
-	this.migrationContext.Log.Infof("Connecting binlog streamer at %+v", coordinates)
+	this.log.Infof("Connecting binlog streamer at %+v", coordinates)
 
 	// Start sync with specified GTID set or binlog file and position
 	if this.migrationContext.UseGTIDs {
@@ -85,12 +114,21 @@ func (this *GoMySQLReader) GetCurrentBinlogCoordinates() mysql.BinlogCoordinates
 	return this.currentCoordinates.Clone()
 }
 
+// GetLastTrxCoordinates returns the coordinates of the last transaction completely read
+func (this *GoMySQLReader) GetLastTrxCoordinates() mysql.BinlogCoordinates {
+	return this.LastTrxCoords
+}
+
 func (this *GoMySQLReader) handleRowsEvent(ev *replication.BinlogEvent, rowsEvent *replication.RowsEvent, entriesChannel chan<- *BinlogEntry) error {
 	currentCoords := this.GetCurrentBinlogCoordinates()
 	dml := ToEventDML(ev.Header.EventType.String())
 	if dml == NotDML {
 		return fmt.Errorf("Unknown DML type: %s", ev.Header.EventType.String())
 	}
+	var rowSize int64
+	if len(rowsEvent.Rows) > 0 {
+		rowSize = int64(ev.Header.EventSize) / int64(len(rowsEvent.Rows))
+	}
 	for i, row := range rowsEvent.Rows {
 		if dml == UpdateDML && i%2 == 1 {
 			// An update has two rows (WHERE+SET)
@@ -98,6 +136,8 @@ func (this *GoMySQLReader) handleRowsEvent(ev *replication.BinlogEvent, rowsEven
 			continue
 		}
 		binlogEntry := NewBinlogEntryAt(currentCoords)
+		binlogEntry.Size = rowSize
+		binlogEntry.Timestamp = time.Unix(int64(ev.Header.Timestamp), 0)
 		binlogEntry.DmlEvent = NewBinlogDMLEvent(
 			string(rowsEvent.Table.Schema),
 			string(rowsEvent.Table.Table),
@@ -112,6 +152,7 @@ func (this *GoMySQLReader) handleRowsEvent(ev *replication.BinlogEvent, rowsEven
 			{
 				binlogEntry.DmlEvent.WhereColumnValues = sql.ToColumnValues(row)
 				binlogEntry.DmlEvent.NewColumnValues = sql.ToColumnValues(rowsEvent.Rows[i+1])
+				binlogEntry.Size = 2 * rowSize
 			}
 		case DeleteDML:
 			{
@@ -137,6 +178,11 @@ func (this *GoMySQLReader) StreamEvents(canStopStreaming func() bool, entriesCha
 		if canStopStreaming() {
 			break
 		}
+		// Under backpressure, pause reading and let the server hold on to the binlog instead.
+		if atomic.LoadInt64(&this.migrationContext.IsBackpressuredFlag) > 0 {
+			time.Sleep(backpressureSleepInterval)
+			continue
+		}
 		ev, err := this.binlogStreamer.GetEvent(context.Background())
 		if err != nil {
 			return err
@@ -181,7 +227,7 @@ func (this *GoMySQLReader) StreamEvents(canStopStreaming func() bool, entriesCha
 			this.currentCoordinatesMutex.Lock()
 			coords := this.currentCoordinates.(*mysql.FileBinlogCoordinates)
 			coords.LogFile = string(event.NextLogName)
-			this.migrationContext.Log.Infof("rotate to next log from %s:%d to %s", coords.LogFile, int64(ev.Header.LogPos), event.NextLogName)
+			this.log.Infof("rotate to next log from %s:%d to %s", coords.LogFile, int64(ev.Header.LogPos), event.NextLogName)
 			this.currentCoordinatesMutex.Unlock()
 		case *replication.XIDEvent:
 			if this.migrationContext.UseGTIDs {
@@ -190,12 +236,16 @@ func (this *GoMySQLReader) StreamEvents(canStopStreaming func() bool, entriesCha
 				this.LastTrxCoords = this.currentCoordinates.Clone()
 			}
 		case *replication.RowsEvent:
+			if event.Rows == nil {
+				// Row images not decoded: no one streams this table
+				continue
+			}
 			if err := this.handleRowsEvent(ev, event, entriesChannel); err != nil {
 				return err
 			}
 		}
 	}
-	this.migrationContext.Log.Debugf("done streaming events")
+	this.log.Debugf("done streaming events")
 
 	return nil
 }
@@ -0,0 +1,89 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package binlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/require"
+)
+
+// readTestBinlogEvents returns the raw events of a binary log in testdata, all on `test`.`samplet`
+func readTestBinlogEvents(tb testing.TB) [][]byte {
+	var rawEvents [][]byte
+	err := replication.NewBinlogParser().ParseFile("testdata/mysql-bin.000066", 0, func(ev *replication.BinlogEvent) error {
+		rawEvents = append(rawEvents, ev.RawData)
+		return nil
+	})
+	require.NoError(tb, err)
+	return rawEvents
+}
+
+// parseTestBinlogEvents parses raw events the way the binlog syncer does, with given rows event decoder
+func parseTestBinlogEvents(tb testing.TB, rawEvents [][]byte, rowsEventDecodeFunc func(*replication.RowsEvent, []byte) error) []*replication.RowsEvent {
+	parser := replication.NewBinlogParser()
+	parser.SetUseDecimal(true)
+	parser.SetRowsEventDecodeFunc(rowsEventDecodeFunc)
+	var rowsEvents []*replication.RowsEvent
+	for _, rawEvent := range rawEvents {
+		ev, err := parser.Parse(rawEvent)
+		require.NoError(tb, err)
+		if rowsEvent, ok := ev.Event.(*replication.RowsEvent); ok {
+			rowsEvents = append(rowsEvents, rowsEvent)
+		}
+	}
+	return rowsEvents
+}
+
+func TestRowsEventDecodeFunc(t *testing.T) {
+	rawEvents := readTestBinlogEvents(t)
+
+	t.Run("streamed table", func(t *testing.T) {
+		rowsEventDecodeFunc := newRowsEventDecodeFunc(func(databaseName, tableName string) bool {
+			return strings.EqualFold(databaseName, "test") && strings.EqualFold(tableName, "SAMPLET")
+		})
+		expected := parseTestBinlogEvents(t, rawEvents, nil)
+		rowsEvents := parseTestBinlogEvents(t, rawEvents, rowsEventDecodeFunc)
+		require.Len(t, rowsEvents, len(expected))
+		require.NotEmpty(t, rowsEvents)
+		for i, rowsEvent := range rowsEvents {
+			require.NotEmpty(t, rowsEvent.Rows)
+			require.Equal(t, expected[i].Rows, rowsEvent.Rows)
+		}
+	})
+
+	t.Run("other table", func(t *testing.T) {
+		rowsEventDecodeFunc := newRowsEventDecodeFunc(func(databaseName, tableName string) bool {
+			return strings.EqualFold(databaseName, "test") && strings.EqualFold(tableName, "_samplet_ghc")
+		})
+		rowsEvents := parseTestBinlogEvents(t, rawEvents, rowsEventDecodeFunc)
+		require.NotEmpty(t, rowsEvents)
+		for _, rowsEvent := range rowsEvents {
+			require.Nil(t, rowsEvent.Rows)
+			require.Equal(t, "test", string(rowsEvent.Table.Schema))
+			require.Equal(t, "samplet", string(rowsEvent.Table.Table))
+		}
+	})
+}
+
+func BenchmarkRowsEventDecodeFunc(b *testing.B) {
+	rawEvents := readTestBinlogEvents(b)
+	b.Run("decode all tables", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			parseTestBinlogEvents(b, rawEvents, nil)
+		}
+	})
+	b.Run("skip other tables", func(b *testing.B) {
+		rowsEventDecodeFunc := newRowsEventDecodeFunc(func(databaseName, tableName string) bool {
+			return tableName == "_samplet_ghc"
+		})
+		for i := 0; i < b.N; i++ {
+			parseTestBinlogEvents(b, rawEvents, rowsEventDecodeFunc)
+		}
+	})
+}
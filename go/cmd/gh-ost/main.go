@@ -13,6 +13,7 @@ import (
 	"os/signal"
 	"regexp"
 	"syscall"
+	"time"
 
 	"github.com/github/gh-ost/go/base"
 	"github.com/github/gh-ost/go/logic"
@@ -26,10 +27,10 @@ import (
 var AppVersion, GitCommit string
 
 // acceptSignals registers for OS signals
-func acceptSignals(migrationContext *base.MigrationContext) {
+func acceptSignals(migrationContext *base.MigrationContext, migrator *logic.Migrator) {
 	c := make(chan os.Signal, 1)
 
-	signal.Notify(c, syscall.SIGHUP)
+	signal.Notify(c, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGTERM)
 	go func() {
 		for sig := range c {
 			switch sig {
@@ -40,6 +41,15 @@ func acceptSignals(migrationContext *base.MigrationContext) {
 				} else {
 					migrationContext.MarkPointOfInterest()
 				}
+			case syscall.SIGUSR1:
+				// As the 'sup' interactive command
+				migrator.PrintStatus(logic.ForcePrintStatusOnlyRule)
+			case syscall.SIGUSR2:
+				// As the 'status' interactive command
+				migrator.PrintStatus(logic.ForcePrintStatusAndHintRule)
+			case syscall.SIGTERM:
+				migrationContext.Log.Infof("Received SIGTERM. Aborting the migration")
+				go migrator.Terminate()
 			}
 		}
 	}()
@@ -56,9 +66,12 @@ func main() {
 	flag.StringVar(&migrationContext.CliPassword, "password", "", "MySQL password")
 	flag.StringVar(&migrationContext.CliMasterUser, "master-user", "", "MySQL user on master, if different from that on replica. Requires --assume-master-host")
 	flag.StringVar(&migrationContext.CliMasterPassword, "master-password", "", "MySQL password on master, if different from that on replica. Requires --assume-master-host")
+	passwordFile := flag.String("password-file", "", "File containing MySQL password. Alternative to --password and --ask-pass")
+	masterPasswordFile := flag.String("master-password-file", "", "File containing MySQL password on master, if different from that on replica. Alternative to --master-password. Requires --assume-master-host")
 	flag.StringVar(&migrationContext.ConfigFile, "conf", "", "Config file")
 	askPass := flag.Bool("ask-pass", false, "prompt for MySQL password")
 	charset := flag.String("charset", "utf8mb4,utf8,latin1", "The default charset for the database connection is utf8mb4, utf8, latin1.")
+	timeZone := flag.String("time-zone", "", "Session time_zone of all connections, as an offset (e.g. '+05:30') or a named time zone (e.g. 'UTC'). By default, sessions use the servers' default time_zone, and the applier's default is used for the migration")
 
 	flag.BoolVar(&migrationContext.UseTLS, "ssl", false, "Enable SSL encrypted connections to MySQL hosts")
 	flag.StringVar(&migrationContext.TLSCACertificate, "ssl-ca", "", "CA certificate in PEM format for TLS connections to MySQL hosts. Requires --ssl")
@@ -68,8 +81,10 @@ func main() {
 
 	flag.StringVar(&migrationContext.DatabaseName, "database", "", "database name (mandatory)")
 	flag.StringVar(&migrationContext.OriginalTableName, "table", "", "table name (mandatory)")
+	flag.StringVar(&migrationContext.GhostDatabaseName, "ghost-database", "", "Schema to create the ghost, changelog and checkpoint tables in, on the same server. Defaults to --database")
 	flag.StringVar(&migrationContext.AlterStatement, "alter", "", "alter statement (mandatory)")
 	flag.BoolVar(&migrationContext.AttemptInstantDDL, "attempt-instant-ddl", false, "Attempt to use instant DDL for this migration first")
+	flag.BoolVar(&migrationContext.AttemptInplaceIndexDDL, "attempt-inplace-index-ddl", false, "When the alter only adds/drops indexes, attempt to run it directly with ALGORITHM=INPLACE, LOCK=NONE first")
 	storageEngine := flag.String("storage-engine", "innodb", "Specify table storage engine (default: 'innodb'). When 'rocksdb': the session transaction isolation level is changed from REPEATABLE_READ to READ_COMMITTED.")
 
 	flag.BoolVar(&migrationContext.CountTableRows, "exact-rowcount", false, "actually count table rows as opposed to estimate them (results in more accurate progress estimation)")
@@ -77,22 +92,39 @@ func main() {
 	flag.BoolVar(&migrationContext.AllowedRunningOnMaster, "allow-on-master", false, "allow this migration to run directly on master. Preferably it would run on a replica")
 	flag.BoolVar(&migrationContext.AllowedMasterMaster, "allow-master-master", false, "explicitly allow running in a master-master setup")
 	flag.BoolVar(&migrationContext.NullableUniqueKeyAllowed, "allow-nullable-unique-key", false, "allow gh-ost to migrate based on a unique key with nullable columns. As long as no NULL values exist, this should be OK. If NULL values exist in chosen key, data may be corrupted. Use at your own risk!")
+	flag.BoolVar(&migrationContext.AllowTempIndex, "allow-temp-index", false, "allow migrating a table without PRIMARY nor UNIQUE key where the ALTER adds an AUTO_INCREMENT PRIMARY KEY: rows are identified by all their columns, via a temporary unique index on the ghost table. Refused if any two rows are identical")
+	flag.Int64Var(&migrationContext.TempIndexMaxRows, "temp-index-max-rows", 100000, "with --allow-temp-index, refuse a table with a higher row estimate, as each chunk of its row copy scans, sorts and share-locks the whole table. 0 for no limit")
+	flag.BoolVar(&migrationContext.AllowLossyMigration, "allow-lossy-migration", false, "allow gh-ost to proceed when the ALTER narrows columns (shorter VARCHAR, removed ENUM/SET members, smaller integer type) and existing values do not fit. Such values will be truncated or fail to copy. Use at your own risk!")
 	flag.BoolVar(&migrationContext.ApproveRenamedColumns, "approve-renamed-columns", false, "in case your `ALTER` statement renames columns, gh-ost will note that and offer its interpretation of the rename. By default gh-ost does not proceed to execute. This flag approves that gh-ost's interpretation is correct")
+	ignoreColumns := flag.String("ignore-columns", "", "comma delimited list of columns to exclude from the copy. Ignored columns are neither copied nor written by binlog events, and get their default values on the ghost table, where they must be nullable or have a default. Cannot be part of the chosen unique key")
+	flag.Func("transform-column", "target_col=SQL_EXPRESSION; write the expression's value to the ghost table's target_col, in row copy and when applying binlog events. The expression may reference the original table's columns and must be deterministic. May be given multiple times. Example: --transform-column=\"email_hash=SHA2(email, 256)\"", func(value string) error {
+		transform, err := sql.ParseColumnTransform(value)
+		if err != nil {
+			return err
+		}
+		migrationContext.ColumnTransforms = append(migrationContext.ColumnTransforms, transform)
+		return nil
+	})
 	flag.BoolVar(&migrationContext.SkipRenamedColumns, "skip-renamed-columns", false, "in case your `ALTER` statement renames columns, gh-ost will note that and offer its interpretation of the rename. By default gh-ost does not proceed to execute. This flag tells gh-ost to skip the renamed columns, i.e. to treat what gh-ost thinks are renamed columns as unrelated columns. NOTE: you may lose column data")
 	flag.BoolVar(&migrationContext.IsTungsten, "tungsten", false, "explicitly let gh-ost know that you are running on a tungsten-replication based topology (you are likely to also provide --assume-master-host)")
 	flag.BoolVar(&migrationContext.DiscardForeignKeys, "discard-foreign-keys", false, "DANGER! This flag will migrate a table that has foreign keys and will NOT create foreign keys on the ghost table, thus your altered table will have NO foreign keys. This is useful for intentional dropping of foreign keys")
 	flag.BoolVar(&migrationContext.SkipForeignKeyChecks, "skip-foreign-key-checks", false, "set to 'true' when you know for certain there are no foreign keys on your table, and wish to skip the time it takes for gh-ost to verify that")
 	flag.BoolVar(&migrationContext.SkipStrictMode, "skip-strict-mode", false, "explicitly tell gh-ost binlog applier not to enforce strict sql mode")
 	flag.BoolVar(&migrationContext.AllowZeroInDate, "allow-zero-in-date", false, "explicitly tell gh-ost binlog applier to ignore NO_ZERO_IN_DATE,NO_ZERO_DATE in sql_mode")
-	flag.BoolVar(&migrationContext.AliyunRDS, "aliyun-rds", false, "set to 'true' when you execute on Aliyun RDS.")
-	flag.BoolVar(&migrationContext.GoogleCloudPlatform, "gcp", false, "set to 'true' when you execute on a 1st generation Google Cloud Platform (GCP).")
-	flag.BoolVar(&migrationContext.AzureMySQL, "azure", false, "set to 'true' when you execute on Azure Database on MySQL.")
+	flag.BoolVar(&migrationContext.AliyunRDS, "aliyun-rds", false, "set to 'true' when you execute on Aliyun RDS. Servers are probed for their port and privileges regardless; this acknowledges a server reporting a port other than the one connected to")
+	flag.BoolVar(&migrationContext.GoogleCloudPlatform, "gcp", false, "set to 'true' when you execute on a 1st generation Google Cloud Platform (GCP). Servers are probed for their port and privileges regardless; this acknowledges a server reporting a port other than the one connected to")
+	flag.BoolVar(&migrationContext.AzureMySQL, "azure", false, "set to 'true' when you execute on Azure Database on MySQL. Servers are probed for their port and privileges regardless; this acknowledges a server reporting a port other than the one connected to")
+	flag.BoolVar(&migrationContext.ProxyCompat, "proxy-compat", false, "set to 'true' when you connect through a proxy such as ProxySQL or Vitess vtgate. Disables topology discovery; requires --assume-master-host, --throttle-control-replicas and --binlog-host")
+	flag.StringVar(&migrationContext.ProxyProbeQuery, "proxy-probe-query", "", "(with --proxy-compat) query run through the proxy and routed to the migrated backend, e.g. by a routing comment, returning that backend's binlog_format, which must be ROW")
+	flag.StringVar(&migrationContext.BinlogHostname, "binlog-host", "", "(with --proxy-compat) direct address of the inspected backend, bypassing the proxy, to stream binary logs from and validate binary log settings on. Format: some.host.com[:port]")
 	flag.BoolVar(&migrationContext.UseGTIDs, "gtid", false, "(experimental) set to 'true' to use MySQL GTIDs for binlog positioning.")
 
 	executeFlag := flag.Bool("execute", false, "actually execute the alter & migrate the table. Default is noop: do some tests and exit")
 	flag.BoolVar(&migrationContext.TestOnReplica, "test-on-replica", false, "Have the migration run on a replica, not on the master. At the end of migration replication is stopped, and tables are swapped and immediately swap-revert. Replication remains stopped and you can compare the two tables for building trust")
 	flag.BoolVar(&migrationContext.TestOnReplicaSkipReplicaStop, "test-on-replica-skip-replica-stop", false, "When --test-on-replica is enabled, do not issue commands stop replication (requires --test-on-replica)")
 	flag.BoolVar(&migrationContext.MigrateOnReplica, "migrate-on-replica", false, "Have the migration run on a replica, not on the master. This will do the full migration on the replica including cut-over (as opposed to --test-on-replica)")
+	flag.StringVar(&migrationContext.ReplicaPromotionPlanFile, "replica-promotion-plan-file", "", "(with --migrate-on-replica) upon cut-over, write a JSON plan for promoting the replica to this file: binlog coordinates, the migrated table's structure and the commands to re-point other replicas")
+	flag.BoolVar(&migrationContext.AwaitReplicaPromotion, "await-replica-promotion", false, "(with --migrate-on-replica) upon cut-over, keep replicating into the migrated table until the 'promote' interactive command, which stops replication and rewrites --replica-promotion-plan-file with final coordinates")
 
 	flag.BoolVar(&migrationContext.OkToDropTable, "ok-to-drop-table", false, "Shall the tool drop the old table at end of operation. DROPping tables can be a long locking operation, which is why I'm not doing it by default. I'm an online tool, yes?")
 	flag.BoolVar(&migrationContext.InitiallyDropOldTable, "initially-drop-old-table", false, "Drop a possibly existing OLD table (remains from a previous run?) before beginning operation. Default is to panic and abort if such table exists")
@@ -107,13 +139,19 @@ func main() {
 	flag.BoolVar(&migrationContext.CutOverExponentialBackoff, "cut-over-exponential-backoff", false, "Wait exponentially longer intervals between failed cut-over attempts. Wait intervals obey a maximum configurable with 'exponential-backoff-max-interval').")
 	exponentialBackoffMaxInterval := flag.Int64("exponential-backoff-max-interval", 64, "Maximum number of seconds to wait between attempts when performing various operations with exponential backoff.")
 	chunkSize := flag.Int64("chunk-size", 1000, "amount of rows to handle in each iteration (allowed range: 10-100,000)")
+	flag.StringVar(&migrationContext.ChunkIndex, "chunk-index", "", "name of the unique key to iterate the table by. Must be a non-nullable unique key shared by the original and altered tables. By default gh-ost elects the key, preferring non-nullable, integer and short keys")
+	flag.Int64Var(&migrationContext.QueryMaxExecutionTimeMillis, "query-max-execution-time-millis", 0, "when positive, limit gh-ost's chunk range calculation and exact row count queries to this execution time, via MAX_EXECUTION_TIME optimizer hint. A range calculation exceeding it is retried with a halved chunk-size. 0 to disable")
+	chunkCopyOptimizerHints := flag.String("chunk-copy-optimizer-hints", "", "optimizer hints to inject into the rowcopy SELECT, without the enclosing /*+ */. Example: 'INDEX(mytable my_idx)'. Only use for pathological optimizer cases")
+	copyConflictStrategy := flag.String("copy-conflict-strategy", "ignore", "how row copy treats a copied row conflicting with a ghost table row: ignore (insert ignore, keeping the ghost row), replace (replace into), upsert (insert ... on duplicate key update). Conflicts other than duplicate keys fail the chunk with replace and upsert")
 	dmlBatchSize := flag.Int64("dml-batch-size", 10, "batch size for DML events to apply in a single transaction (range 1-1000)")
+	applierParallelism := flag.Int64("applier-parallelism", 1, "number of applier workers applying binlog events onto the ghost table concurrently, each on its own connection (range 1-64). Events on a same row are always applied in order by the same worker")
 	defaultRetries := flag.Int64("default-retries", 60, "Default number of retries for various operations before panicking")
 	flag.BoolVar(&migrationContext.PanicOnWarnings, "panic-on-warnings", false, "Panic when SQL warnings are encountered when copying a batch indicating data loss")
 	cutOverLockTimeoutSeconds := flag.Int64("cut-over-lock-timeout-seconds", 3, "Max number of seconds to hold locks on tables while attempting to cut-over (retry attempted when lock exceeds timeout) or attempting instant DDL")
 	niceRatio := flag.Float64("nice-ratio", 0, "force being 'nice', imply sleep time per chunk time; range: [0.0..100.0]. Example values: 0 is aggressive. 1: for every 1ms spent copying rows, sleep additional 1ms (effectively doubling runtime); 0.7: for every 10ms spend in a rowcopy chunk, spend 7ms sleeping immediately after")
 
 	maxLagMillis := flag.Int64("max-lag-millis", 1500, "replication lag at which to throttle operation")
+	flag.DurationVar(&migrationContext.MaxApplierLag, "max-applier-lag", 0, "When positive, postpone cut-over while the ghost table lags behind the binary log by more than this duration, e.g. '5s'. 0 disables")
 	replicationLagQuery := flag.String("replication-lag-query", "", "Deprecated. gh-ost uses an internal, subsecond resolution query")
 	throttleControlReplicas := flag.String("throttle-control-replicas", "", "List of replicas on which to check for lag; comma delimited. Example: myhost1.com:3306,myhost2.com,myhost3.com:3307")
 	throttleQuery := flag.String("throttle-query", "", "when given, issued (every second) to check if operation should throttle. Expecting to return zero for no-throttle, >0 for throttle. Query is issued on the migrated server. Make sure this query is lightweight")
@@ -126,10 +164,13 @@ func main() {
 	flag.StringVar(&migrationContext.ThrottleAdditionalFlagFile, "throttle-additional-flag-file", "/tmp/gh-ost.throttle", "operation pauses when this file exists; hint: keep default, use for throttling multiple gh-ost operations")
 	flag.StringVar(&migrationContext.PostponeCutOverFlagFile, "postpone-cut-over-flag-file", "", "while this file exists, migration will postpone the final stage of swapping tables, and will keep on syncing the ghost table. Cut-over/swapping would be ready to perform the moment the file is deleted.")
 	flag.StringVar(&migrationContext.PanicFlagFile, "panic-flag-file", "", "when this file is created, gh-ost will immediately terminate, without cleanup")
+	flag.StringVar(&migrationContext.ReportFile, "report-file", "", "upon exit, successful or not, write a JSON report of the migration to this file: outcome, timings, counters, binlog coordinates, configuration (passwords redacted) and hooks executed")
+	flag.StringVar(&migrationContext.SummaryFile, "summary-file", "", "upon success, write a JSON summary of the migration, including binlog coordinates at start, row-copy completion and cut-over, to this file")
 
 	flag.BoolVar(&migrationContext.DropServeSocket, "initially-drop-socket-file", false, "Should gh-ost forcibly delete an existing socket file. Be careful: this might drop the socket file of a running migration!")
 	flag.StringVar(&migrationContext.ServeSocketFile, "serve-socket-file", "", "Unix socket file to serve on. Default: auto-determined and advertised upon startup")
 	flag.Int64Var(&migrationContext.ServeTCPPort, "serve-tcp-port", 0, "TCP port to serve on. Default: disabled")
+	flag.StringVar(&migrationContext.StatusListenAddress, "status-listen", "", "Address to serve read-only HTTP status on (/status, /healthz, /progress), e.g. ':8080'. Default: disabled")
 
 	flag.StringVar(&migrationContext.HooksPath, "hooks-path", "", "directory where hook files are found (default: empty, ie. hooks disabled). Hook files found on this path, and conforming to hook naming conventions will be executed")
 	flag.StringVar(&migrationContext.HooksHintMessage, "hooks-hint", "", "arbitrary message to be injected to hooks via GH_OST_HOOKS_HINT, for your convenience")
@@ -148,9 +189,23 @@ func main() {
 	flag.BoolVar(&migrationContext.SkipPortValidation, "skip-port-validation", false, "Skip port validation for MySQL connections")
 	flag.BoolVar(&migrationContext.Checkpoint, "checkpoint", false, "Enable migration checkpoints")
 	flag.Int64Var(&migrationContext.CheckpointIntervalSeconds, "checkpoint-seconds", 300, "The number of seconds between checkpoints")
+	flag.Int64Var(&migrationContext.StallTimeoutSeconds, "stall-timeout-seconds", 0, "When positive, report a stalled migration if it makes no progress (copying rows, applying binlog events) for this many seconds while not throttled or postponing cut-over. 0 disables")
+	flag.BoolVar(&migrationContext.AbortOnStall, "abort-on-stall", false, "Abort the migration when it is found stalled (requires --stall-timeout-seconds)")
+	maxRuntime := flag.Duration("max-runtime", 0, "When positive, a deadline for the migration, e.g. '6h'. gh-ost never cuts over past the deadline; see --max-runtime-action. 0 disables")
+	flag.StringVar(&migrationContext.MaxRuntimeAction, "max-runtime-action", "abort", "What to do once --max-runtime is exceeded: 'abort' (drop gh-ost tables and exit) or 'postpone' (keep applying binlog events, postponing cut-over)")
+	flag.Int64Var(&migrationContext.MaxBacklogMemoryMB, "max-backlog-memory", 0, "When positive, pause reading the binary log while binlog events buffered for applying take more than this many megabytes. 0 disables")
+	flag.Int64Var(&migrationContext.MaxMemoryMB, "max-memory", 0, "When positive, pause reading the binary log while gh-ost's heap takes more than this many megabytes and binlog events are buffered for applying. 0 disables")
+	flag.Int64Var(&migrationContext.WarmUpSeconds, "warm-up-seconds", 0, "When positive, once row copy is complete and before cut-over, scan the ghost table's indexes for up to this many seconds, warming up the buffer pool. 0 disables")
+	flag.Int64Var(&migrationContext.VerifyRowCountThreshold, "verify-rowcount-threshold", 0, "When positive, and the table is estimated to have fewer rows than this, exactly count the rows of the original and ghost tables while the original table is locked for cut-over, and fail the cut-over on mismatch. 0 disables")
+	flag.Int64Var(&migrationContext.MaxConcurrentMigrations, "max-concurrent-migrations", 0, "When positive, register on --coordination-table and wait before copying rows until fewer than this many other migrations are running or queued ahead. 0 disables")
+	flag.StringVar(&migrationContext.CoordinationTable, "coordination-table", "_gh_ost_migrations", "Table concurrent migrations register on (see --max-concurrent-migrations). Lives in the migrated schema, unless given as 'schema.table'")
+	flag.Int64Var(&migrationContext.CoordinationStaleSeconds, "coordination-stale-seconds", 60, "Ignore registrations on --coordination-table that have not heartbeated for this many seconds")
 	flag.BoolVar(&migrationContext.Resume, "resume", false, "Attempt to resume migration from checkpoint")
 	flag.BoolVar(&migrationContext.Revert, "revert", false, "Attempt to revert completed migration")
 	flag.StringVar(&migrationContext.OldTableName, "old-table", "", "The name of the old table when using --revert, e.g. '_mytable_del'")
+	flag.BoolVar(&migrationContext.Cleanup, "cleanup", false, "Rather than migrate, drop the tables left behind by a failed or killed migration of the table, given the same flags. Without --execute, only list them")
+	flag.BoolVar(&migrationContext.Observe, "observe", false, "Rather than migrate, run preflight checks and watch the table's binary log events and the throttler for --observe-duration, creating nothing, then report the write rate and projections for the migration")
+	flag.DurationVar(&migrationContext.ObserveDuration, "observe-duration", time.Hour, "How long --observe watches for, e.g. '30m'")
 
 	maxLoad := flag.String("max-load", "", "Comma delimited status-name=threshold. e.g: 'Threads_running=100,Threads_connected=500'. When status exceeds threshold, app throttles writes")
 	criticalLoad := flag.String("critical-load", "", "Comma delimited status-name=threshold, same format as --max-load. When status exceeds threshold, app panics and quits")
@@ -159,6 +214,7 @@ func main() {
 	quiet := flag.Bool("quiet", false, "quiet")
 	verbose := flag.Bool("verbose", false, "verbose")
 	debug := flag.Bool("debug", false, "debug mode (very verbose)")
+	logLevels := flag.String("log-levels", "", "Comma delimited component=level, overriding the general log level for given components. Components: applier, inspector, migrator, server, streamer, throttler. e.g: 'applier=debug,throttler=error'")
 	stack := flag.Bool("stack", false, "add stack trace upon error")
 	help := flag.Bool("help", false, "Display usage")
 	version := flag.Bool("version", false, "Print version & exit")
@@ -188,6 +244,11 @@ func main() {
 		return
 	}
 
+	migrationContext.CommandLineFlags = make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		migrationContext.CommandLineFlags[f.Name] = f.Value.String()
+	})
+
 	migrationContext.Log.SetLevel(log.ERROR)
 	if *verbose {
 		migrationContext.Log.SetLevel(log.INFO)
@@ -202,14 +263,20 @@ func main() {
 		// Override!!
 		migrationContext.Log.SetLevel(log.ERROR)
 	}
+	if err := migrationContext.SetComponentLogLevels(*logLevels); err != nil {
+		migrationContext.Log.Fatale(err)
+	}
 
 	if err := migrationContext.SetConnectionConfig(*storageEngine); err != nil {
 		migrationContext.Log.Fatale(err)
 	}
 
 	migrationContext.SetConnectionCharset(*charset)
+	if err := migrationContext.SetConnectionTimeZone(*timeZone); err != nil {
+		migrationContext.Log.Fatale(err)
+	}
 
-	if migrationContext.AlterStatement == "" && !migrationContext.Revert {
+	if migrationContext.AlterStatement == "" && !migrationContext.Revert && !migrationContext.Cleanup {
 		log.Fatal("--alter must be provided and statement must not be empty")
 	}
 	parser := sql.NewParserFromAlterStatement(migrationContext.AlterStatement)
@@ -230,6 +297,12 @@ func main() {
 		if migrationContext.AttemptInstantDDL {
 			log.Warning("--attempt-instant-ddl was provided with --revert, it will be ignored")
 		}
+		if migrationContext.AttemptInplaceIndexDDL {
+			log.Warning("--attempt-inplace-index-ddl was provided with --revert, it will be ignored")
+		}
+		if migrationContext.AllowTempIndex {
+			log.Warning("--allow-temp-index was provided with --revert, it will be ignored")
+		}
 		if migrationContext.IncludeTriggers {
 			log.Warning("--include-triggers was provided with --revert, it will be ignored")
 		}
@@ -238,6 +311,33 @@ func main() {
 		}
 	}
 
+	if migrationContext.Cleanup {
+		if migrationContext.Revert {
+			log.Fatal("--cleanup cannot be used with --revert")
+		}
+		if migrationContext.Resume {
+			log.Fatal("--cleanup cannot be used with --resume")
+		}
+	}
+
+	if migrationContext.Observe {
+		if migrationContext.Revert {
+			log.Fatal("--observe cannot be used with --revert")
+		}
+		if migrationContext.Cleanup {
+			log.Fatal("--observe cannot be used with --cleanup")
+		}
+		if migrationContext.Resume {
+			log.Fatal("--observe cannot be used with --resume")
+		}
+		if migrationContext.ObserveDuration <= 0 {
+			log.Fatal("--observe-duration must be positive")
+		}
+		if *executeFlag {
+			log.Warning("--execute was provided with --observe, it will be ignored: nothing is written")
+		}
+	}
+
 	if migrationContext.DatabaseName == "" {
 		if parser.HasExplicitSchema() {
 			migrationContext.DatabaseName = parser.GetExplicitSchema()
@@ -267,6 +367,9 @@ func main() {
 	if migrationContext.MigrateOnReplica && migrationContext.TestOnReplica {
 		migrationContext.Log.Fatal("--migrate-on-replica and --test-on-replica are mutually exclusive")
 	}
+	if (migrationContext.ReplicaPromotionPlanFile != "" || migrationContext.AwaitReplicaPromotion) && !migrationContext.MigrateOnReplica {
+		migrationContext.Log.Fatal("--replica-promotion-plan-file and --await-replica-promotion require --migrate-on-replica")
+	}
 	if migrationContext.SwitchToRowBinlogFormat && migrationContext.AssumeRBR {
 		migrationContext.Log.Fatal("--switch-to-rbr and --assume-rbr are mutually exclusive")
 	}
@@ -276,12 +379,49 @@ func main() {
 		}
 		migrationContext.Log.Warning("--test-on-replica-skip-replica-stop enabled. We will not stop replication before cut-over. Ensure you have a plugin that does this.")
 	}
+	if migrationContext.ProxyCompat {
+		if migrationContext.AssumeMasterHostname == "" {
+			migrationContext.Log.Fatal("--proxy-compat requires --assume-master-host: the master cannot be discovered through a proxy")
+		}
+		if *throttleControlReplicas == "" {
+			migrationContext.Log.Fatal("--proxy-compat requires --throttle-control-replicas: replicas cannot be discovered through a proxy")
+		}
+		if migrationContext.BinlogHostname == "" {
+			migrationContext.Log.Fatal("--proxy-compat requires --binlog-host: the replication protocol cannot go through a proxy, and binary logs must be streamed from a direct backend address")
+		}
+		if migrationContext.TestOnReplica || migrationContext.MigrateOnReplica {
+			migrationContext.Log.Fatal("--proxy-compat does not support --test-on-replica and --migrate-on-replica, which control replication through the inspected connection")
+		}
+		if migrationContext.SwitchToRowBinlogFormat {
+			migrationContext.Log.Fatal("--proxy-compat does not support --switch-to-rbr")
+		}
+		if !migrationContext.AssumeRBR {
+			migrationContext.Log.Infof("--proxy-compat given; implying --assume-rbr, as replication cannot be restarted through a proxy")
+			migrationContext.AssumeRBR = true
+		}
+	} else if migrationContext.BinlogHostname != "" {
+		migrationContext.Log.Fatal("--binlog-host requires --proxy-compat")
+	} else if migrationContext.ProxyProbeQuery != "" {
+		migrationContext.Log.Fatal("--proxy-probe-query requires --proxy-compat")
+	}
 	if migrationContext.CliMasterUser != "" && migrationContext.AssumeMasterHostname == "" {
 		migrationContext.Log.Fatal("--master-user requires --assume-master-host")
 	}
 	if migrationContext.CliMasterPassword != "" && migrationContext.AssumeMasterHostname == "" {
 		migrationContext.Log.Fatal("--master-password requires --assume-master-host")
 	}
+	if *masterPasswordFile != "" && migrationContext.AssumeMasterHostname == "" {
+		migrationContext.Log.Fatal("--master-password-file requires --assume-master-host")
+	}
+	if migrationContext.CliMasterPassword != "" && *masterPasswordFile != "" {
+		migrationContext.Log.Fatal("--master-password and --master-password-file are mutually exclusive")
+	}
+	if migrationContext.CliPassword != "" && *passwordFile != "" {
+		migrationContext.Log.Fatal("--password and --password-file are mutually exclusive")
+	}
+	if *askPass && (migrationContext.CliPassword != "" || *passwordFile != "") {
+		migrationContext.Log.Fatal("--ask-pass is mutually exclusive with --password and --password-file")
+	}
 	if migrationContext.TLSCACertificate != "" && !migrationContext.UseTLS {
 		migrationContext.Log.Fatal("--ssl-ca requires --ssl")
 	}
@@ -297,6 +437,12 @@ func main() {
 	if *replicationLagQuery != "" {
 		migrationContext.Log.Warningf("--replication-lag-query is deprecated")
 	}
+	if migrationContext.GhostDatabaseName == migrationContext.DatabaseName {
+		migrationContext.GhostDatabaseName = ""
+	}
+	if migrationContext.GhostDatabaseName != "" && migrationContext.IncludeTriggers {
+		migrationContext.Log.Fatalf("--ghost-database cannot be used with --include-triggers: MySQL cannot rename a table with triggers to another schema")
+	}
 	if migrationContext.IncludeTriggers && migrationContext.TriggerSuffix == "" {
 		migrationContext.Log.Fatalf("--trigger-suffix must be used with --include-triggers")
 	}
@@ -316,6 +462,41 @@ func main() {
 	if migrationContext.CheckpointIntervalSeconds < 10 {
 		migrationContext.Log.Fatalf("--checkpoint-seconds should be >=10")
 	}
+	if migrationContext.StallTimeoutSeconds < 0 {
+		migrationContext.Log.Fatalf("--stall-timeout-seconds must be >= 0")
+	}
+	if migrationContext.AbortOnStall && migrationContext.StallTimeoutSeconds == 0 {
+		migrationContext.Log.Fatalf("--abort-on-stall requires --stall-timeout-seconds")
+	}
+	if migrationContext.MaxBacklogMemoryMB < 0 {
+		migrationContext.Log.Fatalf("--max-backlog-memory must be >= 0")
+	}
+	if migrationContext.MaxMemoryMB < 0 {
+		migrationContext.Log.Fatalf("--max-memory must be >= 0")
+	}
+	if migrationContext.MaxApplierLag < 0 {
+		migrationContext.Log.Fatalf("--max-applier-lag must be >= 0")
+	}
+	if *maxRuntime < 0 {
+		migrationContext.Log.Fatalf("--max-runtime must be >= 0")
+	}
+	switch migrationContext.MaxRuntimeAction {
+	case "abort", "postpone":
+	default:
+		migrationContext.Log.Fatalf("Unknown max-runtime-action: %s", migrationContext.MaxRuntimeAction)
+	}
+	if migrationContext.WarmUpSeconds < 0 {
+		migrationContext.Log.Fatalf("--warm-up-seconds must be >= 0")
+	}
+	if migrationContext.VerifyRowCountThreshold < 0 {
+		migrationContext.Log.Fatalf("--verify-rowcount-threshold must be >= 0")
+	}
+	if migrationContext.MaxConcurrentMigrations < 0 {
+		migrationContext.Log.Fatalf("--max-concurrent-migrations must be >= 0")
+	}
+	if migrationContext.CoordinationStaleSeconds < 5 {
+		migrationContext.Log.Fatalf("--coordination-stale-seconds should be >=5")
+	}
 
 	switch *cutOver {
 	case "atomic", "default", "":
@@ -325,9 +506,16 @@ func main() {
 	default:
 		migrationContext.Log.Fatalf("Unknown cut-over: %s", *cutOver)
 	}
+	switch strategy := sql.CopyConflictStrategy(*copyConflictStrategy); strategy {
+	case sql.CopyConflictIgnore, sql.CopyConflictReplace, sql.CopyConflictUpsert:
+		migrationContext.CopyConflictStrategy = strategy
+	default:
+		migrationContext.Log.Fatalf("Unknown copy-conflict-strategy: %s", *copyConflictStrategy)
+	}
 	if err := migrationContext.ReadConfigFile(); err != nil {
 		migrationContext.Log.Fatale(err)
 	}
+	migrationContext.ReadIgnoredColumns(*ignoreColumns)
 	if err := migrationContext.ReadThrottleControlReplicaKeys(*throttleControlReplicas); err != nil {
 		migrationContext.Log.Fatale(err)
 	}
@@ -348,13 +536,28 @@ func main() {
 		}
 		migrationContext.CliPassword = string(bytePassword)
 	}
+	if err := migrationContext.ReadPasswords(*passwordFile, *masterPasswordFile); err != nil {
+		migrationContext.Log.Fatale(err)
+	}
+
+	if *dmlBatchSize < 1 || *dmlBatchSize > base.MaxEventsBatchSize {
+		migrationContext.Log.Fatalf("--dml-batch-size must be between 1 and %d", base.MaxEventsBatchSize)
+	}
+	if *applierParallelism < 1 || *applierParallelism > base.MaxApplierParallelism {
+		migrationContext.Log.Fatalf("--applier-parallelism must be between 1 and %d", base.MaxApplierParallelism)
+	}
 
 	migrationContext.SetHeartbeatIntervalMilliseconds(*heartbeatIntervalMillis)
 	migrationContext.SetNiceRatio(*niceRatio)
 	migrationContext.SetChunkSize(*chunkSize)
 	migrationContext.SetDMLBatchSize(*dmlBatchSize)
+	migrationContext.SetApplierParallelism(*applierParallelism)
 	migrationContext.SetMaxLagMillisecondsThrottleThreshold(*maxLagMillis)
+	migrationContext.SetMaxRuntime(*maxRuntime)
 	migrationContext.SetThrottleQuery(*throttleQuery)
+	if err := migrationContext.SetChunkCopyOptimizerHints(*chunkCopyOptimizerHints); err != nil {
+		migrationContext.Log.Fatale(err)
+	}
 	migrationContext.SetThrottleHTTP(*throttleHTTP)
 	migrationContext.SetIgnoreHTTPErrors(*ignoreHTTPErrors)
 	migrationContext.SetDefaultNumRetries(*defaultRetries)
@@ -370,18 +573,25 @@ func main() {
 	}
 
 	log.Infof("starting gh-ost %+v (git commit: %s)", AppVersion, GitCommit)
-	acceptSignals(migrationContext)
-
 	migrator := logic.NewMigrator(migrationContext, AppVersion)
+	acceptSignals(migrationContext, migrator)
+
 	var err error
 	if migrationContext.Revert {
 		err = migrator.Revert()
+	} else if migrationContext.Cleanup {
+		err = migrator.Cleanup()
+	} else if migrationContext.Observe {
+		err = migrator.Observe()
 	} else {
 		err = migrator.Migrate()
 	}
 
 	if err != nil {
 		migrator.ExecOnFailureHook()
+	}
+	migrator.WriteReport(err)
+	if err != nil {
 		migrationContext.Log.Fatale(err)
 	}
 	fmt.Fprintln(os.Stdout, "# Done")
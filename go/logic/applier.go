@@ -8,6 +8,8 @@ package logic
 import (
 	gosql "database/sql"
 	"fmt"
+	"hash/fnv"
+	"maps"
 	"reflect"
 	"regexp"
 	"strings"
@@ -32,31 +34,70 @@ import (
 const (
 	GhostChangelogTableComment = "gh-ost changelog"
 	atomicCutOverMagicHint     = "ghost-cut-over-sentry"
+	changelogPruneInterval     = time.Hour
+	changelogPruneRetention    = 24 * time.Hour
+	dmlBatchQueryCacheSize     = 256
+	dmlStatementCacheSize      = 16
 )
 
 // ErrNoCheckpointFound is returned when an empty checkpoint table is queried.
 var ErrNoCheckpointFound = errors.New("no checkpoint found in _ghk table")
 
+// dmlQueryKind identifies the builder query a DML statement runs. With column transforms, inserts
+// and updates alike write the full row through the transforms, which is a query of its own.
+type dmlQueryKind byte
+
+const (
+	dmlDeleteQuery dmlQueryKind = iota
+	dmlInsertQuery
+	dmlUpdateQuery
+	dmlTransformedInsertQuery
+)
+
+// dmlQueryKindKeys are the prepared statement cache keys of each query kind
+var dmlQueryKindKeys = [...]string{
+	dmlDeleteQuery:            "delete",
+	dmlInsertQuery:            "insert",
+	dmlUpdateQuery:            "update",
+	dmlTransformedInsertQuery: "transformed-insert",
+}
+
 type dmlBuildResult struct {
 	query     string
 	args      []interface{}
+	kind      dmlQueryKind
 	rowsDelta int64
-	err       error
 }
 
-func newDmlBuildResult(query string, args []interface{}, rowsDelta int64, err error) *dmlBuildResult {
-	return &dmlBuildResult{
-		query:     query,
-		args:      args,
-		rowsDelta: rowsDelta,
-		err:       err,
-	}
+// dmlBatch holds the buffers used to build a batch of DML events into a single
+// multi-statement query. Batches are pooled so that buffers are reused across batches.
+type dmlBatch struct {
+	results   []dmlBuildResult
+	args      []interface{}
+	namedArgs []driver.NamedValue
+	queryKey  []byte
 }
 
-func newDmlBuildResultError(err error) *dmlBuildResult {
-	return &dmlBuildResult{
-		err: err,
-	}
+// dmlStatement is a cached prepared statement, closed once evicted and no longer in use
+type dmlStatement struct {
+	stmt    *gosql.Stmt
+	users   int
+	evicted bool
+}
+
+var dmlBatchPool = sync.Pool{
+	New: func() interface{} { return &dmlBatch{} },
+}
+
+func (this *dmlBatch) release() {
+	clear(this.results)
+	clear(this.args)
+	clear(this.namedArgs)
+	this.results = this.results[:0]
+	this.args = this.args[:0]
+	this.namedArgs = this.namedArgs[:0]
+	this.queryKey = this.queryKey[:0]
+	dmlBatchPool.Put(this)
 }
 
 // Applier connects and writes the applier-server, which is the server where migration
@@ -69,8 +110,10 @@ type Applier struct {
 	db                *gosql.DB
 	singletonDB       *gosql.DB
 	migrationContext  *base.MigrationContext
+	log               base.Logger
 	finishedMigrating int64
 	name              string
+	poolParallelism   int
 
 	CurrentCoordinatesMutex sync.Mutex
 	CurrentCoordinates      mysql.BinlogCoordinates
@@ -78,23 +121,71 @@ type Applier struct {
 	LastIterationRangeMutex     sync.Mutex
 	LastIterationRangeMinValues *sql.ColumnValues
 	LastIterationRangeMaxValues *sql.ColumnValues
+	LastIterationPartitionIndex int
 
 	dmlDeleteQueryBuilder        *sql.DMLDeleteQueryBuilder
 	dmlInsertQueryBuilder        *sql.DMLInsertQueryBuilder
 	dmlUpdateQueryBuilder        *sql.DMLUpdateQueryBuilder
 	checkpointInsertQueryBuilder *sql.CheckpointInsertQueryBuilder
+
+	dmlBatchQueryCacheMutex sync.Mutex
+	dmlBatchQueryCache      map[string]string
+
+	// dmlStatements holds the server-side prepared statements of single statement batches by query kind,
+	// or a nil statement for a query that failed to prepare.
+	// database/sql prepares a statement on each connection it runs on, including reconnected ones.
+	dmlStatementsMutex sync.Mutex
+	dmlStatements      map[string]*dmlStatement
+
+	// heartbeatMutex is held while writing a heartbeat, or recreating the changelog table
+	heartbeatMutex   sync.Mutex
+	heartbeatStopped bool
+
+	// changelogRows holds the last value written to the changelog table's state and throttle rows,
+	// restored should the changelog table be recreated
+	changelogRowsMutex sync.Mutex
+	changelogRows      map[string]string
+
+	// lockedOriginalTableRows is the exact row count of the original table as counted while locked
+	// for cut-over, or -1 when not counted (see --verify-rowcount-threshold)
+	lockedOriginalTableRows int64
 }
 
 func NewApplier(migrationContext *base.MigrationContext) *Applier {
 	return &Applier{
-		connectionConfig:  migrationContext.ApplierConnectionConfig,
-		migrationContext:  migrationContext,
-		finishedMigrating: 0,
-		name:              "applier",
+		connectionConfig:   migrationContext.ApplierConnectionConfig,
+		migrationContext:   migrationContext,
+		log:                migrationContext.NewComponentLogger(base.ApplierLogComponent),
+		finishedMigrating:  0,
+		name:               "applier",
+		dmlBatchQueryCache: make(map[string]string),
+		dmlStatements:      make(map[string]*dmlStatement),
+		changelogRows:      make(map[string]string),
 	}
 }
 
+// ResizeConnectionPool sizes the connection pool for the given number of applier workers, each of which
+// holds a connection while applying its events, on top of the row copy. It is not safe for concurrent use.
+func (this *Applier) ResizeConnectionPool(parallelism int) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism == this.poolParallelism {
+		return
+	}
+	this.poolParallelism = parallelism
+	connections := mysql.MaxDBPoolConnections
+	if parallelism > 1 {
+		connections += parallelism
+	}
+	this.db.SetMaxOpenConns(connections)
+	this.db.SetMaxIdleConns(connections)
+}
+
 func (this *Applier) InitDBConnections() (err error) {
+	if err := base.ValidateTimeZone(this.connectionConfig, this.migrationContext, this.name); err != nil {
+		return err
+	}
 	applierUri := this.connectionConfig.GetDBUri(this.migrationContext.DatabaseName)
 	uriWithMulti := fmt.Sprintf("%s&multiStatements=true", applierUri)
 	if this.db, _, err = mysql.GetDB(this.migrationContext.Uuid, uriWithMulti); err != nil {
@@ -105,6 +196,7 @@ func (this *Applier) InitDBConnections() (err error) {
 		return err
 	}
 	this.singletonDB.SetMaxOpenConns(1)
+	this.ResizeConnectionPool(int(this.migrationContext.GetApplierParallelism()))
 	version, err := base.ValidateConnection(this.db, this.connectionConfig, this.migrationContext, this.name)
 	if err != nil {
 		return err
@@ -116,23 +208,26 @@ func (this *Applier) InitDBConnections() (err error) {
 	if err := this.validateAndReadGlobalVariables(); err != nil {
 		return err
 	}
-	if !this.migrationContext.AliyunRDS && !this.migrationContext.GoogleCloudPlatform && !this.migrationContext.AzureMySQL {
+	if !this.migrationContext.TrustsConnectionPort() {
 		if impliedKey, err := mysql.GetInstanceKey(this.db); err != nil {
 			return err
 		} else {
 			this.connectionConfig.ImpliedKey = impliedKey
 		}
 	}
-	if err := this.readTableColumns(); err != nil {
-		return err
+	if !this.migrationContext.Cleanup {
+		// A failed cut-over may leave the original table renamed away; cleanup does not need it
+		if err := this.readTableColumns(); err != nil {
+			return err
+		}
 	}
-	this.migrationContext.Log.Infof("Applier initiated on %+v, version %+v", this.connectionConfig.ImpliedKey, this.migrationContext.ApplierMySQLVersion)
+	this.log.Infof("Applier initiated on %+v, version %+v", this.connectionConfig.ImpliedKey, this.migrationContext.ApplierMySQLVersion)
 	return nil
 }
 
 func (this *Applier) prepareQueries() (err error) {
 	if this.dmlDeleteQueryBuilder, err = sql.NewDMLDeleteQueryBuilder(
-		this.migrationContext.DatabaseName,
+		this.migrationContext.GetGhostDatabaseName(),
 		this.migrationContext.GetGhostTableName(),
 		this.migrationContext.OriginalTableColumns,
 		&this.migrationContext.UniqueKey.Columns,
@@ -140,16 +235,17 @@ func (this *Applier) prepareQueries() (err error) {
 		return err
 	}
 	if this.dmlInsertQueryBuilder, err = sql.NewDMLInsertQueryBuilder(
-		this.migrationContext.DatabaseName,
+		this.migrationContext.GetGhostDatabaseName(),
 		this.migrationContext.GetGhostTableName(),
 		this.migrationContext.OriginalTableColumns,
 		this.migrationContext.SharedColumns,
 		this.migrationContext.MappedSharedColumns,
+		this.migrationContext.ColumnTransforms,
 	); err != nil {
 		return err
 	}
 	if this.dmlUpdateQueryBuilder, err = sql.NewDMLUpdateQueryBuilder(
-		this.migrationContext.DatabaseName,
+		this.migrationContext.GetGhostDatabaseName(),
 		this.migrationContext.GetGhostTableName(),
 		this.migrationContext.OriginalTableColumns,
 		this.migrationContext.SharedColumns,
@@ -160,7 +256,7 @@ func (this *Applier) prepareQueries() (err error) {
 	}
 	if this.migrationContext.Checkpoint {
 		if this.checkpointInsertQueryBuilder, err = sql.NewCheckpointQueryBuilder(
-			this.migrationContext.DatabaseName,
+			this.migrationContext.GetChangelogDatabaseName(),
 			this.migrationContext.GetCheckpointTableName(),
 			&this.migrationContext.UniqueKey.Columns,
 		); err != nil {
@@ -179,8 +275,13 @@ func (this *Applier) validateAndReadGlobalVariables() error {
 	); err != nil {
 		return err
 	}
+	if this.migrationContext.TimeZone != "" {
+		this.migrationContext.ApplierTimeZone = this.migrationContext.TimeZone
+		this.log.Infof("will use time_zone='%s' on applier, as on all connections (--time-zone)", this.migrationContext.ApplierTimeZone)
+		return nil
+	}
 
-	this.migrationContext.Log.Infof("will use time_zone='%s' on applier", this.migrationContext.ApplierTimeZone)
+	this.log.Infof("will use time_zone='%s' on applier", this.migrationContext.ApplierTimeZone)
 	return nil
 }
 
@@ -211,9 +312,89 @@ func (this *Applier) generateInstantDDLQuery() string {
 	)
 }
 
+// generateInplaceIndexDDLQuery returns the SQL for this index-only ALTER operation,
+// asserting it runs in-place and without blocking writes
+func (this *Applier) generateInplaceIndexDDLQuery() string {
+	return fmt.Sprintf(`ALTER /* gh-ost */ TABLE %s.%s %s, ALGORITHM=INPLACE, LOCK=NONE`,
+		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.OriginalTableName),
+		this.migrationContext.AlterStatementOptions,
+	)
+}
+
+// AttemptInplaceIndexDDL runs an index-only ALTER in-place on the original table. onStarted is called with the
+// id of the connection running the ALTER, so that its progress may be monitored. The ALTER is killed if the
+// context is cancelled.
+func (this *Applier) AttemptInplaceIndexDDL(ctx context.Context, onStarted func(connectionID string)) error {
+	conn, err := this.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var connectionID string
+	if err := conn.QueryRowContext(ctx, `select /* gh-ost */ connection_id()`).Scan(&connectionID); err != nil {
+		return err
+	}
+	// As with instant DDL, reuse cut-over-lock-timeout to bound waiting on the metadata lock.
+	tableLockTimeoutSeconds := this.migrationContext.CutOverLockTimeoutSeconds * 2
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`set /* gh-ost */ session lock_wait_timeout:=%d`, tableLockTimeoutSeconds)); err != nil {
+		return err
+	}
+	query := this.generateInplaceIndexDDLQuery()
+	this.log.Infof("In-place index DDL query is: %s", query)
+	onStarted(connectionID)
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		if ctx.Err() != nil {
+			if killErr := mysql.Kill(this.db, connectionID); killErr != nil {
+				this.log.Errore(killErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// ReadInplaceDDLProgress reads the current stage and progress of an ALTER running on given connection,
+// via performance_schema stage events. found is false when there is no progress to report, e.g. when
+// the stage/innodb/alter% instruments or the events_stages_current consumer are disabled.
+func (this *Applier) ReadInplaceDDLProgress(connectionID string) (stage string, workCompleted, workEstimated int64, found bool, err error) {
+	query := `
+		select /* gh-ost */ stages.event_name, stages.work_completed, stages.work_estimated
+		from performance_schema.events_stages_current as stages
+			join performance_schema.threads as threads using (thread_id)
+		where threads.processlist_id = ?`
+	var completed, estimated gosql.NullInt64
+	if err := this.db.QueryRow(query, connectionID).Scan(&stage, &completed, &estimated); err != nil {
+		if errors.Is(err, gosql.ErrNoRows) {
+			return "", 0, 0, false, nil
+		}
+		return "", 0, 0, false, err
+	}
+	return stage, completed.Int64, estimated.Int64, true, nil
+}
+
+// ValidateWritable checks the applier server is not read-only, as expected of a master
+func (this *Applier) ValidateWritable() error {
+	var readOnly bool
+	if err := this.db.QueryRow(`select /* gh-ost */ @@global.read_only`).Scan(&readOnly); err != nil {
+		return err
+	}
+	if readOnly {
+		return fmt.Errorf("%s is read_only, and so is not a master", this.connectionConfig.Key.String())
+	}
+	return nil
+}
+
+// ReadServerUUID returns the server_uuid of the applier server
+func (this *Applier) ReadServerUUID() (serverUUID string, err error) {
+	err = this.db.QueryRow(`select /* gh-ost */ @@global.server_uuid`).Scan(&serverUUID)
+	return serverUUID, err
+}
+
 // readTableColumns reads table columns on applier
 func (this *Applier) readTableColumns() (err error) {
-	this.migrationContext.Log.Infof("Examining table structure on applier")
+	this.log.Infof("Examining table structure on applier")
 	this.migrationContext.OriginalTableColumnsOnApplier, _, err = mysql.GetTableColumns(this.db, this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
 	if err != nil {
 		return err
@@ -221,9 +402,9 @@ func (this *Applier) readTableColumns() (err error) {
 	return nil
 }
 
-// showTableStatus returns the output of `show table status like '...'` command
-func (this *Applier) showTableStatus(tableName string) (rowMap sqlutils.RowMap) {
-	query := fmt.Sprintf(`show /* gh-ost */ table status from %s like '%s'`, sql.EscapeName(this.migrationContext.DatabaseName), tableName)
+// showTableStatus returns the output of `show table status` for given table
+func (this *Applier) showTableStatus(databaseName, tableName string) (rowMap sqlutils.RowMap) {
+	query := fmt.Sprintf(`show /* gh-ost */ table status from %s where name = %s`, sql.EscapeName(databaseName), sql.QuoteLiteral(tableName))
 	sqlutils.QueryRowsMap(this.db, query, func(m sqlutils.RowMap) error {
 		rowMap = m
 		return nil
@@ -232,11 +413,59 @@ func (this *Applier) showTableStatus(tableName string) (rowMap sqlutils.RowMap)
 }
 
 // tableExists checks if a given table exists in database
-func (this *Applier) tableExists(tableName string) (tableFound bool) {
-	m := this.showTableStatus(tableName)
+func (this *Applier) tableExists(databaseName, tableName string) (tableFound bool) {
+	m := this.showTableStatus(databaseName, tableName)
 	return (m != nil)
 }
 
+// ReadTableAge returns how long ago the given table was created; found is false when there is no such table
+func (this *Applier) ReadTableAge(databaseName, tableName string) (age time.Duration, found bool, err error) {
+	query := `
+		select /* gh-ost */ timestampdiff(second, create_time, now())
+		from information_schema.tables
+		where table_schema = ? and table_name = ?`
+	var ageSeconds gosql.NullInt64
+	if err := this.db.QueryRow(query, databaseName, tableName).Scan(&ageSeconds); err != nil {
+		if errors.Is(err, gosql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return time.Duration(ageSeconds.Int64) * time.Second, true, nil
+}
+
+// IsAtomicCutOverSentryTable returns true when the given table is the magic table created by an atomic cut-over
+func (this *Applier) IsAtomicCutOverSentryTable(tableName string) bool {
+	rowMap := this.showTableStatus(this.migrationContext.DatabaseName, tableName)
+	return rowMap != nil && rowMap["Comment"].String == atomicCutOverMagicHint
+}
+
+// ReadChangelogHeartbeatAge returns how long ago a heartbeat was last written to the changelog table;
+// found is false when there is no changelog table, or no heartbeat in it.
+func (this *Applier) ReadChangelogHeartbeatAge() (age time.Duration, found bool, err error) {
+	query := fmt.Sprintf(`select /* gh-ost */ timestampdiff(second, last_update, now()) from %s.%s where hint = 'heartbeat'`,
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
+	)
+	var ageSeconds int64
+	if err := this.db.QueryRow(query).Scan(&ageSeconds); err != nil {
+		if errors.Is(err, gosql.ErrNoRows) || mysql.IsNoSuchTableError(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return time.Duration(ageSeconds) * time.Second, true, nil
+}
+
+// existingTableError explains that a table gh-ost is about to create already exists, and since when
+func (this *Applier) existingTableError(databaseName, tableName string, dropFlag string) error {
+	created := ""
+	if age, found, err := this.ReadTableAge(databaseName, tableName); err == nil && found {
+		created = fmt.Sprintf(", created %+v ago", age)
+	}
+	return fmt.Errorf("Table %s already exists%s, possibly left behind by a failed migration. Panicking. Use --cleanup to review and drop the leftovers of a previous migration, or %s to force dropping it, though I really prefer that you drop it or rename it away", sql.EscapeName(databaseName)+"."+sql.EscapeName(tableName), created, dropFlag)
+}
+
 // ValidateOrDropExistingTables verifies ghost and changelog tables do not exist,
 // or attempts to drop them if instructed to.
 func (this *Applier) ValidateOrDropExistingTables() error {
@@ -245,8 +474,8 @@ func (this *Applier) ValidateOrDropExistingTables() error {
 			return err
 		}
 	}
-	if this.tableExists(this.migrationContext.GetGhostTableName()) {
-		return fmt.Errorf("Table %s already exists. Panicking. Use --initially-drop-ghost-table to force dropping it, though I really prefer that you drop it or rename it away", sql.EscapeName(this.migrationContext.GetGhostTableName()))
+	if this.tableExists(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName()) {
+		return this.existingTableError(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName(), "--initially-drop-ghost-table")
 	}
 	if this.migrationContext.InitiallyDropOldTable {
 		if err := this.DropOldTable(); err != nil {
@@ -254,11 +483,11 @@ func (this *Applier) ValidateOrDropExistingTables() error {
 		}
 	}
 	if len(this.migrationContext.GetOldTableName()) > mysql.MaxTableNameLength {
-		this.migrationContext.Log.Fatalf("--timestamp-old-table defined, but resulting table name (%s) is too long (only %d characters allowed)", this.migrationContext.GetOldTableName(), mysql.MaxTableNameLength)
+		this.log.Fatalf("--timestamp-old-table defined, but resulting table name (%s) is too long (only %d characters allowed)", this.migrationContext.GetOldTableName(), mysql.MaxTableNameLength)
 	}
 
-	if this.tableExists(this.migrationContext.GetOldTableName()) {
-		return fmt.Errorf("Table %s already exists. Panicking. Use --initially-drop-old-table to force dropping it, though I really prefer that you drop it or rename it away", sql.EscapeName(this.migrationContext.GetOldTableName()))
+	if this.tableExists(this.migrationContext.DatabaseName, this.migrationContext.GetOldTableName()) {
+		return this.existingTableError(this.migrationContext.DatabaseName, this.migrationContext.GetOldTableName(), "--initially-drop-old-table")
 	}
 
 	return nil
@@ -279,12 +508,12 @@ func (this *Applier) ValidateOrDropExistingTables() error {
 // that is difficult to identify.
 func (this *Applier) AttemptInstantDDL() error {
 	query := this.generateInstantDDLQuery()
-	this.migrationContext.Log.Infof("INSTANT DDL query is: %s", query)
+	this.log.Infof("INSTANT DDL query is: %s", query)
 
 	// Reuse cut-over-lock-timeout from regular migration process to reduce risk
 	// in situations where there may be long-running transactions.
 	tableLockTimeoutSeconds := this.migrationContext.CutOverLockTimeoutSeconds * 2
-	this.migrationContext.Log.Infof("Setting LOCK timeout as %d seconds", tableLockTimeoutSeconds)
+	this.log.Infof("Setting LOCK timeout as %d seconds", tableLockTimeoutSeconds)
 	lockTimeoutQuery := fmt.Sprintf(`set /* gh-ost */ session lock_wait_timeout:=%d`, tableLockTimeoutSeconds)
 	if _, err := this.db.Exec(lockTimeoutQuery); err != nil {
 		return err
@@ -297,13 +526,13 @@ func (this *Applier) AttemptInstantDDL() error {
 // CreateGhostTable creates the ghost table on the applier host
 func (this *Applier) CreateGhostTable() error {
 	query := fmt.Sprintf(`create /* gh-ost */ table %s.%s like %s.%s`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Creating ghost table %s.%s",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	this.log.Infof("Creating ghost table %s.%s",
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 	)
 
@@ -323,7 +552,7 @@ func (this *Applier) CreateGhostTable() error {
 		if _, err := tx.Exec(query); err != nil {
 			return err
 		}
-		this.migrationContext.Log.Infof("Ghost table created")
+		this.log.Infof("Ghost table created")
 		if err := tx.Commit(); err != nil {
 			// Neither SET SESSION nor ALTER are really transactional, so strictly speaking
 			// there's no need to commit; but let's do this the legit way anyway.
@@ -338,15 +567,15 @@ func (this *Applier) CreateGhostTable() error {
 // AlterGhost applies `alter` statement on ghost table
 func (this *Applier) AlterGhost() error {
 	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s %s`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 		this.migrationContext.AlterStatementOptions,
 	)
-	this.migrationContext.Log.Infof("Altering ghost table %s.%s",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	this.log.Infof("Altering ghost table %s.%s",
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 	)
-	this.migrationContext.Log.Debugf("ALTER statement: %s", query)
+	this.log.Debugf("ALTER statement: %s", query)
 
 	err := func() error {
 		tx, err := this.db.Begin()
@@ -364,7 +593,7 @@ func (this *Applier) AlterGhost() error {
 		if _, err := tx.Exec(query); err != nil {
 			return err
 		}
-		this.migrationContext.Log.Infof("Ghost table altered")
+		this.log.Infof("Ghost table altered")
 		if err := tx.Commit(); err != nil {
 			// Neither SET SESSION nor ALTER are really transactional, so strictly speaking
 			// there's no need to commit; but let's do this the legit way anyway.
@@ -379,19 +608,93 @@ func (this *Applier) AlterGhost() error {
 // AlterGhost applies `alter` statement on ghost table
 func (this *Applier) AlterGhostAutoIncrement() error {
 	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s AUTO_INCREMENT=%d`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 		this.migrationContext.OriginalTableAutoIncrement,
 	)
-	this.migrationContext.Log.Infof("Altering ghost table AUTO_INCREMENT value %s.%s",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	this.log.Infof("Altering ghost table AUTO_INCREMENT value %s.%s",
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 	)
-	this.migrationContext.Log.Debugf("AUTO_INCREMENT ALTER statement: %s", query)
+	this.log.Debugf("AUTO_INCREMENT ALTER statement: %s", query)
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Ghost table AUTO_INCREMENT altered")
+	this.log.Infof("Ghost table AUTO_INCREMENT altered")
+	return nil
+}
+
+// fullRowIndexExists checks whether the temporary full row index exists on given table
+func (this *Applier) fullRowIndexExists(databaseName, tableName string) (bool, error) {
+	query := `
+		select /* gh-ost */ count(*)
+		from information_schema.statistics
+		where table_schema = ? and table_name = ? and index_name = ?`
+	var count int64
+	if err := this.db.QueryRow(query, databaseName, tableName, sql.FullRowUniqueKeyName).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateGhostFullRowIndex creates a temporary unique index on the ghost table over the columns of the
+// full row key (see --allow-temp-index), unless it exists. Rows then have an identity on the ghost table,
+// so that row copy and binlog events write a row once.
+func (this *Applier) CreateGhostFullRowIndex() error {
+	uniqueKey := this.migrationContext.GetFullRowUniqueKey()
+	if uniqueKey == nil {
+		return nil
+	}
+	exists, err := this.fullRowIndexExists(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName())
+	if err != nil || exists {
+		return err
+	}
+	columnNames := make([]string, uniqueKey.Len())
+	for i, column := range uniqueKey.Columns.Columns() {
+		columnNames[i] = sql.EscapeName(column.Name)
+	}
+	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s add unique key %s (%s)`,
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetGhostTableName()),
+		sql.EscapeName(sql.FullRowUniqueKeyName),
+		strings.Join(columnNames, ", "),
+	)
+	this.log.Infof("Creating temporary index %s on ghost table %s.%s",
+		sql.EscapeName(sql.FullRowUniqueKeyName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetGhostTableName()),
+	)
+	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
+		return err
+	}
+	this.log.Infof("Temporary index created")
+	return nil
+}
+
+// DropFullRowIndex drops the temporary full row index, if it exists, off given table: the migrated table,
+// once the cut-over renamed it. Until then the index dedupes writes to the ghost table.
+func (this *Applier) DropFullRowIndex(databaseName, tableName string) error {
+	if this.migrationContext.GetFullRowUniqueKey() == nil {
+		return nil
+	}
+	exists, err := this.fullRowIndexExists(databaseName, tableName)
+	if err != nil || !exists {
+		return err
+	}
+	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s drop index %s`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+		sql.EscapeName(sql.FullRowUniqueKeyName),
+	)
+	this.log.Infof("Dropping temporary index %s off table %s.%s",
+		sql.EscapeName(sql.FullRowUniqueKeyName),
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
+		return err
+	}
+	this.log.Infof("Temporary index dropped")
 	return nil
 }
 
@@ -408,21 +711,188 @@ func (this *Applier) CreateChangelogTable() error {
 			primary key(id),
 			unique key hint_uidx(hint)
 		) auto_increment=256 comment='%s'`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
 		GhostChangelogTableComment,
 	)
-	this.migrationContext.Log.Infof("Creating changelog table %s.%s",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	this.log.Infof("Creating changelog table %s.%s",
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
 	)
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Changelog table created")
+	this.log.Infof("Changelog table created")
+	return nil
+}
+
+// ReadGhostTableIndexes returns the names of the ghost table's scannable indexes, primary key first
+func (this *Applier) ReadGhostTableIndexes() (indexNames []string, err error) {
+	query := `
+		select /* gh-ost */ index_name
+		from information_schema.statistics
+		where table_schema = ? and table_name = ? and index_type not in ('FULLTEXT', 'SPATIAL')
+		group by index_name
+		order by index_name = 'PRIMARY' desc, index_name`
+	rows, err := this.db.Query(query, this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var indexName string
+		if err := rows.Scan(&indexName); err != nil {
+			return nil, err
+		}
+		indexNames = append(indexNames, indexName)
+	}
+	return indexNames, rows.Err()
+}
+
+// WarmUpGhostTableIndex scans a whole index of the ghost table, reading its pages into the buffer pool.
+// The scan is limited to given execution time, and killed if the context is cancelled.
+func (this *Applier) WarmUpGhostTableIndex(ctx context.Context, indexName string, maxExecutionTime time.Duration) error {
+	conn, err := this.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var connectionID string
+	if err := conn.QueryRowContext(ctx, `select /* gh-ost */ connection_id()`).Scan(&connectionID); err != nil {
+		return err
+	}
+	query := sql.BuildWarmUpIndexQuery(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName(), indexName, maxExecutionTime.Milliseconds())
+	var rowsScanned int64
+	if err := conn.QueryRowContext(ctx, query).Scan(&rowsScanned); err != nil {
+		if ctx.Err() != nil {
+			if killErr := mysql.Kill(this.db, connectionID); killErr != nil {
+				this.log.Errore(killErr)
+			}
+		}
+		return err
+	}
+	this.log.Debugf("Warmed up index %s: %d rows", sql.EscapeName(indexName), rowsScanned)
+	return nil
+}
+
+// CreateCoordinationTable creates the table concurrent migrations register on, unless it exists.
+// The table is shared by migrations, and is never dropped by gh-ost.
+func (this *Applier) CreateCoordinationTable() error {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`create /* gh-ost */ table if not exists %s.%s (
+			id bigint unsigned auto_increment,
+			database_name varchar(64) not null,
+			table_name varchar(64) not null,
+			hostname varchar(255) not null,
+			state varchar(32) charset ascii not null,
+			registered_at timestamp(6) not null default current_timestamp(6),
+			heartbeat_at timestamp(6) not null default current_timestamp(6),
+			primary key(id),
+			key heartbeat_at_idx(heartbeat_at)
+		) comment='gh-ost concurrent migrations'`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
+		return err
+	}
+	this.log.Infof("Coordination table %s.%s is ready", sql.EscapeName(databaseName), sql.EscapeName(tableName))
 	return nil
 }
 
+// RegisterMigration registers this migration as queued on the coordination table, returning its registration id.
+// Registrations of migrations that crashed, and so stopped heartbeating, are purged on the way.
+func (this *Applier) RegisterMigration() (int64, error) {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`delete /* gh-ost */ from %s.%s where heartbeat_at < now(6) - interval ? second`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	if _, err := this.db.Exec(query, this.migrationContext.CoordinationStaleSeconds); err != nil {
+		return 0, err
+	}
+	query = fmt.Sprintf(`insert /* gh-ost */ into %s.%s (database_name, table_name, hostname, state) values (?, ?, ?, ?)`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	result, err := this.db.Exec(query,
+		this.migrationContext.DatabaseName,
+		this.migrationContext.OriginalTableName,
+		this.migrationContext.Hostname,
+		MigrationRegistrationQueued,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// HeartbeatMigrationRegistration marks a registration as live
+func (this *Applier) HeartbeatMigrationRegistration(id int64) error {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`update /* gh-ost */ %s.%s set heartbeat_at = now(6) where id = ?`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	_, err := this.db.Exec(query, id)
+	return err
+}
+
+// SetMigrationRegistrationState updates the state of a registration, and marks it as live
+func (this *Applier) SetMigrationRegistrationState(id int64, state string) error {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`update /* gh-ost */ %s.%s set state = ?, heartbeat_at = now(6) where id = ?`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	_, err := this.db.Exec(query, state, id)
+	return err
+}
+
+// DeregisterMigration removes a registration from the coordination table
+func (this *Applier) DeregisterMigration(id int64) error {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`delete /* gh-ost */ from %s.%s where id = ?`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	_, err := this.db.Exec(query, id)
+	return err
+}
+
+// ReadMigrationRegistrations returns live registrations, in order of registration. Registrations that
+// have not heartbeated for --coordination-stale-seconds are ignored.
+func (this *Applier) ReadMigrationRegistrations() ([]*MigrationRegistration, error) {
+	databaseName, tableName := this.migrationContext.GetCoordinationTable()
+	query := fmt.Sprintf(`
+		select /* gh-ost */
+			id, database_name, table_name, hostname, state,
+			timestampdiff(microsecond, heartbeat_at, now(6)) / 1000000
+		from %s.%s
+		where heartbeat_at >= now(6) - interval ? second
+		order by id`,
+		sql.EscapeName(databaseName),
+		sql.EscapeName(tableName),
+	)
+	rows, err := this.db.Query(query, this.migrationContext.CoordinationStaleSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var registrations []*MigrationRegistration
+	for rows.Next() {
+		registration := &MigrationRegistration{}
+		if err := rows.Scan(&registration.Id, &registration.DatabaseName, &registration.TableName,
+			&registration.Hostname, &registration.State, &registration.HeartbeatAge); err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, registration)
+	}
+	return registrations, rows.Err()
+}
+
 // Create the checkpoint table to store the chunk copy and applier state.
 // There are two sets of columns with the same types as the shared unique key,
 // one for IterationMinValues and one for IterationMaxValues.
@@ -438,6 +908,7 @@ func (this *Applier) CreateCheckpointTable() error {
 		"`gh_ost_rows_copied` bigint",
 		"`gh_ost_dml_applied` bigint",
 		"`gh_ost_is_cutover` tinyint(1) DEFAULT '0'",
+		"`gh_ost_chk_partition_index` bigint DEFAULT '0'",
 	}
 	for _, col := range this.migrationContext.UniqueKey.Columns.Columns() {
 		if col.MySQLType == "" {
@@ -455,11 +926,11 @@ func (this *Applier) CreateCheckpointTable() error {
 	}
 
 	query := fmt.Sprintf("create /* gh-ost */ table %s.%s (\n %s\n)",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetCheckpointTableName()),
 		strings.Join(colDefs, ",\n "),
 	)
-	this.migrationContext.Log.Infof("Created checkpoint table")
+	this.log.Infof("Created checkpoint table")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
@@ -467,19 +938,19 @@ func (this *Applier) CreateCheckpointTable() error {
 }
 
 // dropTable drops a given table on the applied host
-func (this *Applier) dropTable(tableName string) error {
+func (this *Applier) dropTable(databaseName, tableName string) error {
 	query := fmt.Sprintf(`drop /* gh-ost */ table if exists %s.%s`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(databaseName),
 		sql.EscapeName(tableName),
 	)
-	this.migrationContext.Log.Infof("Dropping table %s.%s",
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	this.log.Infof("Dropping table %s.%s",
+		sql.EscapeName(databaseName),
 		sql.EscapeName(tableName),
 	)
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Table dropped")
+	this.log.Infof("Table dropped")
 	return nil
 }
 
@@ -493,7 +964,7 @@ func (this *Applier) StateMetadataLockInstrument() error {
 			// performance_schema may be disabled.
 			return nil
 		}
-		return this.migrationContext.Log.Errorf("query performance_schema.setup_instruments with name wait/lock/metadata/sql/mdl error: %s", err)
+		return this.log.Errorf("query performance_schema.setup_instruments with name wait/lock/metadata/sql/mdl error: %s", err)
 	}
 	if strings.EqualFold(enabled, "YES") && strings.EqualFold(timed, "YES") {
 		this.migrationContext.IsOpenMetadataLockInstruments = true
@@ -502,12 +973,12 @@ func (this *Applier) StateMetadataLockInstrument() error {
 	if !this.migrationContext.AllowSetupMetadataLockInstruments {
 		return nil
 	}
-	this.migrationContext.Log.Infof("instrument wait/lock/metadata/sql/mdl state: enabled %s, timed %s", enabled, timed)
+	this.log.Infof("instrument wait/lock/metadata/sql/mdl state: enabled %s, timed %s", enabled, timed)
 	if _, err := this.db.Exec(`UPDATE performance_schema.setup_instruments SET ENABLED = 'YES', TIMED = 'YES' WHERE NAME = 'wait/lock/metadata/sql/mdl'`); err != nil {
-		return this.migrationContext.Log.Errorf("enable instrument wait/lock/metadata/sql/mdl error: %s", err)
+		return this.log.Errorf("enable instrument wait/lock/metadata/sql/mdl error: %s", err)
 	}
 	this.migrationContext.IsOpenMetadataLockInstruments = true
-	this.migrationContext.Log.Infof("instrument wait/lock/metadata/sql/mdl enabled")
+	this.log.Infof("instrument wait/lock/metadata/sql/mdl enabled")
 	return nil
 }
 
@@ -521,7 +992,7 @@ func (this *Applier) DropTriggersFromGhost() error {
 			if err != nil {
 				return err
 			}
-			this.migrationContext.Log.Infof("Trigger '%s' dropped", triggerName)
+			this.log.Infof("Trigger '%s' dropped", triggerName)
 		}
 	}
 	return nil
@@ -541,7 +1012,7 @@ func (this *Applier) createTriggers(tableName string) error {
 				sql.EscapeName(tableName),
 				trigger.Statement,
 			)
-			this.migrationContext.Log.Infof("Createing trigger %s on %s.%s",
+			this.log.Infof("Createing trigger %s on %s.%s",
 				sql.EscapeName(triggerName),
 				sql.EscapeName(this.migrationContext.DatabaseName),
 				sql.EscapeName(tableName),
@@ -550,7 +1021,7 @@ func (this *Applier) createTriggers(tableName string) error {
 				return err
 			}
 		}
-		this.migrationContext.Log.Infof("Triggers created on %s", tableName)
+		this.log.Infof("Triggers created on %s", tableName)
 	}
 	return nil
 }
@@ -563,27 +1034,31 @@ func (this *Applier) CreateTriggersOnGhost() error {
 
 // DropChangelogTable drops the changelog table on the applier host
 func (this *Applier) DropChangelogTable() error {
-	return this.dropTable(this.migrationContext.GetChangelogTableName())
+	return this.dropTable(this.migrationContext.GetChangelogDatabaseName(), this.migrationContext.GetChangelogTableName())
 }
 
 // DropCheckpointTable drops the checkpoint table on applier host
 func (this *Applier) DropCheckpointTable() error {
-	return this.dropTable(this.migrationContext.GetCheckpointTableName())
+	return this.dropTable(this.migrationContext.GetChangelogDatabaseName(), this.migrationContext.GetCheckpointTableName())
 }
 
 // DropOldTable drops the _Old table on the applier host
 func (this *Applier) DropOldTable() error {
-	return this.dropTable(this.migrationContext.GetOldTableName())
+	return this.dropTable(this.migrationContext.DatabaseName, this.migrationContext.GetOldTableName())
 }
 
 // DropGhostTable drops the ghost table on the applier host
 func (this *Applier) DropGhostTable() error {
-	return this.dropTable(this.migrationContext.GetGhostTableName())
+	return this.dropTable(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName())
 }
 
 // WriteChangelog writes a value to the changelog table.
 // It returns the hint as given, for convenience
 func (this *Applier) WriteChangelog(hint, value string) (string, error) {
+	if this.migrationContext.Observe {
+		// --observe creates no changelog table
+		return hint, nil
+	}
 	explicitId := 0
 	switch hint {
 	case "heartbeat":
@@ -603,13 +1078,32 @@ func (this *Applier) WriteChangelog(hint, value string) (string, error) {
 		on duplicate key update
 			last_update=NOW(),
 			value=VALUES(value)`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
 	)
 	_, err := sqlutils.ExecNoPrepare(this.db, query, explicitId, hint, value)
+	if err == nil && (hint == "state" && ReadChangelogState(value) != AllEventsUpToLockProcessed || hint == "throttle") {
+		// A cut-over's challenge is not kept, as its cut-over attempt is over by the time it is restored
+		this.changelogRowsMutex.Lock()
+		this.changelogRows[hint] = value
+		this.changelogRowsMutex.Unlock()
+	}
 	return hint, err
 }
 
+// restoreChangelogRows writes the state and throttle rows of a recreated changelog table
+func (this *Applier) restoreChangelogRows() error {
+	this.changelogRowsMutex.Lock()
+	changelogRows := maps.Clone(this.changelogRows)
+	this.changelogRowsMutex.Unlock()
+	for hint, value := range changelogRows {
+		if _, err := this.WriteChangelog(hint, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (this *Applier) WriteAndLogChangelog(hint, value string) (string, error) {
 	this.WriteChangelog(hint, value)
 	return this.WriteChangelog(fmt.Sprintf("%s at %d", hint, time.Now().UnixNano()), value)
@@ -628,7 +1122,7 @@ func (this *Applier) WriteCheckpoint(chk *Checkpoint) (int64, error) {
 	if err != nil {
 		return insertId, err
 	}
-	args := sqlutils.Args(chk.LastTrxCoords.String(), chk.Iteration, chk.RowsCopied, chk.DMLApplied, chk.IsCutover)
+	args := sqlutils.Args(chk.LastTrxCoords.String(), chk.Iteration, chk.RowsCopied, chk.DMLApplied, chk.IsCutover, chk.IterationPartitionIndex)
 	args = append(args, uniqueKeyArgs...)
 	res, err := this.db.Exec(query, args...)
 	if err != nil {
@@ -638,7 +1132,7 @@ func (this *Applier) WriteCheckpoint(chk *Checkpoint) (int64, error) {
 }
 
 func (this *Applier) ReadLastCheckpoint() (*Checkpoint, error) {
-	row := this.db.QueryRow(fmt.Sprintf(`select /* gh-ost */ * from %s.%s order by gh_ost_chk_id desc limit 1`, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.GetCheckpointTableName())))
+	row := this.db.QueryRow(fmt.Sprintf(`select /* gh-ost */ * from %s.%s order by gh_ost_chk_id desc limit 1`, sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()), sql.EscapeName(this.migrationContext.GetCheckpointTableName())))
 	chk := &Checkpoint{
 		IterationRangeMin: sql.NewColumnValues(this.migrationContext.UniqueKey.Columns.Len()),
 		IterationRangeMax: sql.NewColumnValues(this.migrationContext.UniqueKey.Columns.Len()),
@@ -646,7 +1140,7 @@ func (this *Applier) ReadLastCheckpoint() (*Checkpoint, error) {
 
 	var coordStr string
 	var timestamp int64
-	ptrs := []interface{}{&chk.Id, &timestamp, &coordStr, &chk.Iteration, &chk.RowsCopied, &chk.DMLApplied, &chk.IsCutover}
+	ptrs := []interface{}{&chk.Id, &timestamp, &coordStr, &chk.Iteration, &chk.RowsCopied, &chk.DMLApplied, &chk.IsCutover, &chk.IterationPartitionIndex}
 	ptrs = append(ptrs, chk.IterationRangeMin.ValuesPointers...)
 	ptrs = append(ptrs, chk.IterationRangeMax.ValuesPointers...)
 	err := row.Scan(ptrs...)
@@ -673,30 +1167,123 @@ func (this *Applier) ReadLastCheckpoint() (*Checkpoint, error) {
 	return chk, nil
 }
 
+// prepareHeartbeatStatement prepares the statement writing heartbeats to the changelog table,
+// so that it is reused across heartbeat intervals.
+func (this *Applier) prepareHeartbeatStatement() (*gosql.Stmt, error) {
+	query := fmt.Sprintf(`
+		insert /* gh-ost */
+		into
+			%s.%s
+			(id, hint, value)
+		values
+			(1, 'heartbeat', ?)
+		on duplicate key update
+			last_update=NOW(),
+			value=VALUES(value)`,
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
+	)
+	return this.db.Prepare(query)
+}
+
+// PruneChangelog deletes changelog entries, other than the heartbeat, state and throttle ones,
+// not updated within given retention. It returns the number of deleted entries.
+func (this *Applier) PruneChangelog(retention time.Duration) (int64, error) {
+	query := fmt.Sprintf(`
+		delete /* gh-ost */
+		from
+			%s.%s
+		where
+			id > 3
+			and last_update < NOW() - interval ? second`,
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
+	)
+	result, err := sqlutils.ExecNoPrepare(this.db, query, int64(retention.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // InitiateHeartbeat creates a heartbeat cycle, writing to the changelog table.
-// This is done asynchronously
-func (this *Applier) InitiateHeartbeat() {
+// This is done asynchronously. Should the changelog table disappear, it is recreated with its
+// state and throttle rows, and onChangelogRecreated is called. Old changelog entries are
+// periodically pruned. The cycle ends with StopHeartbeat(). Failing heartbeats abort the migration once
+// they fail more than --default-retries times in a row, and for as many seconds, whatever the interval.
+func (this *Applier) InitiateHeartbeat(onChangelogRecreated func() error) {
+	var heartbeatStmt *gosql.Stmt
+	defer func() {
+		if heartbeatStmt != nil {
+			heartbeatStmt.Close()
+		}
+	}()
 	var numSuccessiveFailures int64
+	lastSuccess := time.Now()
+	writeHeartbeat := func() (err error) {
+		if heartbeatStmt == nil {
+			if heartbeatStmt, err = this.prepareHeartbeatStatement(); err != nil {
+				return err
+			}
+		}
+		sequence := this.migrationContext.NextHeartbeatSequence()
+		heartbeatTime := time.Now()
+		if _, err = heartbeatStmt.Exec(formatChangelogHeartbeat(heartbeatTime, sequence)); err != nil {
+			return err
+		}
+		this.migrationContext.MarkHeartbeatInjected(sequence, heartbeatTime)
+		return nil
+	}
 	injectHeartbeat := func() error {
 		if atomic.LoadInt64(&this.migrationContext.HibernateUntil) > 0 {
 			return nil
 		}
-		if _, err := this.WriteChangelog("heartbeat", time.Now().Format(time.RFC3339Nano)); err != nil {
+		this.heartbeatMutex.Lock()
+		defer this.heartbeatMutex.Unlock()
+		if this.heartbeatStopped {
+			// The changelog table may be dropped by now, and is not to be recreated
+			return nil
+		}
+		err := writeHeartbeat()
+		if mysql.IsNoSuchTableError(err) {
+			this.log.Warningf("Changelog table %s.%s not found. Recreating it",
+				sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
+				sql.EscapeName(this.migrationContext.GetChangelogTableName()),
+			)
+			if heartbeatStmt != nil {
+				heartbeatStmt.Close()
+				heartbeatStmt = nil
+			}
+			if err = this.CreateChangelogTable(); err == nil {
+				if err = this.restoreChangelogRows(); err == nil {
+					if onChangelogRecreated != nil {
+						if hookErr := onChangelogRecreated(); hookErr != nil {
+							this.log.Errore(hookErr)
+						}
+					}
+					err = writeHeartbeat()
+				}
+			}
+		}
+		if err != nil {
 			numSuccessiveFailures++
-			if numSuccessiveFailures > this.migrationContext.MaxRetries() {
-				return this.migrationContext.Log.Errore(err)
+			maxRetries := this.migrationContext.MaxRetries()
+			if numSuccessiveFailures > maxRetries && time.Since(lastSuccess) > time.Duration(maxRetries)*time.Second {
+				return this.log.Errore(err)
 			}
 		} else {
 			numSuccessiveFailures = 0
+			lastSuccess = time.Now()
 		}
 		return nil
 	}
 	injectHeartbeat()
 
+	lastPrune := time.Now()
 	ticker := time.NewTicker(time.Duration(this.migrationContext.HeartbeatIntervalMilliseconds) * time.Millisecond)
 	defer ticker.Stop()
 	for range ticker.C {
-		if atomic.LoadInt64(&this.finishedMigrating) > 0 {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 || this.isHeartbeatStopped() {
 			return
 		}
 		// Generally speaking, we would issue a goroutine, but I'd actually rather
@@ -709,9 +1296,31 @@ func (this *Applier) InitiateHeartbeat() {
 			this.migrationContext.PanicAbort <- fmt.Errorf("injectHeartbeat writing failed %d times, last error: %w", numSuccessiveFailures, err)
 			return
 		}
+		if time.Since(lastPrune) >= changelogPruneInterval {
+			lastPrune = time.Now()
+			if pruned, err := this.PruneChangelog(changelogPruneRetention); err != nil {
+				this.log.Warningf("Failed pruning changelog table: %s", err)
+			} else if pruned > 0 {
+				this.log.Debugf("Pruned %d changelog entries older than %s", pruned, changelogPruneRetention)
+			}
+		}
 	}
 }
 
+// StopHeartbeat ends the heartbeat cycle. It waits for a heartbeat in progress, so that once it returns
+// the changelog table may be dropped without the heartbeat recreating it.
+func (this *Applier) StopHeartbeat() {
+	this.heartbeatMutex.Lock()
+	defer this.heartbeatMutex.Unlock()
+	this.heartbeatStopped = true
+}
+
+func (this *Applier) isHeartbeatStopped() bool {
+	this.heartbeatMutex.Lock()
+	defer this.heartbeatMutex.Unlock()
+	return this.heartbeatStopped
+}
+
 // ExecuteThrottleQuery executes the `--throttle-query` and returns its results.
 func (this *Applier) ExecuteThrottleQuery() (int64, error) {
 	throttleQuery := this.migrationContext.GetThrottleQuery()
@@ -721,14 +1330,14 @@ func (this *Applier) ExecuteThrottleQuery() (int64, error) {
 	}
 	var result int64
 	if err := this.db.QueryRow(throttleQuery).Scan(&result); err != nil {
-		return 0, this.migrationContext.Log.Errore(err)
+		return 0, this.log.Errore(err)
 	}
 	return result, nil
 }
 
 // readMigrationMinValues returns the minimum values to be iterated on rowcopy
 func (this *Applier) readMigrationMinValues(tx *gosql.Tx, uniqueKey *sql.UniqueKey) error {
-	this.migrationContext.Log.Debugf("Reading migration range according to key: %s", uniqueKey.Name)
+	this.log.Debugf("Reading migration range according to key: %s", uniqueKey.Name)
 	query, err := sql.BuildUniqueKeyMinValuesPreparedQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, uniqueKey)
 	if err != nil {
 		return err
@@ -746,14 +1355,14 @@ func (this *Applier) readMigrationMinValues(tx *gosql.Tx, uniqueKey *sql.UniqueK
 			return err
 		}
 	}
-	this.migrationContext.Log.Infof("Migration min values: [%s]", this.migrationContext.MigrationRangeMinValues)
+	this.log.Infof("Migration min values: [%s]", this.migrationContext.MigrationRangeMinValues)
 
 	return rows.Err()
 }
 
 // readMigrationMaxValues returns the maximum values to be iterated on rowcopy
 func (this *Applier) readMigrationMaxValues(tx *gosql.Tx, uniqueKey *sql.UniqueKey) error {
-	this.migrationContext.Log.Debugf("Reading migration range according to key: %s", uniqueKey.Name)
+	this.log.Debugf("Reading migration range according to key: %s", uniqueKey.Name)
 	query, err := sql.BuildUniqueKeyMaxValuesPreparedQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, uniqueKey)
 	if err != nil {
 		return err
@@ -771,7 +1380,7 @@ func (this *Applier) readMigrationMaxValues(tx *gosql.Tx, uniqueKey *sql.UniqueK
 			return err
 		}
 	}
-	this.migrationContext.Log.Infof("Migration max values: [%s]", this.migrationContext.MigrationRangeMaxValues)
+	this.log.Infof("Migration max values: [%s]", this.migrationContext.MigrationRangeMaxValues)
 
 	return rows.Err()
 }
@@ -823,6 +1432,7 @@ func (this *Applier) CalculateNextIterationRangeEndValues() (hasFurtherRange boo
 	if this.migrationContext.MigrationIterationRangeMinValues != nil && this.migrationContext.MigrationIterationRangeMaxValues != nil {
 		this.LastIterationRangeMinValues = this.migrationContext.MigrationIterationRangeMinValues.Clone()
 		this.LastIterationRangeMaxValues = this.migrationContext.MigrationIterationRangeMaxValues.Clone()
+		this.LastIterationPartitionIndex = this.migrationContext.IterationPartitionIndex
 	}
 	this.LastIterationRangeMutex.Unlock()
 
@@ -830,63 +1440,96 @@ func (this *Applier) CalculateNextIterationRangeEndValues() (hasFurtherRange boo
 	if this.migrationContext.MigrationIterationRangeMinValues == nil {
 		this.migrationContext.MigrationIterationRangeMinValues = this.migrationContext.MigrationRangeMinValues
 	}
-	for i := 0; i < 2; i++ {
-		buildFunc := sql.BuildUniqueKeyRangeEndPreparedQueryViaOffset
-		if i == 1 {
-			buildFunc = sql.BuildUniqueKeyRangeEndPreparedQueryViaTemptable
-		}
-		query, explodedArgs, err := buildFunc(
-			this.migrationContext.DatabaseName,
-			this.migrationContext.OriginalTableName,
-			&this.migrationContext.UniqueKey.Columns,
-			this.migrationContext.MigrationIterationRangeMinValues.AbstractValues(),
-			this.migrationContext.MigrationRangeMaxValues.AbstractValues(),
-			atomic.LoadInt64(&this.migrationContext.ChunkSize),
-			this.migrationContext.GetIteration() == 0,
-			fmt.Sprintf("iteration:%d", this.migrationContext.GetIteration()),
-		)
-		if err != nil {
-			return hasFurtherRange, err
-		}
-
-		rows, err := this.db.Query(query, explodedArgs...)
-		if err != nil {
-			return hasFurtherRange, err
-		}
-		defer rows.Close()
+	// When iterating partition by partition, move on to the next partition once the current one is exhausted
+	for {
+		partitionName := this.migrationContext.GetIterationPartition()
+		for i := 0; i < 2; i++ {
+			buildFunc := sql.BuildUniqueKeyRangeEndPreparedQueryViaOffset
+			if i == 1 {
+				buildFunc = sql.BuildUniqueKeyRangeEndPreparedQueryViaTemptable
+			}
+			query, explodedArgs, err := buildFunc(
+				this.migrationContext.DatabaseName,
+				this.migrationContext.OriginalTableName,
+				&this.migrationContext.UniqueKey.Columns,
+				this.migrationContext.MigrationIterationRangeMinValues.AbstractValues(),
+				this.migrationContext.MigrationRangeMaxValues.AbstractValues(),
+				atomic.LoadInt64(&this.migrationContext.ChunkSize),
+				this.migrationContext.GetIteration() == 0,
+				fmt.Sprintf("iteration:%d", this.migrationContext.GetIteration()),
+				partitionName,
+				this.migrationContext.QueryMaxExecutionTimeMillis,
+			)
+			if err != nil {
+				return hasFurtherRange, err
+			}
 
-		iterationRangeMaxValues := sql.NewColumnValues(this.migrationContext.UniqueKey.Len())
-		for rows.Next() {
-			if err = rows.Scan(iterationRangeMaxValues.ValuesPointers...); err != nil {
+			var iterationRangeMaxValues *sql.ColumnValues
+			iterationRangeMaxValues, hasFurtherRange, err = this.readIterationRangeEndValues(query, explodedArgs)
+			if err != nil {
+				if mysql.IsQueryTimeoutError(err) {
+					// Chunk boundary query was killed by MAX_EXECUTION_TIME. Reduce chunk size; the caller retries.
+					// With nothing left to reduce, the error counts towards the caller's retries like any other.
+					chunkSize := atomic.LoadInt64(&this.migrationContext.ChunkSize)
+					if chunkSize <= base.MinChunkSize {
+						return hasFurtherRange, fmt.Errorf("Range end query exceeded max execution time of %dms with the minimal chunk-size %d: %w", this.migrationContext.QueryMaxExecutionTimeMillis, chunkSize, err)
+					}
+					this.migrationContext.SetChunkSize(chunkSize / 2)
+					this.log.Warningf("Range end query exceeded max execution time of %dms with chunk-size %d; chunk-size is now %d", this.migrationContext.QueryMaxExecutionTimeMillis, chunkSize, atomic.LoadInt64(&this.migrationContext.ChunkSize))
+				}
 				return hasFurtherRange, err
 			}
-			hasFurtherRange = true
-		}
-		if err = rows.Err(); err != nil {
-			return hasFurtherRange, err
+			if hasFurtherRange {
+				this.migrationContext.MigrationIterationRangeMaxValues = iterationRangeMaxValues
+				return hasFurtherRange, nil
+			}
 		}
-		if hasFurtherRange {
-			this.migrationContext.MigrationIterationRangeMaxValues = iterationRangeMaxValues
-			return hasFurtherRange, nil
+		if partitionName == "" || this.migrationContext.IterationPartitionIndex+1 >= len(this.migrationContext.IterationPartitions) {
+			break
 		}
+		this.migrationContext.IterationPartitionIndex++
+		this.log.Debugf("Partition %s complete; iterating partition %s", partitionName, this.migrationContext.GetIterationPartition())
 	}
-	this.migrationContext.Log.Debugf("Iteration complete: no further range to iterate")
+	this.log.Debugf("Iteration complete: no further range to iterate")
 	return hasFurtherRange, nil
 }
 
+// readIterationRangeEndValues runs a range end query, returning the values it found, if any. The query's
+// rows are closed before returning, as the caller may run further queries.
+func (this *Applier) readIterationRangeEndValues(query string, args []interface{}) (iterationRangeMaxValues *sql.ColumnValues, found bool, err error) {
+	rows, err := this.db.Query(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	iterationRangeMaxValues = sql.NewColumnValues(this.migrationContext.UniqueKey.Len())
+	for rows.Next() {
+		if err = rows.Scan(iterationRangeMaxValues.ValuesPointers...); err != nil {
+			return nil, false, err
+		}
+		found = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return iterationRangeMaxValues, found, nil
+}
+
 // ApplyIterationInsertQuery issues a chunk-INSERT query on the ghost table. It is where
 // data actually gets copied from original table.
-func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsAffected int64, duration time.Duration, err error) {
+func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsCopied int64, duration time.Duration, err error) {
 	startTime := time.Now()
 	chunkSize = atomic.LoadInt64(&this.migrationContext.ChunkSize)
 
 	query, explodedArgs, err := sql.BuildRangeInsertPreparedQuery(
 		this.migrationContext.DatabaseName,
 		this.migrationContext.OriginalTableName,
+		this.migrationContext.GetGhostDatabaseName(),
 		this.migrationContext.GetGhostTableName(),
 		this.migrationContext.SharedColumns.Names(),
 		this.migrationContext.MappedSharedColumns.Names(),
-		this.migrationContext.UniqueKey.Name,
+		this.migrationContext.UniqueKey.IndexName(),
 		&this.migrationContext.UniqueKey.Columns,
 		this.migrationContext.MigrationIterationRangeMinValues.AbstractValues(),
 		this.migrationContext.MigrationIterationRangeMaxValues.AbstractValues(),
@@ -894,15 +1537,18 @@ func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsAffected
 		this.migrationContext.IsTransactionalTable(),
 		// TODO: Don't hardcode this
 		strings.HasPrefix(this.migrationContext.ApplierMySQLVersion, "8."),
+		this.migrationContext.GetIterationPartition(),
+		this.migrationContext.GetChunkCopyOptimizerHints(),
+		this.migrationContext.ColumnTransforms,
+		this.migrationContext.CopyConflictStrategy,
 	)
 	if err != nil {
-		return chunkSize, rowsAffected, duration, err
+		return chunkSize, rowsCopied, duration, err
 	}
-
-	sqlResult, err := func() (gosql.Result, error) {
+	rowsCopied, err = func() (int64, error) {
 		tx, err := this.db.Begin()
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 		defer tx.Rollback()
 
@@ -910,22 +1556,27 @@ func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsAffected
 		sessionQuery = fmt.Sprintf("%s, %s", sessionQuery, this.generateSqlModeQuery())
 
 		if _, err := tx.Exec(sessionQuery); err != nil {
-			return nil, err
+			return 0, err
 		}
 		result, err := tx.Exec(query, explodedArgs...)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
+		// Rows copied are approximated by affected rows. With REPLACE, a row replacing a conflicting
+		// row counts twice. With INSERT ... ON DUPLICATE KEY UPDATE, a row updating a conflicting row
+		// counts twice, or not at all if unchanged. Conflicts are rows already applied from the binary
+		// log, which are few, and an exact count would take a second read of the chunk's range.
+		copied, _ := result.RowsAffected()
 
 		if this.migrationContext.PanicOnWarnings {
 			//nolint:execinquery
 			rows, err := tx.Query("SHOW WARNINGS")
 			if err != nil {
-				return nil, err
+				return 0, err
 			}
 			defer rows.Close()
 			if err = rows.Err(); err != nil {
-				return nil, err
+				return 0, err
 			}
 
 			var sqlWarnings []string
@@ -933,7 +1584,7 @@ func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsAffected
 				var level, message string
 				var code int
 				if err := rows.Scan(&level, &code, &message); err != nil {
-					this.migrationContext.Log.Warningf("Failed to read SHOW WARNINGS row")
+					this.log.Warningf("Failed to read SHOW WARNINGS row")
 					continue
 				}
 				// Duplicate warnings are formatted differently across mysql versions, hence the optional table name prefix
@@ -942,29 +1593,102 @@ func (this *Applier) ApplyIterationInsertQuery() (chunkSize int64, rowsAffected
 				if strings.Contains(message, "Duplicate entry") && matched {
 					continue
 				}
+				// MySQL 8.0.20 and above deprecate the values() by which upsert reads the copied row
+				if this.migrationContext.CopyConflictStrategy == sql.CopyConflictUpsert && mysql.IsDeprecatedSyntaxWarning(code) {
+					continue
+				}
 				sqlWarnings = append(sqlWarnings, fmt.Sprintf("%s: %s (%d)", level, message, code))
 			}
 			this.migrationContext.MigrationLastInsertSQLWarnings = sqlWarnings
 		}
 
 		if err := tx.Commit(); err != nil {
-			return nil, err
+			return 0, err
 		}
-		return result, nil
+		return copied, nil
 	}()
 
 	if err != nil {
-		return chunkSize, rowsAffected, duration, err
+		return chunkSize, rowsCopied, duration, err
 	}
-	rowsAffected, _ = sqlResult.RowsAffected()
 	duration = time.Since(startTime)
-	this.migrationContext.Log.Debugf(
+	this.log.Debugf(
 		"Issued INSERT on range: [%s]..[%s]; iteration: %d; chunk-size: %d",
 		this.migrationContext.MigrationIterationRangeMinValues,
 		this.migrationContext.MigrationIterationRangeMaxValues,
 		this.migrationContext.GetIteration(),
 		chunkSize)
-	return chunkSize, rowsAffected, duration, nil
+	return chunkSize, rowsCopied, duration, nil
+}
+
+// ReadChunkSample reads a chunk of the original table's rows by given unique key, the way row copy reads a
+// chunk, starting at given values of the key's leading columns, or at the table's first row if none are given.
+// It returns how long the read took, and the key values of the last row read, nil if the chunk reached the
+// end of the table.
+func (this *Applier) ReadChunkSample(uniqueKey *sql.UniqueKey, startArgs []interface{}) (lastValues *sql.ColumnValues, duration time.Duration, err error) {
+	columns := this.migrationContext.OriginalTableColumns
+	chunkSize := atomic.LoadInt64(&this.migrationContext.ChunkSize)
+	query, explodedArgs, err := sql.BuildChunkSamplePreparedQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName,
+		columns, uniqueKey, startArgs, chunkSize)
+	if err != nil {
+		return nil, duration, err
+	}
+	startTime := time.Now()
+	rows, err := this.db.Query(query, explodedArgs...)
+	if err != nil {
+		return nil, duration, err
+	}
+	defer rows.Close()
+	rowValues := sql.NewColumnValues(columns.Len())
+	rowsRead := 0
+	for rows.Next() {
+		if err := rows.Scan(rowValues.ValuesPointers...); err != nil {
+			return nil, duration, err
+		}
+		rowsRead++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, duration, err
+	}
+	duration = time.Since(startTime)
+	if int64(rowsRead) < chunkSize {
+		return nil, duration, nil
+	}
+	keyValues := make([]interface{}, uniqueKey.Len())
+	for i, column := range uniqueKey.Columns.Columns() {
+		keyValues[i] = rowValues.AbstractValues()[columns.Ordinals[column.Name]]
+	}
+	return sql.ToColumnValues(keyValues), duration, nil
+}
+
+// ReadUniqueKeyRangeValues reads the minimum and maximum values of given unique key on the original table,
+// nil on an empty table
+func (this *Applier) ReadUniqueKeyRangeValues(uniqueKey *sql.UniqueKey) (minValues, maxValues *sql.ColumnValues, err error) {
+	readValues := func(buildFunc func(string, string, *sql.UniqueKey) (string, error)) (values *sql.ColumnValues, err error) {
+		query, err := buildFunc(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, uniqueKey)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := this.db.Query(query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			values = sql.NewColumnValues(uniqueKey.Len())
+			if err := rows.Scan(values.ValuesPointers...); err != nil {
+				return nil, err
+			}
+		}
+		return values, rows.Err()
+	}
+	if minValues, err = readValues(sql.BuildUniqueKeyMinValuesPreparedQuery); err != nil {
+		return nil, nil, err
+	}
+	if maxValues, err = readValues(sql.BuildUniqueKeyMaxValuesPreparedQuery); err != nil {
+		return nil, nil, err
+	}
+	return minValues, maxValues, nil
 }
 
 // LockOriginalTable places a write lock on the original table
@@ -973,7 +1697,7 @@ func (this *Applier) LockOriginalTable() error {
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Locking %s.%s",
+	this.log.Infof("Locking %s.%s",
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
@@ -981,18 +1705,49 @@ func (this *Applier) LockOriginalTable() error {
 	if _, err := sqlutils.ExecNoPrepare(this.singletonDB, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Table locked")
+	this.log.Infof("Table locked")
+	return this.countLockedOriginalTableRows(this.singletonDB)
+}
+
+// countLockedOriginalTableRows exactly counts the rows of the original table with --verify-rowcount-threshold,
+// on the session holding the cut-over lock, where no other session can modify the table until it's unlocked
+func (this *Applier) countLockedOriginalTableRows(queryer interface {
+	QueryRow(query string, args ...interface{}) *gosql.Row
+}) error {
+	this.lockedOriginalTableRows = -1
+	threshold := this.migrationContext.VerifyRowCountThreshold
+	if threshold <= 0 {
+		return nil
+	}
+	rowsEstimate := atomic.LoadInt64(&this.migrationContext.RowsEstimate) + atomic.LoadInt64(&this.migrationContext.RowsDeltaEstimate)
+	if rowsEstimate >= threshold {
+		this.log.Infof("Skipping row count verification: estimated %d rows, not below --verify-rowcount-threshold=%d", rowsEstimate, threshold)
+		return nil
+	}
+	query := sql.BuildCountRowsQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, 0)
+	var rows int64
+	if err := queryer.QueryRow(query).Scan(&rows); err != nil {
+		return err
+	}
+	this.lockedOriginalTableRows = rows
 	return nil
 }
 
+// CountGhostTableRows exactly counts the rows of the ghost table
+func (this *Applier) CountGhostTableRows() (rows int64, err error) {
+	query := sql.BuildCountRowsQuery(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName(), 0)
+	err = this.db.QueryRow(query).Scan(&rows)
+	return rows, err
+}
+
 // UnlockTables makes tea. No wait, it unlocks tables.
 func (this *Applier) UnlockTables() error {
 	query := `unlock /* gh-ost */ tables`
-	this.migrationContext.Log.Infof("Unlocking tables")
+	this.log.Infof("Unlocking tables")
 	if _, err := sqlutils.ExecNoPrepare(this.singletonDB, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Tables unlocked")
+	this.log.Infof("Tables unlocked")
 	return nil
 }
 
@@ -1001,28 +1756,30 @@ func (this *Applier) UnlockTables() error {
 // - rename ghost table to original
 // There is a point in time in between where the table does not exist.
 func (this *Applier) SwapTablesQuickAndBumpy() error {
-	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s rename %s`,
+	query := fmt.Sprintf(`alter /* gh-ost */ table %s.%s rename %s.%s`,
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
+		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.GetOldTableName()),
 	)
-	this.migrationContext.Log.Infof("Renaming original table")
+	this.log.Infof("Renaming original table")
 	this.migrationContext.RenameTablesStartTime = time.Now()
 	if _, err := sqlutils.ExecNoPrepare(this.singletonDB, query); err != nil {
 		return err
 	}
-	query = fmt.Sprintf(`alter /* gh-ost */ table %s.%s rename %s`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+	query = fmt.Sprintf(`alter /* gh-ost */ table %s.%s rename %s.%s`,
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
+		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Renaming ghost table")
+	this.log.Infof("Renaming ghost table")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
 	this.migrationContext.RenameTablesEndTime = time.Now()
 
-	this.migrationContext.Log.Infof("Tables renamed")
+	this.log.Infof("Tables renamed")
 	return nil
 }
 
@@ -1034,14 +1791,14 @@ func (this *Applier) RenameTablesRollback() (renameError error) {
 	query := fmt.Sprintf(`rename /* gh-ost */ table %s.%s to %s.%s, %s.%s to %s.%s`,
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.GetOldTableName()),
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Renaming back both tables")
+	this.log.Infof("Renaming back both tables")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err == nil {
 		return nil
 	}
@@ -1049,10 +1806,10 @@ func (this *Applier) RenameTablesRollback() (renameError error) {
 	query = fmt.Sprintf(`rename /* gh-ost */ table %s.%s to %s.%s`,
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 	)
-	this.migrationContext.Log.Infof("Renaming back to ghost table")
+	this.log.Infof("Renaming back to ghost table")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		renameError = err
 	}
@@ -1062,11 +1819,11 @@ func (this *Applier) RenameTablesRollback() (renameError error) {
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Renaming back to original table")
+	this.log.Infof("Renaming back to original table")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		renameError = err
 	}
-	return this.migrationContext.Log.Errore(renameError)
+	return this.log.Errore(renameError)
 }
 
 // StopSlaveIOThread is applicable with --test-on-replica; it stops the IO thread, duh.
@@ -1075,11 +1832,11 @@ func (this *Applier) RenameTablesRollback() (renameError error) {
 func (this *Applier) StopSlaveIOThread() error {
 	replicaTerm := mysql.ReplicaTermFor(this.migrationContext.ApplierMySQLVersion, `slave`)
 	query := fmt.Sprintf("stop /* gh-ost */ %s io_thread", replicaTerm)
-	this.migrationContext.Log.Infof("Stopping replication IO thread")
+	this.log.Infof("Stopping replication IO thread")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Replication IO thread stopped")
+	this.log.Infof("Replication IO thread stopped")
 	return nil
 }
 
@@ -1087,11 +1844,11 @@ func (this *Applier) StopSlaveIOThread() error {
 func (this *Applier) StartSlaveIOThread() error {
 	replicaTerm := mysql.ReplicaTermFor(this.migrationContext.ApplierMySQLVersion, `slave`)
 	query := fmt.Sprintf("start /* gh-ost */ %s io_thread", replicaTerm)
-	this.migrationContext.Log.Infof("Starting replication IO thread")
+	this.log.Infof("Starting replication IO thread")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Replication IO thread started")
+	this.log.Infof("Replication IO thread started")
 	return nil
 }
 
@@ -1099,11 +1856,11 @@ func (this *Applier) StartSlaveIOThread() error {
 func (this *Applier) StopSlaveSQLThread() error {
 	replicaTerm := mysql.ReplicaTermFor(this.migrationContext.ApplierMySQLVersion, `slave`)
 	query := fmt.Sprintf("stop /* gh-ost */ %s sql_thread", replicaTerm)
-	this.migrationContext.Log.Infof("Verifying SQL thread is stopped")
+	this.log.Infof("Verifying SQL thread is stopped")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("SQL thread stopped")
+	this.log.Infof("SQL thread stopped")
 	return nil
 }
 
@@ -1111,11 +1868,11 @@ func (this *Applier) StopSlaveSQLThread() error {
 func (this *Applier) StartSlaveSQLThread() error {
 	replicaTerm := mysql.ReplicaTermFor(this.migrationContext.ApplierMySQLVersion, `slave`)
 	query := fmt.Sprintf("start /* gh-ost */ %s sql_thread", replicaTerm)
-	this.migrationContext.Log.Infof("Verifying SQL thread is running")
+	this.log.Infof("Verifying SQL thread is running")
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("SQL thread started")
+	this.log.Infof("SQL thread started")
 	return nil
 }
 
@@ -1132,7 +1889,7 @@ func (this *Applier) StopReplication() error {
 	if err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Replication IO thread at %+v. SQL thread is at %+v", readBinlogCoordinates, executeBinlogCoordinates)
+	this.log.Infof("Replication IO thread at %+v. SQL thread is at %+v", readBinlogCoordinates, executeBinlogCoordinates)
 	return nil
 }
 
@@ -1144,7 +1901,7 @@ func (this *Applier) StartReplication() error {
 	if err := this.StartSlaveSQLThread(); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Replication started")
+	this.log.Infof("Replication started")
 	return nil
 }
 
@@ -1158,7 +1915,7 @@ func (this *Applier) ExpectUsedLock(sessionId int64) error {
 	var result int64
 	query := `select /* gh-ost */ is_used_lock(?)`
 	lockName := this.GetSessionLockName(sessionId)
-	this.migrationContext.Log.Infof("Checking session lock: %s", lockName)
+	this.log.Infof("Checking session lock: %s", lockName)
 	if err := this.db.QueryRow(query, lockName).Scan(&result); err != nil || result != sessionId {
 		return fmt.Errorf("Session lock %s expected to be found but wasn't", lockName)
 	}
@@ -1193,9 +1950,9 @@ func (this *Applier) ExpectProcess(sessionId int64, stateHint, infoHint string)
 // DropAtomicCutOverSentryTableIfExists checks if the "old" table name
 // happens to be a cut-over magic table; if so, it drops it.
 func (this *Applier) DropAtomicCutOverSentryTableIfExists() error {
-	this.migrationContext.Log.Infof("Looking for magic cut-over table")
+	this.log.Infof("Looking for magic cut-over table")
 	tableName := this.migrationContext.GetOldTableName()
-	rowMap := this.showTableStatus(tableName)
+	rowMap := this.showTableStatus(this.migrationContext.DatabaseName, tableName)
 	if rowMap == nil {
 		// Table does not exist
 		return nil
@@ -1203,8 +1960,8 @@ func (this *Applier) DropAtomicCutOverSentryTableIfExists() error {
 	if rowMap["Comment"].String != atomicCutOverMagicHint {
 		return fmt.Errorf("Expected magic comment on %s, did not find it", tableName)
 	}
-	this.migrationContext.Log.Infof("Dropping magic cut-over table")
-	return this.dropTable(tableName)
+	this.log.Infof("Dropping magic cut-over table")
+	return this.dropTable(this.migrationContext.DatabaseName, tableName)
 }
 
 // CreateAtomicCutOverSentryTable
@@ -1223,14 +1980,14 @@ func (this *Applier) CreateAtomicCutOverSentryTable() error {
 		this.migrationContext.TableEngine,
 		atomicCutOverMagicHint,
 	)
-	this.migrationContext.Log.Infof("Creating magic cut-over table %s.%s",
+	this.log.Infof("Creating magic cut-over table %s.%s",
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(tableName),
 	)
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Magic cut-over table created")
+	this.log.Infof("Magic cut-over table created")
 
 	return nil
 }
@@ -1239,7 +1996,7 @@ func (this *Applier) CreateAtomicCutOverSentryTable() error {
 // time an unresponsive (but still connected) gh-ost process can hold the cut-over lock.
 func (this *Applier) InitAtomicCutOverWaitTimeout(tx *gosql.Tx) error {
 	cutOverWaitTimeoutSeconds := this.migrationContext.CutOverLockTimeoutSeconds * 3
-	this.migrationContext.Log.Infof("Setting cut-over idle timeout as %d seconds", cutOverWaitTimeoutSeconds)
+	this.log.Infof("Setting cut-over idle timeout as %d seconds", cutOverWaitTimeoutSeconds)
 	query := fmt.Sprintf(`set /* gh-ost */ session wait_timeout:=%d`, cutOverWaitTimeoutSeconds)
 	_, err := tx.Exec(query)
 	return err
@@ -1247,10 +2004,10 @@ func (this *Applier) InitAtomicCutOverWaitTimeout(tx *gosql.Tx) error {
 
 // RevertAtomicCutOverWaitTimeout restores the original wait_timeout for the applier session post-cut-over.
 func (this *Applier) RevertAtomicCutOverWaitTimeout() {
-	this.migrationContext.Log.Infof("Reverting cut-over idle timeout to %d seconds", this.migrationContext.ApplierWaitTimeout)
+	this.log.Infof("Reverting cut-over idle timeout to %d seconds", this.migrationContext.ApplierWaitTimeout)
 	query := fmt.Sprintf(`set /* gh-ost */ session wait_timeout:=%d`, this.migrationContext.ApplierWaitTimeout)
 	if _, err := sqlutils.ExecNoPrepare(this.db, query); err != nil {
-		this.migrationContext.Log.Errorf("Failed to restore applier wait_timeout to %d seconds: %v",
+		this.log.Errorf("Failed to restore applier wait_timeout to %d seconds: %v",
 			this.migrationContext.ApplierWaitTimeout, err,
 		)
 	}
@@ -1281,7 +2038,7 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 	lockResult := 0
 	query := `select /* gh-ost */ get_lock(?, 0)`
 	lockName := this.GetSessionLockName(sessionId)
-	this.migrationContext.Log.Infof("Grabbing voluntary lock: %s", lockName)
+	this.log.Infof("Grabbing voluntary lock: %s", lockName)
 	if err := tx.QueryRow(query, lockName).Scan(&lockResult); err != nil || lockResult != 1 {
 		err := fmt.Errorf("Unable to acquire lock %s", lockName)
 		tableLocked <- err
@@ -1289,7 +2046,7 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 	}
 
 	tableLockTimeoutSeconds := this.migrationContext.CutOverLockTimeoutSeconds * 2
-	this.migrationContext.Log.Infof("Setting LOCK timeout as %d seconds", tableLockTimeoutSeconds)
+	this.log.Infof("Setting LOCK timeout as %d seconds", tableLockTimeoutSeconds)
 	query = fmt.Sprintf(`set /* gh-ost */ session lock_wait_timeout:=%d`, tableLockTimeoutSeconds)
 	if _, err := tx.Exec(query); err != nil {
 		tableLocked <- err
@@ -1313,7 +2070,7 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.GetOldTableName()),
 	)
-	this.migrationContext.Log.Infof("Locking %s.%s, %s.%s",
+	this.log.Infof("Locking %s.%s, %s.%s",
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 		sql.EscapeName(this.migrationContext.DatabaseName),
@@ -1324,7 +2081,15 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 		tableLocked <- err
 		return err
 	}
-	this.migrationContext.Log.Infof("Tables locked")
+	this.log.Infof("Tables locked")
+	if err := this.countLockedOriginalTableRows(tx); err != nil {
+		// We hold the lock; release it before the connection returns to the pool
+		if _, unlockErr := tx.Exec(`unlock /* gh-ost */ tables`); unlockErr != nil {
+			this.log.Errore(unlockErr)
+		}
+		tableLocked <- err
+		return err
+	}
 	tableLocked <- nil // No error.
 
 	// From this point on, we are committed to UNLOCK TABLES. No matter what happens,
@@ -1333,29 +2098,29 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 	// The cut-over phase will proceed to apply remaining backlog onto ghost table,
 	// and issue RENAME. We wait here until told to proceed.
 	<-okToUnlockTable
-	this.migrationContext.Log.Infof("Will now proceed to drop magic table and unlock tables")
+	this.log.Infof("Will now proceed to drop magic table and unlock tables")
 
 	// The magic table is here because we locked it. And we are the only ones allowed to drop it.
 	// And in fact, we will:
-	this.migrationContext.Log.Infof("Dropping magic cut-over table")
+	this.log.Infof("Dropping magic cut-over table")
 	query = fmt.Sprintf(`drop /* gh-ost */ table if exists %s.%s`,
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.GetOldTableName()),
 	)
 
 	if _, err := tx.Exec(query); err != nil {
-		this.migrationContext.Log.Errore(err)
+		this.log.Errore(err)
 		// We DO NOT return here because we must `UNLOCK TABLES`!
 	}
 
-	this.migrationContext.Log.Infof("Session renameLockSessionId is %+v", *renameLockSessionId)
+	this.log.Infof("Session renameLockSessionId is %+v", *renameLockSessionId)
 	// Checking the lock is held by rename session
 	if *renameLockSessionId > 0 && this.migrationContext.IsOpenMetadataLockInstruments && !this.migrationContext.SkipMetadataLockCheck {
 		sleepDuration := time.Duration(10*this.migrationContext.CutOverLockTimeoutSeconds) * time.Millisecond
 		for i := 1; i <= 100; i++ {
 			err := this.ExpectMetadataLock(*renameLockSessionId)
 			if err == nil {
-				this.migrationContext.Log.Infof("Rename session is pending lock on the origin table !")
+				this.log.Infof("Rename session is pending lock on the origin table !")
 				break
 			} else {
 				time.Sleep(sleepDuration)
@@ -1363,7 +2128,7 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 		}
 	}
 	// Tables still locked
-	this.migrationContext.Log.Infof("Releasing lock from %s.%s, %s.%s",
+	this.log.Infof("Releasing lock from %s.%s, %s.%s",
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 		sql.EscapeName(this.migrationContext.DatabaseName),
@@ -1372,9 +2137,9 @@ func (this *Applier) AtomicCutOverMagicLock(sessionIdChan chan int64, tableLocke
 	query = `unlock /* gh-ost */ tables`
 	if _, err := tx.Exec(query); err != nil {
 		tableUnlocked <- err
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
-	this.migrationContext.Log.Infof("Tables unlocked")
+	this.log.Infof("Tables unlocked")
 	tableUnlocked <- nil
 	return nil
 }
@@ -1396,7 +2161,7 @@ func (this *Applier) AtomicCutoverRename(sessionIdChan chan int64, tablesRenamed
 	}
 	sessionIdChan <- sessionId
 
-	this.migrationContext.Log.Infof("Setting RENAME timeout as %d seconds", this.migrationContext.CutOverLockTimeoutSeconds)
+	this.log.Infof("Setting RENAME timeout as %d seconds", this.migrationContext.CutOverLockTimeoutSeconds)
 	query := fmt.Sprintf(`set /* gh-ost */ session lock_wait_timeout:=%d`, this.migrationContext.CutOverLockTimeoutSeconds)
 	if _, err := tx.Exec(query); err != nil {
 		return err
@@ -1407,18 +2172,18 @@ func (this *Applier) AtomicCutoverRename(sessionIdChan chan int64, tablesRenamed
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.GetOldTableName()),
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
 	)
-	this.migrationContext.Log.Infof("Issuing and expecting this to block: %s", query)
+	this.log.Infof("Issuing and expecting this to block: %s", query)
 	if _, err := tx.Exec(query); err != nil {
 		tablesRenamed <- err
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	tablesRenamed <- nil
-	this.migrationContext.Log.Infof("Tables renamed")
+	this.log.Infof("Tables renamed")
 	return nil
 }
 
@@ -1446,38 +2211,183 @@ func (this *Applier) updateModifiesUniqueKeyColumns(dmlEvent *binlog.BinlogDMLEv
 	return "", false
 }
 
-// buildDMLEventQuery creates a query to operate on the ghost table, based on an intercepted binlog
-// event entry on the original table.
-func (this *Applier) buildDMLEventQuery(dmlEvent *binlog.BinlogDMLEvent) []*dmlBuildResult {
+// dmlEventWorkerIndex routes a DML event to one of numWorkers applier workers, by hash of the unique
+// key values of the row the event applies to, so that events on a same row go to the same worker.
+// An UPDATE modifying the unique key applies to two rows and cannot be routed, in which case ok is false.
+func (this *Applier) dmlEventWorkerIndex(dmlEvent *binlog.BinlogDMLEvent, numWorkers int) (index int, ok bool) {
+	values := dmlEvent.WhereColumnValues
 	switch dmlEvent.DML {
-	case binlog.DeleteDML:
-		{
-			query, uniqueKeyArgs, err := this.dmlDeleteQueryBuilder.BuildQuery(dmlEvent.WhereColumnValues.AbstractValues())
-			return []*dmlBuildResult{newDmlBuildResult(query, uniqueKeyArgs, -1, err)}
-		}
 	case binlog.InsertDML:
-		{
-			query, sharedArgs, err := this.dmlInsertQueryBuilder.BuildQuery(dmlEvent.NewColumnValues.AbstractValues())
-			return []*dmlBuildResult{newDmlBuildResult(query, sharedArgs, 1, err)}
+		values = dmlEvent.NewColumnValues
+	case binlog.UpdateDML:
+		if _, isModified := this.updateModifiesUniqueKeyColumns(dmlEvent); isModified {
+			return 0, false
 		}
+	}
+	hash := fnv.New64a()
+	for _, column := range this.migrationContext.UniqueKey.Columns.Columns() {
+		tableOrdinal := this.migrationContext.OriginalTableColumns.Ordinals[column.Name]
+		fmt.Fprintf(hash, "%v\x00", values.AbstractValues()[tableOrdinal])
+	}
+	return int(hash.Sum64() % uint64(numWorkers)), true
+}
+
+// appendDMLEventQuery creates the queries to operate on the ghost table, based on an intercepted binlog
+// event entry on the original table. The queries are appended to results and their arguments to args;
+// each result's args is a view into args.
+func (this *Applier) appendDMLEventQuery(results []dmlBuildResult, args []interface{}, dmlEvent *binlog.BinlogDMLEvent) ([]dmlBuildResult, []interface{}, error) {
+	var query string
+	var kind dmlQueryKind
+	var rowsDelta int64
+	var err error
+	insertKind := dmlInsertQuery
+	if len(this.migrationContext.ColumnTransforms) > 0 {
+		insertKind = dmlTransformedInsertQuery
+	}
+	argsStart := len(args)
+	switch dmlEvent.DML {
+	case binlog.DeleteDML:
+		query, args, err = this.dmlDeleteQueryBuilder.AppendQueryArgs(args, dmlEvent.WhereColumnValues.AbstractValues())
+		kind, rowsDelta = dmlDeleteQuery, -1
+	case binlog.InsertDML:
+		query, args, err = this.dmlInsertQueryBuilder.AppendQueryArgs(args, dmlEvent.NewColumnValues.AbstractValues())
+		kind, rowsDelta = insertKind, 1
 	case binlog.UpdateDML:
-		{
-			if _, isModified := this.updateModifiesUniqueKeyColumns(dmlEvent); isModified {
-				results := make([]*dmlBuildResult, 0, 2)
-				dmlEvent.DML = binlog.DeleteDML
-				results = append(results, this.buildDMLEventQuery(dmlEvent)...)
-				dmlEvent.DML = binlog.InsertDML
-				results = append(results, this.buildDMLEventQuery(dmlEvent)...)
-				return results
+		if _, isModified := this.updateModifiesUniqueKeyColumns(dmlEvent); isModified {
+			// The row moves to another key: delete it by its old key, which is a no-op where the row
+			// is yet to be copied, then write it by its new key. Both apply in the batch's transaction.
+			// The event itself is left as is, for retries of the batch to build the same queries.
+			query, args, err = this.dmlDeleteQueryBuilder.AppendQueryArgs(args, dmlEvent.WhereColumnValues.AbstractValues())
+			if err != nil {
+				return results, args, err
+			}
+			results = append(results, dmlBuildResult{query: query, args: args[argsStart:], kind: dmlDeleteQuery, rowsDelta: -1})
+			argsStart = len(args)
+			query, args, err = this.dmlInsertQueryBuilder.AppendQueryArgs(args, dmlEvent.NewColumnValues.AbstractValues())
+			kind, rowsDelta = insertKind, 1
+			break
+		}
+		if len(this.migrationContext.ColumnTransforms) > 0 {
+			// transform expressions are evaluated against the row's values, which an UPDATE cannot
+			// bind; the binlog event carries the full new row, so it is written as a whole instead
+			query, args, err = this.dmlInsertQueryBuilder.AppendQueryArgs(args, dmlEvent.NewColumnValues.AbstractValues())
+			kind = dmlTransformedInsertQuery
+			break
+		}
+		query, args, err = this.dmlUpdateQueryBuilder.AppendQueryArgs(args, dmlEvent.NewColumnValues.AbstractValues(), dmlEvent.WhereColumnValues.AbstractValues())
+		kind = dmlUpdateQuery
+	default:
+		return results, args, fmt.Errorf("Unknown dml event type: %+v", dmlEvent.DML)
+	}
+	if err != nil {
+		return results, args, err
+	}
+	results = append(results, dmlBuildResult{query: query, args: args[argsStart:], kind: kind, rowsDelta: rowsDelta})
+	return results, args, nil
+}
+
+// buildDMLBatchQuery returns the multi-statement query for the batch. Each query kind is a single
+// fixed query, but not each rows delta: with column transforms an update runs the transformed insert.
+// The multi-statement query only depends on the sequence of query kinds, and is cached by it.
+func (this *Applier) buildDMLBatchQuery(batch *dmlBatch) string {
+	batch.queryKey = batch.queryKey[:0]
+	for _, result := range batch.results {
+		batch.queryKey = append(batch.queryKey, byte(result.kind))
+	}
+
+	this.dmlBatchQueryCacheMutex.Lock()
+	defer this.dmlBatchQueryCacheMutex.Unlock()
+	if query, ok := this.dmlBatchQueryCache[string(batch.queryKey)]; ok {
+		return query
+	}
+
+	multiQueryBuilder := strings.Builder{}
+	for _, result := range batch.results {
+		multiQueryBuilder.WriteString(result.query)
+		multiQueryBuilder.WriteString(";\n")
+	}
+	query := multiQueryBuilder.String()
+	if len(this.dmlBatchQueryCache) >= dmlBatchQueryCacheSize {
+		clear(this.dmlBatchQueryCache)
+	}
+	this.dmlBatchQueryCache[string(batch.queryKey)] = query
+	return query
+}
+
+// acquireDMLStatement returns the prepared statement for given query, cached by key, preparing it on first
+// use, to be released once run. It returns nil where the query could not be prepared, e.g. for
+// max_prepared_stmt_count, for the query to run as text instead. The cache is bounded, and is emptied when full.
+func (this *Applier) acquireDMLStatement(ctx context.Context, key, query string) *dmlStatement {
+	this.dmlStatementsMutex.Lock()
+	defer this.dmlStatementsMutex.Unlock()
+	statement, ok := this.dmlStatements[key]
+	if !ok {
+		// preparing takes a connection from the pool, not to be waited for while locked
+		this.dmlStatementsMutex.Unlock()
+		stmt, err := this.db.PrepareContext(ctx, query)
+		this.dmlStatementsMutex.Lock()
+		if err != nil {
+			this.log.Warningf("Cannot prepare DML query, running it as text: %+v", err)
+		}
+		if statement, ok = this.dmlStatements[key]; ok {
+			if stmt != nil {
+				stmt.Close()
+			}
+		} else {
+			if len(this.dmlStatements) >= dmlStatementCacheSize {
+				for cachedKey := range this.dmlStatements {
+					this.evictDMLStatementLocked(cachedKey)
+				}
 			}
-			query, sharedArgs, uniqueKeyArgs, err := this.dmlUpdateQueryBuilder.BuildQuery(dmlEvent.NewColumnValues.AbstractValues(), dmlEvent.WhereColumnValues.AbstractValues())
-			args := sqlutils.Args()
-			args = append(args, sharedArgs...)
-			args = append(args, uniqueKeyArgs...)
-			return []*dmlBuildResult{newDmlBuildResult(query, args, 0, err)}
+			statement = &dmlStatement{stmt: stmt}
+			this.dmlStatements[key] = statement
 		}
 	}
-	return []*dmlBuildResult{newDmlBuildResultError(fmt.Errorf("Unknown dml event type: %+v", dmlEvent.DML))}
+	if statement.stmt == nil {
+		return nil
+	}
+	statement.users++
+	return statement
+}
+
+// releaseDMLStatement releases an acquired statement, evicting it where the server no longer holds it
+func (this *Applier) releaseDMLStatement(key string, statement *dmlStatement, stale bool) {
+	this.dmlStatementsMutex.Lock()
+	defer this.dmlStatementsMutex.Unlock()
+	statement.users--
+	if stale && this.dmlStatements[key] == statement {
+		this.evictDMLStatementLocked(key)
+	} else if statement.evicted && statement.users == 0 {
+		statement.stmt.Close()
+	}
+}
+
+// evictDMLStatementLocked drops given key's statement from the cache, closing it unless in use
+func (this *Applier) evictDMLStatementLocked(key string) {
+	statement := this.dmlStatements[key]
+	delete(this.dmlStatements, key)
+	statement.evicted = true
+	if statement.stmt != nil && statement.users == 0 {
+		statement.stmt.Close()
+	}
+}
+
+// execDMLStatement runs the batch's single statement as given prepared statement within tx. It reports
+// the statement stale where the server no longer holds it, for the caller to run the query as text instead.
+func (this *Applier) execDMLStatement(ctx context.Context, tx *gosql.Tx, stmt *gosql.Stmt, result dmlBuildResult) (rowsDelta int64, stale bool, err error) {
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, result.args...)
+	if mysql.IsStaleStatementError(err) {
+		this.log.Debugf("Prepared DML statement is stale, running it as text: %+v", err)
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%w; query=%s; args=%+v", err, result.query, result.args)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	return result.rowsDelta * rowsAffected, false, nil
 }
 
 // ApplyDMLEventQueries applies multiple DML queries onto the _ghost_ table
@@ -1486,6 +2396,25 @@ func (this *Applier) ApplyDMLEventQueries(dmlEvents [](*binlog.BinlogDMLEvent))
 	ctx := context.Background()
 
 	err := func() error {
+		batch := dmlBatchPool.Get().(*dmlBatch)
+		defer batch.release()
+		for _, dmlEvent := range dmlEvents {
+			var err error
+			if batch.results, batch.args, err = this.appendDMLEventQuery(batch.results, batch.args, dmlEvent); err != nil {
+				return err
+			}
+		}
+		// A single statement runs as a prepared statement, which saves parsing it on each event.
+		// It is prepared ahead of taking the connection, as preparing takes one from the pool.
+		var statement *dmlStatement
+		var stale bool
+		if len(batch.results) == 1 {
+			key := dmlQueryKindKeys[batch.results[0].kind]
+			if statement = this.acquireDMLStatement(ctx, key, batch.results[0].query); statement != nil {
+				defer func() { this.releaseDMLStatement(key, statement, stale) }()
+			}
+		}
+
 		conn, err := this.db.Conn(ctx)
 		if err != nil {
 			return err
@@ -1507,17 +2436,17 @@ func (this *Applier) ApplyDMLEventQueries(dmlEvents [](*binlog.BinlogDMLEvent))
 			return err
 		}
 
-		buildResults := make([]*dmlBuildResult, 0, len(dmlEvents))
-		nArgs := 0
-		for _, dmlEvent := range dmlEvents {
-			for _, buildResult := range this.buildDMLEventQuery(dmlEvent) {
-				if buildResult.err != nil {
-					return rollback(buildResult.err)
-				}
-				nArgs += len(buildResult.args)
-				buildResults = append(buildResults, buildResult)
+		if statement != nil {
+			var rowsDelta int64
+			if rowsDelta, stale, err = this.execDMLStatement(ctx, tx, statement.stmt, batch.results[0]); err != nil {
+				return rollback(err)
+			}
+			if !stale {
+				totalDelta += rowsDelta
+				return tx.Commit()
 			}
 		}
+		multiQuery := this.buildDMLBatchQuery(batch)
 
 		// We batch together the DML queries into multi-statements to minimize network trips.
 		// We have to use the raw driver connection to access the rows affected
@@ -1526,22 +2455,15 @@ func (this *Applier) ApplyDMLEventQueries(dmlEvents [](*binlog.BinlogDMLEvent))
 			ex := driverConn.(driver.ExecerContext)
 			nvc := driverConn.(driver.NamedValueChecker)
 
-			multiArgs := make([]driver.NamedValue, 0, nArgs)
-			multiQueryBuilder := strings.Builder{}
-			for _, buildResult := range buildResults {
-				for _, arg := range buildResult.args {
-					nv := driver.NamedValue{Value: driver.Value(arg)}
-					nvc.CheckNamedValue(&nv)
-					multiArgs = append(multiArgs, nv)
-				}
-
-				multiQueryBuilder.WriteString(buildResult.query)
-				multiQueryBuilder.WriteString(";\n")
+			for _, arg := range batch.args {
+				nv := driver.NamedValue{Value: driver.Value(arg)}
+				nvc.CheckNamedValue(&nv)
+				batch.namedArgs = append(batch.namedArgs, nv)
 			}
 
-			res, err := ex.ExecContext(ctx, multiQueryBuilder.String(), multiArgs)
+			res, err := ex.ExecContext(ctx, multiQuery, batch.namedArgs)
 			if err != nil {
-				err = fmt.Errorf("%w; query=%s; args=%+v", err, multiQueryBuilder.String(), multiArgs)
+				err = fmt.Errorf("%w; query=%s; args=%+v", err, multiQuery, batch.namedArgs)
 				return err
 			}
 
@@ -1550,7 +2472,7 @@ func (this *Applier) ApplyDMLEventQueries(dmlEvents [](*binlog.BinlogDMLEvent))
 			// each DML is either a single insert (delta +1), update (delta +0) or delete (delta -1).
 			// multiplying by the rows actually affected (either 0 or 1) will give an accurate row delta for this DML event
 			for i, rowsAffected := range mysqlRes.AllRowsAffected() {
-				totalDelta += buildResults[i].rowsDelta * rowsAffected
+				totalDelta += batch.results[i].rowsDelta * rowsAffected
 			}
 			return nil
 		})
@@ -1565,19 +2487,40 @@ func (this *Applier) ApplyDMLEventQueries(dmlEvents [](*binlog.BinlogDMLEvent))
 	}()
 
 	if err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	// no error
 	atomic.AddInt64(&this.migrationContext.TotalDMLEventsApplied, int64(len(dmlEvents)))
+	var inserts, updates, deletes, uniqueKeyUpdates int64
+	for _, dmlEvent := range dmlEvents {
+		switch dmlEvent.DML {
+		case binlog.InsertDML:
+			inserts++
+		case binlog.UpdateDML:
+			updates++
+			if _, isModified := this.updateModifiesUniqueKeyColumns(dmlEvent); isModified {
+				uniqueKeyUpdates++
+			}
+		case binlog.DeleteDML:
+			deletes++
+		}
+	}
+	this.migrationContext.Stats.AddAppliedDML(inserts, updates, deletes, uniqueKeyUpdates)
+	this.migrationContext.MarkDMLApplyProgress()
 	if this.migrationContext.CountTableRows {
 		atomic.AddInt64(&this.migrationContext.RowsDeltaEstimate, totalDelta)
 	}
-	this.migrationContext.Log.Debugf("ApplyDMLEventQueries() applied %d events in one transaction", len(dmlEvents))
+	this.log.Debugf("ApplyDMLEventQueries() applied %d events in one transaction", len(dmlEvents))
 	return nil
 }
 
 func (this *Applier) Teardown() {
-	this.migrationContext.Log.Debugf("Tearing down...")
+	this.log.Debugf("Tearing down...")
+	this.dmlStatementsMutex.Lock()
+	for key := range this.dmlStatements {
+		this.evictDMLStatementLocked(key)
+	}
+	this.dmlStatementsMutex.Unlock()
 	this.db.Close()
 	this.singletonDB.Close()
 	atomic.StoreInt64(&this.finishedMigrating, 1)
@@ -1602,7 +2545,7 @@ func (this *Applier) ExpectMetadataLock(sessionId int64) error {
 	}
 	if !found {
 		err = fmt.Errorf("cannot find PENDING metadata lock on original table: `%s`.`%s`", this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	return nil
 }
@@ -9,7 +9,10 @@ import (
 	"context"
 	gosql "database/sql"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -98,6 +101,106 @@ func TestApplierUpdateModifiesUniqueKeyColumns(t *testing.T) {
 	})
 }
 
+func TestApplierDMLEventWorkerIndex(t *testing.T) {
+	columns := sql.NewColumnList([]string{"id", "item_id"})
+	migrationContext := base.NewMigrationContext()
+	migrationContext.OriginalTableColumns = columns
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	applier := NewApplier(migrationContext)
+
+	workerIndex := func(dml binlog.EventDML, whereValues, newValues []interface{}) (int, bool) {
+		return applier.dmlEventWorkerIndex(&binlog.BinlogDMLEvent{
+			DML:               dml,
+			WhereColumnValues: sql.ToColumnValues(whereValues),
+			NewColumnValues:   sql.ToColumnValues(newValues),
+		}, 8)
+	}
+
+	workers := make(map[int]bool)
+	for id := 0; id < 100; id++ {
+		insertIndex, ok := workerIndex(binlog.InsertDML, nil, []interface{}{id, 1})
+		require.True(t, ok)
+		updateIndex, ok := workerIndex(binlog.UpdateDML, []interface{}{id, 1}, []interface{}{id, 2})
+		require.True(t, ok)
+		deleteIndex, ok := workerIndex(binlog.DeleteDML, []interface{}{id, 2}, nil)
+		require.True(t, ok)
+		require.Equal(t, insertIndex, updateIndex)
+		require.Equal(t, insertIndex, deleteIndex)
+		workers[insertIndex] = true
+	}
+	require.Len(t, workers, 8)
+
+	_, ok := workerIndex(binlog.UpdateDML, []interface{}{1, 1}, []interface{}{2, 1})
+	require.False(t, ok)
+}
+
+func TestApplierHeartbeatToleratesTransientFailures(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	applier := migrator.applier
+	applier.migrationContext.SetHeartbeatIntervalMilliseconds(100)
+	applier.migrationContext.SetDefaultNumRetries(1)
+	heartbeat := `'heartbeat'`
+
+	// Failing for a few sub-second intervals does not abort
+	fake.expect(heartbeat).returnError(newFakeMySQLError(2013)).times(4)
+	go applier.InitiateHeartbeat(nil)
+	defer applier.StopHeartbeat()
+	require.Eventually(t, func() bool { return fake.countQueries(heartbeat) > 6 }, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, applier.migrationContext.PanicAbort)
+
+	// Failing for longer than --default-retries seconds aborts
+	fake.expect(heartbeat).returnError(newFakeMySQLError(2013))
+	select {
+	case err := <-applier.migrationContext.PanicAbort:
+		require.ErrorContains(t, err, "injectHeartbeat writing failed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("migration not aborted")
+	}
+}
+
+func TestApplierHeartbeatRecreatesChangelog(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	applier := migrator.applier
+	applier.migrationContext.SetHeartbeatIntervalMilliseconds(100)
+	_, err := applier.WriteChangelogState(string(GhostTableMigrated))
+	require.NoError(t, err)
+	_, err = applier.WriteChangelogState(fmt.Sprintf("%s:%d", AllEventsUpToLockProcessed, 1))
+	require.NoError(t, err)
+	_, err = applier.WriteChangelog("throttle", "commanded by user")
+	require.NoError(t, err)
+	fake.expect(`^insert /\* gh-ost \*/\s+into\s+\S+\s+\(id, hint, value\)\s+values\s+\(1, 'heartbeat'`).returnError(newFakeMySQLError(1146)).times(1)
+
+	recreated := make(chan bool, 1)
+	go applier.InitiateHeartbeat(func() error {
+		recreated <- true
+		return nil
+	})
+	select {
+	case <-recreated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("changelog table not recreated")
+	}
+	applier.StopHeartbeat()
+	require.Equal(t, 1, fake.countQueries(`^create /\* gh-ost \*/ table`))
+
+	// The state and throttle rows are restored, other than a past cut-over's challenge
+	var restored []string
+	for _, statement := range fake.executedStatements() {
+		if len(statement.args) == 3 && strings.Contains(statement.query, "NULLIF") {
+			restored = append(restored, fmt.Sprintf("%v=%v", statement.args[1], statement.args[2]))
+		}
+	}
+	require.ElementsMatch(t, []string{"throttle=commanded by user", "state=GhostTableMigrated"}, restored[len(restored)-2:])
+
+	// No heartbeat, nor changelog table, once stopped
+	heartbeats := fake.countQueries(`'heartbeat'`)
+	time.Sleep(300 * time.Millisecond)
+	require.Equal(t, heartbeats, fake.countQueries(`'heartbeat'`))
+}
+
 func TestApplierBuildDMLEventQuery(t *testing.T) {
 	columns := sql.NewColumnList([]string{"id", "item_id"})
 	columnValues := sql.ToColumnValues([]interface{}{123456, 42})
@@ -123,9 +226,9 @@ func TestApplierBuildDMLEventQuery(t *testing.T) {
 			WhereColumnValues: columnValues,
 		}
 
-		res := applier.buildDMLEventQuery(binlogEvent)
+		res, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+		require.NoError(t, err)
 		require.Len(t, res, 1)
-		require.NoError(t, res[0].err)
 		require.Equal(t, `delete /* gh-ost `+"`test`.`_test_gho`"+` */
 		from
 			`+"`test`.`_test_gho`"+`
@@ -143,9 +246,9 @@ func TestApplierBuildDMLEventQuery(t *testing.T) {
 			DML:             binlog.InsertDML,
 			NewColumnValues: columnValues,
 		}
-		res := applier.buildDMLEventQuery(binlogEvent)
+		res, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+		require.NoError(t, err)
 		require.Len(t, res, 1)
-		require.NoError(t, res[0].err)
 		require.Equal(t,
 			`replace /* gh-ost `+"`test`.`_test_gho`"+` */
 		into
@@ -166,9 +269,9 @@ func TestApplierBuildDMLEventQuery(t *testing.T) {
 			NewColumnValues:   columnValues,
 			WhereColumnValues: columnValues,
 		}
-		res := applier.buildDMLEventQuery(binlogEvent)
+		res, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+		require.NoError(t, err)
 		require.Len(t, res, 1)
-		require.NoError(t, res[0].err)
 		require.Equal(t,
 			`update /* gh-ost `+"`test`.`_test_gho`"+` */
 			`+"`test`.`_test_gho`"+`
@@ -183,6 +286,241 @@ func TestApplierBuildDMLEventQuery(t *testing.T) {
 		require.Equal(t, 123456, res[0].args[2])
 		require.Equal(t, 42, res[0].args[3])
 	})
+
+	t.Run("update modifying unique key", func(t *testing.T) {
+		binlogEvent := &binlog.BinlogDMLEvent{
+			DatabaseName:      "test",
+			DML:               binlog.UpdateDML,
+			NewColumnValues:   sql.ToColumnValues([]interface{}{123457, 43}),
+			WhereColumnValues: columnValues,
+		}
+		res, args, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, int64(-1), res[0].rowsDelta)
+		require.Equal(t, []interface{}{123456, 42}, res[0].args)
+		require.Equal(t, int64(1), res[1].rowsDelta)
+		require.Equal(t, []interface{}{123457, 43}, res[1].args)
+		require.Equal(t, []interface{}{123456, 42, 123457, 43}, args)
+
+		// the event is left as is, so that a retried batch builds the same queries
+		require.Equal(t, binlog.UpdateDML, binlogEvent.DML)
+		retried, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+		require.NoError(t, err)
+		require.Equal(t, res, retried)
+	})
+}
+
+func TestApplierBuildDMLEventQueryWithColumnTransforms(t *testing.T) {
+	columns := sql.NewColumnList([]string{"id", "email"})
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.OriginalTableName = "test"
+	migrationContext.OriginalTableColumns = columns
+	migrationContext.SharedColumns = columns
+	migrationContext.MappedSharedColumns = columns
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	migrationContext.ColumnTransforms = []*sql.ColumnTransform{{Column: "email", Expression: "SHA2(email, 256)"}}
+
+	applier := NewApplier(migrationContext)
+	require.NoError(t, applier.prepareQueries())
+
+	binlogEvent := &binlog.BinlogDMLEvent{
+		DatabaseName:      "test",
+		DML:               binlog.UpdateDML,
+		NewColumnValues:   sql.ToColumnValues([]interface{}{1, "new@example.com"}),
+		WhereColumnValues: sql.ToColumnValues([]interface{}{1, "old@example.com"}),
+	}
+	res, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.True(t, strings.HasPrefix(strings.TrimSpace(res[0].query), "replace /* gh-ost"))
+	require.Contains(t, res[0].query, "(SHA2(email, 256))")
+	require.Equal(t, dmlTransformedInsertQuery, res[0].kind)
+	require.Equal(t, int64(0), res[0].rowsDelta)
+	require.Equal(t, []interface{}{1, "new@example.com"}, res[0].args)
+
+	// an insert runs the same query, and shares its cached multi-statement query
+	binlogEvent = &binlog.BinlogDMLEvent{
+		DatabaseName:    "test",
+		DML:             binlog.InsertDML,
+		NewColumnValues: sql.ToColumnValues([]interface{}{2, "other@example.com"}),
+	}
+	insertRes, _, err := applier.appendDMLEventQuery(nil, nil, binlogEvent)
+	require.NoError(t, err)
+	require.Equal(t, dmlTransformedInsertQuery, insertRes[0].kind)
+	require.Equal(t, int64(1), insertRes[0].rowsDelta)
+	require.Equal(t, applier.buildDMLBatchQuery(&dmlBatch{results: res}), applier.buildDMLBatchQuery(&dmlBatch{results: insertRes}))
+	require.Len(t, applier.dmlBatchQueryCache, 1)
+}
+
+func newTestDMLBatchApplier() (*Applier, []*binlog.BinlogDMLEvent) {
+	columns := sql.NewColumnList([]string{"id", "item_id", "name"})
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.OriginalTableName = "test"
+	migrationContext.OriginalTableColumns = columns
+	migrationContext.SharedColumns = columns
+	migrationContext.MappedSharedColumns = columns
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	applier := NewApplier(migrationContext)
+	applier.prepareQueries()
+
+	dmlEvents := make([]*binlog.BinlogDMLEvent, 0, 100)
+	for i := 0; i < cap(dmlEvents); i++ {
+		values := sql.ToColumnValues([]interface{}{i, i * 2, "name"})
+		switch i % 3 {
+		case 0:
+			dmlEvents = append(dmlEvents, &binlog.BinlogDMLEvent{DML: binlog.InsertDML, NewColumnValues: values})
+		case 1:
+			dmlEvents = append(dmlEvents, &binlog.BinlogDMLEvent{DML: binlog.UpdateDML, NewColumnValues: values, WhereColumnValues: values})
+		case 2:
+			dmlEvents = append(dmlEvents, &binlog.BinlogDMLEvent{DML: binlog.DeleteDML, WhereColumnValues: values})
+		}
+	}
+	return applier, dmlEvents
+}
+
+func TestApplierBuildDMLBatchQuery(t *testing.T) {
+	applier, dmlEvents := newTestDMLBatchApplier()
+
+	build := func(dmlEvents []*binlog.BinlogDMLEvent) string {
+		batch := dmlBatchPool.Get().(*dmlBatch)
+		defer batch.release()
+		var err error
+		for _, dmlEvent := range dmlEvents {
+			batch.results, batch.args, err = applier.appendDMLEventQuery(batch.results, batch.args, dmlEvent)
+			require.NoError(t, err)
+		}
+		return applier.buildDMLBatchQuery(batch)
+	}
+
+	query := build(dmlEvents[:3])
+	statements := strings.Split(strings.TrimSuffix(query, ";\n"), ";\n")
+	require.Len(t, statements, 3)
+	require.Contains(t, statements[0], "replace /* gh-ost")
+	require.Contains(t, statements[1], "update /* gh-ost")
+	require.Contains(t, statements[2], "delete /* gh-ost")
+	require.Len(t, applier.dmlBatchQueryCache, 1)
+
+	require.Equal(t, query, build(dmlEvents[3:6]))
+	require.Len(t, applier.dmlBatchQueryCache, 1)
+
+	require.NotEqual(t, query, build(dmlEvents[1:4]))
+	require.Len(t, applier.dmlBatchQueryCache, 2)
+}
+
+func TestApplierApplyDMLEventQueriesPrepared(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	applier := migrator.applier
+	prepared := `^replace /\* gh-ost .*\)$`
+	insert := func(ids ...int) []*binlog.BinlogDMLEvent {
+		var dmlEvents []*binlog.BinlogDMLEvent
+		for _, id := range ids {
+			dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{id, id}))
+		}
+		return dmlEvents
+	}
+
+	// Single statement batches run as one prepared statement, prepared once per connection
+	for id := 1; id <= 3; id++ {
+		require.NoError(t, applier.ApplyDMLEventQueries(insert(id)))
+	}
+	require.Equal(t, 3, fake.countQueries(prepared))
+	require.Len(t, applier.dmlStatements, 1)
+	require.LessOrEqual(t, fake.countPrepares(), 2)
+	require.Equal(t, map[int]int{1: 1, 2: 2, 3: 3}, replayFakeStatements(t, fake.executedStatements()))
+
+	// Larger batches run as text multi-statements
+	require.NoError(t, applier.ApplyDMLEventQueries(insert(4, 5)))
+	require.Equal(t, 3, fake.countQueries(prepared))
+	require.Equal(t, 1, fake.countQueries(`^replace .*;$`))
+
+	// A statement the server no longer holds runs as text, and is prepared again on next use
+	fake.expect(prepared).returnError(newFakeMySQLError(1243)).times(1)
+	require.NoError(t, applier.ApplyDMLEventQueries(insert(6)))
+	require.Equal(t, 2, fake.countQueries(`^replace .*;$`))
+	require.Empty(t, applier.dmlStatements)
+	require.NoError(t, applier.ApplyDMLEventQueries(insert(7)))
+	require.Len(t, applier.dmlStatements, 1)
+
+	// Other errors fail the batch
+	fake.expect(prepared).returnError(newFakeMySQLError(1146)).times(1)
+	require.Error(t, applier.ApplyDMLEventQueries(insert(8)))
+
+	// The cache is bounded
+	for i := 0; i < 2*dmlStatementCacheSize; i++ {
+		query := fmt.Sprintf("delete /* gh-ost */ from t%d where id = ?", i)
+		statement := applier.acquireDMLStatement(context.Background(), query, query)
+		require.NotNil(t, statement)
+		applier.releaseDMLStatement(query, statement, false)
+		require.LessOrEqual(t, len(applier.dmlStatements), dmlStatementCacheSize)
+	}
+}
+
+// BenchmarkApplierApplyDMLEventQueries measures single event batches, as applied
+// while the throttler and a status poller concurrently read the migration context
+func BenchmarkApplierApplyDMLEventQueries(b *testing.B) {
+	migrator, _ := newFakeMigrator(b)
+	migrationContext := migrator.migrationContext
+	migrationContext.SetThrottleGeneralCheckResult(base.NewThrottleCheckResult(false, "", base.NoThrottleReasonHint))
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			migrationContext.GetThrottleGeneralCheckResult()
+			migrationContext.IsThrottled()
+			migrationContext.GetNiceRatio()
+			migrationContext.GetTotalRowsCopied()
+			migrationContext.GetCurrentLagDuration()
+		}
+	}()
+
+	dmlEvents := []*binlog.BinlogDMLEvent{newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{1, 1})}
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if err := migrator.applier.ApplyDMLEventQueries(dmlEvents); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "events/sec")
+	b.StopTimer()
+
+	close(done)
+	wg.Wait()
+}
+
+func BenchmarkApplierBuildDMLBatch(b *testing.B) {
+	applier, dmlEvents := newTestDMLBatchApplier()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := dmlBatchPool.Get().(*dmlBatch)
+		var err error
+		for _, dmlEvent := range dmlEvents {
+			if batch.results, batch.args, err = applier.appendDMLEventQuery(batch.results, batch.args, dmlEvent); err != nil {
+				b.Fatal(err)
+			}
+		}
+		applier.buildDMLBatchQuery(batch)
+		batch.release()
+	}
 }
 
 func TestApplierInstantDDL(t *testing.T) {
@@ -199,6 +537,18 @@ func TestApplierInstantDDL(t *testing.T) {
 	})
 }
 
+func TestApplierInplaceIndexDDL(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.SkipPortValidation = true
+	migrationContext.OriginalTableName = "mytable"
+	migrationContext.AlterStatementOptions = "ADD INDEX (foo), DROP INDEX bar"
+	applier := NewApplier(migrationContext)
+
+	stmt := applier.generateInplaceIndexDDLQuery()
+	require.Equal(t, "ALTER /* gh-ost */ TABLE `test`.`mytable` ADD INDEX (foo), DROP INDEX bar, ALGORITHM=INPLACE, LOCK=NONE", stmt)
+}
+
 type ApplierTestSuite struct {
 	suite.Suite
 
@@ -343,6 +693,153 @@ func (suite *ApplierTestSuite) TestApplyDMLEventQueries() {
 	suite.Require().Equal(int64(0), migrationContext.RowsDeltaEstimate)
 }
 
+func (suite *ApplierTestSuite) TestApplyDMLEventQueriesWithColumnTransforms() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, email VARCHAR(64), ssn VARCHAR(16));", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, email VARCHAR(64), ssn VARCHAR(16), email_hash CHAR(64));", getTestGhostTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+
+	columns := sql.NewColumnList([]string{"id", "email", "ssn"})
+	migrationContext.OriginalTableColumns = columns
+	migrationContext.SharedColumns = columns
+	migrationContext.MappedSharedColumns = columns
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	migrationContext.ColumnTransforms = []*sql.ColumnTransform{
+		{Column: "ssn", Expression: "NULL"},
+		{Column: "email_hash", Expression: "SHA2(email, 256)"},
+	}
+
+	applier := NewApplier(migrationContext)
+	suite.Require().NoError(applier.prepareQueries())
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+
+	dmlEvents := []*binlog.BinlogDMLEvent{
+		{
+			DatabaseName:    testMysqlDatabase,
+			TableName:       testMysqlTableName,
+			DML:             binlog.InsertDML,
+			NewColumnValues: sql.ToColumnValues([]interface{}{1, "first@example.com", "123-45-6789"}),
+		},
+		{
+			DatabaseName:    testMysqlDatabase,
+			TableName:       testMysqlTableName,
+			DML:             binlog.InsertDML,
+			NewColumnValues: sql.ToColumnValues([]interface{}{2, "second@example.com", "987-65-4321"}),
+		},
+		{
+			DatabaseName:      testMysqlDatabase,
+			TableName:         testMysqlTableName,
+			DML:               binlog.UpdateDML,
+			WhereColumnValues: sql.ToColumnValues([]interface{}{2, "second@example.com", "987-65-4321"}),
+			NewColumnValues:   sql.ToColumnValues([]interface{}{2, "updated@example.com", "987-65-4321"}),
+		},
+	}
+	suite.Require().NoError(applier.ApplyDMLEventQueries(dmlEvents))
+
+	rows, err := suite.db.Query(fmt.Sprintf("SELECT id, email, ssn IS NULL, email_hash = SHA2(email, 256) FROM %s ORDER BY id", getTestGhostTableName()))
+	suite.Require().NoError(err)
+	defer rows.Close()
+
+	emails := []string{}
+	for rows.Next() {
+		var id int
+		var email string
+		var ssnIsNull, emailHashMatches bool
+		suite.Require().NoError(rows.Scan(&id, &email, &ssnIsNull, &emailHashMatches))
+		suite.Require().True(ssnIsNull)
+		suite.Require().True(emailHashMatches)
+		emails = append(emails, email)
+	}
+	suite.Require().NoError(rows.Err())
+	suite.Require().Equal([]string{"first@example.com", "updated@example.com"}, emails)
+	suite.Require().Equal(int64(3), migrationContext.TotalDMLEventsApplied)
+}
+
+func (suite *ApplierTestSuite) TestApplyDMLEventQueriesUpdatingUniqueKey() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, item_id INT);", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, item_id INT);", getTestGhostTableName()))
+	suite.Require().NoError(err)
+	// only the row with id=1 has been copied
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, 11)", getTestGhostTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+
+	columns := sql.NewColumnList([]string{"id", "item_id"})
+	migrationContext.OriginalTableColumns = columns
+	migrationContext.SharedColumns = columns
+	migrationContext.MappedSharedColumns = columns
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+
+	applier := NewApplier(migrationContext)
+	suite.Require().NoError(applier.prepareQueries())
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+
+	dmlEvents := []*binlog.BinlogDMLEvent{
+		{
+			DatabaseName:      testMysqlDatabase,
+			TableName:         testMysqlTableName,
+			DML:               binlog.UpdateDML,
+			WhereColumnValues: sql.ToColumnValues([]interface{}{1, 11}),
+			NewColumnValues:   sql.ToColumnValues([]interface{}{10, 11}),
+		},
+		{
+			DatabaseName:      testMysqlDatabase,
+			TableName:         testMysqlTableName,
+			DML:               binlog.UpdateDML,
+			WhereColumnValues: sql.ToColumnValues([]interface{}{2, 22}),
+			NewColumnValues:   sql.ToColumnValues([]interface{}{20, 22}),
+		},
+	}
+	suite.Require().NoError(applier.ApplyDMLEventQueries(dmlEvents))
+	// as when retried
+	suite.Require().NoError(applier.ApplyDMLEventQueries(dmlEvents))
+
+	rows, err := suite.db.Query(fmt.Sprintf("SELECT id, item_id FROM %s ORDER BY id", getTestGhostTableName()))
+	suite.Require().NoError(err)
+	defer rows.Close()
+
+	ghostRows := [][]int{}
+	for rows.Next() {
+		var id, itemId int
+		suite.Require().NoError(rows.Scan(&id, &itemId))
+		ghostRows = append(ghostRows, []int{id, itemId})
+	}
+	suite.Require().NoError(rows.Err())
+	suite.Require().Equal([][]int{{10, 11}, {20, 22}}, ghostRows)
+
+	stats := migrationContext.Stats.GetSnapshot()
+	suite.Require().Equal(int64(4), stats.UpdatesApplied)
+	suite.Require().Equal(int64(4), stats.UniqueKeyUpdatesApplied)
+	suite.Require().Zero(stats.InsertsApplied)
+	suite.Require().Zero(stats.DeletesApplied)
+}
+
 func (suite *ApplierTestSuite) TestValidateOrDropExistingTables() {
 	ctx := context.Background()
 
@@ -402,7 +899,8 @@ func (suite *ApplierTestSuite) TestValidateOrDropExistingTablesWithGhostTableExi
 
 	err = applier.ValidateOrDropExistingTables()
 	suite.Require().Error(err)
-	suite.Require().EqualError(err, "Table `_testing_gho` already exists. Panicking. Use --initially-drop-ghost-table to force dropping it, though I really prefer that you drop it or rename it away")
+	suite.Require().ErrorContains(err, "Table `_testing_gho` already exists, created ")
+	suite.Require().ErrorContains(err, "Use --cleanup to review and drop the leftovers of a previous migration, or --initially-drop-ghost-table to force dropping it")
 }
 
 func (suite *ApplierTestSuite) TestValidateOrDropExistingTablesWithGhostTableExistingAndInitiallyDropGhostTableSet() {
@@ -633,6 +1131,149 @@ func (suite *ApplierTestSuite) TestPanicOnWarningsInApplyIterationInsertQueryFai
 	suite.Require().Contains(applier.migrationContext.MigrationLastInsertSQLWarnings[0], "Warning: Data truncated for column 'name' at row 1")
 }
 
+func (suite *ApplierTestSuite) TestApplyIterationInsertQueryCopyConflictStrategy() {
+	ctx := context.Background()
+
+	testCases := []struct {
+		strategy           sql.CopyConflictStrategy
+		expectedRowsCopied int64
+		expectedName       string
+	}{
+		// An ignored row is not counted, a replaced or updated row counts twice
+		{sql.CopyConflictIgnore, 1, "stale"},
+		{sql.CopyConflictReplace, 3, "new"},
+		{sql.CopyConflictUpsert, 3, "new"},
+	}
+	for _, tc := range testCases {
+		suite.Run(string(tc.strategy), func() {
+			for _, tableName := range []string{getTestTableName(), getTestGhostTableName()} {
+				_, err := suite.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+tableName)
+				suite.Require().NoError(err)
+				_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id int not null, name varchar(20), primary key(id))", tableName))
+				suite.Require().NoError(err)
+			}
+			_, err := suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id, name) VALUES (1, 'new'), (2, 'two')", getTestTableName()))
+			suite.Require().NoError(err)
+			// A row already on the ghost table, conflicting with the copied row
+			_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id, name) VALUES (1, 'stale')", getTestGhostTableName()))
+			suite.Require().NoError(err)
+
+			connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+			suite.Require().NoError(err)
+
+			migrationContext := newTestMigrationContext()
+			migrationContext.ApplierConnectionConfig = connectionConfig
+			migrationContext.SetConnectionConfig("innodb")
+			migrationContext.PanicOnWarnings = true
+			migrationContext.CopyConflictStrategy = tc.strategy
+
+			migrationContext.OriginalTableColumns = sql.NewColumnList([]string{"id", "name"})
+			migrationContext.SharedColumns = sql.NewColumnList([]string{"id", "name"})
+			migrationContext.MappedSharedColumns = sql.NewColumnList([]string{"id", "name"})
+			migrationContext.UniqueKey = &sql.UniqueKey{
+				Name:             "PRIMARY",
+				NameInGhostTable: "PRIMARY",
+				Columns:          *sql.NewColumnList([]string{"id"}),
+			}
+			applier := NewApplier(migrationContext)
+			defer applier.Teardown()
+
+			suite.Require().NoError(applier.InitDBConnections())
+			suite.Require().NoError(applier.CreateChangelogTable())
+			suite.Require().NoError(applier.ReadMigrationRangeValues())
+
+			hasFurtherRange, err := applier.CalculateNextIterationRangeEndValues()
+			suite.Require().NoError(err)
+			suite.Require().True(hasFurtherRange)
+
+			_, rowsCopied, _, err := applier.ApplyIterationInsertQuery()
+			suite.Require().NoError(err)
+			suite.Require().Equal(tc.expectedRowsCopied, rowsCopied)
+			suite.Require().Empty(migrationContext.MigrationLastInsertSQLWarnings)
+
+			var name string
+			suite.Require().NoError(suite.db.QueryRow("SELECT name FROM " + getTestGhostTableName() + " WHERE id = 1").Scan(&name))
+			suite.Require().Equal(tc.expectedName, name)
+
+			var count int
+			suite.Require().NoError(suite.db.QueryRow("SELECT COUNT(*) FROM " + getTestGhostTableName()).Scan(&count))
+			suite.Require().Equal(2, count)
+		})
+	}
+}
+
+func (suite *ApplierTestSuite) TestPruneChangelog() {
+	ctx := context.Background()
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+
+	applier := NewApplier(migrationContext)
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+	suite.Require().NoError(applier.CreateChangelogTable())
+	defer applier.DropChangelogTable()
+
+	_, err = applier.WriteChangelog("heartbeat", "old heartbeat")
+	suite.Require().NoError(err)
+	_, err = applier.WriteChangelogState("old state")
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s.%s SET last_update = NOW() - INTERVAL 2 DAY", testMysqlDatabase, migrationContext.GetChangelogTableName()))
+	suite.Require().NoError(err)
+	_, err = applier.WriteChangelogState("recent state")
+	suite.Require().NoError(err)
+
+	pruned, err := applier.PruneChangelog(24 * time.Hour)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(1), pruned)
+
+	var count int64
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", testMysqlDatabase, migrationContext.GetChangelogTableName())).Scan(&count))
+	// heartbeat, state, and the recent state log entry
+	suite.Require().Equal(int64(3), count)
+}
+
+func (suite *ApplierTestSuite) TestInitiateHeartbeatRecreatesChangelog() {
+	ctx := context.Background()
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.SetHeartbeatIntervalMilliseconds(100)
+
+	applier := NewApplier(migrationContext)
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+	suite.Require().NoError(applier.CreateChangelogTable())
+	defer applier.DropChangelogTable()
+
+	recreated := make(chan bool, 1)
+	go applier.InitiateHeartbeat(func() error {
+		recreated <- true
+		return nil
+	})
+	defer atomic.StoreInt64(&applier.finishedMigrating, 1)
+
+	suite.Require().NoError(applier.DropChangelogTable())
+	select {
+	case <-recreated:
+	case <-time.After(5 * time.Second):
+		suite.FailNow("changelog table was not recreated")
+	}
+	suite.Require().Eventually(func() bool {
+		var value string
+		err := suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT value FROM %s.%s WHERE hint = 'heartbeat'", testMysqlDatabase, migrationContext.GetChangelogTableName())).Scan(&value)
+		return err == nil && value != ""
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
 func (suite *ApplierTestSuite) TestWriteCheckpoint() {
 	ctx := context.Background()
 
@@ -704,6 +1345,8 @@ func (suite *ApplierTestSuite) TestWriteCheckpoint() {
 		RowsCopied:        100000,
 		DMLApplied:        200000,
 		IsCutover:         true,
+
+		IterationPartitionIndex: 3,
 	}
 	id, err := applier.WriteCheckpoint(chk)
 	suite.Require().NoError(err)
@@ -719,8 +1362,104 @@ func (suite *ApplierTestSuite) TestWriteCheckpoint() {
 	suite.Require().Equal(chk.RowsCopied, gotChk.RowsCopied)
 	suite.Require().Equal(chk.DMLApplied, gotChk.DMLApplied)
 	suite.Require().Equal(chk.IsCutover, gotChk.IsCutover)
+	suite.Require().Equal(chk.IterationPartitionIndex, gotChk.IterationPartitionIndex)
 }
 
 func TestApplier(t *testing.T) {
 	suite.Run(t, new(ApplierTestSuite))
 }
+
+func (suite *ApplierTestSuite) TestMigrationRegistrations() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY)", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.Hostname = "test-host"
+	migrationContext.CoordinationTable = "_gh_ost_migrations"
+	migrationContext.CoordinationStaleSeconds = 60
+
+	applier := NewApplier(migrationContext)
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+	suite.Require().NoError(applier.CreateCoordinationTable())
+	defer applier.dropTable(migrationContext.DatabaseName, migrationContext.CoordinationTable)
+	// creating the shared table is idempotent
+	suite.Require().NoError(applier.CreateCoordinationTable())
+
+	firstId, err := applier.RegisterMigration()
+	suite.Require().NoError(err)
+	secondId, err := applier.RegisterMigration()
+	suite.Require().NoError(err)
+	suite.Require().Greater(secondId, firstId)
+	suite.Require().NoError(applier.SetMigrationRegistrationState(firstId, MigrationRegistrationRunning))
+
+	registrations, err := applier.ReadMigrationRegistrations()
+	suite.Require().NoError(err)
+	suite.Require().Len(registrations, 2)
+	suite.Require().Equal(firstId, registrations[0].Id)
+	suite.Require().Equal(testMysqlDatabase, registrations[0].DatabaseName)
+	suite.Require().Equal(testMysqlTableName, registrations[0].TableName)
+	suite.Require().Equal("test-host", registrations[0].Hostname)
+	suite.Require().Equal(MigrationRegistrationRunning, registrations[0].State)
+	suite.Require().Equal(MigrationRegistrationQueued, registrations[1].State)
+
+	// a registration that stopped heartbeating is ignored, and purged by the next registration
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s.%s SET heartbeat_at = NOW(6) - INTERVAL 2 MINUTE WHERE id = %d", testMysqlDatabase, migrationContext.CoordinationTable, firstId))
+	suite.Require().NoError(err)
+	registrations, err = applier.ReadMigrationRegistrations()
+	suite.Require().NoError(err)
+	suite.Require().Len(registrations, 1)
+	suite.Require().Equal(secondId, registrations[0].Id)
+
+	suite.Require().NoError(applier.HeartbeatMigrationRegistration(secondId))
+	thirdId, err := applier.RegisterMigration()
+	suite.Require().NoError(err)
+	var count int64
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", testMysqlDatabase, migrationContext.CoordinationTable)).Scan(&count))
+	suite.Require().Equal(int64(2), count)
+
+	suite.Require().NoError(applier.DeregisterMigration(secondId))
+	suite.Require().NoError(applier.DeregisterMigration(thirdId))
+	registrations, err = applier.ReadMigrationRegistrations()
+	suite.Require().NoError(err)
+	suite.Require().Empty(registrations)
+}
+
+func (suite *ApplierTestSuite) TestWarmUpGhostTableIndex() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(64))", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(64), KEY name_idx (name), FULLTEXT KEY name_ft (name))", getTestGhostTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+
+	applier := NewApplier(migrationContext)
+	defer applier.Teardown()
+	suite.Require().NoError(applier.InitDBConnections())
+
+	indexNames, err := applier.ReadGhostTableIndexes()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"PRIMARY", "name_idx"}, indexNames)
+
+	for _, indexName := range indexNames {
+		suite.Require().NoError(applier.WarmUpGhostTableIndex(ctx, indexName, time.Second))
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	suite.Require().Error(applier.WarmUpGhostTableIndex(cancelledCtx, "PRIMARY", time.Second))
+}
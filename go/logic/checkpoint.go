@@ -25,8 +25,11 @@ type Checkpoint struct {
 	// IterationRangeMax is the max shared key value
 	// for the chunk copier range.
 	IterationRangeMax *sql.ColumnValues
-	Iteration         int64
-	RowsCopied        int64
-	DMLApplied        int64
-	IsCutover         bool
+	// IterationPartitionIndex is the index of the partition
+	// the chunk copier range is in, when iterating by partition.
+	IterationPartitionIndex int64
+	Iteration               int64
+	RowsCopied              int64
+	DMLApplied              int64
+	IsCutover               bool
 }
@@ -0,0 +1,37 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+const (
+	MigrationRegistrationQueued  = "queued"
+	MigrationRegistrationRunning = "running"
+)
+
+// MigrationRegistration is a live migration registered on the coordination table.
+type MigrationRegistration struct {
+	Id           int64
+	DatabaseName string
+	TableName    string
+	Hostname     string
+	State        string
+	// HeartbeatAge is the number of seconds since the migration last heartbeated.
+	HeartbeatAge float64
+}
+
+// countMigrationsAhead returns the number of live migrations, other than the given one, that are either
+// running or were queued before it. Migrations queued later are not counted, so that two queued
+// migrations do not wait for each other.
+func countMigrationsAhead(registrations []*MigrationRegistration, id int64) (count int) {
+	for _, registration := range registrations {
+		if registration.Id == id {
+			continue
+		}
+		if registration.State == MigrationRegistrationRunning || registration.Id < id {
+			count++
+		}
+	}
+	return count
+}
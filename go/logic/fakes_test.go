@@ -0,0 +1,429 @@
+/*
+   Copyright 2025 GitHub Inc.
+         See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"context"
+	gosql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	drivermysql "github.com/go-sql-driver/mysql"
+
+	"github.com/github/gh-ost/go/binlog"
+	"github.com/github/gh-ost/go/mysql"
+)
+
+// fakeMySQL is a scriptable, in-memory stand-in for a MySQL server, for logic-level tests that
+// do not require docker. It is plugged in as a database/sql driver, so that code under test keeps
+// using *gosql.DB. Queries are matched against rules in the order they were added. A rule may
+// return rows, an error, or take its time, and may be limited to a number of matches, after which
+// later rules apply. Unmatched statements succeed, and unmatched queries return no rows, other than
+// connection_id() which returns a per-connection id.
+type fakeMySQL struct {
+	mutex            sync.Mutex
+	rules            []*fakeQueryRule
+	queries          []string
+	statements       []fakeStatement
+	prepares         int
+	nextConnectionId int64
+}
+
+// fakeStatement is a statement executed with its arguments
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeQueryRule struct {
+	pattern   *regexp.Regexp
+	columns   []string
+	rows      [][]driver.Value
+	err       error
+	delay     time.Duration
+	remaining int
+}
+
+func newFakeMySQL() *fakeMySQL {
+	return &fakeMySQL{}
+}
+
+// newFakeMySQLError returns the error the MySQL driver would, for given MySQL error code
+func newFakeMySQLError(number uint16) error {
+	return &drivermysql.MySQLError{Number: number, Message: fmt.Sprintf("fake error %d", number)}
+}
+
+// DB returns a connection pool onto this fake server
+func (this *fakeMySQL) DB() *gosql.DB {
+	return gosql.OpenDB(&fakeConnector{mysql: this})
+}
+
+// expect adds a rule for queries matching given case insensitive regular expression
+func (this *fakeMySQL) expect(pattern string) *fakeQueryRule {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	rule := &fakeQueryRule{
+		pattern:   regexp.MustCompile(`(?is)` + pattern),
+		remaining: -1,
+	}
+	this.rules = append(this.rules, rule)
+	return rule
+}
+
+func (this *fakeQueryRule) returnRows(columns []string, rows ...[]driver.Value) *fakeQueryRule {
+	this.columns = columns
+	this.rows = rows
+	return this
+}
+
+func (this *fakeQueryRule) returnError(err error) *fakeQueryRule {
+	this.err = err
+	return this
+}
+
+func (this *fakeQueryRule) withDelay(delay time.Duration) *fakeQueryRule {
+	this.delay = delay
+	return this
+}
+
+// times limits the rule to the next given number of matching queries
+func (this *fakeQueryRule) times(count int) *fakeQueryRule {
+	this.remaining = count
+	return this
+}
+
+// executedQueries returns all queries run so far, trimmed, including transaction begin, commit and rollback
+func (this *fakeMySQL) executedQueries() []string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return append([]string{}, this.queries...)
+}
+
+// executedStatements returns all statements executed so far with arguments, trimmed, in order of arrival
+func (this *fakeMySQL) executedStatements() []fakeStatement {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return append([]fakeStatement{}, this.statements...)
+}
+
+// countPrepares returns the number of statements prepared so far, across connections
+func (this *fakeMySQL) countPrepares() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.prepares
+}
+
+// countQueries returns the number of queries run so far that match given case insensitive regular expression
+func (this *fakeMySQL) countQueries(pattern string) (count int) {
+	re := regexp.MustCompile(`(?is)` + pattern)
+	for _, query := range this.executedQueries() {
+		if re.MatchString(query) {
+			count++
+		}
+	}
+	return count
+}
+
+func (this *fakeMySQL) run(ctx context.Context, query string, args ...driver.NamedValue) (*fakeQueryRule, error) {
+	query = strings.TrimSpace(query)
+	this.mutex.Lock()
+	this.queries = append(this.queries, query)
+	if len(args) > 0 {
+		statement := fakeStatement{query: query}
+		for _, arg := range args {
+			statement.args = append(statement.args, arg.Value)
+		}
+		this.statements = append(this.statements, statement)
+	}
+	var matched *fakeQueryRule
+	for _, rule := range this.rules {
+		if rule.remaining == 0 || !rule.pattern.MatchString(query) {
+			continue
+		}
+		if rule.remaining > 0 {
+			rule.remaining--
+		}
+		matched = rule
+		break
+	}
+	this.mutex.Unlock()
+
+	if matched == nil {
+		return nil, nil
+	}
+	if matched.delay > 0 {
+		select {
+		case <-time.After(matched.delay):
+		case <-ctx.Done():
+			return matched, ctx.Err()
+		}
+	}
+	return matched, matched.err
+}
+
+type fakeConnector struct {
+	mysql *fakeMySQL
+}
+
+func (this *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	this.mysql.mutex.Lock()
+	defer this.mysql.mutex.Unlock()
+	this.mysql.nextConnectionId++
+	return &fakeConn{mysql: this.mysql, connectionId: this.mysql.nextConnectionId}, nil
+}
+
+func (this *fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (this fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakeDriver only connects through fakeMySQL.DB()")
+}
+
+type fakeConn struct {
+	mysql        *fakeMySQL
+	connectionId int64
+}
+
+func (this *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	this.mysql.mutex.Lock()
+	defer this.mysql.mutex.Unlock()
+	this.mysql.prepares++
+	return &fakeStmt{conn: this, query: query}, nil
+}
+
+func (this *fakeConn) Close() error {
+	return nil
+}
+
+func (this *fakeConn) Begin() (driver.Tx, error) {
+	return this.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (this *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := this.mysql.run(ctx, "begin"); err != nil {
+		return nil, err
+	}
+	return &fakeTx{conn: this}, nil
+}
+
+// CheckNamedValue accepts all arguments as they are, as the MySQL driver mostly does
+func (this *fakeConn) CheckNamedValue(namedValue *driver.NamedValue) error {
+	return nil
+}
+
+func (this *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := this.mysql.run(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	// One row affected per statement of a multi-statement query
+	numStatements := strings.Count(query, ";\n")
+	if numStatements == 0 {
+		numStatements = 1
+	}
+	result := &fakeResult{}
+	for i := 0; i < numStatements; i++ {
+		result.affectedRows = append(result.affectedRows, 1)
+	}
+	return result, nil
+}
+
+func (this *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rule, err := this.mysql.run(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		if strings.Contains(query, "connection_id()") {
+			return &fakeRows{columns: []string{"connection_id()"}, rows: [][]driver.Value{{this.connectionId}}}, nil
+		}
+		return &fakeRows{}, nil
+	}
+	return &fakeRows{columns: rule.columns, rows: rule.rows}, nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (this *fakeStmt) Close() error {
+	return nil
+}
+
+func (this *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (this *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return this.conn.ExecContext(context.Background(), this.query, nil)
+}
+
+func (this *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return this.conn.ExecContext(ctx, this.query, args)
+}
+
+func (this *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return this.conn.QueryContext(context.Background(), this.query, nil)
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (this *fakeTx) Commit() error {
+	_, err := this.conn.mysql.run(context.Background(), "commit")
+	return err
+}
+
+func (this *fakeTx) Rollback() error {
+	_, err := this.conn.mysql.run(context.Background(), "rollback")
+	return err
+}
+
+// fakeResult implements the MySQL driver's Result, which reports rows affected per statement
+type fakeResult struct {
+	affectedRows []int64
+}
+
+func (this *fakeResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (this *fakeResult) RowsAffected() (rowsAffected int64, err error) {
+	for _, affected := range this.affectedRows {
+		rowsAffected += affected
+	}
+	return rowsAffected, nil
+}
+
+func (this *fakeResult) AllRowsAffected() []int64 {
+	return this.affectedRows
+}
+
+func (this *fakeResult) AllLastInsertIds() []int64 {
+	return make([]int64, len(this.affectedRows))
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	index   int
+}
+
+func (this *fakeRows) Columns() []string {
+	return this.columns
+}
+
+func (this *fakeRows) Close() error {
+	return nil
+}
+
+func (this *fakeRows) Next(dest []driver.Value) error {
+	if this.index >= len(this.rows) {
+		return io.EOF
+	}
+	copy(dest, this.rows[this.index])
+	this.index++
+	return nil
+}
+
+// fakeBinlogSession is what a fake binlog reader streams over one connection: entries, followed by
+// an error breaking the connection. A session without error streams until told to stop.
+type fakeBinlogSession struct {
+	entries []*binlog.BinlogEntry
+	err     error
+}
+
+// fakeBinlogSource serves scripted sessions to fake binlog readers, one session per connection
+type fakeBinlogSource struct {
+	mutex       sync.Mutex
+	sessions    []fakeBinlogSession
+	connectedAt []mysql.BinlogCoordinates
+}
+
+func newFakeBinlogSource(sessions ...fakeBinlogSession) *fakeBinlogSource {
+	return &fakeBinlogSource{sessions: sessions}
+}
+
+// newBinlogReader is a drop-in for EventsStreamer.newBinlogReader
+func (this *fakeBinlogSource) newBinlogReader() binlog.BinlogReader {
+	return &fakeBinlogReader{source: this}
+}
+
+// connections returns the coordinates each reader connected at
+func (this *fakeBinlogSource) connections() []mysql.BinlogCoordinates {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return append([]mysql.BinlogCoordinates{}, this.connectedAt...)
+}
+
+type fakeBinlogReader struct {
+	source             *fakeBinlogSource
+	session            fakeBinlogSession
+	mutex              sync.Mutex
+	currentCoordinates mysql.BinlogCoordinates
+	lastTrxCoordinates mysql.BinlogCoordinates
+}
+
+func (this *fakeBinlogReader) ConnectBinlogStreamer(coordinates mysql.BinlogCoordinates) error {
+	this.source.mutex.Lock()
+	defer this.source.mutex.Unlock()
+
+	if len(this.source.sessions) == 0 {
+		return fmt.Errorf("fakeBinlogSource has no more sessions to serve")
+	}
+	this.session = this.source.sessions[0]
+	this.source.sessions = this.source.sessions[1:]
+	this.source.connectedAt = append(this.source.connectedAt, coordinates.Clone())
+	this.currentCoordinates = coordinates.Clone()
+	return nil
+}
+
+func (this *fakeBinlogReader) GetCurrentBinlogCoordinates() mysql.BinlogCoordinates {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.currentCoordinates.Clone()
+}
+
+func (this *fakeBinlogReader) GetLastTrxCoordinates() mysql.BinlogCoordinates {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.lastTrxCoordinates
+}
+
+// StreamEvents streams the session's entries, each as a transaction of its own
+func (this *fakeBinlogReader) StreamEvents(canStopStreaming func() bool, entriesChannel chan<- *binlog.BinlogEntry) error {
+	for _, entry := range this.session.entries {
+		if canStopStreaming() {
+			return nil
+		}
+		entriesChannel <- entry
+		this.mutex.Lock()
+		this.currentCoordinates = entry.Coordinates.Clone()
+		this.lastTrxCoordinates = entry.Coordinates.Clone()
+		this.mutex.Unlock()
+	}
+	if this.session.err != nil {
+		return this.session.err
+	}
+	for !canStopStreaming() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+func (this *fakeBinlogReader) Close() error {
+	return nil
+}
@@ -11,7 +11,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/github/gh-ost/go/base"
 	"github.com/openark/golib/log"
@@ -31,25 +34,52 @@ const (
 	onStatus             = "gh-ost-on-status"
 	onStopReplication    = "gh-ost-on-stop-replication"
 	onStartReplication   = "gh-ost-on-start-replication"
+	onChangelogRecreated = "gh-ost-on-changelog-recreated"
+	onStalled            = "gh-ost-on-stalled"
+	onQueued             = "gh-ost-on-queued"
+	onBackpressure       = "gh-ost-on-backpressure"
+	onMaxRuntimeExceeded = "gh-ost-on-max-runtime-exceeded"
+	onReplicaCutOver     = "gh-ost-on-replica-cut-over"
+	onReplicaPromotion   = "gh-ost-on-replica-promotion"
 )
 
+// hookInvocation records a hook executed, for --report-file
+type hookInvocation struct {
+	Hook            string    `json:"hook"`
+	Path            string    `json:"path"`
+	StartTime       time.Time `json:"start_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Error           string    `json:"error,omitempty"`
+}
+
 type HooksExecutor struct {
 	migrationContext *base.MigrationContext
 	writer           io.Writer
+	invocationsMutex *sync.Mutex
+	invocations      []hookInvocation
 }
 
 func NewHooksExecutor(migrationContext *base.MigrationContext) *HooksExecutor {
 	return &HooksExecutor{
 		migrationContext: migrationContext,
 		writer:           os.Stderr,
+		invocationsMutex: &sync.Mutex{},
 	}
 }
 
 func (this *HooksExecutor) applyEnvironmentVariables(extraVariables ...string) []string {
-	env := os.Environ()
+	env := []string{}
+	for _, variable := range os.Environ() {
+		// Do not leak passwords to hooks
+		if strings.HasPrefix(variable, base.PasswordEnvVariable+"=") || strings.HasPrefix(variable, base.MasterPasswordEnvVariable+"=") {
+			continue
+		}
+		env = append(env, variable)
+	}
 	env = append(env, fmt.Sprintf("GH_OST_DATABASE_NAME=%s", this.migrationContext.DatabaseName))
 	env = append(env, fmt.Sprintf("GH_OST_TABLE_NAME=%s", this.migrationContext.OriginalTableName))
 	env = append(env, fmt.Sprintf("GH_OST_GHOST_TABLE_NAME=%s", this.migrationContext.GetGhostTableName()))
+	env = append(env, fmt.Sprintf("GH_OST_GHOST_DATABASE_NAME=%s", this.migrationContext.GetGhostDatabaseName()))
 	env = append(env, fmt.Sprintf("GH_OST_OLD_TABLE_NAME=%s", this.migrationContext.GetOldTableName()))
 	env = append(env, fmt.Sprintf("GH_OST_DDL=%s", this.migrationContext.AlterStatement))
 	env = append(env, fmt.Sprintf("GH_OST_ELAPSED_SECONDS=%f", this.migrationContext.ElapsedTime().Seconds()))
@@ -70,6 +100,7 @@ func (this *HooksExecutor) applyEnvironmentVariables(extraVariables ...string) [
 	env = append(env, fmt.Sprintf("GH_OST_HOOKS_HINT_TOKEN=%s", this.migrationContext.HooksHintToken))
 	env = append(env, fmt.Sprintf("GH_OST_DRY_RUN=%t", this.migrationContext.Noop))
 	env = append(env, fmt.Sprintf("GH_OST_REVERT=%t", this.migrationContext.Revert))
+	env = append(env, fmt.Sprintf("GH_OST_NARROWED_COLUMNS=%s", strings.Join(this.migrationContext.NarrowedColumnsFindings, "; ")))
 
 	env = append(env, extraVariables...)
 	return env
@@ -102,13 +133,38 @@ func (this *HooksExecutor) executeHooks(baseName string, extraVariables ...strin
 	}
 	for _, hook := range hooks {
 		log.Infof("executing %+v hook: %+v", baseName, hook)
-		if err := this.executeHook(hook, extraVariables...); err != nil {
+		startTime := time.Now()
+		err := this.executeHook(hook, extraVariables...)
+		this.recordInvocation(baseName, hook, startTime, err)
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (this *HooksExecutor) recordInvocation(baseName, hook string, startTime time.Time, err error) {
+	invocation := hookInvocation{
+		Hook:            baseName,
+		Path:            hook,
+		StartTime:       startTime,
+		DurationSeconds: time.Since(startTime).Seconds(),
+	}
+	if err != nil {
+		invocation.Error = err.Error()
+	}
+	this.invocationsMutex.Lock()
+	defer this.invocationsMutex.Unlock()
+	this.invocations = append(this.invocations, invocation)
+}
+
+// getInvocations returns the hooks executed so far, in order of execution
+func (this *HooksExecutor) getInvocations() []hookInvocation {
+	this.invocationsMutex.Lock()
+	defer this.invocationsMutex.Unlock()
+	return append([]hookInvocation{}, this.invocations...)
+}
+
 func (this *HooksExecutor) onStartup() error {
 	return this.executeHooks(onStartup)
 }
@@ -142,7 +198,12 @@ func (this *HooksExecutor) onInteractiveCommand(command string) error {
 }
 
 func (this *HooksExecutor) onSuccess() error {
-	return this.executeHooks(onSuccess)
+	return this.executeHooks(onSuccess,
+		fmt.Sprintf("GH_OST_START_BINLOG_COORDINATES=%s", displayBinlogCoordinates(this.migrationContext.StartBinlogCoordinates)),
+		fmt.Sprintf("GH_OST_ROW_COPY_COMPLETE_BINLOG_COORDINATES=%s", displayBinlogCoordinates(this.migrationContext.RowCopyCompleteBinlogCoordinates)),
+		fmt.Sprintf("GH_OST_CUT_OVER_BINLOG_COORDINATES=%s", displayBinlogCoordinates(this.migrationContext.CutOverBinlogCoordinates)),
+		fmt.Sprintf("GH_OST_PENDING_CLEANUP_STATEMENT=%s", this.migrationContext.PendingCleanupStatement),
+	)
 }
 
 func (this *HooksExecutor) onFailure() error {
@@ -161,3 +222,41 @@ func (this *HooksExecutor) onStopReplication() error {
 func (this *HooksExecutor) onStartReplication() error {
 	return this.executeHooks(onStartReplication)
 }
+
+func (this *HooksExecutor) onChangelogRecreated() error {
+	return this.executeHooks(onChangelogRecreated)
+}
+
+func (this *HooksExecutor) onStalled(stalledDuration time.Duration) error {
+	v := fmt.Sprintf("GH_OST_STALLED_SECONDS=%d", int64(stalledDuration.Seconds()))
+	return this.executeHooks(onStalled, v)
+}
+
+func (this *HooksExecutor) onBackpressure(backlogMemoryBytes, heapBytes int64) error {
+	v1 := fmt.Sprintf("GH_OST_BACKLOG_MEMORY_BYTES=%d", backlogMemoryBytes)
+	v2 := fmt.Sprintf("GH_OST_HEAP_BYTES=%d", heapBytes)
+	return this.executeHooks(onBackpressure, v1, v2)
+}
+
+func (this *HooksExecutor) onMaxRuntimeExceeded(maxRuntime time.Duration) error {
+	v1 := fmt.Sprintf("GH_OST_MAX_RUNTIME_SECONDS=%d", int64(maxRuntime.Seconds()))
+	v2 := fmt.Sprintf("GH_OST_MAX_RUNTIME_ACTION=%s", this.migrationContext.MaxRuntimeAction)
+	return this.executeHooks(onMaxRuntimeExceeded, v1, v2)
+}
+
+func (this *HooksExecutor) onQueued(migrationsAhead int) error {
+	v := fmt.Sprintf("GH_OST_MIGRATIONS_AHEAD=%d", migrationsAhead)
+	return this.executeHooks(onQueued, v)
+}
+
+func (this *HooksExecutor) onReplicaCutOver() error {
+	return this.executeHooks(onReplicaCutOver,
+		fmt.Sprintf("GH_OST_CUT_OVER_BINLOG_COORDINATES=%s", displayBinlogCoordinates(this.migrationContext.CutOverBinlogCoordinates)),
+		fmt.Sprintf("GH_OST_REPLICA_PROMOTION_PLAN_FILE=%s", this.migrationContext.ReplicaPromotionPlanFile),
+	)
+}
+
+func (this *HooksExecutor) onReplicaPromotion() error {
+	v := fmt.Sprintf("GH_OST_REPLICA_PROMOTION_PLAN_FILE=%s", this.migrationContext.ReplicaPromotionPlanFile)
+	return this.executeHooks(onReplicaPromotion, v)
+}
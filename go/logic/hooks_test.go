@@ -32,6 +32,7 @@ func TestHooksExecutorExecuteHooks(t *testing.T) {
 	migrationContext.TotalRowsCopied = 123456
 	migrationContext.SetETADuration(time.Minute)
 	migrationContext.SetProgressPct(50)
+	migrationContext.NarrowedColumnsFindings = []string{"name varchar(64)->varchar(8): 1 rows do not fit", "id bigint->int: 2 rows do not fit"}
 	hooksExecutor := NewHooksExecutor(migrationContext)
 
 	writeTmpHookFunc := func(testName, hookName, script string) (path string, err error) {
@@ -58,6 +59,12 @@ func TestHooksExecutorExecuteHooks(t *testing.T) {
 		}
 		defer os.RemoveAll(migrationContext.HooksPath)
 		require.NotNil(t, hooksExecutor.executeHooks("failed-hook"))
+
+		invocations := hooksExecutor.getInvocations()
+		require.Len(t, invocations, 1)
+		require.Equal(t, "failed-hook", invocations[0].Hook)
+		require.Equal(t, filepath.Join(migrationContext.HooksPath, "failed-hook"), invocations[0].Path)
+		require.NotEmpty(t, invocations[0].Error)
 	})
 
 	t.Run("success", func(t *testing.T) {
@@ -70,6 +77,7 @@ func TestHooksExecutorExecuteHooks(t *testing.T) {
 			panic(err)
 		}
 		defer os.RemoveAll(migrationContext.HooksPath)
+		t.Setenv(base.PasswordEnvVariable, "secret")
 
 		var buf bytes.Buffer
 		hooksExecutor.writer = &buf
@@ -96,8 +104,12 @@ func TestHooksExecutorExecuteHooks(t *testing.T) {
 				require.Equal(t, int64(60), etaSeconds)
 			case "GH_OST_EXECUTING_HOST":
 				require.Equal(t, migrationContext.Hostname, split[1])
+			case "GH_OST_GHOST_DATABASE_NAME":
+				require.Equal(t, migrationContext.DatabaseName, split[1])
 			case "GH_OST_GHOST_TABLE_NAME":
 				require.Equal(t, fmt.Sprintf("_%s_gho", migrationContext.OriginalTableName), split[1])
+			case "GH_OST_NARROWED_COLUMNS":
+				require.Equal(t, "name varchar(64)->varchar(8): 1 rows do not fit; id bigint->int: 2 rows do not fit", split[1])
 			case "GH_OST_OLD_TABLE_NAME":
 				require.Equal(t, fmt.Sprintf("_%s_del", migrationContext.OriginalTableName), split[1])
 			case "GH_OST_PROGRESS":
@@ -107,6 +119,8 @@ func TestHooksExecutorExecuteHooks(t *testing.T) {
 				require.Equal(t, migrationContext.OriginalTableName, split[1])
 			case "TEST":
 				require.Equal(t, t.Name(), split[1])
+			case base.PasswordEnvVariable:
+				t.Fatalf("Expected %s to be withheld from hooks", base.PasswordEnvVariable)
 			}
 		}
 	})
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -24,6 +25,9 @@ import (
 
 const startReplicationPostWait = 250 * time.Millisecond
 const startReplicationMaxWait = 2 * time.Second
+const narrowedColumnsCheckRowsLimit = 1000
+const narrowedColumnsCheckScanLimit = 100000
+const nullableUniqueKeyDuplicatesReportLimit = 10
 
 // Inspector reads data from the read-MySQL-server (typically a replica, but can be the master)
 // It is used for gaining initial status and structure, and later also follow up on progress and changelog
@@ -33,18 +37,28 @@ type Inspector struct {
 	dbVersion           string
 	informationSchemaDb *gosql.DB
 	migrationContext    *base.MigrationContext
+	log                 base.Logger
 	name                string
+	// binlogDb connects to the server whose binary logs are streamed; other than db only with --proxy-compat
+	binlogDb               *gosql.DB
+	binlogConnectionConfig *mysql.ConnectionConfig
+	// globalPrivileges are the privileges granted ON *.*, as read by validateGrants()
+	globalPrivileges map[string]bool
 }
 
 func NewInspector(migrationContext *base.MigrationContext) *Inspector {
 	return &Inspector{
 		connectionConfig: migrationContext.InspectorConnectionConfig,
 		migrationContext: migrationContext,
+		log:              migrationContext.NewComponentLogger(base.InspectorLogComponent),
 		name:             "inspector",
 	}
 }
 
 func (this *Inspector) InitDBConnections() (err error) {
+	if err := base.ValidateTimeZone(this.connectionConfig, this.migrationContext, this.name); err != nil {
+		return err
+	}
 	inspectorUri := this.connectionConfig.GetDBUri(this.migrationContext.DatabaseName)
 	if this.db, _, err = mysql.GetDB(this.migrationContext.Uuid, inspectorUri); err != nil {
 		return err
@@ -60,28 +74,51 @@ func (this *Inspector) InitDBConnections() (err error) {
 	}
 	this.dbVersion = this.migrationContext.InspectorMySQLVersion
 
-	if !this.migrationContext.AliyunRDS && !this.migrationContext.GoogleCloudPlatform && !this.migrationContext.AzureMySQL {
+	this.binlogDb = this.db
+	this.binlogConnectionConfig = this.migrationContext.GetBinlogConnectionConfig()
+	if this.migrationContext.ProxyCompat {
+		// Through a proxy, global variables may be read off any backend. Binary log settings
+		// matter on the backend whose binary logs are streamed, and so are validated there.
+		binlogUri := this.binlogConnectionConfig.GetDBUri(this.migrationContext.DatabaseName)
+		if this.binlogDb, _, err = mysql.GetDB(this.migrationContext.Uuid, binlogUri); err != nil {
+			return err
+		}
+		if _, err := base.ValidateConnection(this.binlogDb, this.binlogConnectionConfig, this.migrationContext, this.name); err != nil {
+			return fmt.Errorf("Validating binary log settings requires a direct connection to the backend, but cannot connect to --binlog-host %s: %w", this.binlogConnectionConfig.Key.String(), err)
+		}
+	}
+
+	if !this.migrationContext.TrustsConnectionPort() {
 		if impliedKey, err := mysql.GetInstanceKey(this.db); err != nil {
 			return err
 		} else {
 			this.connectionConfig.ImpliedKey = impliedKey
 		}
 	}
+	if err := this.validateGhostDatabase(); err != nil {
+		return err
+	}
 	if err := this.validateGrants(); err != nil {
 		return err
 	}
 	if err := this.validateBinlogs(); err != nil {
 		return err
 	}
+	if err := this.validateProxyProbe(); err != nil {
+		return err
+	}
 	if this.migrationContext.UseGTIDs {
 		if err := this.validateGTIDConfig(); err != nil {
 			return err
 		}
 	}
+	if err := this.validatePrivilegedOperations(); err != nil {
+		return err
+	}
 	if err := this.applyBinlogFormat(); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Inspector initiated on %+v, version %+v", this.connectionConfig.ImpliedKey, this.migrationContext.InspectorMySQLVersion)
+	this.log.Infof("Inspector initiated on %+v, version %+v", this.connectionConfig.ImpliedKey, this.migrationContext.InspectorMySQLVersion)
 	return nil
 }
 
@@ -101,15 +138,12 @@ func (this *Inspector) ValidateOriginalTable() (err error) {
 	return nil
 }
 
-func (this *Inspector) InspectTableColumnsAndUniqueKeys(tableName string) (columns *sql.ColumnList, virtualColumns *sql.ColumnList, uniqueKeys [](*sql.UniqueKey), err error) {
-	uniqueKeys, err = this.getCandidateUniqueKeys(tableName)
+func (this *Inspector) InspectTableColumnsAndUniqueKeys(databaseName, tableName string) (columns *sql.ColumnList, virtualColumns *sql.ColumnList, uniqueKeys [](*sql.UniqueKey), err error) {
+	uniqueKeys, err = this.getCandidateUniqueKeys(databaseName, tableName)
 	if err != nil {
 		return columns, virtualColumns, uniqueKeys, err
 	}
-	if len(uniqueKeys) == 0 {
-		return columns, virtualColumns, uniqueKeys, fmt.Errorf("No PRIMARY nor UNIQUE key found in table! Bailing out")
-	}
-	columns, virtualColumns, err = mysql.GetTableColumns(this.db, this.migrationContext.DatabaseName, tableName)
+	columns, virtualColumns, err = mysql.GetTableColumns(this.db, databaseName, tableName)
 	if err != nil {
 		return columns, virtualColumns, uniqueKeys, err
 	}
@@ -118,17 +152,106 @@ func (this *Inspector) InspectTableColumnsAndUniqueKeys(tableName string) (colum
 }
 
 func (this *Inspector) InspectOriginalTable() (err error) {
-	this.migrationContext.OriginalTableColumns, this.migrationContext.OriginalTableVirtualColumns, this.migrationContext.OriginalTableUniqueKeys, err = this.InspectTableColumnsAndUniqueKeys(this.migrationContext.OriginalTableName)
+	this.migrationContext.OriginalTableColumns, this.migrationContext.OriginalTableVirtualColumns, this.migrationContext.OriginalTableUniqueKeys, err = this.InspectTableColumnsAndUniqueKeys(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
 	if err != nil {
 		return err
 	}
+	if len(this.migrationContext.OriginalTableUniqueKeys) == 0 {
+		if err := this.inspectKeylessTable(); err != nil {
+			return err
+		}
+	}
 	this.migrationContext.OriginalTableAutoIncrement, err = this.getAutoIncrementValue(this.migrationContext.OriginalTableName)
 	if err != nil {
 		return err
 	}
+	this.migrationContext.OriginalTablePartitioning, err = this.getTablePartitioning(this.migrationContext.OriginalTableName)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// inspectKeylessTable validates the migration of a table without PRIMARY nor UNIQUE key, supported with
+// --allow-temp-index where the ALTER adds an AUTO_INCREMENT PRIMARY KEY. Such a table is iterated by a key
+// of all its columns, which identify a row as long as no two rows are identical. The key must be valid as
+// a unique index on the ghost table, and may not have NULLs, which a unique index does not tell apart.
+func (this *Inspector) inspectKeylessTable() error {
+	if !this.migrationContext.AllowTempIndex {
+		return fmt.Errorf("No PRIMARY nor UNIQUE key found in table! To add an AUTO_INCREMENT PRIMARY KEY to this table, see --allow-temp-index. Bailing out")
+	}
+	if len(this.migrationContext.DroppedColumnsMap) > 0 || len(this.migrationContext.ColumnRenameMap) > 0 {
+		return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key; rows are identified by all their columns, which the ALTER must not drop nor rename. Bailing out")
+	}
+	if maxRows := this.migrationContext.TempIndexMaxRows; maxRows > 0 && this.migrationContext.RowsEstimate > maxRows {
+		return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and an estimated %d rows, more than --temp-index-max-rows=%d. Each chunk of the row copy scans, sorts and share-locks the whole table. Bailing out", this.migrationContext.RowsEstimate, maxRows)
+	}
+	columnNames := []string{}
+	for _, column := range this.migrationContext.OriginalTableColumns.Columns() {
+		if this.migrationContext.OriginalTableVirtualColumns.GetColumn(column.Name) == nil {
+			columnNames = append(columnNames, column.Name)
+		}
+	}
+	if len(columnNames) > sql.MaxUniqueKeyColumns {
+		return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and %d columns, more than the %d a unique index may have. Bailing out", len(columnNames), sql.MaxUniqueKeyColumns)
+	}
+	columns := sql.NewColumnList(columnNames)
+	if err := this.applyColumnTypes(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, columns); err != nil {
+		return err
+	}
+	var keyByteLength uint
+	for _, column := range columns.Columns() {
+		switch column.Type {
+		case sql.FloatColumnType, sql.JSONColumnType:
+			return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and column %s cannot identify its rows due to its %s data type. Bailing out", sql.EscapeName(column.Name), column.MySQLType)
+		}
+		if column.Nullable {
+			return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and column %s is nullable: a unique index does not tell apart rows with NULLs. Bailing out", sql.EscapeName(column.Name))
+		}
+		columnByteLength, ok := column.KeyByteLength()
+		if !ok {
+			return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and column %s of %s data type cannot be indexed as a whole. Bailing out", sql.EscapeName(column.Name), column.MySQLType)
+		}
+		keyByteLength += columnByteLength
+	}
+	if keyByteLength > sql.MaxUniqueKeyByteLength {
+		return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and its columns take %d bytes, more than the %d a unique index may have. Bailing out", keyByteLength, sql.MaxUniqueKeyByteLength)
+	}
+
+	query, err := sql.BuildFullRowDuplicatesQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, columns, 1)
+	if err != nil {
+		return err
+	}
+	this.log.Infof("Table has no PRIMARY nor UNIQUE key. Looking for duplicate rows; this scans the table")
+	var duplicates int64
+	if err := this.db.QueryRow(query).Scan(&duplicates); err != nil {
+		return err
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and rows exist which are identical in all columns. gh-ost cannot tell such rows apart. Bailing out")
+	}
+	this.migrationContext.OriginalTableUniqueKeys = []*sql.UniqueKey{sql.NewFullRowUniqueKey(columns)}
+	this.log.Infof("Table will be iterated by all its columns, via temporary index %s on the ghost table", sql.EscapeName(sql.FullRowUniqueKeyName))
+	this.log.Warningf("--allow-temp-index: each chunk of the row copy scans and sorts the table, holding shared locks on all its rows, which blocks writes to the table while the chunk is copied")
+	return nil
+}
+
+// validateGhostAddedPrimaryKey verifies that the ALTER of a keyless table adds a PRIMARY KEY on a new
+// AUTO_INCREMENT column, which assigns values to the rows copied and applied onto the ghost table
+func (this *Inspector) validateGhostAddedPrimaryKey() error {
+	for _, uniqueKey := range this.migrationContext.GhostTableUniqueKeys {
+		if !uniqueKey.IsPrimary() {
+			continue
+		}
+		if uniqueKey.Len() == 1 && uniqueKey.IsAutoIncrement && this.migrationContext.OriginalTableColumns.GetColumn(uniqueKey.Columns.Names()[0]) == nil {
+			this.log.Infof("ALTER adds AUTO_INCREMENT PRIMARY KEY on %s", sql.EscapeName(uniqueKey.Columns.Names()[0]))
+			return nil
+		}
+		break
+	}
+	return fmt.Errorf("--allow-temp-index: table has no PRIMARY nor UNIQUE key, and the ALTER must add a PRIMARY KEY on a new AUTO_INCREMENT column. Bailing out")
+}
+
 // inspectOriginalAndGhostTables compares original and ghost tables to see whether the migration
 // makes sense and is valid. It extracts the list of shared columns and the chosen migration unique key
 func (this *Inspector) inspectOriginalAndGhostTables() (err error) {
@@ -138,56 +261,97 @@ func (this *Inspector) inspectOriginalAndGhostTables() (err error) {
 		return fmt.Errorf("It seems like table structure is not identical between master and replica. This scenario is not supported.")
 	}
 
-	this.migrationContext.GhostTableColumns, this.migrationContext.GhostTableVirtualColumns, this.migrationContext.GhostTableUniqueKeys, err = this.InspectTableColumnsAndUniqueKeys(this.migrationContext.GetGhostTableName())
+	this.migrationContext.GhostTableColumns, this.migrationContext.GhostTableVirtualColumns, this.migrationContext.GhostTableUniqueKeys, err = this.InspectTableColumnsAndUniqueKeys(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName())
 	if err != nil {
 		return err
 	}
+	if len(this.migrationContext.GhostTableUniqueKeys) == 0 {
+		return fmt.Errorf("No PRIMARY nor UNIQUE key found in table! Bailing out")
+	}
+	if this.migrationContext.GetFullRowUniqueKey() != nil {
+		if err := this.validateGhostAddedPrimaryKey(); err != nil {
+			return err
+		}
+	}
 	sharedUniqueKeys := this.getSharedUniqueKeys(this.migrationContext.OriginalTableUniqueKeys, this.migrationContext.GhostTableUniqueKeys)
-	for i, sharedUniqueKey := range sharedUniqueKeys {
+	candidateUniqueKeys := []*sql.UniqueKey{}
+	refusedPartialUniqueKeys := []string{}
+	for _, sharedUniqueKey := range sharedUniqueKeys {
+		if refusal := partialUniqueKeyRefusal(sharedUniqueKey); refusal != "" {
+			this.log.Warningf("Will not use %+v as shared key: %s", sharedUniqueKey.Name, refusal)
+			refusedPartialUniqueKeys = append(refusedPartialUniqueKeys, sharedUniqueKey.Name)
+			continue
+		}
 		this.applyColumnTypes(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, &sharedUniqueKey.Columns)
 		uniqueKeyIsValid := true
 		for _, column := range sharedUniqueKey.Columns.Columns() {
 			switch column.Type {
 			case sql.FloatColumnType:
 				{
-					this.migrationContext.Log.Warningf("Will not use %+v as shared key due to FLOAT data type", sharedUniqueKey.Name)
+					this.log.Warningf("Will not use %+v as shared key due to FLOAT data type", sharedUniqueKey.Name)
 					uniqueKeyIsValid = false
 				}
 			case sql.JSONColumnType:
 				{
 					// Noteworthy that at this time MySQL does not allow JSON indexing anyhow, but this code
 					// will remain in place to potentially handle the future case where JSON is supported in indexes.
-					this.migrationContext.Log.Warningf("Will not use %+v as shared key due to JSON data type", sharedUniqueKey.Name)
+					this.log.Warningf("Will not use %+v as shared key due to JSON data type", sharedUniqueKey.Name)
 					uniqueKeyIsValid = false
 				}
 			}
 		}
 		if uniqueKeyIsValid {
-			this.migrationContext.UniqueKey = sharedUniqueKeys[i]
-			break
+			candidateUniqueKeys = append(candidateUniqueKeys, sharedUniqueKey)
 		}
 	}
-	if this.migrationContext.UniqueKey == nil {
+	uniqueKey, reason, err := this.electUniqueKey(candidateUniqueKeys)
+	if err != nil {
+		return err
+	}
+	if uniqueKey == nil && len(refusedPartialUniqueKeys) > 0 {
+		return fmt.Errorf("No shared unique key can be used for iteration: %s indexed by column prefixes or expressions. Add a PRIMARY KEY or UNIQUE KEY on whole columns, e.g. an AUTO_INCREMENT column, in a separate migration first. Bailing out", strings.Join(refusedPartialUniqueKeys, ", "))
+	}
+	if uniqueKey == nil {
 		return fmt.Errorf("No shared unique key can be found after ALTER! Bailing out")
 	}
-	this.migrationContext.Log.Infof("Chosen shared unique key is %s", this.migrationContext.UniqueKey.Name)
+	this.migrationContext.UniqueKey = uniqueKey
+	this.log.Infof("Chosen shared unique key is %s (%s)", this.migrationContext.UniqueKey.Name, reason)
 	if this.migrationContext.UniqueKey.HasNullable {
 		if this.migrationContext.NullableUniqueKeyAllowed {
-			this.migrationContext.Log.Warningf("Chosen key (%s) has nullable columns. You have supplied with --allow-nullable-unique-key and so this migration proceeds. As long as there aren't NULL values in this key's column, migration should be fine. NULL values will corrupt migration's data", this.migrationContext.UniqueKey)
+			if err := this.validateNullableUniqueKey(this.migrationContext.UniqueKey); err != nil {
+				return err
+			}
+			this.log.Warningf("Chosen key (%s) has nullable columns. You have supplied with --allow-nullable-unique-key and so this migration proceeds. Rows with NULL values in this key are copied and updated NULL-safely. However, MySQL allows multiple rows with identical key values if one of them is NULL; should such rows be written during the migration, migration's data will be corrupted", this.migrationContext.UniqueKey)
 		} else {
 			return fmt.Errorf("Chosen key (%s) has nullable columns. Bailing out. To force this operation to continue, supply --allow-nullable-unique-key flag. Only do so if you are certain there are no actual NULL values in this key. As long as there aren't, migration should be fine. NULL values in columns of this key will corrupt migration's data", this.migrationContext.UniqueKey)
 		}
 	}
 
+	if partitioning := this.migrationContext.OriginalTablePartitioning; partitioning != nil {
+		if partitioning.IsAlignedWith(this.migrationContext.UniqueKey) {
+			this.migrationContext.IterationPartitions = partitioning.Partitions
+			this.log.Infof("Table is partitioned by %s (%s), aligned with chosen key. Rowcopy will iterate %d partitions one by one", partitioning.Method, partitioning.Expression, len(partitioning.Partitions))
+		} else {
+			this.log.Infof("Table is partitioned by %s (%s), not aligned with chosen key. Rowcopy will iterate across partitions", partitioning.Method, partitioning.Expression)
+		}
+	}
+
 	this.migrationContext.SharedColumns, this.migrationContext.MappedSharedColumns = this.getSharedColumns(this.migrationContext.OriginalTableColumns, this.migrationContext.GhostTableColumns, this.migrationContext.OriginalTableVirtualColumns, this.migrationContext.GhostTableVirtualColumns, this.migrationContext.ColumnRenameMap)
-	this.migrationContext.Log.Infof("Shared columns are %s", this.migrationContext.SharedColumns)
+	this.log.Infof("Shared columns are %s", this.migrationContext.SharedColumns)
 	// By fact that a non-empty unique key exists we also know the shared columns are non-empty
 
 	// This additional step looks at which columns are unsigned. We could have merged this within
 	// the `getTableColumns()` function, but it's a later patch and introduces some complexity; I feel
 	// comfortable in doing this as a separate step.
 	this.applyColumnTypes(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, this.migrationContext.OriginalTableColumns, this.migrationContext.SharedColumns, &this.migrationContext.UniqueKey.Columns)
-	this.applyColumnTypes(this.migrationContext.DatabaseName, this.migrationContext.GetGhostTableName(), this.migrationContext.GhostTableColumns, this.migrationContext.MappedSharedColumns)
+	this.applyColumnTypes(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName(), this.migrationContext.GhostTableColumns, this.migrationContext.MappedSharedColumns)
+	this.validateApplierParallelism()
+	if err := this.validateIgnoredColumns(); err != nil {
+		return err
+	}
+	if err := this.validateColumnTransforms(); err != nil {
+		return err
+	}
 
 	for i := range this.migrationContext.SharedColumns.Columns() {
 		column := this.migrationContext.SharedColumns.Columns()[i]
@@ -203,6 +367,9 @@ func (this *Inspector) inspectOriginalAndGhostTables() (err error) {
 			this.migrationContext.SharedColumns.SetCharsetConversion(column.Name, column.Charset, mappedColumn.Charset)
 		}
 	}
+	if err := this.validateUniqueKeyTypeChanges(); err != nil {
+		return err
+	}
 
 	for _, column := range this.migrationContext.UniqueKey.Columns.Columns() {
 		if this.migrationContext.GhostTableVirtualColumns.GetColumn(column.Name) != nil {
@@ -217,6 +384,251 @@ func (this *Inspector) inspectOriginalAndGhostTables() (err error) {
 	return nil
 }
 
+// validateApplierParallelism restricts applying binlog events to a single worker where events on different
+// rows may conflict: when the ghost table has unique keys other than the migration's, which a REPLACE applied
+// ahead of its turn would resolve by deleting another row, and when the migration's unique key has a textual
+// column, whose values equal by collation, e.g. 'a' and 'A', would be routed to different workers. The
+// restriction applies even without --applier-parallelism, as the applier-parallelism command may not lift it.
+func (this *Inspector) validateApplierParallelism() {
+	var reason string
+	if len(this.migrationContext.GhostTableUniqueKeys) > 1 {
+		reason = fmt.Sprintf("ghost table has %d unique keys", len(this.migrationContext.GhostTableUniqueKeys))
+	} else {
+		for _, column := range this.migrationContext.UniqueKey.Columns.Columns() {
+			if column.Charset != "" {
+				reason = fmt.Sprintf("unique key %s has textual column %s", this.migrationContext.UniqueKey.Name, sql.EscapeName(column.Name))
+				break
+			}
+		}
+	}
+	if reason == "" {
+		return
+	}
+	if this.migrationContext.GetApplierParallelism() > 1 {
+		this.log.Warningf("--applier-parallelism: %s; applying binlog events with a single worker", reason)
+	}
+	this.migrationContext.RestrictApplierParallelism(reason)
+}
+
+// validateUniqueKeyTypeChanges looks for columns of the chosen unique key whose type is changed by the
+// ALTER. Rows are iterated by the original table's key, but binlog UPDATEs and DELETEs find their rows on
+// the ghost table, where the key's values are then cast to the ghost column's type. A change of collation
+// that compares values differently (case, accent or binary sensitivity) could match other rows, and is refused.
+func (this *Inspector) validateUniqueKeyTypeChanges() error {
+	for _, column := range this.migrationContext.UniqueKey.Columns.Columns() {
+		if column.IsVirtual {
+			continue
+		}
+		ordinal, ok := this.migrationContext.SharedColumns.Ordinals[column.Name]
+		if !ok {
+			continue
+		}
+		sharedColumn := this.migrationContext.SharedColumns.Columns()[ordinal]
+		mappedColumn := this.migrationContext.MappedSharedColumns.Columns()[ordinal]
+		if strings.EqualFold(sharedColumn.MySQLType, mappedColumn.MySQLType) && sharedColumn.Charset == mappedColumn.Charset && sharedColumn.Collation == mappedColumn.Collation {
+			continue
+		}
+		fromSensitivity, toSensitivity := collationSensitivity(&sharedColumn), collationSensitivity(&mappedColumn)
+		if fromSensitivity != "" && toSensitivity != "" && fromSensitivity != toSensitivity {
+			return fmt.Errorf("Column %s of the chosen unique key %s changes collation from %s to %s, which compares values differently. Use --chunk-index to choose another key", sql.EscapeName(column.Name), this.migrationContext.UniqueKey.Name, sharedColumn.Collation, mappedColumn.Collation)
+		}
+		ghostCast := sql.BuildGhostCastPreparedValue(&mappedColumn)
+		if ghostCast == "" {
+			continue
+		}
+		this.migrationContext.UniqueKey.Columns.SetGhostCast(column.Name, ghostCast)
+		if sharedColumn.Charset != mappedColumn.Charset {
+			this.migrationContext.UniqueKey.Columns.SetCharsetConversion(column.Name, sharedColumn.Charset, mappedColumn.Charset)
+		}
+		this.log.Infof("Column %s of the chosen unique key %s changes type from %s to %s; comparing its values on the ghost table as %s", sql.EscapeName(column.Name), this.migrationContext.UniqueKey.Name, sharedColumn.MySQLType, mappedColumn.MySQLType, ghostCast)
+	}
+	return nil
+}
+
+// collationSensitivity returns how a string column's collation compares values: "bin", "cs", "as_ci" or "ci".
+// Binary strings compare as "bin". Returns an empty string for other columns.
+func collationSensitivity(column *sql.Column) string {
+	collation := strings.ToLower(column.Collation)
+	mysqlType := strings.ToLower(column.MySQLType)
+	switch {
+	case collation == "" && (strings.Contains(mysqlType, "binary") || strings.Contains(mysqlType, "blob")):
+		return "bin"
+	case collation == "":
+		return ""
+	case collation == "binary" || strings.HasSuffix(collation, "_bin"):
+		return "bin"
+	case strings.HasSuffix(collation, "_cs"):
+		return "cs"
+	case strings.HasSuffix(collation, "_as_ci"):
+		return "as_ci"
+	}
+	return "ci"
+}
+
+// validateIgnoredColumns verifies the columns given in --ignore-columns exist on the original table
+// and are not part of the chosen key. Where an ignored column remains on the ghost table, rows are written
+// without it, so it must be nullable or have a default there.
+func (this *Inspector) validateIgnoredColumns() error {
+	if len(this.migrationContext.IgnoredColumnsMap) == 0 {
+		return nil
+	}
+	ignoredColumnNames := []string{}
+	for ignoredColumn := range this.migrationContext.IgnoredColumnsMap {
+		found := false
+		for _, columnName := range this.migrationContext.OriginalTableColumns.Names() {
+			if strings.EqualFold(columnName, ignoredColumn) {
+				ignoredColumnNames = append(ignoredColumnNames, columnName)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Ignored column %s not found on %s.%s", sql.EscapeName(ignoredColumn), sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		}
+	}
+	sort.Strings(ignoredColumnNames)
+	for _, columnName := range ignoredColumnNames {
+		for _, uniqueKeyColumnName := range this.migrationContext.UniqueKey.Columns.Names() {
+			if strings.EqualFold(columnName, uniqueKeyColumnName) {
+				return fmt.Errorf("Ignored column %s is part of the chosen unique key %s. Bailing out", sql.EscapeName(columnName), this.migrationContext.UniqueKey.Name)
+			}
+		}
+		ghostColumnName := columnName
+		if mapped, ok := this.migrationContext.ColumnRenameMap[columnName]; ok {
+			ghostColumnName = mapped
+		}
+		ghostColumn := this.migrationContext.GhostTableColumns.GetColumn(ghostColumnName)
+		if ghostColumn == nil || ghostColumn.IsVirtual {
+			continue
+		}
+		if !ghostColumn.Nullable && !ghostColumn.HasDefault {
+			return fmt.Errorf("Ignored column %s is NOT NULL and has no default on the ghost table; rows cannot be written without it. Bailing out", sql.EscapeName(ghostColumnName))
+		}
+	}
+	this.log.Infof("Ignored columns are %s", strings.Join(ignoredColumnNames, ","))
+	return nil
+}
+
+// validateColumnTransforms verifies each --transform-column targets a ghost table column outside the chosen key,
+// and that its expression evaluates against the original table's columns.
+func (this *Inspector) validateColumnTransforms() error {
+	transformedColumns := make(map[string]bool)
+	for _, transform := range this.migrationContext.ColumnTransforms {
+		columnName := strings.ToLower(transform.Column)
+		if transformedColumns[columnName] {
+			return fmt.Errorf("Column %s is transformed more than once", sql.EscapeName(transform.Column))
+		}
+		transformedColumns[columnName] = true
+
+		var ghostColumn *sql.Column
+		for i, column := range this.migrationContext.GhostTableColumns.Columns() {
+			if strings.EqualFold(column.Name, transform.Column) {
+				ghostColumn = &this.migrationContext.GhostTableColumns.Columns()[i]
+				break
+			}
+		}
+		if ghostColumn == nil {
+			return fmt.Errorf("Transformed column %s not found on the ghost table", sql.EscapeName(transform.Column))
+		}
+		if ghostColumn.IsVirtual {
+			return fmt.Errorf("Transformed column %s is a generated column", sql.EscapeName(transform.Column))
+		}
+		for _, uniqueKeyColumnName := range this.migrationContext.UniqueKey.Columns.Names() {
+			if mapped, ok := this.migrationContext.ColumnRenameMap[uniqueKeyColumnName]; ok {
+				uniqueKeyColumnName = mapped
+			}
+			if strings.EqualFold(uniqueKeyColumnName, transform.Column) {
+				return fmt.Errorf("Transformed column %s is part of the chosen unique key %s. Bailing out", sql.EscapeName(transform.Column), this.migrationContext.UniqueKey.Name)
+			}
+		}
+
+		query := fmt.Sprintf(`select /* gh-ost */ (%s) from %s.%s limit 0`,
+			transform.Expression,
+			sql.EscapeName(this.migrationContext.DatabaseName),
+			sql.EscapeName(this.migrationContext.OriginalTableName),
+		)
+		rows, err := this.db.Query(query)
+		if err != nil {
+			return fmt.Errorf("Invalid expression for transformed column %s: %+v", sql.EscapeName(transform.Column), err)
+		}
+		rows.Close()
+		this.log.Infof("Column %s will be written as: %s", sql.EscapeName(transform.Column), transform.Expression)
+	}
+	return nil
+}
+
+// validateNullableUniqueKey verifies no two rows share the same values in given key where some
+// of these values are NULL. MySQL permits such duplicates, but gh-ost identifies rows by the key
+// and cannot tell these rows apart: iteration could copy them, but DML would apply to all of them.
+func (this *Inspector) validateNullableUniqueKey(uniqueKey *sql.UniqueKey) error {
+	query, err := sql.BuildNullableUniqueKeyDuplicatesQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, uniqueKey, nullableUniqueKeyDuplicatesReportLimit)
+	if err != nil {
+		return err
+	}
+	duplicates := []string{}
+	rows, err := this.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		values := sql.NewColumnValues(uniqueKey.Len() + 1)
+		if err := rows.Scan(values.ValuesPointers...); err != nil {
+			return err
+		}
+		duplicates = append(duplicates, fmt.Sprintf("[%s]", values))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("Chosen key (%s) has nullable columns, and rows exist which share identical key values including NULLs. gh-ost cannot tell such rows apart. Bailing out. Duplicate key values, followed by count (showing up to %d): %s", uniqueKey, nullableUniqueKeyDuplicatesReportLimit, strings.Join(duplicates, ", "))
+	}
+	return nil
+}
+
+// validateNarrowedColumns looks for shared columns whose type is narrowed by the ALTER (shorter
+// VARCHAR, fewer ENUM/SET members, smaller integer type) and checks whether existing rows would not fit.
+// Such rows would fail the copy under strict mode, or be silently truncated otherwise. The check reads
+// a sample of the table's first rows, so that it costs the same on any table size.
+func (this *Inspector) validateNarrowedColumns() error {
+	this.migrationContext.NarrowedColumnsFindings = []string{}
+	for i, column := range this.migrationContext.SharedColumns.Columns() {
+		mappedColumn := this.migrationContext.MappedSharedColumns.Columns()[i]
+		condition := sql.BuildNarrowingColumnCondition(column.Name, column.MySQLType, mappedColumn.MySQLType)
+		if condition == "" {
+			continue
+		}
+		query, err := sql.BuildNarrowingColumnCountQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, column.Name, condition, narrowedColumnsCheckScanLimit, narrowedColumnsCheckRowsLimit)
+		if err != nil {
+			return err
+		}
+		this.log.Infof("Column %s is narrowed from %s to %s; checking existing values of up to %d rows", sql.EscapeName(column.Name), column.MySQLType, mappedColumn.MySQLType, narrowedColumnsCheckScanLimit)
+		var rowsCount int64
+		if err := this.db.QueryRow(query).Scan(&rowsCount); err != nil {
+			return err
+		}
+		if rowsCount == 0 {
+			continue
+		}
+		rowsDescription := fmt.Sprintf("%d", rowsCount)
+		if rowsCount >= narrowedColumnsCheckRowsLimit {
+			rowsDescription = fmt.Sprintf("at least %d", rowsCount)
+		}
+		finding := fmt.Sprintf("%s %s->%s: %s rows do not fit", column.Name, column.MySQLType, mappedColumn.MySQLType, rowsDescription)
+		this.migrationContext.NarrowedColumnsFindings = append(this.migrationContext.NarrowedColumnsFindings, finding)
+		this.log.Warningf("Narrowed column %s", finding)
+	}
+	if len(this.migrationContext.NarrowedColumnsFindings) == 0 {
+		return nil
+	}
+	if this.migrationContext.AllowLossyMigration {
+		this.log.Warningf("Existing values do not fit narrowed columns. You have supplied with --allow-lossy-migration and so this migration proceeds. These values will be truncated or fail to copy")
+		return nil
+	}
+	return fmt.Errorf("Existing values do not fit narrowed columns: %s. Bailing out. To force this operation to continue, supply --allow-lossy-migration flag", strings.Join(this.migrationContext.NarrowedColumnsFindings, "; "))
+}
+
 // validateConnection issues a simple can-connect to MySQL
 func (this *Inspector) validateConnection() error {
 	version, err := base.ValidateConnection(this.db, this.connectionConfig, this.migrationContext, this.name)
@@ -224,6 +636,24 @@ func (this *Inspector) validateConnection() error {
 	return err
 }
 
+// validateGhostDatabase verifies the schema given by --ghost-database exists
+func (this *Inspector) validateGhostDatabase() error {
+	ghostDatabaseName := this.migrationContext.GetGhostDatabaseName()
+	if ghostDatabaseName == this.migrationContext.DatabaseName {
+		return nil
+	}
+	query := `select /* gh-ost */ count(*) from information_schema.schemata where schema_name = ?`
+	var count int
+	if err := this.db.QueryRow(query, ghostDatabaseName).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return this.log.Errorf("Schema %s, given by --ghost-database, does not exist on %s. Please create it first", sql.EscapeName(ghostDatabaseName), this.connectionConfig.Key.String())
+	}
+	this.log.Infof("Ghost, changelog and checkpoint tables will be created in %s", sql.EscapeName(ghostDatabaseName))
+	return nil
+}
+
 // validateGrants verifies the user by which we're executing has necessary grants
 // to do its thing.
 func (this *Inspector) validateGrants() error {
@@ -233,10 +663,15 @@ func (this *Inspector) validateGrants() error {
 	foundReplicationClient := false
 	foundReplicationSlave := false
 	foundDBAll := false
+	foundGhostDBAll := false
+	this.globalPrivileges = make(map[string]bool)
 
 	err := sqlutils.QueryRowsMap(this.db, query, func(rowMap sqlutils.RowMap) error {
 		for _, grantData := range rowMap {
 			grant := grantData.String
+			for _, privilege := range parseGlobalPrivileges(grant) {
+				this.globalPrivileges[privilege] = true
+			}
 			if strings.Contains(grant, `GRANT ALL PRIVILEGES ON *.*`) {
 				foundAll = true
 			}
@@ -249,17 +684,11 @@ func (this *Inspector) validateGrants() error {
 			if strings.Contains(grant, `REPLICATION SLAVE`) && strings.Contains(grant, ` ON *.*`) {
 				foundReplicationSlave = true
 			}
-			if strings.Contains(grant, fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.*", this.migrationContext.DatabaseName)) {
-				foundDBAll = true
-			}
-			if strings.Contains(grant, fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.*", strings.Replace(this.migrationContext.DatabaseName, "_", "\\_", -1))) {
-				foundDBAll = true
-			}
-			if base.StringContainsAll(grant, `ALTER`, `CREATE`, `DELETE`, `DROP`, `INDEX`, `INSERT`, `LOCK TABLES`, `SELECT`, `TRIGGER`, `UPDATE`, ` ON *.*`) {
+			if grantsAllOnDatabase(grant, this.migrationContext.DatabaseName) {
 				foundDBAll = true
 			}
-			if base.StringContainsAll(grant, `ALTER`, `CREATE`, `DELETE`, `DROP`, `INDEX`, `INSERT`, `LOCK TABLES`, `SELECT`, `TRIGGER`, `UPDATE`, fmt.Sprintf(" ON `%s`.*", this.migrationContext.DatabaseName)) {
-				foundDBAll = true
+			if grantsAllOnDatabase(grant, this.migrationContext.GetGhostDatabaseName()) {
+				foundGhostDBAll = true
 			}
 		}
 		return nil
@@ -270,19 +699,113 @@ func (this *Inspector) validateGrants() error {
 	this.migrationContext.HasSuperPrivilege = foundSuper
 
 	if foundAll {
-		this.migrationContext.Log.Infof("User has ALL privileges")
+		this.log.Infof("User has ALL privileges")
 		return nil
 	}
+	if !foundGhostDBAll {
+		return this.log.Errorf("User has insufficient privileges on %s.*, the schema of the ghost and changelog tables as given by --ghost-database. Needed: ALL on %s.*", sql.EscapeName(this.migrationContext.GetGhostDatabaseName()), sql.EscapeName(this.migrationContext.GetGhostDatabaseName()))
+	}
 	if foundSuper && foundReplicationSlave && foundDBAll {
-		this.migrationContext.Log.Infof("User has SUPER, REPLICATION SLAVE privileges, and has ALL privileges on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
+		this.log.Infof("User has SUPER, REPLICATION SLAVE privileges, and has ALL privileges on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
 		return nil
 	}
 	if foundReplicationClient && foundReplicationSlave && foundDBAll {
-		this.migrationContext.Log.Infof("User has REPLICATION CLIENT, REPLICATION SLAVE privileges, and has ALL privileges on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
+		this.log.Infof("User has REPLICATION CLIENT, REPLICATION SLAVE privileges, and has ALL privileges on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
 		return nil
 	}
-	this.migrationContext.Log.Debugf("Privileges: Super: %t, REPLICATION CLIENT: %t, REPLICATION SLAVE: %t, ALL on *.*: %t, ALL on %s.*: %t", foundSuper, foundReplicationClient, foundReplicationSlave, foundAll, sql.EscapeName(this.migrationContext.DatabaseName), foundDBAll)
-	return this.migrationContext.Log.Errorf("User has insufficient privileges for migration. Needed: SUPER|REPLICATION CLIENT, REPLICATION SLAVE and ALL on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
+	this.log.Debugf("Privileges: Super: %t, REPLICATION CLIENT: %t, REPLICATION SLAVE: %t, ALL on *.*: %t, ALL on %s.*: %t", foundSuper, foundReplicationClient, foundReplicationSlave, foundAll, sql.EscapeName(this.migrationContext.DatabaseName), foundDBAll)
+	return this.log.Errorf("User has insufficient privileges for migration. Needed: SUPER|REPLICATION CLIENT, REPLICATION SLAVE and ALL on %s.*", sql.EscapeName(this.migrationContext.DatabaseName))
+}
+
+// grantsAllOnDatabase returns true when a `show grants` statement grants all privileges gh-ost needs on given schema
+func grantsAllOnDatabase(grant string, databaseName string) bool {
+	if strings.Contains(grant, fmt.Sprintf("GRANT ALL PRIVILEGES ON %s.*", sql.QuoteIdentifier(databaseName))) {
+		return true
+	}
+	if strings.Contains(grant, fmt.Sprintf("GRANT ALL PRIVILEGES ON %s.*", sql.QuoteIdentifier(strings.Replace(databaseName, "_", "\\_", -1)))) {
+		return true
+	}
+	if base.StringContainsAll(grant, `ALTER`, `CREATE`, `DELETE`, `DROP`, `INDEX`, `INSERT`, `LOCK TABLES`, `SELECT`, `TRIGGER`, `UPDATE`, ` ON *.*`) {
+		return true
+	}
+	return base.StringContainsAll(grant, `ALTER`, `CREATE`, `DELETE`, `DROP`, `INDEX`, `INSERT`, `LOCK TABLES`, `SELECT`, `TRIGGER`, `UPDATE`, fmt.Sprintf(" ON %s.*", sql.QuoteIdentifier(databaseName)))
+}
+
+// parseGlobalPrivileges returns the privileges of a `GRANT ... ON *.*` statement, as listed by `show grants`
+func parseGlobalPrivileges(grant string) (privileges []string) {
+	if !strings.HasPrefix(grant, "GRANT ") {
+		return privileges
+	}
+	privilegesList, _, found := strings.Cut(strings.TrimPrefix(grant, "GRANT "), " ON *.* TO ")
+	if !found {
+		return privileges
+	}
+	for _, privilege := range strings.Split(privilegesList, ",") {
+		privileges = append(privileges, strings.ToUpper(strings.TrimSpace(privilege)))
+	}
+	return privileges
+}
+
+// hasGlobalPrivilege returns true when any of given privileges, or all privileges, are granted ON *.*
+func (this *Inspector) hasGlobalPrivilege(privileges ...string) bool {
+	if this.globalPrivileges["ALL PRIVILEGES"] {
+		return true
+	}
+	for _, privilege := range privileges {
+		if this.globalPrivileges[privilege] {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePrivilegedOperations checks up front that the user is privileged for the operations this migration
+// is set to run, rather than failing midway. Managed platforms such as RDS or Cloud SQL do not grant SUPER.
+// All missing privileges are reported at once, along with the flags that avoid the operations requiring them.
+func (this *Inspector) validatePrivilegedOperations() error {
+	var missing []string
+	requirePrivilege := func(operation string, privileges ...string) {
+		if !this.hasGlobalPrivilege(privileges...) {
+			missing = append(missing, fmt.Sprintf("%s, to %s", strings.Join(privileges, " or "), operation))
+		}
+	}
+
+	if this.migrationContext.RequiresBinlogFormatChange() && this.migrationContext.SwitchToRowBinlogFormat {
+		requirePrivilege("set global binlog_format='ROW' (--switch-to-rbr)", "SUPER", "SYSTEM_VARIABLES_ADMIN")
+	}
+	if this.migrationContext.RequiresBinlogFormatChange() || !this.migrationContext.AssumeRBR {
+		masterKey, _ := mysql.GetMasterKeyFromSlaveStatus(this.dbVersion, this.connectionConfig)
+		if masterKey != nil {
+			requirePrivilege("restart replication so that ROW binlog format applies to the replication thread. Use --assume-rbr if you know it does", "SUPER", "REPLICATION_SLAVE_ADMIN")
+		}
+	}
+	if this.migrationContext.TestOnReplica && !this.migrationContext.TestOnReplicaSkipReplicaStop {
+		requirePrivilege("stop replication on cut-over (--test-on-replica). Use --test-on-replica-skip-replica-stop if a hook does that", "SUPER", "REPLICATION_SLAVE_ADMIN")
+	}
+	if this.migrationContext.AwaitReplicaPromotion {
+		requirePrivilege("stop replication upon the 'promote' command (--await-replica-promotion)", "SUPER", "REPLICATION_SLAVE_ADMIN")
+	}
+	if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica {
+		// Writing onto a read_only replica
+		var readOnly, superReadOnly bool
+		if err := this.db.QueryRow(`select /* gh-ost */ @@global.read_only`).Scan(&readOnly); err != nil {
+			return err
+		}
+		if err := this.db.QueryRow(`select /* gh-ost */ @@global.super_read_only`).Scan(&superReadOnly); err != nil { //nolint:staticcheck
+			// swallow this error. not all servers support super_read_only
+		}
+		if superReadOnly {
+			return fmt.Errorf("%s has super_read_only enabled, and so cannot be written to with --test-on-replica or --migrate-on-replica, whatever the privileges", this.connectionConfig.Key.String())
+		}
+		if readOnly {
+			requirePrivilege("write onto read_only replica (--test-on-replica, --migrate-on-replica)", "SUPER", "CONNECTION_ADMIN")
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("User lacks privileges for this migration on %s. Missing: %s", this.connectionConfig.Key.String(), strings.Join(missing, "; "))
+	}
+	return nil
 }
 
 // restartReplication is required so that we are _certain_ the binlog format and
@@ -290,7 +813,7 @@ func (this *Inspector) validateGrants() error {
 // It is entirely possible, for example, that the replication is using 'STATEMENT'
 // binlog format even as the variable says 'ROW'
 func (this *Inspector) restartReplication() error {
-	this.migrationContext.Log.Infof("Restarting replication on %s to make sure binlog settings apply to replication thread", this.connectionConfig.Key.String())
+	this.log.Infof("Restarting replication on %s to make sure binlog settings apply to replication thread", this.connectionConfig.Key.String())
 
 	masterKey, _ := mysql.GetMasterKeyFromSlaveStatus(this.dbVersion, this.connectionConfig)
 	if masterKey == nil {
@@ -322,11 +845,11 @@ func (this *Inspector) restartReplication() error {
 		if time.Since(startTime) > startReplicationMaxWait {
 			return fmt.Errorf("Replication did not restart within the maximum wait time of %s", startReplicationMaxWait)
 		}
-		this.migrationContext.Log.Debugf("Replication not yet restarted, waiting...")
+		this.log.Debugf("Replication not yet restarted, waiting...")
 		time.Sleep(startReplicationPostWait)
 	}
 
-	this.migrationContext.Log.Debugf("Replication restarted")
+	this.log.Debugf("Replication restarted")
 	return nil
 }
 
@@ -370,7 +893,7 @@ func (this *Inspector) applyBinlogFormat() error {
 		if err := this.restartReplication(); err != nil {
 			return err
 		}
-		this.migrationContext.Log.Debugf("'ROW' binlog format applied")
+		this.log.Debugf("'ROW' binlog format applied")
 		return nil
 	}
 	// We already have RBR, no explicit switch
@@ -386,19 +909,19 @@ func (this *Inspector) applyBinlogFormat() error {
 func (this *Inspector) validateBinlogs() error {
 	query := `select /* gh-ost */@@global.log_bin, @@global.binlog_format`
 	var hasBinaryLogs bool
-	if err := this.db.QueryRow(query).Scan(&hasBinaryLogs, &this.migrationContext.OriginalBinlogFormat); err != nil {
+	if err := this.binlogDb.QueryRow(query).Scan(&hasBinaryLogs, &this.migrationContext.OriginalBinlogFormat); err != nil {
 		return err
 	}
 	if !hasBinaryLogs {
-		return fmt.Errorf("%s must have binary logs enabled", this.connectionConfig.Key.String())
+		return fmt.Errorf("%s must have binary logs enabled", this.binlogConnectionConfig.Key.String())
 	}
 	if this.migrationContext.RequiresBinlogFormatChange() {
 		if !this.migrationContext.SwitchToRowBinlogFormat {
-			return fmt.Errorf("You must be using ROW binlog format. I can switch it for you, provided --switch-to-rbr and that %s doesn't have replicas", this.connectionConfig.Key.String())
+			return fmt.Errorf("You must be using ROW binlog format. I can switch it for you, provided --switch-to-rbr and that %s doesn't have replicas", this.binlogConnectionConfig.Key.String())
 		}
 		query := fmt.Sprintf("show /* gh-ost */ %s", mysql.ReplicaTermFor(this.dbVersion, `slave hosts`))
 		countReplicas := 0
-		err := sqlutils.QueryRowsMap(this.db, query, func(rowMap sqlutils.RowMap) error {
+		err := sqlutils.QueryRowsMap(this.binlogDb, query, func(rowMap sqlutils.RowMap) error {
 			countReplicas++
 			return nil
 		})
@@ -406,20 +929,38 @@ func (this *Inspector) validateBinlogs() error {
 			return err
 		}
 		if countReplicas > 0 {
-			return fmt.Errorf("%s has %s binlog_format, but I'm too scared to change it to ROW because it has replicas. Bailing out", this.connectionConfig.Key.String(), this.migrationContext.OriginalBinlogFormat)
+			return fmt.Errorf("%s has %s binlog_format, but I'm too scared to change it to ROW because it has replicas. Bailing out", this.binlogConnectionConfig.Key.String(), this.migrationContext.OriginalBinlogFormat)
 		}
-		this.migrationContext.Log.Infof("%s has %s binlog_format. I will change it to ROW, and will NOT change it back, even in the event of failure.", this.connectionConfig.Key.String(), this.migrationContext.OriginalBinlogFormat)
+		this.log.Infof("%s has %s binlog_format. I will change it to ROW, and will NOT change it back, even in the event of failure.", this.binlogConnectionConfig.Key.String(), this.migrationContext.OriginalBinlogFormat)
 	}
 	query = `select /* gh-ost */ @@global.binlog_row_image`
-	if err := this.db.QueryRow(query).Scan(&this.migrationContext.OriginalBinlogRowImage); err != nil {
+	if err := this.binlogDb.QueryRow(query).Scan(&this.migrationContext.OriginalBinlogRowImage); err != nil {
 		return err
 	}
 	this.migrationContext.OriginalBinlogRowImage = strings.ToUpper(this.migrationContext.OriginalBinlogRowImage)
 	if this.migrationContext.OriginalBinlogRowImage != "FULL" {
-		return fmt.Errorf("%s has '%s' binlog_row_image, and only 'FULL' is supported. This operation cannot proceed. You may `set global binlog_row_image='full'` and try again", this.connectionConfig.Key.String(), this.migrationContext.OriginalBinlogRowImage)
+		return fmt.Errorf("%s has '%s' binlog_row_image, and only 'FULL' is supported. This operation cannot proceed. You may `set global binlog_row_image='full'` and try again", this.binlogConnectionConfig.Key.String(), this.migrationContext.OriginalBinlogRowImage)
 	}
 
-	this.migrationContext.Log.Infof("binary logs validated on %s", this.connectionConfig.Key.String())
+	this.log.Infof("binary logs validated on %s", this.binlogConnectionConfig.Key.String())
+	return nil
+}
+
+// validateProxyProbe checks, with --proxy-compat and --proxy-probe-query, the binlog_format of the backend the
+// proxy routes to. Binary log settings are otherwise validated on --binlog-host, which is not necessarily the
+// backend behind the proxy.
+func (this *Inspector) validateProxyProbe() error {
+	if !this.migrationContext.ProxyCompat || this.migrationContext.ProxyProbeQuery == "" {
+		return nil
+	}
+	var binlogFormat string
+	if err := this.db.QueryRow(this.migrationContext.ProxyProbeQuery).Scan(&binlogFormat); err != nil {
+		return fmt.Errorf("Running --proxy-probe-query through %s: %w", this.connectionConfig.Key.String(), err)
+	}
+	if !strings.EqualFold(binlogFormat, "ROW") {
+		return fmt.Errorf("--proxy-probe-query through %s reports %s binlog_format, while only ROW is supported behind a proxy", this.connectionConfig.Key.String(), binlogFormat)
+	}
+	this.log.Infof("binlog_format validated through %s by --proxy-probe-query", this.connectionConfig.Key.String())
 	return nil
 }
 
@@ -427,15 +968,15 @@ func (this *Inspector) validateBinlogs() error {
 func (this *Inspector) validateGTIDConfig() error {
 	var gtidMode, enforceGtidConsistency string
 	query := `select @@global.gtid_mode, @@global.enforce_gtid_consistency`
-	if err := this.db.QueryRow(query).Scan(&gtidMode, &enforceGtidConsistency); err != nil {
+	if err := this.binlogDb.QueryRow(query).Scan(&gtidMode, &enforceGtidConsistency); err != nil {
 		return err
 	}
 	enforceGtidConsistency = strings.ToUpper(enforceGtidConsistency)
 	if strings.ToUpper(gtidMode) != "ON" || (enforceGtidConsistency != "ON" && enforceGtidConsistency != "1") {
-		return fmt.Errorf("%s must have gtid_mode=ON and enforce_gtid_consistency=ON to use GTID support", this.connectionConfig.Key.String())
+		return fmt.Errorf("%s must have gtid_mode=ON and enforce_gtid_consistency=ON to use GTID support", this.binlogConnectionConfig.Key.String())
 	}
 
-	this.migrationContext.Log.Infof("gtid config validated on %s", this.connectionConfig.Key.String())
+	this.log.Infof("gtid config validated on %s", this.binlogConnectionConfig.Key.String())
 	return nil
 }
 
@@ -443,35 +984,35 @@ func (this *Inspector) validateGTIDConfig() error {
 func (this *Inspector) validateLogSlaveUpdates() error {
 	query := `select /* gh-ost */ @@global.log_slave_updates`
 	var logSlaveUpdates bool
-	if err := this.db.QueryRow(query).Scan(&logSlaveUpdates); err != nil {
+	if err := this.binlogDb.QueryRow(query).Scan(&logSlaveUpdates); err != nil {
 		return err
 	}
 
 	if logSlaveUpdates {
-		this.migrationContext.Log.Infof("log_slave_updates validated on %s", this.connectionConfig.Key.String())
+		this.log.Infof("log_slave_updates validated on %s", this.binlogConnectionConfig.Key.String())
 		return nil
 	}
 
 	if this.migrationContext.IsTungsten {
-		this.migrationContext.Log.Warningf("log_slave_updates not found on %s, but --tungsten provided, so I'm proceeding", this.connectionConfig.Key.String())
+		this.log.Warningf("log_slave_updates not found on %s, but --tungsten provided, so I'm proceeding", this.binlogConnectionConfig.Key.String())
 		return nil
 	}
 
 	if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica {
-		return fmt.Errorf("%s must have log_slave_updates enabled for testing/migrating on replica", this.connectionConfig.Key.String())
+		return fmt.Errorf("%s must have log_slave_updates enabled for testing/migrating on replica", this.binlogConnectionConfig.Key.String())
 	}
 
 	if this.migrationContext.InspectorIsAlsoApplier() {
-		this.migrationContext.Log.Warningf("log_slave_updates not found on %s, but executing directly on master, so I'm proceeding", this.connectionConfig.Key.String())
+		this.log.Warningf("log_slave_updates not found on %s, but executing directly on master, so I'm proceeding", this.binlogConnectionConfig.Key.String())
 		return nil
 	}
 
-	return fmt.Errorf("%s must have log_slave_updates enabled for executing migration", this.connectionConfig.Key.String())
+	return fmt.Errorf("%s must have log_slave_updates enabled for executing migration", this.binlogConnectionConfig.Key.String())
 }
 
 // validateTable makes sure the table we need to operate on actually exists
 func (this *Inspector) validateTable() error {
-	query := fmt.Sprintf(`show /* gh-ost */ table status from %s like '%s'`, sql.EscapeName(this.migrationContext.DatabaseName), this.migrationContext.OriginalTableName)
+	query := fmt.Sprintf(`show /* gh-ost */ table status from %s where name = %s`, sql.EscapeName(this.migrationContext.DatabaseName), sql.QuoteLiteral(this.migrationContext.OriginalTableName))
 
 	tableFound := false
 	err := sqlutils.QueryRowsMap(this.db, query, func(rowMap sqlutils.RowMap) error {
@@ -489,17 +1030,17 @@ func (this *Inspector) validateTable() error {
 		return err
 	}
 	if !tableFound {
-		return this.migrationContext.Log.Errorf("Cannot find table %s.%s!", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		return this.log.Errorf("Cannot find table %s.%s!", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	}
-	this.migrationContext.Log.Infof("Table found. Engine=%s", this.migrationContext.TableEngine)
-	this.migrationContext.Log.Debugf("Estimated number of rows via STATUS: %d", this.migrationContext.RowsEstimate)
+	this.log.Infof("Table found. Engine=%s", this.migrationContext.TableEngine)
+	this.log.Debugf("Estimated number of rows via STATUS: %d", this.migrationContext.RowsEstimate)
 	return nil
 }
 
 // validateTableForeignKeys makes sure no foreign keys exist on the migrated table
 func (this *Inspector) validateTableForeignKeys(allowChildForeignKeys bool) error {
 	if this.migrationContext.SkipForeignKeyChecks {
-		this.migrationContext.Log.Warning("--skip-foreign-key-checks provided: will not check for foreign keys")
+		this.log.Warning("--skip-foreign-key-checks provided: will not check for foreign keys")
 		return nil
 	}
 	query := `
@@ -535,16 +1076,16 @@ func (this *Inspector) validateTableForeignKeys(allowChildForeignKeys bool) erro
 		return err
 	}
 	if numParentForeignKeys > 0 {
-		return this.migrationContext.Log.Errorf("Found %d parent-side foreign keys on %s.%s. Parent-side foreign keys are not supported. Bailing out", numParentForeignKeys, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		return this.log.Errorf("Found %d parent-side foreign keys on %s.%s. Parent-side foreign keys are not supported. Bailing out", numParentForeignKeys, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	}
 	if numChildForeignKeys > 0 {
 		if allowChildForeignKeys {
-			this.migrationContext.Log.Debugf("Foreign keys found and will be dropped, as per given --discard-foreign-keys flag")
+			this.log.Debugf("Foreign keys found and will be dropped, as per given --discard-foreign-keys flag")
 			return nil
 		}
-		return this.migrationContext.Log.Errorf("Found %d child-side foreign keys on %s.%s. Child-side foreign keys are not supported. Bailing out", numChildForeignKeys, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		return this.log.Errorf("Found %d child-side foreign keys on %s.%s. Child-side foreign keys are not supported. Bailing out", numChildForeignKeys, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	}
-	this.migrationContext.Log.Debugf("Validated no foreign keys exist on table")
+	this.log.Debugf("Validated no foreign keys exist on table")
 	return nil
 }
 
@@ -571,7 +1112,7 @@ func (this *Inspector) validateTableTriggers() error {
 	}
 	if numTriggers > 0 {
 		if this.migrationContext.IncludeTriggers {
-			this.migrationContext.Log.Infof("Found %d triggers on %s.%s.", numTriggers, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+			this.log.Infof("Found %d triggers on %s.%s.", numTriggers, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 			this.migrationContext.Triggers, err = mysql.GetTriggers(this.db, this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
 			if err != nil {
 				return err
@@ -584,9 +1125,9 @@ func (this *Inspector) validateTableTriggers() error {
 			}
 			return nil
 		}
-		return this.migrationContext.Log.Errorf("Found triggers on %s.%s. Tables with triggers are supported only when using \"include-triggers\" flag. Bailing out", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		return this.log.Errorf("Found triggers on %s.%s. Tables with triggers are supported only when using \"include-triggers\" flag. Bailing out", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	}
-	this.migrationContext.Log.Debugf("Validated no triggers exist on table")
+	this.log.Debugf("Validated no triggers exist on table")
 	return nil
 }
 
@@ -613,7 +1154,7 @@ func (this *Inspector) validateGhostTriggersDontExist() error {
 			}
 		}
 		if len(foundTriggers) > 0 {
-			return this.migrationContext.Log.Errorf("Found gh-ost triggers (%s). Please use a different suffix or drop them. Bailing out", strings.Join(foundTriggers, ","))
+			return this.log.Errorf("Found gh-ost triggers (%s). Please use a different suffix or drop them. Bailing out", strings.Join(foundTriggers, ","))
 		}
 	}
 
@@ -630,7 +1171,7 @@ func (this *Inspector) validateGhostTriggersLength() error {
 			}
 		}
 		if len(foundTriggers) > 0 {
-			return this.migrationContext.Log.Errorf("Gh-ost triggers (%s) length > %d characters. Bailing out", strings.Join(foundTriggers, ","), mysql.MaxTableNameLength)
+			return this.log.Errorf("Gh-ost triggers (%s) length > %d characters. Bailing out", strings.Join(foundTriggers, ","), mysql.MaxTableNameLength)
 		}
 	}
 	return nil
@@ -652,9 +1193,9 @@ func (this *Inspector) estimateTableRowsViaExplain() error {
 		return err
 	}
 	if !outputFound {
-		return this.migrationContext.Log.Errorf("Cannot run EXPLAIN on %s.%s!", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+		return this.log.Errorf("Cannot run EXPLAIN on %s.%s!", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	}
-	this.migrationContext.Log.Infof("Estimated number of rows via EXPLAIN: %d", this.migrationContext.RowsEstimate)
+	this.log.Infof("Estimated number of rows via EXPLAIN: %d", this.migrationContext.RowsEstimate)
 	return nil
 }
 
@@ -663,7 +1204,7 @@ func (this *Inspector) CountTableRows(ctx context.Context) error {
 	atomic.StoreInt64(&this.migrationContext.CountingRowsFlag, 1)
 	defer atomic.StoreInt64(&this.migrationContext.CountingRowsFlag, 0)
 
-	this.migrationContext.Log.Infof("As instructed, I'm issuing a SELECT COUNT(*) on the table. This may take a while")
+	this.log.Infof("As instructed, I'm issuing a SELECT COUNT(*) on the table. This may take a while")
 
 	conn, err := this.db.Conn(ctx)
 	if err != nil {
@@ -676,13 +1217,18 @@ func (this *Inspector) CountTableRows(ctx context.Context) error {
 		return err
 	}
 
-	query := fmt.Sprintf(`select /* gh-ost */ count(*) as count_rows from %s.%s`, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	query := sql.BuildCountRowsQuery(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName, this.migrationContext.QueryMaxExecutionTimeMillis)
 	var rowsEstimate int64
 	if err := conn.QueryRowContext(ctx, query).Scan(&rowsEstimate); err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-			this.migrationContext.Log.Infof("exact row count cancelled (%s), likely because I'm about to cut over. I'm going to kill that query.", ctx.Err())
+			this.log.Infof("exact row count cancelled (%s), likely because I'm about to cut over. I'm going to kill that query.", ctx.Err())
 			return mysql.Kill(this.db, connectionID)
 		}
+		if mysql.IsQueryTimeoutError(err) {
+			this.log.Warningf("exact row count exceeded max execution time of %dms. Keeping the estimated row count", this.migrationContext.QueryMaxExecutionTimeMillis)
+			this.migrationContext.SetCountTableRowsCancelFunc(nil)
+			return nil
+		}
 		return err
 	}
 
@@ -693,7 +1239,7 @@ func (this *Inspector) CountTableRows(ctx context.Context) error {
 	atomic.StoreInt64(&this.migrationContext.RowsEstimate, rowsEstimate)
 	this.migrationContext.UsedRowsEstimateMethod = base.CountRowsEstimate
 
-	this.migrationContext.Log.Infof("Exact number of rows via COUNT: %d", rowsEstimate)
+	this.log.Infof("Exact number of rows via COUNT: %d", rowsEstimate)
 
 	return nil
 }
@@ -719,9 +1265,13 @@ func (this *Inspector) applyColumnTypes(databaseName, tableName string, columnsL
 				continue
 			}
 			column.MySQLType = columnType
+			column.OctetLength = columnOctetLength
 			if isNullable == "YES" {
 				column.Nullable = true
 			}
+			if m["COLUMN_DEFAULT"].Valid || strings.Contains(extra, "auto_increment") {
+				column.HasDefault = true
+			}
 
 			if strings.Contains(columnType, "unsigned") {
 				column.IsUnsigned = true
@@ -755,6 +1305,7 @@ func (this *Inspector) applyColumnTypes(databaseName, tableName string, columnsL
 			if charset := m.GetString("CHARACTER_SET_NAME"); charset != "" {
 				column.Charset = charset
 			}
+			column.Collation = m.GetString("COLLATION_NAME")
 		}
 		return nil
 	}, databaseName, tableName)
@@ -778,9 +1329,43 @@ func (this *Inspector) getAutoIncrementValue(tableName string) (autoIncrement ui
 	return autoIncrement, err
 }
 
+// getTablePartitioning returns the partitioning of given table, or nil if the table is not partitioned
+func (this *Inspector) getTablePartitioning(tableName string) (partitioning *sql.TablePartitioning, err error) {
+	query := `
+		SELECT /* gh-ost */
+			PARTITION_NAME,
+			PARTITION_METHOD,
+			PARTITION_EXPRESSION,
+			SUBPARTITION_METHOD
+		FROM
+			INFORMATION_SCHEMA.PARTITIONS
+		WHERE
+			TABLE_SCHEMA = ?
+			AND TABLE_NAME = ?
+			AND PARTITION_NAME IS NOT NULL
+		ORDER BY
+			PARTITION_ORDINAL_POSITION,
+			SUBPARTITION_ORDINAL_POSITION`
+	err = sqlutils.QueryRowsMap(this.db, query, func(m sqlutils.RowMap) error {
+		if partitioning == nil {
+			partitioning = &sql.TablePartitioning{
+				Method:           m.GetString("PARTITION_METHOD"),
+				Expression:       m.GetString("PARTITION_EXPRESSION"),
+				HasSubpartitions: m.GetString("SUBPARTITION_METHOD") != "",
+			}
+		}
+		partitionName := m.GetString("PARTITION_NAME")
+		if len(partitioning.Partitions) == 0 || partitioning.Partitions[len(partitioning.Partitions)-1] != partitionName {
+			partitioning.Partitions = append(partitioning.Partitions, partitionName)
+		}
+		return nil
+	}, this.migrationContext.DatabaseName, tableName)
+	return partitioning, err
+}
+
 // getCandidateUniqueKeys investigates a table and returns the list of unique keys
 // candidate for chunking
-func (this *Inspector) getCandidateUniqueKeys(tableName string) (uniqueKeys [](*sql.UniqueKey), err error) {
+func (this *Inspector) getCandidateUniqueKeys(databaseName, tableName string) (uniqueKeys [](*sql.UniqueKey), err error) {
 	query := `
 		SELECT /* gh-ost */
 			COLUMNS.TABLE_SCHEMA,
@@ -788,22 +1373,26 @@ func (this *Inspector) getCandidateUniqueKeys(tableName string) (uniqueKeys [](*
 			COLUMNS.COLUMN_NAME,
 			UNIQUES.INDEX_NAME,
 			UNIQUES.COLUMN_NAMES,
+			UNIQUES.COLUMN_COLLATIONS,
 			UNIQUES.COUNT_COLUMN_IN_INDEX,
 			COLUMNS.DATA_TYPE,
 			COLUMNS.CHARACTER_SET_NAME,
 			LOCATE('auto_increment', EXTRA) > 0 as is_auto_increment,
-			has_nullable
-		FROM
-			INFORMATION_SCHEMA.COLUMNS
-		INNER JOIN (
+			has_nullable,
+			has_prefix_part,
+			has_functional_part
+		FROM (
 			SELECT
 				TABLE_SCHEMA,
 				TABLE_NAME,
 				INDEX_NAME,
 				COUNT(*) AS COUNT_COLUMN_IN_INDEX,
 				GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX ASC) AS COLUMN_NAMES,
+				GROUP_CONCAT(IFNULL(COLLATION, 'A') ORDER BY SEQ_IN_INDEX ASC) AS COLUMN_COLLATIONS,
 				SUBSTRING_INDEX(GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX ASC), ',', 1) AS FIRST_COLUMN_NAME,
-				SUM(NULLABLE='YES') > 0 AS has_nullable
+				SUM(NULLABLE='YES') > 0 AS has_nullable,
+				SUM(SUB_PART IS NOT NULL) > 0 AS has_prefix_part,
+				SUM(COLUMN_NAME IS NULL) > 0 AS has_functional_part
 			FROM
 				INFORMATION_SCHEMA.STATISTICS
 			WHERE
@@ -815,14 +1404,13 @@ func (this *Inspector) getCandidateUniqueKeys(tableName string) (uniqueKeys [](*
 				TABLE_NAME,
 				INDEX_NAME
 		) AS UNIQUES
+		LEFT JOIN INFORMATION_SCHEMA.COLUMNS
 		ON (
-			COLUMNS.COLUMN_NAME = UNIQUES.FIRST_COLUMN_NAME
-		)
-		WHERE
 			COLUMNS.TABLE_SCHEMA = ?
 			AND COLUMNS.TABLE_NAME = ?
+			AND COLUMNS.COLUMN_NAME = UNIQUES.FIRST_COLUMN_NAME
+		)
 		ORDER BY
-			COLUMNS.TABLE_SCHEMA, COLUMNS.TABLE_NAME,
 			CASE UNIQUES.INDEX_NAME
 				WHEN 'PRIMARY' THEN 0
 				ELSE 1
@@ -845,18 +1433,31 @@ func (this *Inspector) getCandidateUniqueKeys(tableName string) (uniqueKeys [](*
 			COUNT_COLUMN_IN_INDEX`
 	err = sqlutils.QueryRowsMap(this.db, query, func(m sqlutils.RowMap) error {
 		uniqueKey := &sql.UniqueKey{
-			Name:            m.GetString("INDEX_NAME"),
-			Columns:         *sql.ParseColumnList(m.GetString("COLUMN_NAMES")),
-			HasNullable:     m.GetBool("has_nullable"),
-			IsAutoIncrement: m.GetBool("is_auto_increment"),
+			Name:              m.GetString("INDEX_NAME"),
+			Columns:           *sql.NewColumnList([]string{}),
+			HasNullable:       m.GetBool("has_nullable"),
+			IsAutoIncrement:   m.GetBool("is_auto_increment"),
+			HasPrefixPart:     m.GetBool("has_prefix_part"),
+			HasFunctionalPart: m.GetBool("has_functional_part"),
+		}
+		// A key made of functional parts only has no columns
+		if columnNames := m.GetString("COLUMN_NAMES"); columnNames != "" {
+			uniqueKey.Columns = *sql.ParseColumnList(columnNames)
+		}
+		// MySQL 8.0 supports descending index parts, flagged with 'D'
+		columnNames := uniqueKey.Columns.Names()
+		for i, collation := range strings.Split(m.GetString("COLUMN_COLLATIONS"), ",") {
+			if collation == "D" && i < len(columnNames) {
+				uniqueKey.Columns.GetColumn(columnNames[i]).IsDescending = true
+			}
 		}
 		uniqueKeys = append(uniqueKeys, uniqueKey)
 		return nil
-	}, this.migrationContext.DatabaseName, tableName, this.migrationContext.DatabaseName, tableName)
+	}, databaseName, tableName, databaseName, tableName)
 	if err != nil {
 		return uniqueKeys, err
 	}
-	this.migrationContext.Log.Debugf("Potential unique keys in %+v: %+v", tableName, uniqueKeys)
+	this.log.Debugf("Potential unique keys in %+v: %+v", tableName, uniqueKeys)
 	return uniqueKeys, nil
 }
 
@@ -867,6 +1468,10 @@ func (this *Inspector) getSharedUniqueKeys(originalUniqueKeys, ghostUniqueKeys [
 	// the ALTER is on the name itself...
 	for _, originalUniqueKey := range originalUniqueKeys {
 		for _, ghostUniqueKey := range ghostUniqueKeys {
+			if ghostUniqueKey.HasFunctionalPart {
+				// its columns alone do not make for the key's uniqueness
+				continue
+			}
 			if originalUniqueKey.Columns.IsSubsetOf(&ghostUniqueKey.Columns) {
 				// In case the unique key gets renamed in -alter, PanicOnWarnings needs to rely on the new name
 				// to check SQL warnings on the ghost table, so return new name here.
@@ -879,6 +1484,122 @@ func (this *Inspector) getSharedUniqueKeys(originalUniqueKeys, ghostUniqueKeys [
 	return uniqueKeys
 }
 
+// partialUniqueKeyRefusal returns why given key cannot be iterated by, where it indexes parts of its
+// columns. A functional key part is not listed among the key's columns, which are then not unique by
+// themselves. A prefix part makes for a key that cannot serve the ordered range scans of the row copy.
+func partialUniqueKeyRefusal(uniqueKey *sql.UniqueKey) string {
+	if uniqueKey.HasFunctionalPart {
+		return "key has functional key parts, which cannot be iterated by"
+	}
+	if uniqueKey.HasPrefixPart {
+		return "key indexes column prefixes, which cannot serve the ordered range scans of the row copy"
+	}
+	return ""
+}
+
+// electUniqueKey chooses the key by which to iterate the table, out of given shared unique keys, and
+// returns the reason for choosing it. The key is either forced via --chunk-index, or elected by
+// preferring non-nullable keys, then keys whose columns the ALTER does not modify, then the PRIMARY KEY,
+// then AUTO_INCREMENT keys, then integer keys, then shorter keys by their byte length. Other than that,
+// the original order of keys is kept.
+func (this *Inspector) electUniqueKey(uniqueKeys []*sql.UniqueKey) (uniqueKey *sql.UniqueKey, reason string, err error) {
+	modifiedColumns := this.migrationContext.ModifiedColumnsMap
+	if chunkIndex := this.migrationContext.ChunkIndex; chunkIndex != "" {
+		for _, uniqueKey := range uniqueKeys {
+			if !strings.EqualFold(uniqueKey.Name, chunkIndex) {
+				continue
+			}
+			if uniqueKey.HasNullable {
+				return nil, "", fmt.Errorf("--chunk-index=%s: key has nullable columns and cannot be used for iteration. Bailing out", chunkIndex)
+			}
+			if modifiedKeyColumns := uniqueKeyModifiedColumns(uniqueKey, modifiedColumns); len(modifiedKeyColumns) > 0 {
+				this.log.Warningf("--chunk-index=%s: the ALTER modifies key column(s) %s", chunkIndex, strings.Join(modifiedKeyColumns, ", "))
+			}
+			return uniqueKey, "forced via --chunk-index", nil
+		}
+		return nil, "", fmt.Errorf("--chunk-index=%s: no such unique key shared by original and ghost tables, or its columns cannot be used for iteration. Bailing out", chunkIndex)
+	}
+	if len(uniqueKeys) == 0 {
+		return nil, "", nil
+	}
+	electedUniqueKeys := make([]*sql.UniqueKey, len(uniqueKeys))
+	copy(electedUniqueKeys, uniqueKeys)
+	sort.SliceStable(electedUniqueKeys, func(i, j int) bool {
+		return uniqueKeyPrecedes(electedUniqueKeys[i], electedUniqueKeys[j], modifiedColumns)
+	})
+	uniqueKey = electedUniqueKeys[0]
+	modifiedKeyColumns := uniqueKeyModifiedColumns(uniqueKey, modifiedColumns)
+	if len(modifiedKeyColumns) > 0 {
+		this.log.Warningf("Elected %s although the ALTER modifies its column(s) %s: no candidate shared key is left unmodified", uniqueKey.Name, strings.Join(modifiedKeyColumns, ", "))
+	}
+
+	traits := []string{}
+	if !uniqueKey.HasNullable {
+		traits = append(traits, "non-nullable")
+	}
+	if len(modifiedColumns) > 0 && len(modifiedKeyColumns) == 0 {
+		traits = append(traits, "unmodified by ALTER")
+	}
+	if uniqueKey.IsInteger() {
+		traits = append(traits, "integer")
+	}
+	if uniqueKey.IsPrimary() {
+		traits = append(traits, "primary")
+	}
+	if uniqueKey.IsAutoIncrement {
+		traits = append(traits, "auto_increment")
+	}
+	if keyByteLength, ok := uniqueKey.KeyByteLength(); ok {
+		traits = append(traits, fmt.Sprintf("%d byte(s)", keyByteLength))
+	}
+	reason = fmt.Sprintf("elected out of %d candidate(s): %s", len(uniqueKeys), strings.Join(traits, ", "))
+	return uniqueKey, reason, nil
+}
+
+// uniqueKeyModifiedColumns returns the columns of given key which the ALTER modifies
+func uniqueKeyModifiedColumns(uniqueKey *sql.UniqueKey, modifiedColumns map[string]bool) (modifiedKeyColumns []string) {
+	for _, columnName := range uniqueKey.Columns.Names() {
+		for modifiedColumn := range modifiedColumns {
+			if strings.EqualFold(columnName, modifiedColumn) {
+				modifiedKeyColumns = append(modifiedKeyColumns, columnName)
+				break
+			}
+		}
+	}
+	return modifiedKeyColumns
+}
+
+// uniqueKeyPrecedes checks whether key a is preferable over key b for iterating the table. Keys whose
+// columns the ALTER modifies are iterated by ranges which may compare differently on the ghost table.
+// Integer keys iterate by compact, sequential ranges, unlike e.g. a random UUID. Among those, the
+// PRIMARY KEY, by which InnoDB clusters rows, reads ranges with no lookup. Shorter keys take less
+// of the buffer pool.
+func uniqueKeyPrecedes(a, b *sql.UniqueKey, modifiedColumns map[string]bool) bool {
+	if a.HasNullable != b.HasNullable {
+		return !a.HasNullable
+	}
+	aIsModified := len(uniqueKeyModifiedColumns(a, modifiedColumns)) > 0
+	bIsModified := len(uniqueKeyModifiedColumns(b, modifiedColumns)) > 0
+	if aIsModified != bIsModified {
+		return !aIsModified
+	}
+	if a.IsInteger() != b.IsInteger() {
+		return a.IsInteger()
+	}
+	if a.IsPrimary() != b.IsPrimary() {
+		return a.IsPrimary()
+	}
+	if a.IsAutoIncrement != b.IsAutoIncrement {
+		return a.IsAutoIncrement
+	}
+	aByteLength, aIsKnown := a.KeyByteLength()
+	bByteLength, bIsKnown := b.KeyByteLength()
+	if aIsKnown != bIsKnown {
+		return aIsKnown
+	}
+	return aByteLength < bByteLength
+}
+
 // getSharedColumns returns the intersection of two lists of columns in same order as the first list
 func (this *Inspector) getSharedColumns(originalColumns, ghostColumns *sql.ColumnList, originalVirtualColumns, ghostVirtualColumns *sql.ColumnList, columnRenameMap map[string]string) (*sql.ColumnList, *sql.ColumnList) {
 	sharedColumnNames := []string{}
@@ -900,6 +1621,12 @@ func (this *Inspector) getSharedColumns(originalColumns, ghostColumns *sql.Colum
 				break
 			}
 		}
+		for ignoredColumn := range this.migrationContext.IgnoredColumnsMap {
+			if strings.EqualFold(originalColumn, ignoredColumn) {
+				isSharedColumn = false
+				break
+			}
+		}
 		for _, virtualColumn := range originalVirtualColumns.Names() {
 			if strings.EqualFold(originalColumn, virtualColumn) {
 				isSharedColumn = false
@@ -909,6 +1636,10 @@ func (this *Inspector) getSharedColumns(originalColumns, ghostColumns *sql.Colum
 			if strings.EqualFold(originalColumn, virtualColumn) {
 				isSharedColumn = false
 			}
+			// a column may be renamed onto a generated column, whose values MySQL computes
+			if strings.EqualFold(columnRenameMap[originalColumn], virtualColumn) {
+				isSharedColumn = false
+			}
 		}
 		if isSharedColumn {
 			sharedColumnNames = append(sharedColumnNames, originalColumn)
@@ -926,9 +1657,9 @@ func (this *Inspector) getSharedColumns(originalColumns, ghostColumns *sql.Colum
 }
 
 // showCreateTable returns the `show create table` statement for given table
-func (this *Inspector) showCreateTable(tableName string) (createTableStatement string, err error) {
+func (this *Inspector) showCreateTable(databaseName, tableName string) (createTableStatement string, err error) {
 	var dummy string
-	query := fmt.Sprintf(`show /* gh-ost */ create table %s.%s`, sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(tableName))
+	query := fmt.Sprintf(`show /* gh-ost */ create table %s.%s`, sql.EscapeName(databaseName), sql.EscapeName(tableName))
 	err = this.db.QueryRow(query).Scan(&dummy, &createTableStatement)
 	return createTableStatement, err
 }
@@ -941,7 +1672,7 @@ func (this *Inspector) readChangelogState(hint string) (string, error) {
 			%s.%s
 		where
 			hint = ? and id <= 255`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
 	)
 	result := ""
@@ -953,11 +1684,50 @@ func (this *Inspector) readChangelogState(hint string) (string, error) {
 }
 
 func (this *Inspector) getMasterConnectionConfig() (applierConfig *mysql.ConnectionConfig, err error) {
-	this.migrationContext.Log.Infof("Recursively searching for replication master")
+	this.log.Infof("Recursively searching for replication master")
 	visitedKeys := mysql.NewInstanceKeyMap()
 	return mysql.GetMasterConnectionConfigSafe(this.dbVersion, this.connectionConfig, visitedKeys, this.migrationContext.AllowedMasterMaster)
 }
 
+// readGTIDExecuted returns the set of GTIDs executed on the inspected server
+func (this *Inspector) readGTIDExecuted() (*mysql.GTIDBinlogCoordinates, error) {
+	var gtidExecuted string
+	if err := this.db.QueryRow(`select /* gh-ost */ @@global.gtid_executed`).Scan(&gtidExecuted); err != nil {
+		return nil, err
+	}
+	return mysql.NewGTIDBinlogCoordinates(gtidExecuted)
+}
+
+// readChangelogValue returns the value of given hint in the changelog table on the inspected server.
+// found is false when the hint, or the changelog table itself, has not replicated yet.
+func (this *Inspector) readChangelogValue(hint string) (value string, found bool, err error) {
+	query := fmt.Sprintf(`select /* gh-ost */ value from %s.%s where hint = ?`,
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
+		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
+	)
+	if err := this.db.QueryRow(query, hint).Scan(&value); err != nil {
+		if errors.Is(err, gosql.ErrNoRows) || mysql.IsNoSuchTableError(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// isReplicaServerIdRegistered returns true when a replica is registered on the binary log server with
+// --replica-server-id, as gh-ost's binlog streamer is. A killed migration may leave behind a dangling
+// dump thread, which remains registered until the server notices the connection is gone.
+func (this *Inspector) isReplicaServerIdRegistered() (registered bool, err error) {
+	query := fmt.Sprintf("show /* gh-ost */ %s", mysql.ReplicaTermFor(this.dbVersion, `slave hosts`))
+	err = sqlutils.QueryRowsMap(this.binlogDb, query, func(rowMap sqlutils.RowMap) error {
+		if rowMap.GetUint("Server_id") == this.migrationContext.ReplicaServerId {
+			registered = true
+		}
+		return nil
+	})
+	return registered, err
+}
+
 func (this *Inspector) getReplicationLag() (replicationLag time.Duration, err error) {
 	replicationLag, err = mysql.GetReplicationLagFromSlaveStatus(
 		this.dbVersion,
@@ -969,4 +1739,7 @@ func (this *Inspector) getReplicationLag() (replicationLag time.Duration, err er
 func (this *Inspector) Teardown() {
 	this.db.Close()
 	this.informationSchemaDb.Close()
+	if this.binlogDb != nil && this.binlogDb != this.db {
+		this.binlogDb.Close()
+	}
 }
@@ -6,8 +6,12 @@
 package logic
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"testing"
 
+	"github.com/github/gh-ost/go/base"
+	"github.com/github/gh-ost/go/mysql"
 	"github.com/github/gh-ost/go/sql"
 	"github.com/stretchr/testify/require"
 )
@@ -30,3 +34,366 @@ func TestInspectGetSharedUniqueKeys(t *testing.T) {
 	require.Equal(t, "id,org_id", sharedUniqKeys[1].Columns.String())
 	require.Equal(t, "id", sharedUniqKeys[2].Columns.String())
 }
+
+func TestInspectGetSharedUniqueKeysSkipsFunctionalGhostKeys(t *testing.T) {
+	origUniqKeys := []*sql.UniqueKey{
+		{Name: "a_uidx", Columns: *sql.NewColumnList([]string{"a"})},
+	}
+	ghostUniqKeys := []*sql.UniqueKey{
+		// UNIQUE KEY (a, (lower(b))): listed by its column a alone
+		{Name: "a_lower_b_uidx", Columns: *sql.NewColumnList([]string{"a"}), HasFunctionalPart: true},
+		{Name: "a_uidx", Columns: *sql.NewColumnList([]string{"a"})},
+	}
+	inspector := &Inspector{}
+	sharedUniqKeys := inspector.getSharedUniqueKeys(origUniqKeys, ghostUniqKeys)
+	require.Len(t, sharedUniqKeys, 1)
+	require.Equal(t, "a_uidx", sharedUniqKeys[0].NameInGhostTable)
+
+	sharedUniqKeys = inspector.getSharedUniqueKeys(origUniqKeys, ghostUniqKeys[:1])
+	require.Empty(t, sharedUniqKeys)
+}
+
+func TestInspectPartialUniqueKeyRefusal(t *testing.T) {
+	require.Empty(t, partialUniqueKeyRefusal(&sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"id"})}))
+
+	// UNIQUE KEY (email(20))
+	prefixUniqueKey := &sql.UniqueKey{Name: "email_uidx", Columns: *sql.NewColumnList([]string{"email"}), HasPrefixPart: true}
+	require.Contains(t, partialUniqueKeyRefusal(prefixUniqueKey), "column prefixes")
+
+	// UNIQUE KEY ((lower(email)))
+	functionalUniqueKey := &sql.UniqueKey{Name: "lower_email_uidx", Columns: *sql.NewColumnList([]string{}), HasFunctionalPart: true}
+	require.Contains(t, partialUniqueKeyRefusal(functionalUniqueKey), "functional key parts")
+}
+
+func TestInspectGetSharedColumnsExcludesGeneratedColumns(t *testing.T) {
+	inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+	originalColumns := sql.NewColumnList([]string{"id", "a", "b", "sum_ab", "c"})
+	originalVirtualColumns := sql.NewColumnList([]string{"sum_ab"})
+	t.Run("virtual", func(t *testing.T) {
+		ghostColumns := sql.NewColumnList([]string{"id", "a", "b", "sum_ab", "c"})
+		ghostVirtualColumns := sql.NewColumnList([]string{"sum_ab"})
+		sharedColumns, mappedSharedColumns := inspector.getSharedColumns(originalColumns, ghostColumns, originalVirtualColumns, ghostVirtualColumns, map[string]string{})
+		require.Equal(t, []string{"id", "a", "b", "c"}, sharedColumns.Names())
+		require.Equal(t, []string{"id", "a", "b", "c"}, mappedSharedColumns.Names())
+	})
+	t.Run("added-stored", func(t *testing.T) {
+		ghostColumns := sql.NewColumnList([]string{"id", "a", "b", "sum_ab", "c", "diff_ab"})
+		ghostVirtualColumns := sql.NewColumnList([]string{"sum_ab", "diff_ab"})
+		sharedColumns, _ := inspector.getSharedColumns(originalColumns, ghostColumns, originalVirtualColumns, ghostVirtualColumns, map[string]string{})
+		require.Equal(t, []string{"id", "a", "b", "c"}, sharedColumns.Names())
+	})
+	t.Run("renamed-onto-generated", func(t *testing.T) {
+		ghostColumns := sql.NewColumnList([]string{"id", "a", "b", "sum_ab", "total"})
+		ghostVirtualColumns := sql.NewColumnList([]string{"sum_ab", "total"})
+		sharedColumns, mappedSharedColumns := inspector.getSharedColumns(originalColumns, ghostColumns, originalVirtualColumns, ghostVirtualColumns, map[string]string{"c": "total"})
+		require.Equal(t, []string{"id", "a", "b"}, sharedColumns.Names())
+		require.Equal(t, []string{"id", "a", "b"}, mappedSharedColumns.Names())
+	})
+}
+
+func TestInspectGetSharedColumnsExcludesIgnoredColumns(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.ReadIgnoredColumns("Scratch, notes")
+	inspector := &Inspector{migrationContext: migrationContext}
+	originalColumns := sql.NewColumnList([]string{"id", "scratch", "name", "notes"})
+	ghostColumns := sql.NewColumnList([]string{"id", "scratch", "name", "notes"})
+	emptyColumns := sql.NewColumnList([]string{})
+	sharedColumns, mappedSharedColumns := inspector.getSharedColumns(originalColumns, ghostColumns, emptyColumns, emptyColumns, map[string]string{})
+	require.Equal(t, []string{"id", "name"}, sharedColumns.Names())
+	require.Equal(t, []string{"id", "name"}, mappedSharedColumns.Names())
+}
+
+func TestInspectElectUniqueKey(t *testing.T) {
+	newUniqueKey := func(name string, columnTypes map[string]string, columnNames ...string) *sql.UniqueKey {
+		uniqueKey := &sql.UniqueKey{Name: name, Columns: *sql.NewColumnList(columnNames)}
+		for columnName, columnType := range columnTypes {
+			column := uniqueKey.Columns.GetColumn(columnName)
+			column.MySQLType = columnType
+			var length uint
+			if _, err := fmt.Sscanf(columnType, "char(%d)", &length); err == nil {
+				column.OctetLength = length * 4
+			} else if _, err := fmt.Sscanf(columnType, "varchar(%d)", &length); err == nil {
+				column.OctetLength = length * 4
+			}
+		}
+		return uniqueKey
+	}
+	uuidPrimaryKey := newUniqueKey("PRIMARY", map[string]string{"uuid": "char(36)"}, "uuid")
+	seqUniqueKey := newUniqueKey("seq_uidx", map[string]string{"seq": "bigint unsigned"}, "seq")
+	compositeUniqueKey := newUniqueKey("a_b_uidx", map[string]string{"a": "int", "b": "int"}, "a", "b")
+	nullableUniqueKey := newUniqueKey("n_uidx", map[string]string{"n": "int"}, "n")
+	nullableUniqueKey.HasNullable = true
+
+	t.Run("sequential-over-uuid-primary", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		autoIncrementUniqueKey := newUniqueKey("seq_uidx", map[string]string{"seq": "bigint unsigned"}, "seq")
+		autoIncrementUniqueKey.IsAutoIncrement = true
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{compositeUniqueKey, uuidPrimaryKey, autoIncrementUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "seq_uidx", uniqueKey.Name)
+		require.Equal(t, "elected out of 3 candidate(s): non-nullable, integer, auto_increment, 8 byte(s)", reason)
+
+		uniqueKey, _, err = inspector.electUniqueKey([]*sql.UniqueKey{uuidPrimaryKey, seqUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "seq_uidx", uniqueKey.Name)
+	})
+	t.Run("integer-primary-first", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		idPrimaryKey := newUniqueKey("PRIMARY", map[string]string{"id": "int"}, "id")
+		autoIncrementUniqueKey := newUniqueKey("seq_uidx", map[string]string{"seq": "bigint unsigned"}, "seq")
+		autoIncrementUniqueKey.IsAutoIncrement = true
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{autoIncrementUniqueKey, compositeUniqueKey, idPrimaryKey})
+		require.NoError(t, err)
+		require.Equal(t, "PRIMARY", uniqueKey.Name)
+		require.Equal(t, "elected out of 3 candidate(s): non-nullable, integer, primary, 4 byte(s)", reason)
+	})
+	t.Run("integer-first", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		textUniqueKey := newUniqueKey("code_uidx", map[string]string{"code": "varchar(16)"}, "code")
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{textUniqueKey, compositeUniqueKey, seqUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "a_b_uidx", uniqueKey.Name)
+		require.Equal(t, "elected out of 3 candidate(s): non-nullable, integer, 8 byte(s)", reason)
+	})
+	t.Run("shorter-over-fewer-columns", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		textUniqueKey := newUniqueKey("code_uidx", map[string]string{"code": "varchar(16)"}, "code")
+		dateUniqueKey := newUniqueKey("day_slot_uidx", map[string]string{"day": "date", "slot": "smallint"}, "day", "slot")
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{textUniqueKey, dateUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "day_slot_uidx", uniqueKey.Name)
+		require.Equal(t, "elected out of 2 candidate(s): non-nullable, 5 byte(s)", reason)
+	})
+	t.Run("non-nullable-first", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		uniqueKey, _, err := inspector.electUniqueKey([]*sql.UniqueKey{nullableUniqueKey, uuidPrimaryKey})
+		require.NoError(t, err)
+		require.Equal(t, "PRIMARY", uniqueKey.Name)
+	})
+	t.Run("unmodified-by-alter", func(t *testing.T) {
+		inspector := NewInspector(base.NewMigrationContext())
+		inspector.migrationContext.ModifiedColumnsMap = map[string]bool{"SEQ": true, "uuid": true}
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{seqUniqueKey, compositeUniqueKey, uuidPrimaryKey})
+		require.NoError(t, err)
+		require.Equal(t, "a_b_uidx", uniqueKey.Name)
+		require.Equal(t, "elected out of 3 candidate(s): non-nullable, unmodified by ALTER, integer, 8 byte(s)", reason)
+	})
+	t.Run("all-modified-by-alter", func(t *testing.T) {
+		inspector := NewInspector(base.NewMigrationContext())
+		inspector.migrationContext.ModifiedColumnsMap = map[string]bool{"seq": true, "uuid": true}
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{uuidPrimaryKey, seqUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "seq_uidx", uniqueKey.Name)
+		require.Equal(t, "elected out of 2 candidate(s): non-nullable, integer, 8 byte(s)", reason)
+	})
+	t.Run("non-nullable-over-unmodified", func(t *testing.T) {
+		inspector := NewInspector(base.NewMigrationContext())
+		inspector.migrationContext.ModifiedColumnsMap = map[string]bool{"uuid": true}
+		uniqueKey, _, err := inspector.electUniqueKey([]*sql.UniqueKey{nullableUniqueKey, uuidPrimaryKey})
+		require.NoError(t, err)
+		require.Equal(t, "PRIMARY", uniqueKey.Name)
+	})
+	t.Run("no-candidates", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		uniqueKey, _, err := inspector.electUniqueKey([]*sql.UniqueKey{})
+		require.NoError(t, err)
+		require.Nil(t, uniqueKey)
+	})
+	t.Run("forced", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		inspector.migrationContext.ChunkIndex = "a_b_UIDX"
+		uniqueKey, reason, err := inspector.electUniqueKey([]*sql.UniqueKey{uuidPrimaryKey, compositeUniqueKey, seqUniqueKey})
+		require.NoError(t, err)
+		require.Equal(t, "a_b_uidx", uniqueKey.Name)
+		require.Equal(t, "forced via --chunk-index", reason)
+	})
+	t.Run("forced-nullable", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		inspector.migrationContext.ChunkIndex = "n_uidx"
+		_, _, err := inspector.electUniqueKey([]*sql.UniqueKey{uuidPrimaryKey, nullableUniqueKey})
+		require.Error(t, err)
+	})
+	t.Run("forced-missing", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext()}
+		inspector.migrationContext.ChunkIndex = "no_such_idx"
+		_, _, err := inspector.electUniqueKey([]*sql.UniqueKey{uuidPrimaryKey})
+		require.Error(t, err)
+	})
+}
+
+func TestInspectParseGlobalPrivileges(t *testing.T) {
+	require.Equal(t, []string{"ALL PRIVILEGES"}, parseGlobalPrivileges("GRANT ALL PRIVILEGES ON *.* TO `root`@`%` WITH GRANT OPTION"))
+	require.Equal(t, []string{"REPLICATION SLAVE", "REPLICATION CLIENT"}, parseGlobalPrivileges("GRANT REPLICATION SLAVE, REPLICATION CLIENT ON *.* TO `gh-ost`@`%`"))
+	require.Equal(t, []string{"REPLICATION_SLAVE_ADMIN", "SYSTEM_VARIABLES_ADMIN"}, parseGlobalPrivileges("GRANT REPLICATION_SLAVE_ADMIN,SYSTEM_VARIABLES_ADMIN ON *.* TO `gh-ost`@`%`"))
+	require.Empty(t, parseGlobalPrivileges("GRANT ALL PRIVILEGES ON `test`.* TO `gh-ost`@`%`"))
+	require.Empty(t, parseGlobalPrivileges("GRANT `rds_superuser_role`@`%` TO `gh-ost`@`%`"))
+}
+
+func TestInspectValidatePrivilegedOperations(t *testing.T) {
+	t.Run("all-privileges", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext(), globalPrivileges: map[string]bool{"ALL PRIVILEGES": true}}
+		require.True(t, inspector.hasGlobalPrivilege("SUPER"))
+	})
+	t.Run("dynamic-privileges", func(t *testing.T) {
+		inspector := &Inspector{migrationContext: base.NewMigrationContext(), globalPrivileges: map[string]bool{"SYSTEM_VARIABLES_ADMIN": true}}
+		require.True(t, inspector.hasGlobalPrivilege("SUPER", "SYSTEM_VARIABLES_ADMIN"))
+		require.False(t, inspector.hasGlobalPrivilege("SUPER", "REPLICATION_SLAVE_ADMIN"))
+	})
+	t.Run("switch-to-rbr", func(t *testing.T) {
+		inspector := &Inspector{
+			connectionConfig: mysql.NewConnectionConfig(),
+			migrationContext: base.NewMigrationContext(),
+			globalPrivileges: map[string]bool{"REPLICATION SLAVE": true, "REPLICATION CLIENT": true},
+		}
+		inspector.migrationContext.OriginalBinlogFormat = "STATEMENT"
+		inspector.migrationContext.SwitchToRowBinlogFormat = true
+		inspector.migrationContext.AssumeRBR = true
+		err := inspector.validatePrivilegedOperations()
+		require.ErrorContains(t, err, "SUPER or SYSTEM_VARIABLES_ADMIN, to set global binlog_format='ROW' (--switch-to-rbr)")
+	})
+	t.Run("assume-rbr", func(t *testing.T) {
+		inspector := &Inspector{
+			connectionConfig: mysql.NewConnectionConfig(),
+			migrationContext: base.NewMigrationContext(),
+			globalPrivileges: map[string]bool{"REPLICATION SLAVE": true, "REPLICATION CLIENT": true},
+		}
+		inspector.migrationContext.OriginalBinlogFormat = "ROW"
+		inspector.migrationContext.AssumeRBR = true
+		require.NoError(t, inspector.validatePrivilegedOperations())
+	})
+}
+
+func TestInspectValidateProxyProbe(t *testing.T) {
+	probeQuery := "select /* hostgroup=10 */ @@global.binlog_format"
+	newInspector := func(fake *fakeMySQL) *Inspector {
+		migrationContext := base.NewMigrationContext()
+		migrationContext.ProxyCompat = true
+		migrationContext.ProxyProbeQuery = probeQuery
+		inspector := NewInspector(migrationContext)
+		inspector.db = fake.DB()
+		return inspector
+	}
+
+	t.Run("row", func(t *testing.T) {
+		fake := newFakeMySQL()
+		fake.expect(`^select /\* hostgroup=10 \*/ @@global.binlog_format$`).returnRows([]string{"binlog_format"}, []driver.Value{"ROW"})
+		require.NoError(t, newInspector(fake).validateProxyProbe())
+		require.Equal(t, 1, fake.countQueries(`hostgroup=10`))
+	})
+	t.Run("statement", func(t *testing.T) {
+		fake := newFakeMySQL()
+		fake.expect(`^select /\* hostgroup=10 \*/ @@global.binlog_format$`).returnRows([]string{"binlog_format"}, []driver.Value{"STATEMENT"})
+		require.ErrorContains(t, newInspector(fake).validateProxyProbe(), "reports STATEMENT binlog_format")
+	})
+	t.Run("no rows", func(t *testing.T) {
+		fake := newFakeMySQL()
+		require.ErrorContains(t, newInspector(fake).validateProxyProbe(), "Running --proxy-probe-query")
+	})
+	t.Run("not given", func(t *testing.T) {
+		fake := newFakeMySQL()
+		inspector := newInspector(fake)
+		inspector.migrationContext.ProxyProbeQuery = ""
+		require.NoError(t, inspector.validateProxyProbe())
+		require.Equal(t, 0, fake.countQueries(`binlog_format`))
+	})
+}
+
+func TestInspectValidateApplierParallelism(t *testing.T) {
+	newInspector := func(uniqueKeyColumns *sql.ColumnList) *Inspector {
+		migrationContext := base.NewMigrationContext()
+		migrationContext.SetApplierParallelism(8)
+		migrationContext.UniqueKey = &sql.UniqueKey{Name: "PRIMARY", Columns: *uniqueKeyColumns}
+		return NewInspector(migrationContext)
+	}
+
+	inspector := newInspector(sql.NewColumnList([]string{"id", "created_at"}))
+	inspector.migrationContext.GhostTableUniqueKeys = []*sql.UniqueKey{inspector.migrationContext.UniqueKey}
+	inspector.validateApplierParallelism()
+	require.Equal(t, int64(8), inspector.migrationContext.GetApplierParallelism())
+	require.Equal(t, "", inspector.migrationContext.GetApplierParallelismRestriction())
+
+	inspector.migrationContext.GhostTableUniqueKeys = append(inspector.migrationContext.GhostTableUniqueKeys, &sql.UniqueKey{Name: "email_uidx"})
+	inspector.validateApplierParallelism()
+	require.Equal(t, int64(1), inspector.migrationContext.GetApplierParallelism())
+	require.Equal(t, "ghost table has 2 unique keys", inspector.migrationContext.GetApplierParallelismRestriction())
+
+	textualColumns := sql.NewColumnList([]string{"id", "name"})
+	textualColumns.GetColumn("name").Charset = "utf8mb4"
+	inspector = newInspector(textualColumns)
+	inspector.validateApplierParallelism()
+	require.Equal(t, int64(1), inspector.migrationContext.GetApplierParallelism())
+	require.Contains(t, inspector.migrationContext.GetApplierParallelismRestriction(), "textual column `name`")
+
+	// Restricted even when starting with a single worker, so that it may not be raised at runtime
+	inspector = newInspector(textualColumns)
+	inspector.migrationContext.SetApplierParallelism(1)
+	inspector.validateApplierParallelism()
+	require.NotEqual(t, "", inspector.migrationContext.GetApplierParallelismRestriction())
+}
+
+func TestInspectValidateUniqueKeyTypeChanges(t *testing.T) {
+	newInspector := func(originalType, ghostType *sql.Column) *Inspector {
+		migrationContext := base.NewMigrationContext()
+		migrationContext.SharedColumns = sql.NewColumnList([]string{"id", "name"})
+		migrationContext.MappedSharedColumns = sql.NewColumnList([]string{"id", "name"})
+		migrationContext.UniqueKey = &sql.UniqueKey{Name: "name_uidx", Columns: *sql.NewColumnList([]string{"name"})}
+		for _, column := range []*sql.Column{migrationContext.SharedColumns.GetColumn("name"), migrationContext.UniqueKey.Columns.GetColumn("name")} {
+			column.MySQLType, column.Charset, column.Collation = originalType.MySQLType, originalType.Charset, originalType.Collation
+		}
+		column := migrationContext.MappedSharedColumns.GetColumn("name")
+		column.MySQLType, column.Charset, column.Collation = ghostType.MySQLType, ghostType.Charset, ghostType.Collation
+		return NewInspector(migrationContext)
+	}
+	buildDeleteQuery := func(inspector *Inspector) string {
+		builder, err := sql.NewDMLDeleteQueryBuilder("test", "_tbl_gho", inspector.migrationContext.SharedColumns, &inspector.migrationContext.UniqueKey.Columns)
+		require.NoError(t, err)
+		query, _, err := builder.BuildQuery([]interface{}{1, "name"})
+		require.NoError(t, err)
+		return query
+	}
+
+	utf8mb4Column := &sql.Column{MySQLType: "varchar(64)", Charset: "utf8mb4", Collation: "utf8mb4_general_ci"}
+
+	inspector := newInspector(utf8mb4Column, utf8mb4Column)
+	require.NoError(t, inspector.validateUniqueKeyTypeChanges())
+	require.Contains(t, buildDeleteQuery(inspector), "(`name` = ?)")
+
+	inspector = newInspector(&sql.Column{MySQLType: "varchar(64)", Charset: "latin1", Collation: "latin1_swedish_ci"}, &sql.Column{MySQLType: "varchar(191)", Charset: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"})
+	require.NoError(t, inspector.validateUniqueKeyTypeChanges())
+	require.Contains(t, buildDeleteQuery(inspector), "(`name` = cast(? as char character set utf8mb4) collate utf8mb4_0900_ai_ci)")
+
+	inspector = newInspector(&sql.Column{MySQLType: "int"}, &sql.Column{MySQLType: "bigint"})
+	require.NoError(t, inspector.validateUniqueKeyTypeChanges())
+	require.Contains(t, buildDeleteQuery(inspector), "(`name` = cast(? as signed))")
+
+	inspector = newInspector(utf8mb4Column, &sql.Column{MySQLType: "varchar(64)", Charset: "utf8mb4", Collation: "utf8mb4_bin"})
+	require.ErrorContains(t, inspector.validateUniqueKeyTypeChanges(), "Use --chunk-index")
+
+	inspector = newInspector(&sql.Column{MySQLType: "varbinary(64)"}, utf8mb4Column)
+	require.ErrorContains(t, inspector.validateUniqueKeyTypeChanges(), "Use --chunk-index")
+}
+
+func TestInspectValidateGhostAddedPrimaryKey(t *testing.T) {
+	newInspector := func(ghostUniqueKeys ...*sql.UniqueKey) *Inspector {
+		migrationContext := base.NewMigrationContext()
+		migrationContext.OriginalTableColumns = sql.NewColumnList([]string{"name", "position"})
+		migrationContext.GhostTableUniqueKeys = ghostUniqueKeys
+		return NewInspector(migrationContext)
+	}
+	fullRowIndex := &sql.UniqueKey{Name: sql.FullRowUniqueKeyName, Columns: *sql.NewColumnList([]string{"name", "position"})}
+
+	inspector := newInspector(&sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"id"}), IsAutoIncrement: true}, fullRowIndex)
+	require.NoError(t, inspector.validateGhostAddedPrimaryKey())
+
+	inspector = newInspector(fullRowIndex)
+	require.ErrorContains(t, inspector.validateGhostAddedPrimaryKey(), "must add a PRIMARY KEY on a new AUTO_INCREMENT column")
+
+	inspector = newInspector(&sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"id"})}, fullRowIndex)
+	require.Error(t, inspector.validateGhostAddedPrimaryKey())
+
+	inspector = newInspector(&sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"id", "name"}), IsAutoIncrement: true}, fullRowIndex)
+	require.Error(t, inspector.validateGhostAddedPrimaryKey())
+
+	inspector = newInspector(&sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"position"}), IsAutoIncrement: true}, fullRowIndex)
+	require.Error(t, inspector.validateGhostAddedPrimaryKey())
+}
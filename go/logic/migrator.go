@@ -7,12 +7,18 @@ package logic
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -24,9 +30,30 @@ import (
 
 var (
 	ErrMigratorUnsupportedRenameAlter = errors.New("ALTER statement seems to RENAME the table. This is not supported, and you should run your RENAME outside gh-ost.")
+	ErrMigrationStalled               = errors.New("migration stalled")
+	ErrMaxRuntimeExceeded             = errors.New("migration exceeded --max-runtime")
+	ErrTerminated                     = errors.New("migration terminated by SIGTERM")
+	ErrRowCountMismatch               = errors.New("row count mismatch between original and ghost tables")
 	ErrMigrationNotAllowedOnMaster    = errors.New("It seems like this migration attempt to run directly on master. Preferably it would be executed on a replica (this reduces load from the master). To proceed please provide --allow-on-master.")
 	RetrySleepFn                      = time.Sleep
 	checkpointTimeout                 = 2 * time.Second
+	backpressureInterval              = 250 * time.Millisecond
+	assumedMasterProbeTimeout         = time.Minute
+	// cleanupHeartbeatStaleThreshold is how long the changelog table must go without heartbeats for --cleanup to consider it abandoned
+	cleanupHeartbeatStaleThreshold = time.Minute
+)
+
+const (
+	// ExitCodeMaxRuntimeExceeded is gh-ost's exit code when aborting a migration that exceeded --max-runtime
+	ExitCodeMaxRuntimeExceeded = 3
+	// ExitCodeTerminated is gh-ost's exit code when aborting a migration upon SIGTERM, as of a process killed by it
+	ExitCodeTerminated = 143
+	// terminateTimeout is how long Terminate() waits for a migration to take the abort, before exiting right away
+	terminateTimeout = time.Second
+
+	bytesPerMB = 1024 * 1024
+	// backpressureReleaseRatio is the fraction of a memory ceiling below which a paused binlog reader resumes
+	backpressureReleaseRatio = 0.8
 )
 
 type ChangelogState string
@@ -53,6 +80,7 @@ type applyEventStruct struct {
 	writeFunc *tableWriteFunc
 	dmlEvent  *binlog.BinlogDMLEvent
 	coords    mysql.BinlogCoordinates
+	size      int64
 }
 
 func newApplyEventStructByFunc(writeFunc *tableWriteFunc) *applyEventStruct {
@@ -61,7 +89,7 @@ func newApplyEventStructByFunc(writeFunc *tableWriteFunc) *applyEventStruct {
 }
 
 func newApplyEventStructByDML(dmlEntry *binlog.BinlogEntry) *applyEventStruct {
-	result := &applyEventStruct{dmlEvent: dmlEntry.DmlEvent, coords: dmlEntry.Coordinates}
+	result := &applyEventStruct{dmlEvent: dmlEntry.DmlEvent, coords: dmlEntry.Coordinates, size: dmlEntry.Size}
 	return result
 }
 
@@ -86,12 +114,15 @@ type Migrator struct {
 	throttler        *Throttler
 	hooksExecutor    *HooksExecutor
 	migrationContext *base.MigrationContext
+	log              base.Logger
 
 	firstThrottlingCollected   chan bool
 	ghostTableMigrated         chan bool
 	rowCopyComplete            chan error
 	allEventsUpToLockProcessed chan *lockProcessedStruct
 	lastLockProcessed          *lockProcessedStruct
+	// replicationMasterKey is the master of the replica migrated on with --migrate-on-replica
+	replicationMasterKey *mysql.InstanceKey
 
 	rowCopyCompleteFlag int64
 	// copyRowsQueue should not be buffered; if buffered some non-damaging but
@@ -100,6 +131,17 @@ type Migrator struct {
 	applyEventsQueue chan *applyEventStruct
 
 	finishedMigrating int64
+	stalledFlag       int64
+	// statusAvailableFlag is set once the components the status reports on are initiated
+	statusAvailableFlag int64
+	// maxRuntimeExceededFlag is set once --max-runtime is reported exceeded, until the deadline is extended
+	maxRuntimeExceededFlag int64
+	// coordinationId is this migration's registration id on the coordination table, if registered
+	coordinationId int64
+	// reportOnce has --report-file written once, by whichever exit path comes first
+	reportOnce sync.Once
+	// observation is what --observe has watched so far
+	observation *observation
 }
 
 func NewMigrator(context *base.MigrationContext, appVersion string) *Migrator {
@@ -107,8 +149,9 @@ func NewMigrator(context *base.MigrationContext, appVersion string) *Migrator {
 		appVersion:                 appVersion,
 		hooksExecutor:              NewHooksExecutor(context),
 		migrationContext:           context,
+		log:                        context.NewComponentLogger(base.MigratorLogComponent),
 		parser:                     sql.NewAlterTableParser(),
-		ghostTableMigrated:         make(chan bool),
+		ghostTableMigrated:         make(chan bool, 1),
 		firstThrottlingCollected:   make(chan bool, 3),
 		rowCopyComplete:            make(chan error),
 		allEventsUpToLockProcessed: make(chan *lockProcessedStruct),
@@ -143,6 +186,7 @@ func (this *Migrator) retryOperation(operation func() error, notFatalHint ...boo
 		if i != 0 {
 			// sleep after previous iteration
 			RetrySleepFn(1 * time.Second)
+			this.migrationContext.Stats.MarkRetry()
 		}
 		err = operation()
 		if err == nil {
@@ -172,6 +216,7 @@ func (this *Migrator) retryOperationWithExponentialBackoff(operation func() erro
 
 		if i != 0 {
 			RetrySleepFn(time.Duration(interval) * time.Second)
+			this.migrationContext.Stats.MarkRetry()
 		}
 		err = operation()
 		if err == nil {
@@ -190,6 +235,7 @@ func (this *Migrator) consumeRowCopyComplete() {
 	if err := <-this.rowCopyComplete; err != nil {
 		this.migrationContext.PanicAbort <- err
 	}
+	this.migrationContext.RowCopyCompleteBinlogCoordinates = this.eventsStreamer.GetCurrentBinlogCoordinates()
 	atomic.StoreInt64(&this.rowCopyCompleteFlag, 1)
 	this.migrationContext.MarkRowCopyEndTime()
 	go func() {
@@ -207,6 +253,10 @@ func (this *Migrator) canStopStreaming() bool {
 
 // onChangelogEvent is called when a binlog event operation on the changelog table is intercepted.
 func (this *Migrator) onChangelogEvent(dmlEntry *binlog.BinlogEntry) (err error) {
+	if dmlEntry.DmlEvent.DML == binlog.DeleteDML {
+		// pruning of old changelog entries
+		return nil
+	}
 	// Hey, I created the changelog table, I know the type of columns it has!
 	switch hint := dmlEntry.DmlEvent.NewColumnValues.StringColumn(2); hint {
 	case "state":
@@ -221,12 +271,16 @@ func (this *Migrator) onChangelogEvent(dmlEntry *binlog.BinlogEntry) (err error)
 func (this *Migrator) onChangelogStateEvent(dmlEntry *binlog.BinlogEntry) (err error) {
 	changelogStateString := dmlEntry.DmlEvent.NewColumnValues.StringColumn(3)
 	changelogState := ReadChangelogState(changelogStateString)
-	this.migrationContext.Log.Infof("Intercepted changelog state %s", changelogState)
+	this.log.Infof("Intercepted changelog state %s", changelogState)
 	switch changelogState {
 	case Migrated, ReadMigrationRangeValues:
 		// no-op event
 	case GhostTableMigrated:
-		this.ghostTableMigrated <- true
+		// Intercepted again should the changelog table be recreated with its state; only awaited once
+		select {
+		case this.ghostTableMigrated <- true:
+		default:
+		}
 	case AllEventsUpToLockProcessed:
 		var applyEventFunc tableWriteFunc = func() error {
 			this.allEventsUpToLockProcessed <- &lockProcessedStruct{
@@ -246,18 +300,23 @@ func (this *Migrator) onChangelogStateEvent(dmlEntry *binlog.BinlogEntry) (err e
 	default:
 		return fmt.Errorf("Unknown changelog state: %+v", changelogState)
 	}
-	this.migrationContext.Log.Infof("Handled changelog state %s", changelogState)
+	this.log.Infof("Handled changelog state %s", changelogState)
 	return nil
 }
 
 func (this *Migrator) onChangelogHeartbeatEvent(dmlEntry *binlog.BinlogEntry) (err error) {
 	changelogHeartbeatString := dmlEntry.DmlEvent.NewColumnValues.StringColumn(3)
 
-	heartbeatTime, err := time.Parse(time.RFC3339Nano, changelogHeartbeatString)
+	heartbeatTime, sequence, err := parseChangelogHeartbeat(changelogHeartbeatString)
 	if err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	} else {
-		this.migrationContext.SetLastHeartbeatOnChangelogTime(heartbeatTime)
+		this.migrationContext.SetLastHeartbeatOnChangelogTime(this.migrationContext.GetHeartbeatInjectionTime(sequence, heartbeatTime))
+		if !dmlEntry.Timestamp.IsZero() {
+			// The heartbeat was written at heartbeatTime by gh-ost's clock, and is timestamped in the binary log
+			// by the server's clock, at second resolution
+			this.migrationContext.SetBinlogClockSkew(dmlEntry.Timestamp.Sub(heartbeatTime.Truncate(time.Second)))
+		}
 		this.applier.CurrentCoordinatesMutex.Lock()
 		this.applier.CurrentCoordinates = dmlEntry.Coordinates
 		this.applier.CurrentCoordinatesMutex.Unlock()
@@ -268,7 +327,67 @@ func (this *Migrator) onChangelogHeartbeatEvent(dmlEntry *binlog.BinlogEntry) (e
 // listenOnPanicAbort aborts on abort request
 func (this *Migrator) listenOnPanicAbort() {
 	err := <-this.migrationContext.PanicAbort
-	this.migrationContext.Log.Fatale(err)
+	switch {
+	case errors.Is(err, ErrMaxRuntimeExceeded):
+		this.cleanupOnAbort()
+		this.writeReport(reportOutcomeAborted, err)
+		this.log.Errore(err)
+		os.Exit(ExitCodeMaxRuntimeExceeded)
+	case errors.Is(err, ErrTerminated):
+		this.cleanupOnAbort()
+		this.writeReport(reportOutcomeAborted, err)
+		this.log.Errore(err)
+		os.Exit(ExitCodeTerminated)
+	}
+	this.writeReport(reportOutcomeAborted, err)
+	this.log.Fatale(err)
+}
+
+// Terminate aborts the migration upon SIGTERM, cleaning up as when exceeding --max-runtime. A cut-over in
+// progress holds table locks and is let to complete first. When there is no migration to take the abort,
+// e.g. with --cleanup, or on a repeated SIGTERM, gh-ost exits right away.
+func (this *Migrator) Terminate() {
+	for atomic.LoadInt64(&this.migrationContext.InCutOverCriticalSectionFlag) > 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if atomic.LoadInt64(&this.migrationContext.CutOverCompleteFlag) > 0 {
+		this.log.Infof("Received SIGTERM after cut-over; completing the migration")
+		return
+	}
+	select {
+	case this.migrationContext.PanicAbort <- ErrTerminated:
+	case <-time.After(terminateTimeout):
+		this.log.Errorf("%+v; exiting", ErrTerminated)
+		os.Exit(ExitCodeTerminated)
+	}
+}
+
+// PrintStatus prints the status to standard output, as the interactive commands do: ForcePrintStatusOnlyRule
+// as 'sup', ForcePrintStatusAndHintRule as 'status'. Until the components the status reports on are initiated,
+// there is no status to print.
+func (this *Migrator) PrintStatus(rule PrintStatusRule) {
+	if atomic.LoadInt64(&this.statusAvailableFlag) == 0 {
+		this.log.Infof("No status available yet")
+		return
+	}
+	this.printStatus(rule)
+}
+
+// cleanupOnAbort drops the ghost and changelog tables, so that a migration aborted for exceeding
+// --max-runtime, or upon SIGTERM, leaves nothing behind. With --checkpoint they are kept, for --resume.
+func (this *Migrator) cleanupOnAbort() {
+	if this.applier != nil && !this.migrationContext.Checkpoint && !this.migrationContext.Revert {
+		this.applier.StopHeartbeat()
+		if err := this.applier.DropGhostTable(); err != nil {
+			this.log.Errore(err)
+		}
+		if err := this.applier.DropChangelogTable(); err != nil {
+			this.log.Errore(err)
+		}
+	}
+	if err := this.hooksExecutor.onFailure(); err != nil {
+		this.log.Errore(err)
+	}
 }
 
 // validateAlterStatement validates the `alter` statement meets criteria.
@@ -284,9 +403,10 @@ func (this *Migrator) validateAlterStatement() (err error) {
 		if !this.migrationContext.ApproveRenamedColumns {
 			return fmt.Errorf("gh-ost believes the ALTER statement renames columns, as follows: %v; as precaution, you are asked to confirm gh-ost is correct, and provide with `--approve-renamed-columns`, and we're all happy. Or you can skip renamed columns via `--skip-renamed-columns`, in which case column data may be lost", this.parser.GetNonTrivialRenames())
 		}
-		this.migrationContext.Log.Infof("Alter statement has column(s) renamed. gh-ost finds the following renames: %v; --approve-renamed-columns is given and so migration proceeds.", this.parser.GetNonTrivialRenames())
+		this.log.Infof("Alter statement has column(s) renamed. gh-ost finds the following renames: %v; --approve-renamed-columns is given and so migration proceeds.", this.parser.GetNonTrivialRenames())
 	}
 	this.migrationContext.DroppedColumnsMap = this.parser.DroppedColumnsMap()
+	this.migrationContext.ModifiedColumnsMap = this.parser.ModifiedColumnsMap()
 	return nil
 }
 
@@ -296,7 +416,7 @@ func (this *Migrator) countTableRows() (err error) {
 		return nil
 	}
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really counting table rows")
+		this.log.Debugf("Noop operation; not really counting table rows")
 		return nil
 	}
 
@@ -315,7 +435,7 @@ func (this *Migrator) countTableRows() (err error) {
 		rowCountContext, rowCountCancel := context.WithCancel(context.Background())
 		this.migrationContext.SetCountTableRowsCancelFunc(rowCountCancel)
 
-		this.migrationContext.Log.Infof("As instructed, counting rows in the background; meanwhile I will use an estimated count, and will update it later on")
+		this.log.Infof("As instructed, counting rows in the background; meanwhile I will use an estimated count, and will update it later on")
 		go countRowsFunc(rowCountContext)
 
 		// and we ignore errors, because this turns to be a background job
@@ -328,9 +448,9 @@ func (this *Migrator) createFlagFiles() (err error) {
 	if this.migrationContext.PostponeCutOverFlagFile != "" {
 		if !base.FileExists(this.migrationContext.PostponeCutOverFlagFile) {
 			if err := base.TouchFile(this.migrationContext.PostponeCutOverFlagFile); err != nil {
-				return this.migrationContext.Log.Errorf("--postpone-cut-over-flag-file indicated by gh-ost is unable to create said file: %s", err.Error())
+				return this.log.Errorf("--postpone-cut-over-flag-file indicated by gh-ost is unable to create said file: %s", err.Error())
 			}
-			this.migrationContext.Log.Infof("Created postpone-cut-over-flag-file: %s", this.migrationContext.PostponeCutOverFlagFile)
+			this.log.Infof("Created postpone-cut-over-flag-file: %s", this.migrationContext.PostponeCutOverFlagFile)
 		}
 	}
 	return nil
@@ -338,7 +458,8 @@ func (this *Migrator) createFlagFiles() (err error) {
 
 // Migrate executes the complete migration logic. This is *the* major gh-ost function.
 func (this *Migrator) Migrate() (err error) {
-	this.migrationContext.Log.Infof("Migrating %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	defer this.reportPanic()
+	this.log.Infof("Migrating %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	this.migrationContext.StartTime = time.Now()
 	if this.migrationContext.Hostname, err = os.Hostname(); err != nil {
 		return err
@@ -363,6 +484,22 @@ func (this *Migrator) Migrate() (err error) {
 	if err := this.initiateInspector(); err != nil {
 		return err
 	}
+	// A migration queues for a slot ahead of streaming, or creating any table. The server is up
+	// meanwhile, for the 'queue' command and to report the migration as queued.
+	if err := this.connectApplier(); err != nil {
+		return err
+	}
+	if err := this.initiateServer(); err != nil {
+		return err
+	}
+	defer this.server.RemoveSocketFile()
+	defer this.server.ShutdownStatusHTTP()
+
+	defer this.deregisterMigration()
+	if err := this.waitForMigrationSlot(); err != nil {
+		return err
+	}
+
 	// If we are resuming, we will initiateStreaming later when we know
 	// the binlog coordinates to resume streaming from.
 	// If not resuming, the streamer must be initiated before the applier,
@@ -382,29 +519,55 @@ func (this *Migrator) Migrate() (err error) {
 	// Attempt to do this if AttemptInstantDDL is set.
 	if this.migrationContext.AttemptInstantDDL {
 		if this.migrationContext.Noop {
-			this.migrationContext.Log.Debugf("Noop operation; not really attempting instant DDL")
+			this.log.Debugf("Noop operation; not really attempting instant DDL")
 		} else {
-			this.migrationContext.Log.Infof("Attempting to execute alter with ALGORITHM=INSTANT")
+			this.log.Infof("Attempting to execute alter with ALGORITHM=INSTANT")
 			if err := this.applier.AttemptInstantDDL(); err == nil {
 				if err := this.finalCleanup(); err != nil {
 					return nil
 				}
+				this.reportSummary()
+				if err := this.hooksExecutor.onSuccess(); err != nil {
+					return err
+				}
+				this.log.Infof("Success! table %s.%s migrated instantly", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+				return nil
+			} else {
+				this.log.Infof("ALGORITHM=INSTANT not supported for this operation, proceeding with original algorithm: %s", err)
+			}
+		}
+	}
+	// Index-only ALTERs may be run in-place without blocking writes. Attempt to do this if AttemptInplaceIndexDDL is set.
+	if this.migrationContext.AttemptInplaceIndexDDL && this.parser.IsIndexOnly() {
+		if this.migrationContext.Noop {
+			this.log.Debugf("Noop operation; not really attempting in-place index DDL")
+		} else {
+			this.log.Infof("Attempting to execute index-only alter with ALGORITHM=INPLACE, LOCK=NONE")
+			if err := this.attemptInplaceIndexDDL(); err == nil {
+				if err := this.finalCleanup(); err != nil {
+					return nil
+				}
+				this.reportSummary()
 				if err := this.hooksExecutor.onSuccess(); err != nil {
 					return err
 				}
-				this.migrationContext.Log.Infof("Success! table %s.%s migrated instantly", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+				this.log.Infof("Success! table %s.%s migrated in-place", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 				return nil
+			} else if mysql.IsAlterRefusedError(err) {
+				this.log.Infof("ALGORITHM=INPLACE, LOCK=NONE not possible for this operation, proceeding with original algorithm: %s", err)
 			} else {
-				this.migrationContext.Log.Infof("ALGORITHM=INSTANT not supported for this operation, proceeding with original algorithm: %s", err)
+				// Other errors, e.g. duplicate entries for a new UNIQUE KEY, would fail (or worse, silently lose
+				// rows) with the original algorithm as well.
+				return this.log.Errorf("In-place index DDL failed: %+v", err)
 			}
 		}
 	}
 
 	initialLag, _ := this.inspector.getReplicationLag()
 	if !this.migrationContext.Resume {
-		this.migrationContext.Log.Infof("Waiting for ghost table to be migrated. Current lag is %+v", initialLag)
+		this.log.Infof("Waiting for ghost table to be migrated. Current lag is %+v", initialLag)
 		<-this.ghostTableMigrated
-		this.migrationContext.Log.Debugf("ghost table migrated")
+		this.log.Debugf("ghost table migrated")
 	}
 	// Yay! We now know the Ghost and Changelog tables are good to examine!
 	// When running on replica, this means the replica has those tables. When running
@@ -413,6 +576,9 @@ func (this *Migrator) Migrate() (err error) {
 	if err := this.inspector.inspectOriginalAndGhostTables(); err != nil {
 		return err
 	}
+	if err := this.inspector.validateNarrowedColumns(); err != nil {
+		return err
+	}
 
 	// We can prepare some of the queries on the applier
 	if err := this.applier.prepareQueries(); err != nil {
@@ -422,17 +588,20 @@ func (this *Migrator) Migrate() (err error) {
 	// inspectOriginalAndGhostTables must be called before creating checkpoint table.
 	if this.migrationContext.Checkpoint && !this.migrationContext.Resume {
 		if err := this.applier.CreateCheckpointTable(); err != nil {
-			this.migrationContext.Log.Errorf("Unable to create checkpoint table, see further error details.")
+			this.log.Errorf("Unable to create checkpoint table, see further error details.")
 		}
 	}
 
 	if this.migrationContext.Resume {
 		lastCheckpoint, err := this.applier.ReadLastCheckpoint()
 		if err != nil {
-			return this.migrationContext.Log.Errorf("No checkpoint found, unable to resume: %+v", err)
+			return this.log.Errorf("No checkpoint found, unable to resume: %+v", err)
+		}
+		this.log.Infof("Resuming from checkpoint coords=%+v range_min=%+v range_max=%+v iteration=%d partition_index=%d",
+			lastCheckpoint.LastTrxCoords, lastCheckpoint.IterationRangeMin.String(), lastCheckpoint.IterationRangeMax.String(), lastCheckpoint.Iteration, lastCheckpoint.IterationPartitionIndex)
+		if err := this.resumeIterationPartition(lastCheckpoint); err != nil {
+			return err
 		}
-		this.migrationContext.Log.Infof("Resuming from checkpoint coords=%+v range_min=%+v range_max=%+v iteration=%d",
-			lastCheckpoint.LastTrxCoords, lastCheckpoint.IterationRangeMin.String(), lastCheckpoint.IterationRangeMax.String(), lastCheckpoint.Iteration)
 
 		this.migrationContext.MigrationIterationRangeMinValues = lastCheckpoint.IterationRangeMin
 		this.migrationContext.MigrationIterationRangeMaxValues = lastCheckpoint.IterationRangeMax
@@ -449,11 +618,7 @@ func (this *Migrator) Migrate() (err error) {
 	if err := this.hooksExecutor.onValidated(); err != nil {
 		return err
 	}
-
-	if err := this.initiateServer(); err != nil {
-		return err
-	}
-	defer this.server.RemoveSocketFile()
+	atomic.StoreInt64(&this.statusAvailableFlag, 1)
 
 	if err := this.countTableRows(); err != nil {
 		return err
@@ -474,22 +639,26 @@ func (this *Migrator) Migrate() (err error) {
 	go this.iterateChunks()
 	this.migrationContext.MarkRowCopyStartTime()
 	go this.initiateStatus()
+	go this.initiateStallWatchdog()
+	go this.initiateBackpressureMonitor()
+	go this.initiateMaxRuntimeMonitor()
 	if this.migrationContext.Checkpoint {
 		go this.checkpointLoop()
 	}
 
-	this.migrationContext.Log.Debugf("Operating until row copy is complete")
+	this.log.Debugf("Operating until row copy is complete")
 	this.consumeRowCopyComplete()
-	this.migrationContext.Log.Infof("Row copy complete")
+	this.log.Infof("Row copy complete")
 	if err := this.hooksExecutor.onRowCopyComplete(); err != nil {
 		return err
 	}
 	this.printStatus(ForcePrintStatusRule)
 
 	if this.migrationContext.IsCountingTableRows() {
-		this.migrationContext.Log.Info("stopping query for exact row count, because that can accidentally lock out the cut over")
+		this.log.Info("stopping query for exact row count, because that can accidentally lock out the cut over")
 		this.migrationContext.CancelTableRowsCount()
 	}
+	this.warmUpGhostTable()
 	if err := this.hooksExecutor.onBeforeCutOver(); err != nil {
 		return err
 	}
@@ -507,19 +676,30 @@ func (this *Migrator) Migrate() (err error) {
 	if this.migrationContext.Checkpoint && !this.migrationContext.Noop {
 		cutoverChk, err := this.CheckpointAfterCutOver()
 		if err != nil {
-			this.migrationContext.Log.Warningf("failed to checkpoint after cutover: %+v", err)
+			this.log.Warningf("failed to checkpoint after cutover: %+v", err)
 		} else {
-			this.migrationContext.Log.Infof("checkpoint success after cutover at coords=%+v", cutoverChk.LastTrxCoords.DisplayString())
+			this.log.Infof("checkpoint success after cutover at coords=%+v", cutoverChk.LastTrxCoords.DisplayString())
 		}
 	}
 
-	if err := this.finalCleanup(); err != nil {
+	// Streaming and applying stop ahead of awaiting promotion, during which the master's writes
+	// keep replicating onto the migrated table, and have no ghost table to be applied onto
+	if err := this.stopMigrating(); err != nil {
 		return nil
 	}
+	if this.migrationContext.MigrateOnReplica && !this.migrationContext.Noop {
+		if err := this.prepareReplicaPromotion(); err != nil {
+			return err
+		}
+	}
+	if err := this.dropMigrationTables(); err != nil {
+		return nil
+	}
+	this.reportSummary()
 	if err := this.hooksExecutor.onSuccess(); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Done migrating %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	this.log.Infof("Done migrating %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
 	return nil
 }
 
@@ -527,7 +707,8 @@ func (this *Migrator) Migrate() (err error) {
 // after the original cutover, then doing another cutover to swap the tables back.
 // The steps are similar to Migrate(), but without row copying.
 func (this *Migrator) Revert() error {
-	this.migrationContext.Log.Infof("Reverting %s.%s from %s.%s",
+	defer this.reportPanic()
+	this.log.Infof("Reverting %s.%s from %s.%s",
 		sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName),
 		sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OldTableName))
 	this.migrationContext.StartTime = time.Now()
@@ -564,10 +745,10 @@ func (this *Migrator) Revert() error {
 
 	lastCheckpoint, err := this.applier.ReadLastCheckpoint()
 	if err != nil {
-		return this.migrationContext.Log.Errorf("No checkpoint found, unable to revert: %+v", err)
+		return this.log.Errorf("No checkpoint found, unable to revert: %+v", err)
 	}
 	if !lastCheckpoint.IsCutover {
-		return this.migrationContext.Log.Errorf("Last checkpoint is not after cutover, unable to revert: coords=%+v time=%+v", lastCheckpoint.LastTrxCoords, lastCheckpoint.Timestamp)
+		return this.log.Errorf("Last checkpoint is not after cutover, unable to revert: coords=%+v time=%+v", lastCheckpoint.LastTrxCoords, lastCheckpoint.Timestamp)
 	}
 	this.migrationContext.InitialStreamerCoords = lastCheckpoint.LastTrxCoords
 	this.migrationContext.TotalRowsCopied = lastCheckpoint.RowsCopied
@@ -583,12 +764,17 @@ func (this *Migrator) Revert() error {
 		return err
 	}
 	defer this.server.RemoveSocketFile()
+	defer this.server.ShutdownStatusHTTP()
+	atomic.StoreInt64(&this.statusAvailableFlag, 1)
 	if err := this.addDMLEventsListener(); err != nil {
 		return err
 	}
 
 	this.initiateThrottler()
 	go this.initiateStatus()
+	go this.initiateStallWatchdog()
+	go this.initiateBackpressureMonitor()
+	go this.initiateMaxRuntimeMonitor()
 	go this.executeDMLWriteFuncs()
 
 	this.printStatus(ForcePrintStatusRule)
@@ -608,10 +794,134 @@ func (this *Migrator) Revert() error {
 	if err := this.finalCleanup(); err != nil {
 		return nil
 	}
+	this.reportSummary()
 	if err := this.hooksExecutor.onSuccess(); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Done reverting %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	this.log.Infof("Done reverting %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	return nil
+}
+
+// Cleanup finds the tables left behind by a failed or killed migration of the original table, verifies
+// no migration still uses them, and drops them. Without --execute, it only lists what it would drop.
+func (this *Migrator) Cleanup() error {
+	this.log.Infof("Looking for leftovers of a previous migration of %s.%s",
+		sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	this.migrationContext.StartTime = time.Now()
+	defer this.teardown()
+
+	if err := this.connectInspector(); err != nil {
+		return err
+	}
+	if err := this.resolveApplierConnectionConfig(); err != nil {
+		return err
+	}
+	this.applier = NewApplier(this.migrationContext)
+	if err := this.applier.InitDBConnections(); err != nil {
+		return err
+	}
+	if err := this.validateArtifactsNotInUse(); err != nil {
+		return err
+	}
+	artifacts, err := this.findMigrationArtifacts()
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		this.log.Infof("No leftovers found")
+		return nil
+	}
+	for _, artifact := range artifacts {
+		tableName := fmt.Sprintf("%s.%s", sql.EscapeName(artifact.databaseName), sql.EscapeName(artifact.tableName))
+		switch {
+		case artifact.keepReason != "":
+			this.log.Infof("Keeping %s, created %+v ago: %s", tableName, artifact.age, artifact.keepReason)
+		case this.migrationContext.Noop:
+			this.log.Infof("Would drop %s, created %+v ago", tableName, artifact.age)
+		default:
+			if err := this.applier.dropTable(artifact.databaseName, artifact.tableName); err != nil {
+				return err
+			}
+		}
+	}
+	if this.migrationContext.Noop {
+		this.log.Infof("Dry run, nothing dropped. Use --execute to drop the above")
+	}
+	return nil
+}
+
+// migrationArtifact is a table left behind by a previous migration of the original table
+type migrationArtifact struct {
+	databaseName string
+	tableName    string
+	age          time.Duration
+	// keepReason, when non-empty, explains why the table is not to be dropped
+	keepReason string
+}
+
+// findMigrationArtifacts lists the existing tables named after the original table the way a migration names
+// its ghost, changelog, old and checkpoint tables. Old and checkpoint tables may be kept by a successful
+// migration, and so are only dropped with --ok-to-drop-table.
+func (this *Migrator) findMigrationArtifacts() (artifacts []*migrationArtifact, err error) {
+	_, originalTableExists, err := this.applier.ReadTableAge(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []migrationArtifact{
+		{databaseName: this.migrationContext.GetGhostDatabaseName(), tableName: this.migrationContext.GetGhostTableName()},
+		{databaseName: this.migrationContext.GetChangelogDatabaseName(), tableName: this.migrationContext.GetChangelogTableName()},
+		{databaseName: this.migrationContext.DatabaseName, tableName: this.migrationContext.GetOldTableName()},
+		{databaseName: this.migrationContext.GetChangelogDatabaseName(), tableName: this.migrationContext.GetCheckpointTableName()},
+	}
+	for _, candidate := range candidates {
+		tableName := candidate.tableName
+		age, found, err := this.applier.ReadTableAge(candidate.databaseName, tableName)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		artifact := &migrationArtifact{databaseName: candidate.databaseName, tableName: tableName, age: age}
+		switch tableName {
+		case this.migrationContext.GetOldTableName():
+			if this.applier.IsAtomicCutOverSentryTable(tableName) {
+				break
+			}
+			if !originalTableExists {
+				artifact.keepReason = "the original table is missing, and this may be its only copy. Rename it back manually"
+			} else if !this.migrationContext.OkToDropTable {
+				artifact.keepReason = "this may be the original table of a successful migration. Use --ok-to-drop-table to drop it"
+			}
+		case this.migrationContext.GetCheckpointTableName():
+			if !this.migrationContext.OkToDropTable {
+				artifact.keepReason = "--revert relies on it after a successful migration. Use --ok-to-drop-table to drop it"
+			}
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+// validateArtifactsNotInUse verifies no migration of the original table is running: nothing heartbeats
+// to the changelog table, and no binlog streamer is registered with --replica-server-id.
+func (this *Migrator) validateArtifactsNotInUse() error {
+	heartbeatAge, found, err := this.applier.ReadChangelogHeartbeatAge()
+	if err != nil {
+		return err
+	}
+	if found && heartbeatAge < cleanupHeartbeatStaleThreshold {
+		return fmt.Errorf("%s.%s was heartbeated %+v ago; a migration seems to be running. Refusing to clean up",
+			sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()), sql.EscapeName(this.migrationContext.GetChangelogTableName()), heartbeatAge)
+	}
+	registered, err := this.inspector.isReplicaServerIdRegistered()
+	if err != nil {
+		return err
+	}
+	if registered {
+		return fmt.Errorf("A replica with server id %d (see --replica-server-id) is registered on %s; a migration seems to be streaming binary logs. If it is a dangling dump thread of a killed migration, kill it (see `show processlist`) and try again. Refusing to clean up",
+			this.migrationContext.ReplicaServerId, this.migrationContext.GetBinlogConnectionConfig().Key.String())
+	}
 	return nil
 }
 
@@ -637,14 +947,14 @@ func (this *Migrator) handleCutOverResult(cutOverError error) (err error) {
 		// and swap the tables.
 		// The difference is that we will later swap the tables back.
 		if err := this.hooksExecutor.onStartReplication(); err != nil {
-			return this.migrationContext.Log.Errore(err)
+			return this.log.Errore(err)
 		}
 		if this.migrationContext.TestOnReplicaSkipReplicaStop {
-			this.migrationContext.Log.Warningf("--test-on-replica-skip-replica-stop enabled, we are not starting replication.")
+			this.log.Warningf("--test-on-replica-skip-replica-stop enabled, we are not starting replication.")
 		} else {
-			this.migrationContext.Log.Debugf("testing on replica. Starting replication IO thread after cut-over failure")
+			this.log.Debugf("testing on replica. Starting replication IO thread after cut-over failure")
 			if err := this.retryOperation(this.applier.StartReplication); err != nil {
-				return this.migrationContext.Log.Errore(err)
+				return this.log.Errore(err)
 			}
 		}
 	}
@@ -655,23 +965,45 @@ func (this *Migrator) handleCutOverResult(cutOverError error) (err error) {
 // type (on replica? atomic? safe?)
 func (this *Migrator) cutOver() (err error) {
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really swapping tables")
+		this.log.Debugf("Noop operation; not really swapping tables")
 		return nil
 	}
 	this.migrationContext.MarkPointOfInterest()
 	this.throttler.throttle(func() {
-		this.migrationContext.Log.Debugf("throttling before swapping tables")
+		this.log.Debugf("throttling before swapping tables")
 	})
 
 	this.migrationContext.MarkPointOfInterest()
-	this.migrationContext.Log.Debugf("checking for cut-over postpone")
+	this.log.Debugf("checking for cut-over postpone")
+	// The time waited since the previous check is accounted for by what was waited for
+	lastCheckTime := time.Now()
+	var addWaitTime func(time.Duration)
 	this.sleepWhileTrue(
 		func() (bool, error) {
+			now := time.Now()
+			if addWaitTime != nil {
+				addWaitTime(now.Sub(lastCheckTime))
+			}
+			lastCheckTime = now
+			addWaitTime = this.migrationContext.AddBinlogCatchUpTime
+
 			heartbeatLag := this.migrationContext.TimeSinceLastHeartbeatOnChangelog()
 			maxLagMillisecondsThrottle := time.Duration(atomic.LoadInt64(&this.migrationContext.MaxLagMillisecondsThrottleThreshold)) * time.Millisecond
 			cutOverLockTimeout := time.Duration(this.migrationContext.CutOverLockTimeoutSeconds) * time.Second
 			if heartbeatLag > maxLagMillisecondsThrottle || heartbeatLag > cutOverLockTimeout {
-				this.migrationContext.Log.Debugf("current HeartbeatLag (%.2fs) is too high, it needs to be less than both --max-lag-millis (%.2fs) and --cut-over-lock-timeout-seconds (%.2fs) to continue", heartbeatLag.Seconds(), maxLagMillisecondsThrottle.Seconds(), cutOverLockTimeout.Seconds())
+				this.log.Debugf("current HeartbeatLag (%.2fs) is too high, it needs to be less than both --max-lag-millis (%.2fs) and --cut-over-lock-timeout-seconds (%.2fs) to continue", heartbeatLag.Seconds(), maxLagMillisecondsThrottle.Seconds(), cutOverLockTimeout.Seconds())
+				return true, nil
+			}
+			if maxApplierLag := this.migrationContext.MaxApplierLag; maxApplierLag > 0 {
+				if applierLag := this.getApplierLag(); applierLag > maxApplierLag {
+					this.log.Debugf("current applier lag (%.2fs) is too high, it needs to be less than --max-applier-lag (%.2fs) to continue", applierLag.Seconds(), maxApplierLag.Seconds())
+					return true, nil
+				}
+			}
+			addWaitTime = this.migrationContext.AddPostponedCutOverTime
+			if this.migrationContext.IsPastDeadline() {
+				// Never cut over past --max-runtime, unless the deadline is extended
+				atomic.StoreInt64(&this.migrationContext.IsPostponingCutOver, 1)
 				return true, nil
 			}
 			if this.migrationContext.PostponeCutOverFlagFile == "" {
@@ -696,7 +1028,7 @@ func (this *Migrator) cutOver() (err error) {
 	)
 	atomic.StoreInt64(&this.migrationContext.IsPostponingCutOver, 0)
 	this.migrationContext.MarkPointOfInterest()
-	this.migrationContext.Log.Debugf("checking for cut-over postpone: complete")
+	this.log.Debugf("checking for cut-over postpone: complete")
 
 	if this.migrationContext.TestOnReplica {
 		// With `--test-on-replica` we stop replication thread, and then proceed to use
@@ -707,9 +1039,9 @@ func (this *Migrator) cutOver() (err error) {
 			return err
 		}
 		if this.migrationContext.TestOnReplicaSkipReplicaStop {
-			this.migrationContext.Log.Warningf("--test-on-replica-skip-replica-stop enabled, we are not stopping replication.")
+			this.log.Warningf("--test-on-replica-skip-replica-stop enabled, we are not stopping replication.")
 		} else {
-			this.migrationContext.Log.Debugf("testing on replica. Stopping replication IO thread")
+			this.log.Debugf("testing on replica. Stopping replication IO thread")
 			if err := this.retryOperation(this.applier.StopReplication); err != nil {
 				return err
 			}
@@ -724,12 +1056,37 @@ func (this *Migrator) cutOver() (err error) {
 	case base.CutOverTwoStep:
 		err = this.cutOverTwoStep()
 	default:
-		return this.migrationContext.Log.Fatalf("Unknown cut-over type: %d; should never get here!", this.migrationContext.CutOverType)
+		return this.log.Fatalf("Unknown cut-over type: %d; should never get here!", this.migrationContext.CutOverType)
 	}
 	this.handleCutOverResult(err)
+	if err == nil {
+		this.dropFullRowIndex()
+	}
 	return err
 }
 
+// dropFullRowIndex drops the temporary full row index of --allow-temp-index off the migrated table, now
+// that the cut-over renamed it. The migration is complete regardless: a failure is logged, and the
+// statement dropping the index is reported in --summary-file and to the on-success hook.
+func (this *Migrator) dropFullRowIndex() {
+	databaseName, tableName := this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName
+	if this.migrationContext.TestOnReplica {
+		// tables are renamed back
+		databaseName, tableName = this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName()
+	}
+	if err := this.retryOperation(func() error {
+		return this.applier.DropFullRowIndex(databaseName, tableName)
+	}, true); err != nil {
+		this.migrationContext.PendingCleanupStatement = fmt.Sprintf("alter table %s.%s drop index %s",
+			sql.EscapeName(databaseName), sql.EscapeName(tableName), sql.EscapeName(sql.FullRowUniqueKeyName),
+		)
+		this.log.Errorf("Failed dropping temporary index %s off %s.%s: %+v. To drop it, issue: %s",
+			sql.EscapeName(sql.FullRowUniqueKeyName), sql.EscapeName(databaseName), sql.EscapeName(tableName), err,
+			this.migrationContext.PendingCleanupStatement,
+		)
+	}
+}
+
 // Inject the "AllEventsUpToLockProcessed" state hint, wait for it to appear in the binary logs,
 // make sure the queue is drained.
 func (this *Migrator) waitForEventsUpToLock() error {
@@ -739,34 +1096,35 @@ func (this *Migrator) waitForEventsUpToLock() error {
 	waitForEventsUpToLockStartTime := time.Now()
 
 	allEventsUpToLockProcessedChallenge := fmt.Sprintf("%s:%d", string(AllEventsUpToLockProcessed), waitForEventsUpToLockStartTime.UnixNano())
-	this.migrationContext.Log.Infof("Writing changelog state: %+v", allEventsUpToLockProcessedChallenge)
+	this.log.Infof("Writing changelog state: %+v", allEventsUpToLockProcessedChallenge)
 	if _, err := this.applier.WriteChangelogState(allEventsUpToLockProcessedChallenge); err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Waiting for events up to lock")
+	this.log.Infof("Waiting for events up to lock")
 	atomic.StoreInt64(&this.migrationContext.AllEventsUpToLockProcessedInjectedFlag, 1)
 	var lockProcessed *lockProcessedStruct
 	for found := false; !found; {
 		select {
 		case <-timeout.C:
 			{
-				return this.migrationContext.Log.Errorf("Timeout while waiting for events up to lock")
+				return this.log.Errorf("Timeout while waiting for events up to lock")
 			}
 		case lockProcessed = <-this.allEventsUpToLockProcessed:
 			{
 				if lockProcessed.state == allEventsUpToLockProcessedChallenge {
-					this.migrationContext.Log.Infof("Waiting for events up to lock: got %s", lockProcessed.state)
+					this.log.Infof("Waiting for events up to lock: got %s", lockProcessed.state)
 					found = true
 					this.lastLockProcessed = lockProcessed
+					this.migrationContext.CutOverBinlogCoordinates = lockProcessed.coords
 				} else {
-					this.migrationContext.Log.Infof("Waiting for events up to lock: skipping %s", lockProcessed.state)
+					this.log.Infof("Waiting for events up to lock: skipping %s", lockProcessed.state)
 				}
 			}
 		}
 	}
 	waitForEventsUpToLockDuration := time.Since(waitForEventsUpToLockStartTime)
 
-	this.migrationContext.Log.Infof("Done waiting for events up to lock; duration=%+v", waitForEventsUpToLockDuration)
+	this.log.Infof("Done waiting for events up to lock; duration=%+v", waitForEventsUpToLockDuration)
 	this.printStatus(ForcePrintStatusAndHintRule)
 
 	return nil
@@ -788,6 +1146,12 @@ func (this *Migrator) cutOverTwoStep() (err error) {
 	if err := this.retryOperation(this.waitForEventsUpToLock); err != nil {
 		return err
 	}
+	if err := this.verifyRowCount(); err != nil {
+		if unlockErr := this.applier.UnlockTables(); unlockErr != nil {
+			this.log.Errore(unlockErr)
+		}
+		return this.log.Errore(err)
+	}
 	// If we need to create triggers we need to do it here (only create part)
 	if this.migrationContext.IncludeTriggers && len(this.migrationContext.Triggers) > 0 {
 		if err := this.retryOperation(this.applier.CreateTriggersOnGhost); err != nil {
@@ -803,7 +1167,31 @@ func (this *Migrator) cutOverTwoStep() (err error) {
 
 	lockAndRenameDuration := this.migrationContext.RenameTablesEndTime.Sub(this.migrationContext.LockTablesStartTime)
 	renameDuration := this.migrationContext.RenameTablesEndTime.Sub(this.migrationContext.RenameTablesStartTime)
-	this.migrationContext.Log.Debugf("Lock & rename duration: %s (rename only: %s). During this time, queries on %s were locked or failing", lockAndRenameDuration, renameDuration, sql.EscapeName(this.migrationContext.OriginalTableName))
+	this.log.Debugf("Lock & rename duration: %s (rename only: %s). During this time, queries on %s were locked or failing", lockAndRenameDuration, renameDuration, sql.EscapeName(this.migrationContext.OriginalTableName))
+	return nil
+}
+
+// verifyRowCount compares the row count of the original table, as counted while locked for cut-over,
+// with that of the ghost table, once all events up to the lock are applied (see --verify-rowcount-threshold)
+func (this *Migrator) verifyRowCount() error {
+	originalTableRows := this.applier.lockedOriginalTableRows
+	if originalTableRows < 0 {
+		return nil
+	}
+	ghostTableRows, err := this.applier.CountGhostTableRows()
+	if err != nil {
+		return err
+	}
+	this.log.Infof("Row count verification: %d rows on %s, %d rows on %s",
+		originalTableRows, sql.EscapeName(this.migrationContext.OriginalTableName),
+		ghostTableRows, sql.EscapeName(this.migrationContext.GetGhostTableName()),
+	)
+	if originalTableRows != ghostTableRows {
+		return fmt.Errorf("%w: %d rows on %s, %d rows on %s", ErrRowCountMismatch,
+			originalTableRows, sql.EscapeName(this.migrationContext.OriginalTableName),
+			ghostTableRows, sql.EscapeName(this.migrationContext.GetGhostTableName()),
+		)
+	}
 	return nil
 }
 
@@ -825,24 +1213,27 @@ func (this *Migrator) atomicCutOver() (err error) {
 	var renameLockSessionId int64
 	go func() {
 		if err := this.applier.AtomicCutOverMagicLock(lockOriginalSessionIdChan, tableLocked, okToUnlockTable, tableUnlocked, &renameLockSessionId); err != nil {
-			this.migrationContext.Log.Errore(err)
+			this.log.Errore(err)
 		}
 	}()
 	if err := <-tableLocked; err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	lockOriginalSessionId := <-lockOriginalSessionIdChan
-	this.migrationContext.Log.Infof("Session locking original & magic tables is %+v", lockOriginalSessionId)
+	this.log.Infof("Session locking original & magic tables is %+v", lockOriginalSessionId)
 	// At this point we know the original table is locked.
 	// We know any newly incoming DML on original table is blocked.
 	if err := this.waitForEventsUpToLock(); err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
+	}
+	if err := this.verifyRowCount(); err != nil {
+		return this.log.Errore(err)
 	}
 
 	// If we need to create triggers we need to do it here (only create part)
 	if this.migrationContext.IncludeTriggers && len(this.migrationContext.Triggers) > 0 {
 		if err := this.applier.CreateTriggersOnGhost(); err != nil {
-			this.migrationContext.Log.Errore(err)
+			this.log.Errore(err)
 		}
 	}
 
@@ -861,7 +1252,7 @@ func (this *Migrator) atomicCutOver() (err error) {
 		}
 	}()
 	renameSessionId := <-renameSessionIdChan
-	this.migrationContext.Log.Infof("Session renaming tables is %+v", renameSessionId)
+	this.log.Infof("Session renaming tables is %+v", renameSessionId)
 
 	waitForRename := func() error {
 		if atomic.LoadInt64(&tableRenameKnownToHaveFailed) == 1 {
@@ -878,13 +1269,13 @@ func (this *Migrator) atomicCutOver() (err error) {
 		return err
 	}
 	if atomic.LoadInt64(&tableRenameKnownToHaveFailed) == 0 {
-		this.migrationContext.Log.Infof("Found atomic RENAME to be blocking, as expected. Double checking the lock is still in place (though I don't strictly have to)")
+		this.log.Infof("Found atomic RENAME to be blocking, as expected. Double checking the lock is still in place (though I don't strictly have to)")
 	}
 	if err := this.applier.ExpectUsedLock(lockOriginalSessionId); err != nil {
 		// Abort operation. Just make sure to drop the magic table.
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
-	this.migrationContext.Log.Infof("Connection holding lock on original table still exists")
+	this.log.Infof("Connection holding lock on original table still exists")
 
 	// Now that we've found the RENAME blocking, AND the locking connection still alive,
 	// we know it is safe to proceed to release the lock
@@ -894,36 +1285,155 @@ func (this *Migrator) atomicCutOver() (err error) {
 	// BAM! magic table dropped, original table lock is released
 	// -> RENAME released -> queries on original are unblocked.
 	if err := <-tableUnlocked; err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	if err := <-tablesRenamed; err != nil {
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	this.migrationContext.RenameTablesEndTime = time.Now()
 
 	// ooh nice! We're actually truly and thankfully done
 	lockAndRenameDuration := this.migrationContext.RenameTablesEndTime.Sub(this.migrationContext.LockTablesStartTime)
-	this.migrationContext.Log.Infof("Lock & rename duration: %s. During this time, queries on %s were blocked", lockAndRenameDuration, sql.EscapeName(this.migrationContext.OriginalTableName))
+	this.log.Infof("Lock & rename duration: %s. During this time, queries on %s were blocked", lockAndRenameDuration, sql.EscapeName(this.migrationContext.OriginalTableName))
 	return nil
 }
 
 // initiateServer begins listening on unix socket/tcp for incoming interactive commands
 func (this *Migrator) initiateServer() (err error) {
 	var f printStatusFunc = func(rule PrintStatusRule, writer io.Writer) {
+		if atomic.LoadInt64(&this.statusAvailableFlag) == 0 {
+			this.printPendingStatus(writer)
+			return
+		}
 		this.printStatus(rule, writer)
 	}
-	this.server = NewServer(this.migrationContext, this.hooksExecutor, f)
+	this.server = NewServer(this.migrationContext, this.hooksExecutor, f, this.getStatusSnapshot, this.printMigrationQueue)
 	if err := this.server.BindSocketFile(); err != nil {
 		return err
 	}
 	if err := this.server.BindTCPPort(); err != nil {
 		return err
 	}
+	if err := this.server.BindStatusHTTP(); err != nil {
+		return err
+	}
 
 	go this.server.Serve()
 	return nil
 }
 
+// printPendingStatus prints the state of a migration that serves interactive commands, yet has no
+// status to report on, e.g. while queued
+func (this *Migrator) printPendingStatus(writer io.Writer) {
+	state := "starting"
+	if atomic.LoadInt64(&this.migrationContext.IsQueuedFlag) > 0 {
+		state = "queued"
+	}
+	fmt.Fprintf(writer, "State: %s; Time: %+v(total)\n", state, base.PrettifyDurationOutput(this.migrationContext.ElapsedTime()))
+}
+
+// waitForMigrationSlot registers this migration on the coordination table, then waits until fewer than
+// --max-concurrent-migrations other live migrations are running or queued ahead of it.
+func (this *Migrator) waitForMigrationSlot() error {
+	if this.migrationContext.MaxConcurrentMigrations <= 0 {
+		return nil
+	}
+	if this.migrationContext.Noop {
+		this.log.Debugf("Noop operation; not registering on coordination table")
+		return nil
+	}
+	if err := this.applier.CreateCoordinationTable(); err != nil {
+		return err
+	}
+	id, err := this.applier.RegisterMigration()
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(&this.coordinationId, id)
+	go this.heartbeatMigrationRegistration(id)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		registrations, err := this.applier.ReadMigrationRegistrations()
+		if err != nil {
+			return err
+		}
+		migrationsAhead := countMigrationsAhead(registrations, id)
+		if int64(migrationsAhead) < this.migrationContext.MaxConcurrentMigrations {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&this.migrationContext.IsQueuedFlag, 0, 1) {
+			this.log.Infof("Queued: %d migrations running or queued ahead, and --max-concurrent-migrations is %d", migrationsAhead, this.migrationContext.MaxConcurrentMigrations)
+			if err := this.hooksExecutor.onQueued(migrationsAhead); err != nil {
+				return err
+			}
+		}
+		<-ticker.C
+	}
+	if atomic.CompareAndSwapInt64(&this.migrationContext.IsQueuedFlag, 1, 0) {
+		this.log.Infof("No longer queued")
+	}
+	return this.applier.SetMigrationRegistrationState(id, MigrationRegistrationRunning)
+}
+
+// heartbeatMigrationRegistration keeps this migration's registration live until the migration is done
+func (this *Migrator) heartbeatMigrationRegistration(id int64) {
+	interval := time.Duration(this.migrationContext.CoordinationStaleSeconds) * time.Second / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 || atomic.LoadInt64(&this.coordinationId) == 0 {
+			return
+		}
+		if err := this.applier.HeartbeatMigrationRegistration(id); err != nil {
+			this.log.Errore(err)
+		}
+	}
+}
+
+// deregisterMigration removes this migration's registration, if any, from the coordination table
+func (this *Migrator) deregisterMigration() {
+	id := atomic.SwapInt64(&this.coordinationId, 0)
+	if id == 0 {
+		return
+	}
+	if err := this.applier.DeregisterMigration(id); err != nil {
+		this.log.Errore(err)
+	}
+}
+
+// printMigrationQueue prints the live migrations registered on the coordination table
+func (this *Migrator) printMigrationQueue(writer io.Writer) error {
+	id := atomic.LoadInt64(&this.coordinationId)
+	if id == 0 {
+		fmt.Fprintln(writer, "# This migration is not registered for coordination. See --max-concurrent-migrations")
+		return nil
+	}
+	registrations, err := this.applier.ReadMigrationRegistrations()
+	if err != nil {
+		return err
+	}
+	for _, registration := range registrations {
+		self := ""
+		if registration.Id == id {
+			self = " (this migration)"
+		}
+		fmt.Fprintf(writer, "%d: %s.%s on %s; state: %s; heartbeat: %.1fs ago%s\n",
+			registration.Id,
+			sql.EscapeName(registration.DatabaseName), sql.EscapeName(registration.TableName),
+			registration.Hostname,
+			registration.State,
+			registration.HeartbeatAge,
+			self,
+		)
+	}
+	return nil
+}
+
 // initiateInspector connects, validates and inspects the "inspector" server.
 // The "inspector" server is typically a replica; it is where we issue some
 // queries such as:
@@ -932,8 +1442,7 @@ func (this *Migrator) initiateServer() (err error) {
 // - heartbeat
 // When `--allow-on-master` is supplied, the inspector is actually the master.
 func (this *Migrator) initiateInspector() (err error) {
-	this.inspector = NewInspector(this.migrationContext)
-	if err := this.inspector.InitDBConnections(); err != nil {
+	if err := this.connectInspector(); err != nil {
 		return err
 	}
 	if err := this.inspector.ValidateOriginalTable(); err != nil {
@@ -943,13 +1452,35 @@ func (this *Migrator) initiateInspector() (err error) {
 		return err
 	}
 	// So far so good, table is accessible and valid.
-	// Let's get master connection config
+	return this.resolveApplierConnectionConfig()
+}
+
+// connectInspector connects to the inspected server and, with --binlog-host, to the binary log server
+func (this *Migrator) connectInspector() error {
+	if this.migrationContext.BinlogHostname != "" {
+		key, err := mysql.ParseInstanceKey(this.migrationContext.BinlogHostname)
+		if err != nil {
+			return err
+		}
+		this.migrationContext.BinlogConnectionConfig = this.migrationContext.InspectorConnectionConfig.DuplicateCredentials(*key)
+		if err := this.migrationContext.BinlogConnectionConfig.RegisterTLSConfig(); err != nil {
+			return err
+		}
+		this.log.Infof("Binary logs to be streamed from %+v", this.migrationContext.BinlogConnectionConfig.Key)
+	}
+	this.inspector = NewInspector(this.migrationContext)
+	return this.inspector.InitDBConnections()
+}
+
+// resolveApplierConnectionConfig figures out the server to apply changes on: the master, or with
+// --test-on-replica and --migrate-on-replica, the inspected replica itself
+func (this *Migrator) resolveApplierConnectionConfig() (err error) {
 	if this.migrationContext.AssumeMasterHostname == "" {
 		// No forced master host; detect master
 		if this.migrationContext.ApplierConnectionConfig, err = this.inspector.getMasterConnectionConfig(); err != nil {
 			return err
 		}
-		this.migrationContext.Log.Infof("Master found to be %+v", *this.migrationContext.ApplierConnectionConfig.ImpliedKey)
+		this.log.Infof("Master found to be %+v", *this.migrationContext.ApplierConnectionConfig.ImpliedKey)
 	} else {
 		// Forced master host.
 		key, err := mysql.ParseInstanceKey(this.migrationContext.AssumeMasterHostname)
@@ -966,16 +1497,17 @@ func (this *Migrator) initiateInspector() (err error) {
 		if err := this.migrationContext.ApplierConnectionConfig.RegisterTLSConfig(); err != nil {
 			return err
 		}
-		this.migrationContext.Log.Infof("Master forced to be %+v", *this.migrationContext.ApplierConnectionConfig.ImpliedKey)
+		this.log.Infof("Master forced to be %+v", *this.migrationContext.ApplierConnectionConfig.ImpliedKey)
 	}
 	// validate configs
 	if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica {
 		if this.migrationContext.InspectorIsAlsoApplier() {
 			return fmt.Errorf("Instructed to --test-on-replica or --migrate-on-replica, but the server we connect to doesn't seem to be a replica")
 		}
-		this.migrationContext.Log.Infof("--test-on-replica or --migrate-on-replica given. Will not execute on master %+v but rather on replica %+v itself",
+		this.log.Infof("--test-on-replica or --migrate-on-replica given. Will not execute on master %+v but rather on replica %+v itself",
 			*this.migrationContext.ApplierConnectionConfig.ImpliedKey, *this.migrationContext.InspectorConnectionConfig.ImpliedKey,
 		)
+		this.replicationMasterKey = &this.migrationContext.ApplierConnectionConfig.Key
 		this.migrationContext.ApplierConnectionConfig = this.migrationContext.InspectorConnectionConfig.Duplicate()
 		if this.migrationContext.GetThrottleControlReplicaKeys().Len() == 0 {
 			this.migrationContext.AddThrottleControlReplicaKey(this.migrationContext.InspectorConnectionConfig.Key)
@@ -990,6 +1522,79 @@ func (this *Migrator) initiateInspector() (err error) {
 	return nil
 }
 
+// shouldValidateAssumedMaster returns true when gh-ost writes to an --assume-master-host other than the inspected server
+func (this *Migrator) shouldValidateAssumedMaster() bool {
+	if this.migrationContext.AssumeMasterHostname == "" {
+		return false
+	}
+	if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica {
+		return false
+	}
+	return !this.migrationContext.InspectorIsAlsoApplier()
+}
+
+// validateAssumedMaster verifies the --assume-master-host is writable, and that the inspected replica
+// replicates from it. As topology is not discovered, a wrong assumption would otherwise go unnoticed:
+// gh-ost would write to one server, and wait forever for its writes on the binary log of another.
+func (this *Migrator) validateAssumedMaster() error {
+	masterKey := this.migrationContext.ApplierConnectionConfig.Key
+	if err := this.applier.ValidateWritable(); err != nil {
+		return fmt.Errorf("--assume-master-host %s: %w", masterKey.String(), err)
+	}
+	if this.migrationContext.UseGTIDs {
+		serverUUID, err := this.applier.ReadServerUUID()
+		if err != nil {
+			return err
+		}
+		gtidExecuted, err := this.inspector.readGTIDExecuted()
+		if err != nil {
+			return err
+		}
+		if gtidExecuted.ContainsServerUUID(serverUUID) {
+			this.log.Infof("Validated --assume-master-host %s: its server_uuid %s is found in gtid_executed of %s",
+				masterKey.String(), serverUUID, this.migrationContext.InspectorConnectionConfig.Key.String(),
+			)
+			return nil
+		}
+		this.log.Infof("server_uuid %s of --assume-master-host %s not found in gtid_executed of %s; probing replication",
+			serverUUID, masterKey.String(), this.migrationContext.InspectorConnectionConfig.Key.String(),
+		)
+	}
+	return this.probeAssumedMaster()
+}
+
+// probeAssumedMaster writes a unique value to the changelog table on the assumed master, and waits for it to
+// replicate to the inspected server.
+func (this *Migrator) probeAssumedMaster() error {
+	probeValue := fmt.Sprintf("%s:%d", this.migrationContext.Uuid, time.Now().UnixNano())
+	if _, err := this.applier.WriteChangelog("assume-master-probe", probeValue); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(assumedMasterProbeTimeout)
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("Probe written to --assume-master-host %s did not replicate to %s within %+v. Either %s does not replicate from %s, or it lags too far behind. Bailing out",
+				this.migrationContext.ApplierConnectionConfig.Key.String(), this.migrationContext.InspectorConnectionConfig.Key.String(), assumedMasterProbeTimeout,
+				this.migrationContext.InspectorConnectionConfig.Key.String(), this.migrationContext.ApplierConnectionConfig.Key.String(),
+			)
+		case <-ticker.C:
+			value, found, err := this.inspector.readChangelogValue("assume-master-probe")
+			if err != nil {
+				return err
+			}
+			if found && value == probeValue {
+				this.log.Infof("Validated --assume-master-host %s: probe replicated to %s",
+					this.migrationContext.ApplierConnectionConfig.Key.String(), this.migrationContext.InspectorConnectionConfig.Key.String(),
+				)
+				return nil
+			}
+		}
+	}
+}
+
 // initiateStatus sets and activates the printStatus() ticker
 func (this *Migrator) initiateStatus() {
 	this.printStatus(ForcePrintStatusAndHintRule)
@@ -1000,6 +1605,7 @@ func (this *Migrator) initiateStatus() {
 		if atomic.LoadInt64(&this.finishedMigrating) > 0 {
 			return
 		}
+		this.migrationContext.RecordApplierLag(this.getApplierLag())
 		go this.printStatus(HeuristicPrintStatusRule)
 		totalCopied := atomic.LoadInt64(&this.migrationContext.TotalRowsCopied)
 		if previousCount > 0 {
@@ -1019,7 +1625,7 @@ func (this *Migrator) printMigrationStatusHint(writers ...io.Writer) {
 	fmt.Fprintf(w, "# Migrating %s.%s; Ghost table is %s.%s\n",
 		sql.EscapeName(this.migrationContext.DatabaseName),
 		sql.EscapeName(this.migrationContext.OriginalTableName),
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetGhostDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetGhostTableName()),
 	)
 	fmt.Fprintf(w, "# Migrating %+v; inspecting %+v; executing on %+v\n",
@@ -1030,6 +1636,17 @@ func (this *Migrator) printMigrationStatusHint(writers ...io.Writer) {
 	fmt.Fprintf(w, "# Migration started at %+v\n",
 		this.migrationContext.StartTime.Format(time.RubyDate),
 	)
+	timeZoneSource := "applier default"
+	if this.migrationContext.TimeZone != "" {
+		timeZoneSource = "--time-zone"
+	}
+	fmt.Fprintf(w, "# time_zone: %s (%s)\n", this.migrationContext.ApplierTimeZone, timeZoneSource)
+	if maxRuntime := this.migrationContext.GetMaxRuntime(); maxRuntime > 0 {
+		fmt.Fprintf(w, "# max-runtime: %+v; max-runtime-action: %s\n",
+			maxRuntime,
+			this.migrationContext.MaxRuntimeAction,
+		)
+	}
 	maxLoad := this.migrationContext.GetMaxLoad()
 	criticalLoad := this.migrationContext.GetCriticalLoad()
 	fmt.Fprintf(w, "# chunk-size: %+v; max-lag-millis: %+vms; dml-batch-size: %+v; max-load: %s; critical-load: %s; nice-ratio: %f\n",
@@ -1040,6 +1657,22 @@ func (this *Migrator) printMigrationStatusHint(writers ...io.Writer) {
 		criticalLoad.String(),
 		this.migrationContext.GetNiceRatio(),
 	)
+	if restriction := this.migrationContext.GetApplierParallelismRestriction(); restriction != "" {
+		fmt.Fprintf(w, "# applier-parallelism: 1 (restricted: %s)\n", restriction)
+	} else {
+		fmt.Fprintf(w, "# applier-parallelism: %d\n", this.migrationContext.GetApplierParallelism())
+	}
+	if len(this.migrationContext.IgnoredColumnsMap) > 0 {
+		ignoredColumns := make([]string, 0, len(this.migrationContext.IgnoredColumnsMap))
+		for columnName := range this.migrationContext.IgnoredColumnsMap {
+			ignoredColumns = append(ignoredColumns, columnName)
+		}
+		sort.Strings(ignoredColumns)
+		fmt.Fprintf(w, "# ignore-columns: %s\n", strings.Join(ignoredColumns, ","))
+	}
+	for _, transform := range this.migrationContext.ColumnTransforms {
+		fmt.Fprintf(w, "# transform-column: %s\n", transform)
+	}
 	if this.migrationContext.ThrottleFlagFile != "" {
 		setIndicator := ""
 		if base.FileExists(this.migrationContext.ThrottleFlagFile) {
@@ -1141,17 +1774,49 @@ func (this *Migrator) getMigrationETA(rowsEstimate int64) (eta string, duration
 	return eta, duration
 }
 
+// getApplierLag returns how far behind in time the ghost table is: the age of the oldest binlog event
+// queued and not yet applied. With no such event, the applier is caught up.
+func (this *Migrator) getApplierLag() time.Duration {
+	oldestUnappliedEventTime := this.migrationContext.GetOldestUnappliedEventTime()
+	if oldestUnappliedEventTime.IsZero() {
+		return 0
+	}
+	return time.Since(oldestUnappliedEventTime)
+}
+
+// formatRuntimeRemaining formats the time left until the --max-runtime deadline
+func formatRuntimeRemaining(remaining time.Duration) string {
+	if remaining <= 0 {
+		return "exceeded"
+	}
+	return base.PrettifyDurationOutput(remaining)
+}
+
 // getMigrationStateAndETA returns the state and eta of the migration.
 func (this *Migrator) getMigrationStateAndETA(rowsEstimate int64) (state, eta string, etaDuration time.Duration) {
 	eta, etaDuration = this.getMigrationETA(rowsEstimate)
 	state = "migrating"
-	if atomic.LoadInt64(&this.migrationContext.CountingRowsFlag) > 0 && !this.migrationContext.ConcurrentCountTableRows {
+	if atomic.LoadInt64(&this.migrationContext.IsQueuedFlag) > 0 {
+		state = "queued"
+	} else if atomic.LoadInt64(&this.migrationContext.CountingRowsFlag) > 0 && !this.migrationContext.ConcurrentCountTableRows {
 		state = "counting rows"
 	} else if atomic.LoadInt64(&this.migrationContext.IsPostponingCutOver) > 0 {
 		eta = "due"
 		state = "postponing cut-over"
+		if this.migrationContext.IsPastDeadline() {
+			state = "postponing cut-over, max-runtime exceeded"
+		}
 	} else if isThrottled, throttleReason, _ := this.migrationContext.IsThrottled(); isThrottled {
 		state = fmt.Sprintf("throttled, %s", throttleReason)
+	} else if atomic.LoadInt64(&this.migrationContext.IsBackpressuredFlag) > 0 {
+		state = fmt.Sprintf("backpressured, backlog memory %dMB", atomic.LoadInt64(&this.migrationContext.BacklogMemoryBytes)/bytesPerMB)
+	} else if atomic.LoadInt64(&this.migrationContext.IsRunningInplaceIndexDDLFlag) > 0 {
+		state = "running in-place index DDL"
+	} else if atomic.LoadInt64(&this.migrationContext.IsWarmingUpFlag) > 0 {
+		state = fmt.Sprintf("warming up ghost table, %d/%d indexes",
+			atomic.LoadInt64(&this.migrationContext.WarmUpIndexesDone),
+			atomic.LoadInt64(&this.migrationContext.WarmUpIndexesTotal),
+		)
 	}
 	return state, eta, etaDuration
 }
@@ -1190,6 +1855,207 @@ func (this *Migrator) shouldPrintMigrationStatusHint(rule PrintStatusRule, elaps
 	return shouldPrint
 }
 
+// initiateStallWatchdog checks once a second that the migration makes forward progress: copies rows,
+// applies DML events or, when there is nothing else to do, reads heartbeats. Time spent throttled or
+// postponing cut-over does not count towards a stall. A stall is reported once, until progress resumes.
+func (this *Migrator) initiateStallWatchdog() {
+	stallTimeout := time.Duration(this.migrationContext.StallTimeoutSeconds) * time.Second
+	if stallTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastProgressTime := time.Now()
+	for range ticker.C {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 {
+			return
+		}
+		if this.isStallExempt() {
+			lastProgressTime = time.Now()
+			atomic.StoreInt64(&this.stalledFlag, 0)
+			continue
+		}
+		if progressTime := this.getLastProgressTime(); progressTime.After(lastProgressTime) {
+			lastProgressTime = progressTime
+			atomic.StoreInt64(&this.stalledFlag, 0)
+		}
+		stalledDuration := time.Since(lastProgressTime)
+		if atomic.LoadInt64(&this.stalledFlag) > 0 || stalledDuration < stallTimeout {
+			continue
+		}
+		atomic.StoreInt64(&this.stalledFlag, 1)
+		this.onStalled(stalledDuration)
+	}
+}
+
+// initiateMaxRuntimeMonitor checks once a second whether the migration is past its --max-runtime deadline.
+// This is reported once, until the deadline is extended. Cut-over is postponed while past the deadline, and
+// with --max-runtime-action=abort the migration is aborted, unless already in the cut-over critical section.
+func (this *Migrator) initiateMaxRuntimeMonitor() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 || atomic.LoadInt64(&this.migrationContext.CutOverCompleteFlag) > 0 {
+			return
+		}
+		if !this.migrationContext.IsPastDeadline() {
+			atomic.StoreInt64(&this.maxRuntimeExceededFlag, 0)
+			continue
+		}
+		if atomic.LoadInt64(&this.maxRuntimeExceededFlag) > 0 || atomic.LoadInt64(&this.migrationContext.InCutOverCriticalSectionFlag) > 0 {
+			continue
+		}
+		atomic.StoreInt64(&this.maxRuntimeExceededFlag, 1)
+		this.onMaxRuntimeExceeded()
+	}
+}
+
+func (this *Migrator) onMaxRuntimeExceeded() {
+	maxRuntime := this.migrationContext.GetMaxRuntime()
+	this.log.Warningf("Migration exceeded --max-runtime of %+v; --max-runtime-action is %s", maxRuntime, this.migrationContext.MaxRuntimeAction)
+	if err := this.hooksExecutor.onMaxRuntimeExceeded(maxRuntime); err != nil {
+		this.log.Errore(err)
+	}
+	if this.migrationContext.MaxRuntimeAction == "abort" {
+		this.migrationContext.PanicAbort <- fmt.Errorf("%w: %+v", ErrMaxRuntimeExceeded, maxRuntime)
+	}
+}
+
+// initiateBackpressureMonitor pauses reading the binary log while buffered binlog events, or gh-ost's heap,
+// exceed their configured ceiling. The streamer then falls behind, but memory stays bounded.
+func (this *Migrator) initiateBackpressureMonitor() {
+	if this.migrationContext.MaxBacklogMemoryMB <= 0 && this.migrationContext.MaxMemoryMB <= 0 {
+		return
+	}
+	if this.migrationContext.MaxMemoryMB > 0 {
+		// Have the GC work harder as the heap approaches its ceiling
+		debug.SetMemoryLimit(this.migrationContext.MaxMemoryMB * bytesPerMB)
+	}
+	ticker := time.NewTicker(backpressureInterval)
+	defer ticker.Stop()
+	var memStats runtime.MemStats
+	for range ticker.C {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 {
+			atomic.StoreInt64(&this.migrationContext.IsBackpressuredFlag, 0)
+			return
+		}
+		var heapBytes int64
+		if this.migrationContext.MaxMemoryMB > 0 {
+			runtime.ReadMemStats(&memStats)
+			heapBytes = int64(memStats.HeapAlloc)
+		}
+		backlogBytes := atomic.LoadInt64(&this.migrationContext.BacklogMemoryBytes)
+		isBackpressured := atomic.LoadInt64(&this.migrationContext.IsBackpressuredFlag) > 0
+		shouldBackpressure := this.shouldBackpressure(backlogBytes, heapBytes, isBackpressured)
+		switch {
+		case shouldBackpressure && !isBackpressured:
+			atomic.StoreInt64(&this.migrationContext.IsBackpressuredFlag, 1)
+			this.log.Warningf("Pausing binlog reader: backlog memory is %d bytes, heap is %d bytes", backlogBytes, heapBytes)
+			go func() {
+				if err := this.hooksExecutor.onBackpressure(backlogBytes, heapBytes); err != nil {
+					this.log.Errore(err)
+				}
+			}()
+		case !shouldBackpressure && isBackpressured:
+			atomic.StoreInt64(&this.migrationContext.IsBackpressuredFlag, 0)
+			this.log.Infof("Resuming binlog reader: backlog memory is %d bytes, heap is %d bytes", backlogBytes, heapBytes)
+		}
+	}
+}
+
+// shouldBackpressure returns true when the binlog reader should be paused, given the memory held by buffered
+// binlog events and by the heap. A paused reader is only resumed once memory drops well below the ceiling, so
+// as not to flap. Pausing is pointless with nothing buffered: the applier cannot free up anything.
+func (this *Migrator) shouldBackpressure(backlogBytes, heapBytes int64, isBackpressured bool) bool {
+	if backlogBytes <= 0 {
+		return false
+	}
+	exceeds := func(bytes, ceilingMB int64) bool {
+		if ceilingMB <= 0 {
+			return false
+		}
+		ceiling := float64(ceilingMB * bytesPerMB)
+		if isBackpressured {
+			ceiling *= backpressureReleaseRatio
+		}
+		return float64(bytes) >= ceiling
+	}
+	return exceeds(backlogBytes, this.migrationContext.MaxBacklogMemoryMB) || exceeds(heapBytes, this.migrationContext.MaxMemoryMB)
+}
+
+// isStallExempt returns true when the migration is not expected to make progress
+func (this *Migrator) isStallExempt() bool {
+	if isThrottled, _, _ := this.migrationContext.IsThrottled(); isThrottled {
+		return true
+	}
+	return atomic.LoadInt64(&this.migrationContext.IsPostponingCutOver) > 0
+}
+
+// getLastProgressTime returns the time of the most recent forward progress. Heartbeats only prove
+// the streamer is alive, and so count as progress only when there is no row copy or backlog to work on.
+func (this *Migrator) getLastProgressTime() time.Time {
+	progressTime := this.migrationContext.GetLastRowCopyProgressTime()
+	if dmlApplyTime := this.migrationContext.GetLastDMLApplyProgressTime(); dmlApplyTime.After(progressTime) {
+		progressTime = dmlApplyTime
+	}
+	if atomic.LoadInt64(&this.rowCopyCompleteFlag) == 1 && len(this.applyEventsQueue) == 0 {
+		if heartbeatTime := this.migrationContext.GetLastHeartbeatOnChangelogTime(); heartbeatTime.After(progressTime) {
+			progressTime = heartbeatTime
+		}
+	}
+	return progressTime
+}
+
+func (this *Migrator) onStalled(stalledDuration time.Duration) {
+	this.log.Warningf("Migration made no progress for %+v", stalledDuration.Round(time.Second))
+	this.printStallDiagnostics(os.Stderr)
+	if err := this.hooksExecutor.onStalled(stalledDuration); err != nil {
+		this.log.Errore(err)
+	}
+	if this.migrationContext.AbortOnStall {
+		this.migrationContext.PanicAbort <- fmt.Errorf("%w: no progress for %+v", ErrMigrationStalled, stalledDuration.Round(time.Second))
+	}
+}
+
+// printStallDiagnostics prints what each component last did, followed by a dump of all goroutines,
+// which shows what each component is waiting on.
+func (this *Migrator) printStallDiagnostics(w io.Writer) {
+	timeSince := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return fmt.Sprintf("%+v ago", time.Since(t).Round(time.Millisecond))
+	}
+	fmt.Fprintf(w, "# Row copy: complete: %t; iteration: %d; rows copied: %d; last copied: %s\n",
+		atomic.LoadInt64(&this.rowCopyCompleteFlag) == 1,
+		this.migrationContext.GetIteration(),
+		this.migrationContext.GetTotalRowsCopied(),
+		timeSince(this.migrationContext.GetLastRowCopyProgressTime()),
+	)
+	fmt.Fprintf(w, "# Applier: events applied: %d; backlog: %d/%d, %d bytes; backpressured: %t; last applied: %s\n",
+		atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
+		len(this.applyEventsQueue), cap(this.applyEventsQueue),
+		atomic.LoadInt64(&this.migrationContext.BacklogMemoryBytes),
+		atomic.LoadInt64(&this.migrationContext.IsBackpressuredFlag) > 0,
+		timeSince(this.migrationContext.GetLastDMLApplyProgressTime()),
+	)
+	streamerCoordinates := "n/a"
+	if this.eventsStreamer != nil {
+		if coordinates := this.eventsStreamer.GetCurrentBinlogCoordinates(); coordinates != nil {
+			streamerCoordinates = coordinates.DisplayString()
+		}
+	}
+	fmt.Fprintf(w, "# Streamer: coordinates: %s; last heartbeat read: %s\n",
+		streamerCoordinates,
+		timeSince(this.migrationContext.GetLastHeartbeatOnChangelogTime()),
+	)
+	fmt.Fprintf(w, "# Cut-over: in critical section: %t\n",
+		atomic.LoadInt64(&this.migrationContext.InCutOverCriticalSectionFlag) > 0,
+	)
+	fmt.Fprintf(w, "# Goroutines:\n")
+	pprof.Lookup("goroutine").WriteTo(w, 1)
+}
+
 // printStatus prints the progress status, and optionally additionally detailed
 // dump of configuration.
 // `rule` indicates the type of output expected.
@@ -1230,7 +2096,7 @@ func (this *Migrator) printStatus(rule PrintStatusRule, writers ...io.Writer) {
 
 	currentBinlogCoordinates := this.eventsStreamer.GetCurrentBinlogCoordinates()
 
-	status := fmt.Sprintf("Copy: %d/%d %.1f%%; Applied: %d; Backlog: %d/%d; Time: %+v(total), %+v(copy); streamer: %+v; Lag: %.2fs, HeartbeatLag: %.2fs, State: %s; ETA: %s",
+	status := fmt.Sprintf("Copy: %d/%d %.1f%%; Applied: %d; Backlog: %d/%d; Time: %+v(total), %+v(copy); streamer: %+v; Lag: %.2fs, HeartbeatLag: %.2fs, ApplierLag: %.2fs, State: %s; ETA: %s",
 		totalRowsCopied, rowsEstimate, progressPct,
 		atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
 		len(this.applyEventsQueue), cap(this.applyEventsQueue),
@@ -1238,9 +2104,13 @@ func (this *Migrator) printStatus(rule PrintStatusRule, writers ...io.Writer) {
 		currentBinlogCoordinates.DisplayString(),
 		this.migrationContext.GetCurrentLagDuration().Seconds(),
 		this.migrationContext.TimeSinceLastHeartbeatOnChangelog().Seconds(),
+		this.getApplierLag().Seconds(),
 		state,
 		eta,
 	)
+	if remaining, ok := this.migrationContext.GetRuntimeRemaining(); ok {
+		status = fmt.Sprintf("%s; Deadline: %s", status, formatRuntimeRemaining(remaining))
+	}
 	this.applier.WriteChangelog(
 		fmt.Sprintf("copy iteration %d at %d", this.migrationContext.GetIteration(), time.Now().Unix()),
 		state,
@@ -1248,13 +2118,7 @@ func (this *Migrator) printStatus(rule PrintStatusRule, writers ...io.Writer) {
 	w := io.MultiWriter(writers...)
 	fmt.Fprintln(w, status)
 
-	// This "hack" is required here because the underlying logging library
-	// github.com/outbrain/golib/log provides two functions Info and Infof; but the arguments of
-	// both these functions are eventually redirected to the same function, which internally calls
-	// fmt.Sprintf. So, the argument of every function called on the DefaultLogger object
-	// migrationContext.Log will eventually pass through fmt.Sprintf, and thus the '%' character
-	// needs to be escaped.
-	this.migrationContext.Log.Info(strings.Replace(status, "%", "%%", 1))
+	this.log.Infof("%s", status)
 
 	hooksStatusIntervalSec := this.migrationContext.HooksStatusIntervalSec
 	if hooksStatusIntervalSec > 0 && elapsedSeconds%hooksStatusIntervalSec == 0 {
@@ -1262,15 +2126,191 @@ func (this *Migrator) printStatus(rule PrintStatusRule, writers ...io.Writer) {
 	}
 }
 
+// getStatusSnapshot returns the current migration status. It only reads atomically updated
+// state, and so is safe to call from any goroutine.
+func (this *Migrator) getStatusSnapshot() *MigrationStatus {
+	totalRowsCopied := this.migrationContext.GetTotalRowsCopied()
+	rowsEstimate := atomic.LoadInt64(&this.migrationContext.RowsEstimate) + atomic.LoadInt64(&this.migrationContext.RowsDeltaEstimate)
+	rowCopyComplete := atomic.LoadInt64(&this.rowCopyCompleteFlag) == 1
+	if rowCopyComplete {
+		rowsEstimate = totalRowsCopied
+	}
+	state, _, _ := this.getMigrationStateAndETA(rowsEstimate)
+	isThrottled, throttleReason, _ := this.migrationContext.IsThrottled()
+	binlogCoordinates := ""
+	if coordinates := this.migrationContext.GetRecentBinlogCoordinates(); coordinates != nil {
+		binlogCoordinates = coordinates.DisplayString()
+	}
+	return &MigrationStatus{
+		DatabaseName:          this.migrationContext.DatabaseName,
+		TableName:             this.migrationContext.OriginalTableName,
+		GhostTableName:        this.migrationContext.GetGhostTableName(),
+		State:                 state,
+		RowsCopied:            totalRowsCopied,
+		RowsEstimate:          rowsEstimate,
+		ProgressPct:           this.migrationContext.GetProgressPct(),
+		RowCopyComplete:       rowCopyComplete,
+		EventsApplied:         atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
+		Backlog:               len(this.applyEventsQueue),
+		BacklogCapacity:       cap(this.applyEventsQueue),
+		BacklogMemoryBytes:    atomic.LoadInt64(&this.migrationContext.BacklogMemoryBytes),
+		Backpressured:         atomic.LoadInt64(&this.migrationContext.IsBackpressuredFlag) > 0,
+		ElapsedSeconds:        int64(this.migrationContext.ElapsedTime().Seconds()),
+		RowCopyElapsedSeconds: int64(this.migrationContext.ElapsedRowCopyTime().Seconds()),
+		ETASeconds:            this.migrationContext.GetETASeconds(),
+		BinlogCoordinates:     binlogCoordinates,
+		LagSeconds:            this.migrationContext.GetCurrentLagDuration().Seconds(),
+		HeartbeatLagSeconds:   this.migrationContext.TimeSinceLastHeartbeatOnChangelog().Seconds(),
+		ApplierLagSeconds:     this.getApplierLag().Seconds(),
+		MaxApplierLagSeconds:  this.migrationContext.GetApplierLagHighWaterMark().Seconds(),
+		Throttled:             isThrottled,
+		ThrottleReason:        throttleReason,
+		PostponingCutOver:     atomic.LoadInt64(&this.migrationContext.IsPostponingCutOver) > 0,
+		Stalled:               atomic.LoadInt64(&this.stalledFlag) > 0,
+		Stats:                 this.migrationContext.Stats.GetSnapshot(),
+	}
+}
+
+// warmUpGhostTable scans the ghost table's indexes once row copy is complete, reading their pages into
+// the buffer pool so that the migrated table is not served off cold pages right after cut-over.
+// The warm-up is best effort: it pauses while throttled, is bounded by --warm-up-seconds, may be
+// skipped via the 'skip-warm-up' interactive command, and never fails the migration.
+func (this *Migrator) warmUpGhostTable() {
+	budget := time.Duration(this.migrationContext.WarmUpSeconds) * time.Second
+	if budget <= 0 || this.migrationContext.Noop {
+		return
+	}
+	if atomic.LoadInt64(&this.migrationContext.UserCommandedSkipWarmUpFlag) > 0 {
+		this.log.Infof("Skipping ghost table warm-up, as instructed")
+		return
+	}
+	indexNames, err := this.applier.ReadGhostTableIndexes()
+	if err != nil {
+		this.log.Warningf("Skipping ghost table warm-up: cannot read indexes: %+v", err)
+		return
+	}
+	atomic.StoreInt64(&this.migrationContext.WarmUpIndexesTotal, int64(len(indexNames)))
+	atomic.StoreInt64(&this.migrationContext.IsWarmingUpFlag, 1)
+	defer atomic.StoreInt64(&this.migrationContext.IsWarmingUpFlag, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if atomic.LoadInt64(&this.migrationContext.UserCommandedSkipWarmUpFlag) > 0 {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	this.log.Infof("Warming up %d indexes of ghost table, for up to %+v", len(indexNames), budget)
+	for _, indexName := range indexNames {
+		for isThrottled, _, _ := this.migrationContext.IsThrottled(); isThrottled && ctx.Err() == nil; isThrottled, _, _ = this.migrationContext.IsThrottled() {
+			time.Sleep(250 * time.Millisecond)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		deadline, _ := ctx.Deadline()
+		if err := this.applier.WarmUpGhostTableIndex(ctx, indexName, time.Until(deadline)); err != nil {
+			if ctx.Err() != nil || mysql.IsQueryTimeoutError(err) {
+				break
+			}
+			this.log.Warningf("Stopping ghost table warm-up: %+v", err)
+			return
+		}
+		atomic.AddInt64(&this.migrationContext.WarmUpIndexesDone, 1)
+	}
+	warmedUp := atomic.LoadInt64(&this.migrationContext.WarmUpIndexesDone)
+	switch {
+	case atomic.LoadInt64(&this.migrationContext.UserCommandedSkipWarmUpFlag) > 0:
+		this.log.Infof("Ghost table warm-up skipped, as instructed, after %d/%d indexes", warmedUp, len(indexNames))
+	case warmedUp < int64(len(indexNames)):
+		this.log.Infof("Ghost table warm-up ran out of time after %d/%d indexes", warmedUp, len(indexNames))
+	default:
+		this.log.Infof("Ghost table warm-up complete")
+	}
+}
+
+// attemptInplaceIndexDDL runs the index-only ALTER in-place on the original table, logging its progress
+// as reported by performance_schema. The ALTER is killed if the panic flag file is created meanwhile.
+func (this *Migrator) attemptInplaceIndexDDL() error {
+	atomic.StoreInt64(&this.migrationContext.IsRunningInplaceIndexDDLFlag, 1)
+	defer atomic.StoreInt64(&this.migrationContext.IsRunningInplaceIndexDDLFlag, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	onStarted := func(connectionID string) {
+		go this.monitorInplaceIndexDDL(ctx, cancel, connectionID)
+	}
+	if err := this.applier.AttemptInplaceIndexDDL(ctx, onStarted); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("In-place index DDL aborted: found panic-flag-file %s", this.migrationContext.PanicFlagFile)
+		}
+		return err
+	}
+	return nil
+}
+
+// monitorInplaceIndexDDL periodically logs the progress of an in-place ALTER running on given connection,
+// and cancels it when the panic flag file is found.
+func (this *Migrator) monitorInplaceIndexDDL(ctx context.Context, cancel context.CancelFunc, connectionID string) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	startTime := time.Now()
+	progressReported := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if this.migrationContext.PanicFlagFile != "" && base.FileExists(this.migrationContext.PanicFlagFile) {
+			this.log.Errorf("Found panic-flag-file %s; aborting in-place index DDL", this.migrationContext.PanicFlagFile)
+			cancel()
+			return
+		}
+		elapsedSeconds := int64(time.Since(startTime).Seconds())
+		if elapsedSeconds%5 != 0 {
+			continue
+		}
+		stage, workCompleted, workEstimated, found, err := this.applier.ReadInplaceDDLProgress(connectionID)
+		switch {
+		case err != nil:
+			this.log.Debugf("Cannot read in-place index DDL progress: %+v", err)
+		case found && workEstimated > 0:
+			progressReported = true
+			this.log.Infof("In-place index DDL: %s, %d/%d (%.1f%%); Time: %+v",
+				stage, workCompleted, workEstimated, 100.0*float64(workCompleted)/float64(workEstimated), time.Since(startTime).Round(time.Second),
+			)
+		case found:
+			progressReported = true
+			this.log.Infof("In-place index DDL: %s; Time: %+v", stage, time.Since(startTime).Round(time.Second))
+		case !progressReported:
+			progressReported = true
+			this.log.Infof("In-place index DDL running; no progress reported by performance_schema. Enable the stage/innodb/alter%% instruments and the events_stages_current consumer for progress info")
+		}
+	}
+}
+
 // initiateStreaming begins streaming of binary log events and registers listeners for such events
 func (this *Migrator) initiateStreaming() error {
 	this.eventsStreamer = NewEventsStreamer(this.migrationContext)
 	if err := this.eventsStreamer.InitDBConnections(); err != nil {
 		return err
 	}
+	this.migrationContext.StartBinlogCoordinates = this.eventsStreamer.GetCurrentBinlogCoordinates()
 	this.eventsStreamer.AddListener(
 		false,
-		this.migrationContext.DatabaseName,
+		this.migrationContext.GetChangelogDatabaseName(),
 		this.migrationContext.GetChangelogTableName(),
 		func(dmlEntry *binlog.BinlogEntry) error {
 			return this.onChangelogEvent(dmlEntry)
@@ -1278,12 +2318,12 @@ func (this *Migrator) initiateStreaming() error {
 	)
 
 	go func() {
-		this.migrationContext.Log.Debugf("Beginning streaming")
+		this.log.Debugf("Beginning streaming")
 		err := this.eventsStreamer.StreamEvents(this.canStopStreaming)
 		if err != nil {
 			this.migrationContext.PanicAbort <- err
 		}
-		this.migrationContext.Log.Debugf("Done streaming")
+		this.log.Debugf("Done streaming")
 	}()
 
 	go func() {
@@ -1307,6 +2347,8 @@ func (this *Migrator) addDMLEventsListener() error {
 		this.migrationContext.DatabaseName,
 		this.migrationContext.OriginalTableName,
 		func(dmlEntry *binlog.BinlogEntry) error {
+			atomic.AddInt64(&this.migrationContext.BacklogMemoryBytes, dmlEntry.Size)
+			this.migrationContext.MarkBinlogEventQueued(dmlEntry.Timestamp)
 			this.applyEventsQueue <- newApplyEventStructByDML(dmlEntry)
 			return nil
 		},
@@ -1319,22 +2361,29 @@ func (this *Migrator) initiateThrottler() {
 	this.throttler = NewThrottler(this.migrationContext, this.applier, this.inspector, this.appVersion)
 
 	go this.throttler.initiateThrottlerCollection(this.firstThrottlingCollected)
-	this.migrationContext.Log.Infof("Waiting for first throttle metrics to be collected")
+	this.log.Infof("Waiting for first throttle metrics to be collected")
 	<-this.firstThrottlingCollected // replication lag
 	<-this.firstThrottlingCollected // HTTP status
 	<-this.firstThrottlingCollected // other, general metrics
-	this.migrationContext.Log.Infof("First throttle metrics collected")
+	this.log.Infof("First throttle metrics collected")
 	go this.throttler.initiateThrottlerChecks()
 }
 
-func (this *Migrator) initiateApplier() error {
+// connectApplier connects the applier, without creating any table
+func (this *Migrator) connectApplier() error {
 	this.applier = NewApplier(this.migrationContext)
-	if err := this.applier.InitDBConnections(); err != nil {
-		return err
+	return this.applier.InitDBConnections()
+}
+
+func (this *Migrator) initiateApplier() error {
+	if this.applier == nil {
+		if err := this.connectApplier(); err != nil {
+			return err
+		}
 	}
 	if this.migrationContext.Revert {
 		if err := this.applier.CreateChangelogTable(); err != nil {
-			this.migrationContext.Log.Errorf("Unable to create changelog table, see further error details. Perhaps a previous migration failed without dropping the table? OR is there a running migration? Bailing out")
+			this.log.Errorf("Unable to create changelog table, see further error details. Perhaps a previous migration failed without dropping the table? OR is there a running migration? Bailing out")
 			return err
 		}
 	} else if !this.migrationContext.Resume {
@@ -1342,15 +2391,15 @@ func (this *Migrator) initiateApplier() error {
 			return err
 		}
 		if err := this.applier.CreateChangelogTable(); err != nil {
-			this.migrationContext.Log.Errorf("Unable to create changelog table, see further error details. Perhaps a previous migration failed without dropping the table? OR is there a running migration? Bailing out")
+			this.log.Errorf("Unable to create changelog table, see further error details. Perhaps a previous migration failed without dropping the table? OR is there a running migration? Bailing out")
 			return err
 		}
 		if err := this.applier.CreateGhostTable(); err != nil {
-			this.migrationContext.Log.Errorf("Unable to create ghost table, see further error details. Perhaps a previous migration failed without dropping the table? Bailing out")
+			this.log.Errorf("Unable to create ghost table, see further error details. Perhaps a previous migration failed without dropping the table? Bailing out")
 			return err
 		}
 		if err := this.applier.AlterGhost(); err != nil {
-			this.migrationContext.Log.Errorf("Unable to ALTER ghost table, see further error details. Bailing out")
+			this.log.Errorf("Unable to ALTER ghost table, see further error details. Bailing out")
 			return err
 		}
 
@@ -1358,25 +2407,34 @@ func (this *Migrator) initiateApplier() error {
 			// Original table has AUTO_INCREMENT value and the -alter statement does not indicate any override,
 			// so we should copy AUTO_INCREMENT value onto our ghost table.
 			if err := this.applier.AlterGhostAutoIncrement(); err != nil {
-				this.migrationContext.Log.Errorf("Unable to ALTER ghost table AUTO_INCREMENT value, see further error details. Bailing out")
+				this.log.Errorf("Unable to ALTER ghost table AUTO_INCREMENT value, see further error details. Bailing out")
 				return err
 			}
 		}
+		if err := this.applier.CreateGhostFullRowIndex(); err != nil {
+			this.log.Errorf("Unable to create temporary index on ghost table, see further error details. Bailing out")
+			return err
+		}
 		this.applier.WriteChangelogState(string(GhostTableMigrated))
 	}
+	if this.shouldValidateAssumedMaster() {
+		if err := this.validateAssumedMaster(); err != nil {
+			return err
+		}
+	}
 
 	// ensure performance_schema.metadata_locks is available.
 	if err := this.applier.StateMetadataLockInstrument(); err != nil {
-		this.migrationContext.Log.Warning("Unable to enable metadata lock instrument, see further error details.")
+		this.log.Warning("Unable to enable metadata lock instrument, see further error details.")
 	}
 	if !this.migrationContext.IsOpenMetadataLockInstruments {
 		if !this.migrationContext.SkipMetadataLockCheck {
-			return this.migrationContext.Log.Errorf("Bailing out because metadata lock instrument not enabled. Use --skip-metadata-lock-check if you wish to proceed without. See https://github.com/github/gh-ost/pull/1536 for details.")
+			return this.log.Errorf("Bailing out because metadata lock instrument not enabled. Use --skip-metadata-lock-check if you wish to proceed without. See https://github.com/github/gh-ost/pull/1536 for details.")
 		}
-		this.migrationContext.Log.Warning("Proceeding without metadata lock check. There is a small chance of data loss if another session accesses the ghost table during cut-over. See https://github.com/github/gh-ost/pull/1536 for details.")
+		this.log.Warning("Proceeding without metadata lock check. There is a small chance of data loss if another session accesses the ghost table during cut-over. See https://github.com/github/gh-ost/pull/1536 for details.")
 	}
 
-	go this.applier.InitiateHeartbeat()
+	go this.applier.InitiateHeartbeat(this.hooksExecutor.onChangelogRecreated)
 	return nil
 }
 
@@ -1385,16 +2443,17 @@ func (this *Migrator) initiateApplier() error {
 func (this *Migrator) iterateChunks() error {
 	terminateRowIteration := func(err error) error {
 		this.rowCopyComplete <- err
-		return this.migrationContext.Log.Errore(err)
+		return this.log.Errore(err)
 	}
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really copying data")
+		this.log.Debugf("Noop operation; not really copying data")
 		return terminateRowIteration(nil)
 	}
 	if this.migrationContext.MigrationRangeMinValues == nil {
-		this.migrationContext.Log.Debugf("No rows found in table. Rowcopy will be implicitly empty")
+		this.log.Debugf("No rows found in table. Rowcopy will be implicitly empty")
 		return terminateRowIteration(nil)
 	}
+	this.log.Infof("Copying rows with copy-conflict-strategy %s", this.migrationContext.CopyConflictStrategy)
 
 	var hasNoFurtherRangeFlag int64
 	// Iterate per chunk:
@@ -1437,22 +2496,24 @@ func (this *Migrator) iterateChunks() error {
 					// _ghost_ table, which no longer exists. So, bothering error messages and all, but no damage.
 					return nil
 				}
-				_, rowsAffected, _, err := this.applier.ApplyIterationInsertQuery()
+				chunkSize, rowsCopied, duration, err := this.applier.ApplyIterationInsertQuery()
 				if err != nil {
 					return err // wrapping call will retry
 				}
+				this.migrationContext.Stats.MarkChunkCopied(chunkSize, rowsCopied, duration)
 
 				if this.migrationContext.PanicOnWarnings {
 					if len(this.migrationContext.MigrationLastInsertSQLWarnings) > 0 {
 						for _, warning := range this.migrationContext.MigrationLastInsertSQLWarnings {
-							this.migrationContext.Log.Infof("ApplyIterationInsertQuery has SQL warnings! %s", warning)
+							this.log.Infof("ApplyIterationInsertQuery has SQL warnings! %s", warning)
 						}
 						joinedWarnings := strings.Join(this.migrationContext.MigrationLastInsertSQLWarnings, "; ")
 						terminateRowIteration(fmt.Errorf("ApplyIterationInsertQuery failed because of SQL warnings: [%s]", joinedWarnings))
 					}
 				}
 
-				atomic.AddInt64(&this.migrationContext.TotalRowsCopied, rowsAffected)
+				atomic.AddInt64(&this.migrationContext.TotalRowsCopied, rowsCopied)
+				this.migrationContext.MarkRowCopyProgress()
 				atomic.AddInt64(&this.migrationContext.Iteration, 1)
 				return nil
 			}
@@ -1470,7 +2531,7 @@ func (this *Migrator) onApplyEventStruct(eventStruct *applyEventStruct) error {
 	handleNonDMLEventStruct := func(eventStruct *applyEventStruct) error {
 		if eventStruct.writeFunc != nil {
 			if err := this.retryOperation(*eventStruct.writeFunc); err != nil {
-				return this.migrationContext.Log.Errore(err)
+				return this.log.Errore(err)
 			}
 		}
 		return nil
@@ -1481,10 +2542,12 @@ func (this *Migrator) onApplyEventStruct(eventStruct *applyEventStruct) error {
 	if eventStruct.dmlEvent != nil {
 		dmlEvents := [](*binlog.BinlogDMLEvent){}
 		dmlEvents = append(dmlEvents, eventStruct.dmlEvent)
+		dmlEventsSize := eventStruct.size
 		var nonDmlStructToApply *applyEventStruct
 
 		availableEvents := len(this.applyEventsQueue)
-		batchSize := int(atomic.LoadInt64(&this.migrationContext.DMLBatchSize))
+		// Each applier worker gets to apply up to a batch
+		batchSize := int(atomic.LoadInt64(&this.migrationContext.DMLBatchSize)) * int(this.migrationContext.GetApplierParallelism())
 		if availableEvents > batchSize-1 {
 			// The "- 1" is because we already consumed one event: the original event that led to this function getting called.
 			// So, if DMLBatchSize==1 we wish to not process any further events
@@ -1498,14 +2561,13 @@ func (this *Migrator) onApplyEventStruct(eventStruct *applyEventStruct) error {
 				break
 			}
 			dmlEvents = append(dmlEvents, additionalStruct.dmlEvent)
+			dmlEventsSize += additionalStruct.size
 		}
-		// Create a task to apply the DML event; this will be execute by executeWriteFuncs()
-		var applyEventFunc tableWriteFunc = func() error {
-			return this.applier.ApplyDMLEventQueries(dmlEvents)
-		}
-		if err := this.retryOperation(applyEventFunc); err != nil {
-			return this.migrationContext.Log.Errore(err)
+		if err := this.applyDMLEvents(dmlEvents); err != nil {
+			return this.log.Errore(err)
 		}
+		atomic.AddInt64(&this.migrationContext.BacklogMemoryBytes, -dmlEventsSize)
+		this.migrationContext.MarkBinlogEventsApplied(int64(len(dmlEvents)))
 		// update applier coordinates
 		this.applier.CurrentCoordinatesMutex.Lock()
 		this.applier.CurrentCoordinates = eventStruct.coords
@@ -1515,13 +2577,95 @@ func (this *Migrator) onApplyEventStruct(eventStruct *applyEventStruct) error {
 			// We pulled DML events from the queue, and then we hit a non-DML event. Wait!
 			// We need to handle it!
 			if err := handleNonDMLEventStruct(nonDmlStructToApply); err != nil {
-				return this.migrationContext.Log.Errore(err)
+				return this.log.Errore(err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyDMLEvents applies DML events onto the ghost table. With --applier-parallelism, events are spread
+// over applier workers by the row they apply to: events on a same row are applied in order, by the same
+// worker, while different rows are applied concurrently. An UPDATE modifying the unique key is applied on
+// its own, after all events preceding it and before any event following it. All workers are done by the
+// time this function returns, so that non-DML events, such as the cut-over's, find no event in flight.
+func (this *Migrator) applyDMLEvents(dmlEvents []*binlog.BinlogDMLEvent) error {
+	numWorkers := int(this.migrationContext.GetApplierParallelism())
+	// The applier-parallelism command may have changed the number of workers since the last call
+	this.applier.ResizeConnectionPool(numWorkers)
+	if numWorkers <= 1 {
+		return this.retryOperation(func() error {
+			return this.applier.ApplyDMLEventQueries(dmlEvents)
+		})
+	}
+	workersEvents := make([][]*binlog.BinlogDMLEvent, numWorkers)
+	for _, dmlEvent := range dmlEvents {
+		if index, ok := this.applier.dmlEventWorkerIndex(dmlEvent, numWorkers); ok {
+			workersEvents[index] = append(workersEvents[index], dmlEvent)
+			continue
+		}
+		if err := this.applyDMLEventsByWorkers(workersEvents); err != nil {
+			return err
+		}
+		clear(workersEvents)
+		if err := this.retryOperation(func() error {
+			return this.applier.ApplyDMLEventQueries([]*binlog.BinlogDMLEvent{dmlEvent})
+		}); err != nil {
+			return err
+		}
+	}
+	return this.applyDMLEventsByWorkers(workersEvents)
+}
+
+// applyDMLEventsByWorkers applies each worker's events, in batches of --dml-batch-size, concurrently to
+// other workers. It returns once all workers are done.
+func (this *Migrator) applyDMLEventsByWorkers(workersEvents [][]*binlog.BinlogDMLEvent) error {
+	batchSize := int(atomic.LoadInt64(&this.migrationContext.DMLBatchSize))
+	workersErrors := make([]error, len(workersEvents))
+	var wg sync.WaitGroup
+	for i, workerEvents := range workersEvents {
+		if len(workerEvents) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := 0; start < len(workerEvents); start += batchSize {
+				batch := workerEvents[start:min(start+batchSize, len(workerEvents))]
+				applyBatchFunc := func() error {
+					return this.applier.ApplyDMLEventQueries(batch)
+				}
+				if workersErrors[i] = this.retryOperation(applyBatchFunc, true); workersErrors[i] != nil {
+					return
+				}
 			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range workersErrors {
+		if err != nil {
+			this.migrationContext.PanicAbort <- err
+			return err
 		}
 	}
 	return nil
 }
 
+// resumeIterationPartition restores the partition row copy iterates, as of given checkpoint. Its range
+// is that partition's, and the partitions before it are copied.
+func (this *Migrator) resumeIterationPartition(checkpoint *Checkpoint) error {
+	partitionIndex := int(checkpoint.IterationPartitionIndex)
+	if partitionIndex == 0 {
+		return nil
+	}
+	if partitionIndex < 0 || partitionIndex >= len(this.migrationContext.IterationPartitions) {
+		return this.log.Errorf("Checkpoint is at partition index %d, but row copy iterates %d partitions; unable to resume", partitionIndex, len(this.migrationContext.IterationPartitions))
+	}
+	this.migrationContext.IterationPartitionIndex = partitionIndex
+	this.log.Infof("Resuming row copy at partition %s", this.migrationContext.GetIterationPartition())
+	return nil
+}
+
 // Checkpoint attempts to write a checkpoint of the Migrator's current state.
 // It gets the binlog coordinates of the last received trx and waits until the
 // applier reaches that trx. At that point it's safe to resume from these coordinates.
@@ -1533,12 +2677,13 @@ func (this *Migrator) Checkpoint(ctx context.Context) (*Checkpoint, error) {
 		return nil, errors.New("iteration range is empty, not checkpointing...")
 	}
 	chk := &Checkpoint{
-		Iteration:         this.migrationContext.GetIteration(),
-		IterationRangeMin: this.applier.LastIterationRangeMinValues.Clone(),
-		IterationRangeMax: this.applier.LastIterationRangeMaxValues.Clone(),
-		LastTrxCoords:     coords,
-		RowsCopied:        atomic.LoadInt64(&this.migrationContext.TotalRowsCopied),
-		DMLApplied:        atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
+		Iteration:               this.migrationContext.GetIteration(),
+		IterationRangeMin:       this.applier.LastIterationRangeMinValues.Clone(),
+		IterationRangeMax:       this.applier.LastIterationRangeMaxValues.Clone(),
+		IterationPartitionIndex: int64(this.applier.LastIterationPartitionIndex),
+		LastTrxCoords:           coords,
+		RowsCopied:              atomic.LoadInt64(&this.migrationContext.TotalRowsCopied),
+		DMLApplied:              atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
 	}
 	this.applier.LastIterationRangeMutex.Unlock()
 
@@ -1563,7 +2708,7 @@ func (this *Migrator) Checkpoint(ctx context.Context) (*Checkpoint, error) {
 // CheckpointAfterCutOver writes a final checkpoint after the cutover completes successfully.
 func (this *Migrator) CheckpointAfterCutOver() (*Checkpoint, error) {
 	if this.lastLockProcessed == nil || this.lastLockProcessed.coords.IsEmpty() {
-		return nil, this.migrationContext.Log.Errorf("lastLockProcessed coords are empty")
+		return nil, this.log.Errorf("lastLockProcessed coords are empty")
 	}
 
 	chk := &Checkpoint{
@@ -1582,6 +2727,7 @@ func (this *Migrator) CheckpointAfterCutOver() (*Checkpoint, error) {
 	if this.applier.LastIterationRangeMaxValues != nil {
 		chk.IterationRangeMax = this.applier.LastIterationRangeMaxValues.Clone()
 	}
+	chk.IterationPartitionIndex = int64(this.applier.LastIterationPartitionIndex)
 	this.applier.LastIterationRangeMutex.Unlock()
 
 	id, err := this.applier.WriteCheckpoint(chk)
@@ -1591,7 +2737,7 @@ func (this *Migrator) CheckpointAfterCutOver() (*Checkpoint, error) {
 
 func (this *Migrator) checkpointLoop() {
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really checkpointing")
+		this.log.Debugf("Noop operation; not really checkpointing")
 		return
 	}
 	checkpointInterval := time.Duration(this.migrationContext.CheckpointIntervalSeconds) * time.Second
@@ -1603,17 +2749,17 @@ func (this *Migrator) checkpointLoop() {
 		if atomic.LoadInt64(&this.migrationContext.InCutOverCriticalSectionFlag) > 0 {
 			continue
 		}
-		this.migrationContext.Log.Infof("starting checkpoint at %+v", t)
+		this.log.Infof("starting checkpoint at %+v", t)
 		ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
 		chk, err := this.Checkpoint(ctx)
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				this.migrationContext.Log.Errorf("checkpoint attempt timed out after %+v", checkpointTimeout)
+				this.log.Errorf("checkpoint attempt timed out after %+v", checkpointTimeout)
 			} else {
-				this.migrationContext.Log.Errorf("error attempting checkpoint: %+v", err)
+				this.log.Errorf("error attempting checkpoint: %+v", err)
 			}
 		} else {
-			this.migrationContext.Log.Infof("checkpoint success at coords=%+v range_min=%+v range_max=%+v iteration=%d",
+			this.log.Infof("checkpoint success at coords=%+v range_min=%+v range_max=%+v iteration=%d",
 				chk.LastTrxCoords.DisplayString(), chk.IterationRangeMin.String(), chk.IterationRangeMax.String(), chk.Iteration)
 		}
 		cancel()
@@ -1625,7 +2771,7 @@ func (this *Migrator) checkpointLoop() {
 // Both event backlog and rowcopy events are polled; the backlog events have precedence.
 func (this *Migrator) executeWriteFuncs() error {
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really executing write funcs")
+		this.log.Debugf("Noop operation; not really executing write funcs")
 		return nil
 	}
 	for {
@@ -1652,7 +2798,7 @@ func (this *Migrator) executeWriteFuncs() error {
 						copyRowsStartTime := time.Now()
 						// Retries are handled within the copyRowsFunc
 						if err := copyRowsFunc(); err != nil {
-							return this.migrationContext.Log.Errore(err)
+							return this.log.Errore(err)
 						}
 						if niceRatio := this.migrationContext.GetNiceRatio(); niceRatio > 0 {
 							copyRowsDuration := time.Since(copyRowsStartTime)
@@ -1665,7 +2811,7 @@ func (this *Migrator) executeWriteFuncs() error {
 					{
 						// Hmmmmm... nothing in the queue; no events, but also no row copy.
 						// This is possible upon load. Let's just sleep it over.
-						this.migrationContext.Log.Debugf("Getting nothing in the write queue. Sleeping...")
+						this.log.Debugf("Getting nothing in the write queue. Sleeping...")
 						time.Sleep(time.Second)
 					}
 				}
@@ -1676,7 +2822,7 @@ func (this *Migrator) executeWriteFuncs() error {
 
 func (this *Migrator) executeDMLWriteFuncs() error {
 	if this.migrationContext.Noop {
-		this.migrationContext.Log.Debugf("Noop operation; not really executing DML write funcs")
+		this.log.Debugf("Noop operation; not really executing DML write funcs")
 		return nil
 	}
 	for {
@@ -1699,27 +2845,101 @@ func (this *Migrator) executeDMLWriteFuncs() error {
 	}
 }
 
+// migrationSummary is written to --summary-file upon success
+type migrationSummary struct {
+	DatabaseName                     string  `json:"database_name"`
+	TableName                        string  `json:"table_name"`
+	AlterStatement                   string  `json:"alter_statement"`
+	Revert                           bool    `json:"revert"`
+	ElapsedSeconds                   float64 `json:"elapsed_seconds"`
+	RowsCopied                       int64   `json:"rows_copied"`
+	DMLEventsApplied                 int64   `json:"dml_events_applied"`
+	StartBinlogCoordinates           string  `json:"start_binlog_coordinates"`
+	RowCopyCompleteBinlogCoordinates string  `json:"row_copy_complete_binlog_coordinates"`
+	CutOverBinlogCoordinates         string  `json:"cut_over_binlog_coordinates"`
+	TimeZone                         string  `json:"time_zone"`
+	PendingCleanupStatement          string  `json:"pending_cleanup_statement,omitempty"`
+}
+
+// displayBinlogCoordinates returns the file:pos or GTID set of given coordinates, or an empty string
+func displayBinlogCoordinates(coordinates mysql.BinlogCoordinates) string {
+	if coordinates == nil || coordinates.IsEmpty() {
+		return ""
+	}
+	return coordinates.DisplayString()
+}
+
+// reportSummary logs the binlog coordinates the migration went through, and writes --summary-file.
+// The migration has succeeded by now, and so failing to write the summary is not fatal.
+func (this *Migrator) reportSummary() {
+	summary := migrationSummary{
+		DatabaseName:                     this.migrationContext.DatabaseName,
+		TableName:                        this.migrationContext.OriginalTableName,
+		AlterStatement:                   this.migrationContext.AlterStatement,
+		Revert:                           this.migrationContext.Revert,
+		ElapsedSeconds:                   this.migrationContext.ElapsedTime().Seconds(),
+		RowsCopied:                       this.migrationContext.GetTotalRowsCopied(),
+		DMLEventsApplied:                 atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
+		StartBinlogCoordinates:           displayBinlogCoordinates(this.migrationContext.StartBinlogCoordinates),
+		RowCopyCompleteBinlogCoordinates: displayBinlogCoordinates(this.migrationContext.RowCopyCompleteBinlogCoordinates),
+		CutOverBinlogCoordinates:         displayBinlogCoordinates(this.migrationContext.CutOverBinlogCoordinates),
+		TimeZone:                         this.migrationContext.ApplierTimeZone,
+		PendingCleanupStatement:          this.migrationContext.PendingCleanupStatement,
+	}
+	this.log.Infof("Binlog coordinates: start: %s; row-copy complete: %s; cut-over: %s",
+		summary.StartBinlogCoordinates, summary.RowCopyCompleteBinlogCoordinates, summary.CutOverBinlogCoordinates,
+	)
+	if this.migrationContext.SummaryFile == "" {
+		return
+	}
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		this.log.Errore(err)
+		return
+	}
+	if err := os.WriteFile(this.migrationContext.SummaryFile, append(content, '\n'), 0644); err != nil {
+		this.log.Errorf("Failed to write --summary-file %s: %+v", this.migrationContext.SummaryFile, err)
+		return
+	}
+	this.log.Infof("Wrote summary to %s", this.migrationContext.SummaryFile)
+}
+
 // finalCleanup takes actions at very end of migration, dropping tables etc.
 func (this *Migrator) finalCleanup() error {
+	if err := this.stopMigrating(); err != nil {
+		return err
+	}
+	return this.dropMigrationTables()
+}
+
+// stopMigrating stops streaming and applying binlog events, and the heartbeat, once cut over
+func (this *Migrator) stopMigrating() error {
 	atomic.StoreInt64(&this.migrationContext.CleanupImminentFlag, 1)
+	atomic.StoreInt64(&this.finishedMigrating, 1)
+	// The changelog table is dropped below, and must not be recreated by the heartbeat
+	this.applier.StopHeartbeat()
 
-	this.migrationContext.Log.Infof("Writing changelog state: %+v", Migrated)
+	this.log.Infof("Writing changelog state: %+v", Migrated)
 	if _, err := this.applier.WriteChangelogState(string(Migrated)); err != nil {
 		return err
 	}
 
 	if this.migrationContext.Noop {
-		if createTableStatement, err := this.inspector.showCreateTable(this.migrationContext.GetGhostTableName()); err == nil {
-			this.migrationContext.Log.Infof("New table structure follows")
+		if createTableStatement, err := this.inspector.showCreateTable(this.migrationContext.GetGhostDatabaseName(), this.migrationContext.GetGhostTableName()); err == nil {
+			this.log.Infof("New table structure follows")
 			fmt.Println(createTableStatement)
 		} else {
-			this.migrationContext.Log.Errore(err)
+			this.log.Errore(err)
 		}
 	}
 	if err := this.eventsStreamer.Close(); err != nil {
-		this.migrationContext.Log.Errore(err)
+		this.log.Errore(err)
 	}
+	return nil
+}
 
+// dropMigrationTables drops the changelog table, and the old and checkpoint tables if so requested
+func (this *Migrator) dropMigrationTables() error {
 	if err := this.retryOperation(this.applier.DropChangelogTable); err != nil {
 		return err
 	}
@@ -1731,11 +2951,11 @@ func (this *Migrator) finalCleanup() error {
 			return err
 		}
 	} else if !this.migrationContext.Noop {
-		this.migrationContext.Log.Infof("Am not dropping old table because I want this operation to be as live as possible. If you insist I should do it, please add `--ok-to-drop-table` next time. But I prefer you do not. To drop the old table, issue:")
-		this.migrationContext.Log.Infof("-- drop table %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.GetOldTableName()))
+		this.log.Infof("Am not dropping old table because I want this operation to be as live as possible. If you insist I should do it, please add `--ok-to-drop-table` next time. But I prefer you do not. To drop the old table, issue:")
+		this.log.Infof("-- drop table %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.GetOldTableName()))
 		if this.migrationContext.Checkpoint {
-			this.migrationContext.Log.Infof("Am not dropping checkpoint table without `--ok-to-drop-table`. To drop the checkpoint table, issue:")
-			this.migrationContext.Log.Infof("-- drop table %s.%s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.GetCheckpointTableName()))
+			this.log.Infof("Am not dropping checkpoint table without `--ok-to-drop-table`. To drop the checkpoint table, issue:")
+			this.log.Infof("-- drop table %s.%s", sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()), sql.EscapeName(this.migrationContext.GetCheckpointTableName()))
 		}
 	}
 	if this.migrationContext.Noop {
@@ -1751,22 +2971,22 @@ func (this *Migrator) teardown() {
 	atomic.StoreInt64(&this.finishedMigrating, 1)
 
 	if this.inspector != nil {
-		this.migrationContext.Log.Infof("Tearing down inspector")
+		this.log.Infof("Tearing down inspector")
 		this.inspector.Teardown()
 	}
 
 	if this.applier != nil {
-		this.migrationContext.Log.Infof("Tearing down applier")
+		this.log.Infof("Tearing down applier")
 		this.applier.Teardown()
 	}
 
 	if this.eventsStreamer != nil {
-		this.migrationContext.Log.Infof("Tearing down streamer")
+		this.log.Infof("Tearing down streamer")
 		this.eventsStreamer.Teardown()
 	}
 
 	if this.throttler != nil {
-		this.migrationContext.Log.Infof("Tearing down throttler")
+		this.log.Infof("Tearing down throttler")
 		this.throttler.Teardown()
 	}
 }
@@ -0,0 +1,753 @@
+/*
+   Copyright 2025 GitHub Inc.
+         See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/github/gh-ost/go/base"
+	"github.com/github/gh-ost/go/binlog"
+	"github.com/github/gh-ost/go/mysql"
+	"github.com/github/gh-ost/go/sql"
+)
+
+// newFakeMigrator returns a migrator whose applier runs against a fake MySQL server
+func newFakeMigrator(t testing.TB) (*Migrator, *fakeMySQL) {
+	oldRetrySleepFn := RetrySleepFn
+	t.Cleanup(func() { RetrySleepFn = oldRetrySleepFn })
+	RetrySleepFn = func(time.Duration) {}
+
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = testMysqlDatabase
+	migrationContext.OriginalTableName = testMysqlTableName
+	migrationContext.OriginalTableColumns = sql.NewColumnList([]string{"id", "item_id"})
+	migrationContext.SharedColumns = sql.NewColumnList([]string{"id", "item_id"})
+	migrationContext.MappedSharedColumns = sql.NewColumnList([]string{"id", "item_id"})
+	migrationContext.UniqueKey = &sql.UniqueKey{
+		Name:    "PRIMARY",
+		Columns: *sql.NewColumnList([]string{"id"}),
+	}
+	migrationContext.PanicAbort = make(chan error, 1)
+
+	fake := newFakeMySQL()
+	applier := NewApplier(migrationContext)
+	require.NoError(t, applier.prepareQueries())
+	applier.db = fake.DB()
+	applier.singletonDB = fake.DB()
+
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	migrator.applier = applier
+	return migrator, fake
+}
+
+func newFakeInsertEventStruct(id int, logPos int64) *applyEventStruct {
+	return newApplyEventStructByDML(&binlog.BinlogEntry{
+		Coordinates: mysql.NewFileBinlogCoordinates("mysql-bin.000001", logPos),
+		DmlEvent: &binlog.BinlogDMLEvent{
+			DatabaseName:    testMysqlDatabase,
+			TableName:       testMysqlTableName,
+			DML:             binlog.InsertDML,
+			NewColumnValues: sql.ToColumnValues([]interface{}{id, 42}),
+		},
+	})
+}
+
+func TestMigratorScenarioApplyDMLEvents(t *testing.T) {
+	t.Run("happy-path", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+
+		require.NoError(t, migrator.onApplyEventStruct(newFakeInsertEventStruct(1, 100)))
+		require.Equal(t, int64(1), migrator.migrationContext.TotalDMLEventsApplied)
+		require.Equal(t, mysql.NewFileBinlogCoordinates("mysql-bin.000001", 100), migrator.applier.CurrentCoordinates)
+		require.Equal(t, 1, fake.countQueries(`^SET /\* gh-ost \*/ SESSION time_zone`))
+		require.Equal(t, 1, fake.countQueries(`^replace /\* gh-ost `+"`test`.`_testing_gho`"))
+		require.Equal(t, 1, fake.countQueries(`^commit$`))
+		require.Equal(t, 0, fake.countQueries(`^rollback$`))
+	})
+
+	t.Run("deadlock-retry", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		fake.expect(`^replace /\* gh-ost`).returnError(newFakeMySQLError(1213)).withDelay(time.Millisecond).times(2)
+
+		require.NoError(t, migrator.onApplyEventStruct(newFakeInsertEventStruct(1, 100)))
+		require.Equal(t, int64(1), migrator.migrationContext.TotalDMLEventsApplied)
+		require.Equal(t, int64(2), migrator.migrationContext.Stats.GetSnapshot().Retries)
+		require.Equal(t, 3, fake.countQueries(`^replace /\* gh-ost`))
+		require.Equal(t, 2, fake.countQueries(`^rollback$`))
+		require.Equal(t, 1, fake.countQueries(`^commit$`))
+	})
+
+	t.Run("retries-exhausted", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.SetDefaultNumRetries(3)
+		fake.expect(`^replace /\* gh-ost`).returnError(newFakeMySQLError(1205))
+
+		require.Error(t, migrator.onApplyEventStruct(newFakeInsertEventStruct(1, 100)))
+		require.ErrorContains(t, <-migrator.migrationContext.PanicAbort, "1205")
+		require.Equal(t, int64(0), migrator.migrationContext.TotalDMLEventsApplied)
+		require.Equal(t, 3, fake.countQueries(`^replace /\* gh-ost`))
+		require.Nil(t, migrator.applier.CurrentCoordinates)
+	})
+
+	t.Run("parallel-retries-exhausted", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.SetDefaultNumRetries(3)
+		migrator.migrationContext.SetApplierParallelism(4)
+		fake.expect(`^replace /\* gh-ost`).returnError(newFakeMySQLError(1205))
+
+		dmlEvents := []*binlog.BinlogDMLEvent{}
+		for id := 0; id < 8; id++ {
+			dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{id, 42}))
+		}
+		require.ErrorContains(t, migrator.applyDMLEvents(dmlEvents), "1205")
+		require.ErrorContains(t, <-migrator.migrationContext.PanicAbort, "1205")
+		require.Len(t, migrator.migrationContext.PanicAbort, 0)
+		require.Equal(t, int64(0), migrator.migrationContext.TotalDMLEventsApplied)
+	})
+}
+
+func TestMigratorScenarioAtomicCutOverLockTimeout(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	fake.expect(`get_lock`).returnRows([]string{"get_lock"}, []driver.Value{int64(1)})
+	// The sentry table does not exist before being created, and is found afterwards
+	fake.expect(`show /\* gh-ost \*/ table status`).returnRows([]string{"Name", "Comment"}).times(1)
+	fake.expect(`show /\* gh-ost \*/ table status`).returnRows([]string{"Name", "Comment"}, []driver.Value{"_testing_del", atomicCutOverMagicHint})
+	fake.expect(`^lock /\* gh-ost \*/ tables`).returnError(newFakeMySQLError(1205))
+
+	err := migrator.atomicCutOver()
+	require.ErrorContains(t, err, "1205")
+	require.Equal(t, int64(0), atomic.LoadInt64(&migrator.migrationContext.InCutOverCriticalSectionFlag))
+
+	// The lock session rolls back and drops the sentry table, releasing the way for the next attempt
+	require.Eventually(t, func() bool {
+		return fake.countQueries("^drop /\\* gh-ost \\*/ table if exists `test`.`_testing_del`") == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, fake.countQueries(`^rollback$`))
+	require.Equal(t, 0, fake.countQueries(`rename /\* gh-ost \*/ table`))
+}
+
+func TestMigratorScenarioVerifyRowCount(t *testing.T) {
+	countOriginal := "count\\(\\*\\) as count_rows from `test`.`testing`$"
+	countGhost := "count\\(\\*\\) as count_rows from `test`.`_testing_gho`$"
+
+	t.Run("disabled", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		require.NoError(t, migrator.applier.LockOriginalTable())
+		require.NoError(t, migrator.verifyRowCount())
+		require.Equal(t, 0, fake.countQueries(`count\(\*\)`))
+	})
+
+	t.Run("above-threshold", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.VerifyRowCountThreshold = 100
+		migrator.migrationContext.RowsEstimate = 90
+		migrator.migrationContext.RowsDeltaEstimate = 10
+		require.NoError(t, migrator.applier.LockOriginalTable())
+		require.NoError(t, migrator.verifyRowCount())
+		require.Equal(t, 0, fake.countQueries(`count\(\*\)`))
+	})
+
+	t.Run("match", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.VerifyRowCountThreshold = 100
+		migrator.migrationContext.RowsEstimate = 2
+		fake.expect(countOriginal).returnRows([]string{"count_rows"}, []driver.Value{int64(3)})
+		fake.expect(countGhost).returnRows([]string{"count_rows"}, []driver.Value{int64(3)})
+		require.NoError(t, migrator.applier.LockOriginalTable())
+		require.NoError(t, migrator.verifyRowCount())
+		require.Equal(t, 1, fake.countQueries(countOriginal))
+		require.Equal(t, 1, fake.countQueries(countGhost))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.VerifyRowCountThreshold = 100
+		migrator.migrationContext.RowsEstimate = 2
+		fake.expect(countOriginal).returnRows([]string{"count_rows"}, []driver.Value{int64(3)})
+		fake.expect(countGhost).returnRows([]string{"count_rows"}, []driver.Value{int64(2)})
+		require.NoError(t, migrator.applier.LockOriginalTable())
+		err := migrator.verifyRowCount()
+		require.ErrorIs(t, err, ErrRowCountMismatch)
+		require.ErrorContains(t, err, "3 rows on `testing`, 2 rows on `_testing_gho`")
+		require.Equal(t, "row-count-mismatch", migrator.failureClass(err))
+	})
+}
+
+func TestMigratorScenarioCopyConflictRowsCopied(t *testing.T) {
+	for _, strategy := range []sql.CopyConflictStrategy{sql.CopyConflictIgnore, sql.CopyConflictReplace, sql.CopyConflictUpsert} {
+		t.Run(string(strategy), func(t *testing.T) {
+			migrator, fake := newFakeMigrator(t)
+			migrator.migrationContext.CopyConflictStrategy = strategy
+			migrator.migrationContext.MigrationIterationRangeMinValues = sql.ToColumnValues([]interface{}{1})
+			migrator.migrationContext.MigrationIterationRangeMaxValues = sql.ToColumnValues([]interface{}{10})
+
+			// Rows copied are the chunk-INSERT's affected rows, with no further read of the range
+			_, rowsCopied, _, err := migrator.applier.ApplyIterationInsertQuery()
+			require.NoError(t, err)
+			require.Equal(t, int64(1), rowsCopied)
+			require.Equal(t, 0, fake.countQueries(`count\(\*\)`))
+		})
+	}
+}
+
+func TestMigratorScenarioRangeEndTimeout(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrationContext := migrator.migrationContext
+	migrationContext.MigrationRangeMinValues = sql.ToColumnValues([]interface{}{1})
+	migrationContext.MigrationRangeMaxValues = sql.ToColumnValues([]interface{}{100000})
+	migrationContext.QueryMaxExecutionTimeMillis = 1000
+	migrationContext.SetChunkSize(80)
+	migrationContext.SetDefaultNumRetries(10)
+	rangeEndQuery := `^select /\*\+ MAX_EXECUTION_TIME\(1000\) \*/ /\* gh-ost .* iteration:`
+	fake.expect(rangeEndQuery).returnError(newFakeMySQLError(3024))
+
+	// Each timeout halves the chunk-size, down to the minimum, past which timeouts use up the retries
+	err := migrator.retryOperation(func() (e error) {
+		_, e = migrator.applier.CalculateNextIterationRangeEndValues()
+		return e
+	}, true)
+	require.ErrorContains(t, err, "with the minimal chunk-size 10")
+	require.Equal(t, int64(base.MinChunkSize), atomic.LoadInt64(&migrationContext.ChunkSize))
+	require.Equal(t, 10, fake.countQueries(rangeEndQuery))
+}
+
+func TestMigratorScenarioResumePartitionedRowCopy(t *testing.T) {
+	newPartitionedMigrator := func(t *testing.T) (*Migrator, *fakeMySQL) {
+		migrator, fake := newFakeMigrator(t)
+		migrator.migrationContext.IterationPartitions = []string{"p0", "p1", "p2"}
+		migrator.migrationContext.MigrationRangeMinValues = sql.ToColumnValues([]interface{}{int64(1)})
+		migrator.migrationContext.MigrationRangeMaxValues = sql.ToColumnValues([]interface{}{int64(100)})
+		return migrator, fake
+	}
+	rangeEndQuery := func(partitionName string) string {
+		return fmt.Sprintf("^select\\s+/\\* gh-ost .* iteration:.* partition \\(`%s`\\)", partitionName)
+	}
+
+	migrator, fake := newPartitionedMigrator(t)
+	fake.expect(rangeEndQuery("p0")).returnRows([]string{"id"}, []driver.Value{int64(10)}).times(1)
+	fake.expect(rangeEndQuery("p0")).returnRows([]string{"id"})
+	fake.expect(rangeEndQuery("p1")).returnRows([]string{"id"}, []driver.Value{int64(20)})
+	for i := 0; i < 3; i++ {
+		hasFurtherRange, err := migrator.applier.CalculateNextIterationRangeEndValues()
+		require.NoError(t, err)
+		require.True(t, hasFurtherRange)
+		atomic.AddInt64(&migrator.migrationContext.Iteration, 1)
+	}
+	// The last range, as checkpointed, is p1's first chunk
+	require.Equal(t, "10", migrator.applier.LastIterationRangeMinValues.String())
+	require.Equal(t, "20", migrator.applier.LastIterationRangeMaxValues.String())
+	require.Equal(t, 1, migrator.applier.LastIterationPartitionIndex)
+
+	t.Run("resume", func(t *testing.T) {
+		migrator, fake := newPartitionedMigrator(t)
+		fake.expect(rangeEndQuery("p1")).returnRows([]string{"id"}, []driver.Value{int64(30)})
+		require.NoError(t, migrator.resumeIterationPartition(&Checkpoint{IterationPartitionIndex: 1}))
+		migrator.migrationContext.MigrationIterationRangeMinValues = sql.ToColumnValues([]interface{}{int64(10)})
+		migrator.migrationContext.MigrationIterationRangeMaxValues = sql.ToColumnValues([]interface{}{int64(20)})
+		migrator.migrationContext.Iteration = 3
+
+		hasFurtherRange, err := migrator.applier.CalculateNextIterationRangeEndValues()
+		require.NoError(t, err)
+		require.True(t, hasFurtherRange)
+		require.Equal(t, "p1", migrator.migrationContext.GetIterationPartition())
+		require.Equal(t, 0, fake.countQueries(rangeEndQuery("p0")))
+	})
+
+	t.Run("unknown-partition", func(t *testing.T) {
+		migrator, _ := newPartitionedMigrator(t)
+		require.Error(t, migrator.resumeIterationPartition(&Checkpoint{IterationPartitionIndex: 3}))
+		require.Equal(t, "p0", migrator.migrationContext.GetIterationPartition())
+	})
+}
+
+func TestMigratorScenarioGhostDatabase(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrator.migrationContext.GhostDatabaseName = "scratch"
+	require.NoError(t, migrator.applier.prepareQueries())
+
+	require.NoError(t, migrator.onApplyEventStruct(newFakeInsertEventStruct(1, 100)))
+	require.Equal(t, 1, fake.countQueries(`^replace /\* gh-ost `+"`scratch`.`_testing_gho`"))
+
+	require.NoError(t, migrator.applier.SwapTablesQuickAndBumpy())
+	require.Equal(t, 1, fake.countQueries("^alter /\\* gh-ost \\*/ table `test`.`testing` rename `test`.`_testing_del`$"))
+	require.Equal(t, 1, fake.countQueries("^alter /\\* gh-ost \\*/ table `scratch`.`_testing_gho` rename `test`.`testing`$"))
+
+	require.NoError(t, migrator.applier.DropChangelogTable())
+	require.Equal(t, 1, fake.countQueries("^drop /\\* gh-ost \\*/ table if exists `scratch`.`_testing_ghc`$"))
+}
+
+func TestMigratorScenarioKeylessTable(t *testing.T) {
+	duplicatesQuery := `count\(\*\)\s+from \(\s+select 1\s+from\s+` + "`test`.`testing`" + `\s+group by\s+` + "`id`, `item_id`"
+	indexExistsQuery := `from information_schema.statistics\s+where table_schema = \? and table_name = \? and index_name = \?`
+	columnsQuery := `from\s+information_schema.columns`
+	columnsOf := func(columnTypes ...string) *sql.ColumnList {
+		columnNames := make([]string, len(columnTypes))
+		for i := range columnTypes {
+			columnNames[i] = fmt.Sprintf("c%d", i)
+		}
+		return sql.NewColumnList(columnNames)
+	}
+	// expectColumns fakes information_schema.columns rows of given "type" or "type null" columns, in utf8mb4
+	expectColumns := func(fake *fakeMySQL, columns *sql.ColumnList, columnTypes ...string) {
+		rows := make([][]driver.Value, len(columnTypes))
+		for i, columnType := range columnTypes {
+			columnType, nullable := strings.CutSuffix(columnType, " null")
+			var octetLength interface{}
+			if _, size, ok := strings.Cut(columnType, "char("); ok {
+				var length int64
+				fmt.Sscanf(size, "%d)", &length)
+				octetLength = length * 4
+			}
+			isNullable := "NO"
+			if nullable {
+				isNullable = "YES"
+			}
+			rows[i] = []driver.Value{columns.Names()[i], columnType, octetLength, isNullable, "", nil}
+		}
+		fake.expect(columnsQuery).returnRows([]string{"COLUMN_NAME", "COLUMN_TYPE", "CHARACTER_OCTET_LENGTH", "IS_NULLABLE", "EXTRA", "COLUMN_DEFAULT"}, rows...)
+	}
+	newKeylessMigrator := func(t *testing.T, columnTypes ...string) (*Migrator, *fakeMySQL) {
+		migrator, fake := newFakeMigrator(t)
+		if len(columnTypes) == 0 {
+			columnTypes = []string{"int", "varchar(32)"}
+			migrator.migrationContext.OriginalTableColumns = sql.NewColumnList([]string{"id", "item_id"})
+		} else {
+			migrator.migrationContext.OriginalTableColumns = columnsOf(columnTypes...)
+		}
+		expectColumns(fake, migrator.migrationContext.OriginalTableColumns, columnTypes...)
+		migrator.migrationContext.OriginalTableVirtualColumns = sql.NewColumnList([]string{})
+		migrator.migrationContext.AllowTempIndex = true
+		migrator.inspector = NewInspector(migrator.migrationContext)
+		migrator.inspector.db = fake.DB()
+		return migrator, fake
+	}
+
+	t.Run("not-allowed", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		migrator.migrationContext.AllowTempIndex = false
+		require.ErrorContains(t, migrator.inspector.inspectKeylessTable(), "No PRIMARY nor UNIQUE key found in table! To add an AUTO_INCREMENT PRIMARY KEY to this table, see --allow-temp-index")
+		require.Equal(t, 0, fake.countQueries(duplicatesQuery))
+	})
+
+	t.Run("dropped-column", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		migrator.migrationContext.DroppedColumnsMap = map[string]bool{"item_id": true}
+		require.ErrorContains(t, migrator.inspector.inspectKeylessTable(), "must not drop nor rename")
+		require.Equal(t, 0, fake.countQueries(duplicatesQuery))
+	})
+
+	t.Run("row-estimate", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		migrator.migrationContext.TempIndexMaxRows = 1000
+		migrator.migrationContext.RowsEstimate = 1001
+		require.ErrorContains(t, migrator.inspector.inspectKeylessTable(), "more than --temp-index-max-rows=1000")
+		require.Equal(t, 0, fake.countQueries(duplicatesQuery))
+	})
+
+	t.Run("unindexable-columns", func(t *testing.T) {
+		for columnTypes, expectedError := range map[string]string{
+			"int,varchar(32) null":   "column `c1` is nullable",
+			"int,text":               "column `c1` of text data type cannot be indexed as a whole",
+			"varchar(600),char(255)": "its columns take 3420 bytes, more than the 3072 a unique index may have",
+			strings.Repeat("int,", sql.MaxUniqueKeyColumns) + "int": "17 columns, more than the 16 a unique index may have",
+		} {
+			migrator, fake := newKeylessMigrator(t, strings.Split(columnTypes, ",")...)
+			require.ErrorContains(t, migrator.inspector.inspectKeylessTable(), expectedError, columnTypes)
+			require.Equal(t, 0, fake.countQueries(duplicatesQuery))
+		}
+	})
+
+	t.Run("duplicate-rows", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		fake.expect(duplicatesQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(1)})
+		require.ErrorContains(t, migrator.inspector.inspectKeylessTable(), "rows exist which are identical in all columns")
+		require.Empty(t, migrator.migrationContext.OriginalTableUniqueKeys)
+	})
+
+	t.Run("temp-index", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		fake.expect(duplicatesQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(0)})
+		require.NoError(t, migrator.inspector.inspectKeylessTable())
+		uniqueKey := migrator.migrationContext.GetFullRowUniqueKey()
+		require.NotNil(t, uniqueKey)
+		require.Equal(t, []string{"id", "item_id"}, uniqueKey.Columns.Names())
+
+		fake.expect(indexExistsQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(0)}).times(1)
+		fake.expect(indexExistsQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(1)})
+		require.NoError(t, migrator.applier.CreateGhostFullRowIndex())
+		require.NoError(t, migrator.applier.CreateGhostFullRowIndex())
+		require.Equal(t, 1, fake.countQueries("^alter /\\* gh-ost \\*/ table `test`.`_testing_gho` add unique key `_gh_ost_full_row` \\(`id`, `item_id`\\)$"))
+
+		require.NoError(t, migrator.applier.DropFullRowIndex("test", "testing"))
+		require.Equal(t, 1, fake.countQueries("^alter /\\* gh-ost \\*/ table `test`.`testing` drop index `_gh_ost_full_row`$"))
+	})
+
+	t.Run("failed-drop", func(t *testing.T) {
+		migrator, fake := newKeylessMigrator(t)
+		migrator.migrationContext.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+		fake.expect(duplicatesQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(0)})
+		require.NoError(t, migrator.inspector.inspectKeylessTable())
+		fake.expect(indexExistsQuery).returnRows([]string{"count(*)"}, []driver.Value{int64(1)})
+		fake.expect("drop index `_gh_ost_full_row`$").returnError(newFakeMySQLError(1205))
+
+		// The migration is complete regardless, and the statement left to issue is reported
+		migrator.dropFullRowIndex()
+		pendingCleanupStatement := "alter table `test`.`testing` drop index `_gh_ost_full_row`"
+		require.Equal(t, pendingCleanupStatement, migrator.migrationContext.PendingCleanupStatement)
+		require.Empty(t, migrator.migrationContext.PanicAbort)
+		migrator.reportSummary()
+		content, err := os.ReadFile(migrator.migrationContext.SummaryFile)
+		require.NoError(t, err)
+		var summary migrationSummary
+		require.NoError(t, json.Unmarshal(content, &summary))
+		require.Equal(t, pendingCleanupStatement, summary.PendingCleanupStatement)
+	})
+
+	t.Run("keyed-table", func(t *testing.T) {
+		migrator, fake := newFakeMigrator(t)
+		require.NoError(t, migrator.applier.CreateGhostFullRowIndex())
+		require.NoError(t, migrator.applier.DropFullRowIndex("test", "testing"))
+		require.Equal(t, 0, fake.countQueries(indexExistsQuery))
+	})
+}
+
+func TestMigratorScenarioKeylessTableRowCopy(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrationContext := migrator.migrationContext
+	migrationContext.UniqueKey = sql.NewFullRowUniqueKey(sql.NewColumnList([]string{"id", "item_id"}))
+	migrationContext.MigrationRangeMinValues = sql.ToColumnValues([]interface{}{int64(1), int64(1)})
+	migrationContext.MigrationRangeMaxValues = sql.ToColumnValues([]interface{}{int64(2), int64(9)})
+	migrationContext.SetChunkSize(3)
+
+	// Rows share their leading column, and so do consecutive chunk boundaries
+	var rows [][2]int64
+	for item := int64(1); item <= 7; item++ {
+		rows = append(rows, [2]int64{1, item})
+	}
+	for item := int64(1); item <= 9; item++ {
+		rows = append(rows, [2]int64{2, item})
+	}
+	boundaries := [][2]int64{{1, 3}, {1, 6}, {2, 2}, {2, 5}, {2, 8}, {2, 9}}
+	for _, boundary := range boundaries {
+		fake.expect(`^select\s+/\* gh-ost .* iteration:`).returnRows([]string{"id", "item_id"}, []driver.Value{boundary[0], boundary[1]}).times(1)
+	}
+	for {
+		hasFurtherRange, err := migrator.applier.CalculateNextIterationRangeEndValues()
+		require.NoError(t, err)
+		if !hasFurtherRange {
+			break
+		}
+		_, _, _, err = migrator.applier.ApplyIterationInsertQuery()
+		require.NoError(t, err)
+		atomic.AddInt64(&migrationContext.Iteration, 1)
+	}
+
+	// Each chunk picks up past the previous chunk's end, on both columns: a chunk copies rows greater
+	// than its start, or equal to it for the first chunk, and up to its end
+	compare := func(row [2]int64, id, item interface{}) int {
+		return cmp.Or(cmp.Compare(row[0], id.(int64)), cmp.Compare(row[1], item.(int64)))
+	}
+	copies := map[[2]int64]int{}
+	var chunks int
+	for _, statement := range fake.executedStatements() {
+		if !strings.HasPrefix(statement.query, "insert /* gh-ost") {
+			continue
+		}
+		require.NotContains(t, statement.query, "force index")
+		args := statement.args
+		includeRangeStart := chunks == 0
+		if includeRangeStart {
+			require.Len(t, args, 10)
+		} else {
+			require.Len(t, args, 8)
+			require.Equal(t, boundaries[chunks-1][:], []int64{args[0].(int64), args[2].(int64)})
+		}
+		require.Equal(t, boundaries[chunks][:], []int64{args[len(args)-5].(int64), args[len(args)-3].(int64)})
+		for _, row := range rows {
+			start, end := compare(row, args[0], args[2]), compare(row, args[len(args)-5], args[len(args)-3])
+			if (start > 0 || (includeRangeStart && start == 0)) && end <= 0 {
+				copies[row]++
+			}
+		}
+		chunks++
+	}
+	require.Equal(t, len(boundaries), chunks)
+	for _, row := range rows {
+		require.Equal(t, 1, copies[row], "row %v", row)
+	}
+}
+
+func TestMigratorScenarioThrottleStorm(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	require.NoError(t, migrator.migrationContext.ReadMaxLoad("Threads_running=50"))
+	fake.expect(`global status like 'Threads_running'`).returnRows([]string{"Variable_name", "Value"}, []driver.Value{"Threads_running", int64(80)}).times(3)
+	fake.expect(`global status like 'Threads_running'`).returnRows([]string{"Variable_name", "Value"}, []driver.Value{"Threads_running", int64(10)}).times(1)
+	fake.expect(`global status like 'Threads_running'`).returnError(newFakeMySQLError(2013))
+	throttler := NewThrottler(migrator.migrationContext, migrator.applier, nil, "1.2.3")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttler.collectGeneralThrottleMetrics())
+		shouldThrottle, reason, _ := throttler.shouldThrottle()
+		require.True(t, shouldThrottle)
+		require.Equal(t, "max-load Threads_running=80 >= 50", reason)
+	}
+	require.NoError(t, throttler.collectGeneralThrottleMetrics())
+	shouldThrottle, _, _ := throttler.shouldThrottle()
+	require.False(t, shouldThrottle)
+
+	// A failing status query throttles rather than lets the migration run blind
+	require.NoError(t, throttler.collectGeneralThrottleMetrics())
+	shouldThrottle, reason, _ := throttler.shouldThrottle()
+	require.True(t, shouldThrottle)
+	require.Contains(t, reason, "Threads_running")
+}
+
+func TestMigratorScenarioStreamerReconnect(t *testing.T) {
+	newEntry := func(logPos int64) *binlog.BinlogEntry {
+		return &binlog.BinlogEntry{
+			Coordinates: mysql.NewFileBinlogCoordinates("mysql-bin.000001", logPos),
+			DmlEvent:    binlog.NewBinlogDMLEvent(testMysqlDatabase, testMysqlTableName, binlog.InsertDML),
+		}
+	}
+	source := newFakeBinlogSource(
+		fakeBinlogSession{entries: []*binlog.BinlogEntry{newEntry(100), newEntry(200)}, err: io.ErrUnexpectedEOF},
+		fakeBinlogSession{entries: []*binlog.BinlogEntry{newEntry(300)}},
+	)
+
+	migrationContext := base.NewMigrationContext()
+	streamer := NewEventsStreamer(migrationContext)
+	streamer.newBinlogReader = source.newBinlogReader
+	streamer.initialBinlogCoordinates = mysql.NewFileBinlogCoordinates("mysql-bin.000001", 4)
+	require.NoError(t, streamer.initBinlogReader(streamer.initialBinlogCoordinates))
+
+	var mutex sync.Mutex
+	var received []int64
+	require.NoError(t, streamer.AddListener(false, testMysqlDatabase, testMysqlTableName, func(entry *binlog.BinlogEntry) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		received = append(received, entry.Coordinates.(*mysql.FileBinlogCoordinates).LogPos)
+		return nil
+	}))
+	canStopStreaming := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == 3
+	}
+	require.NoError(t, streamer.StreamEvents(canStopStreaming))
+
+	require.Equal(t, []int64{100, 200, 300}, received)
+	connections := source.connections()
+	require.Len(t, connections, 2)
+	require.Equal(t, mysql.NewFileBinlogCoordinates("mysql-bin.000001", 4), connections[0])
+	// Reconnects at the last transaction completely read
+	require.Equal(t, mysql.NewFileBinlogCoordinates("mysql-bin.000001", 200), connections[1])
+}
+
+func TestMigratorScenarioAwaitReplicaPromotion(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrator.migrationContext.MigrateOnReplica = true
+	migrator.migrationContext.AwaitReplicaPromotion = true
+	migrator.throttler = NewThrottler(migrator.migrationContext, migrator.applier, nil, "1.2.3")
+	migrator.eventsStreamer = NewEventsStreamer(migrator.migrationContext)
+	migrator.eventsStreamer.newBinlogReader = newFakeBinlogSource(fakeBinlogSession{}).newBinlogReader
+	require.NoError(t, migrator.eventsStreamer.initBinlogReader(mysql.NewFileBinlogCoordinates("mysql-bin.000001", 4)))
+	dropChangelog := `^drop /\* gh-ost \*/ table if exists .*_ghc`
+
+	writeFuncsDone := make(chan error, 1)
+	go func() { writeFuncsDone <- migrator.executeWriteFuncs() }()
+	migrator.applyEventsQueue <- newFakeInsertEventStruct(1, 100)
+	require.Eventually(t, func() bool { return fake.countQueries(`^replace /\* gh-ost`) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// As in Migrate() once cut over
+	promoted := make(chan error, 1)
+	go func() {
+		if err := migrator.stopMigrating(); err != nil {
+			promoted <- err
+			return
+		}
+		if err := migrator.prepareReplicaPromotion(); err != nil {
+			promoted <- err
+			return
+		}
+		promoted <- migrator.dropMigrationTables()
+	}()
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&migrator.migrationContext.IsAwaitingReplicaPromotionFlag) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, <-writeFuncsDone)
+
+	// The master's writes onto the migrated table, replicated while awaiting promotion, are not applied
+	migrator.applyEventsQueue <- newFakeInsertEventStruct(2, 200)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 1, fake.countQueries(`^replace /\* gh-ost`))
+	require.Empty(t, migrator.migrationContext.PanicAbort)
+	require.Zero(t, fake.countQueries(dropChangelog))
+
+	atomic.StoreInt64(&migrator.migrationContext.UserCommandedPromoteFlag, 1)
+	select {
+	case err := <-promoted:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("promotion not completed")
+	}
+	require.Equal(t, 1, fake.countQueries(`^stop /\* gh-ost \*/ slave io_thread`))
+	require.Equal(t, 1, fake.countQueries(dropChangelog))
+}
+
+// newFakeDMLEvent returns an event on the fake migrator's (id, item_id) table. A nil row is absent.
+func newFakeDMLEvent(dml binlog.EventDML, whereRow, newRow []interface{}) *binlog.BinlogDMLEvent {
+	dmlEvent := binlog.NewBinlogDMLEvent(testMysqlDatabase, testMysqlTableName, dml)
+	if whereRow != nil {
+		dmlEvent.WhereColumnValues = sql.ToColumnValues(whereRow)
+	}
+	if newRow != nil {
+		dmlEvent.NewColumnValues = sql.ToColumnValues(newRow)
+	}
+	return dmlEvent
+}
+
+// replayFakeStatements applies the ghost table statements the fake server received onto an id => item_id map.
+// Statements must apply in the order of the events they come from: rows are inserted while absent, and
+// updated and deleted while present.
+func replayFakeStatements(t *testing.T, statements []fakeStatement) map[int]int {
+	rows := make(map[int]int)
+	for _, statement := range statements {
+		args := statement.args
+		for _, query := range strings.Split(strings.TrimSuffix(statement.query, ";"), ";\n") {
+			query = strings.TrimSpace(query)
+			switch {
+			case strings.HasPrefix(query, "delete"):
+				id := args[0].(int)
+				require.Contains(t, rows, id, "delete of absent row")
+				delete(rows, id)
+				args = args[1:]
+			case strings.HasPrefix(query, "replace"):
+				id := args[0].(int)
+				require.NotContains(t, rows, id, "insert of present row")
+				rows[id] = args[1].(int)
+				args = args[2:]
+			case strings.HasPrefix(query, "update"):
+				id := args[2].(int)
+				require.Equal(t, id, args[0], "update modifying the unique key")
+				require.Contains(t, rows, id, "update of absent row")
+				rows[id] = args[1].(int)
+				args = args[3:]
+			default:
+				t.Fatalf("unexpected statement: %s", query)
+			}
+		}
+		require.Empty(t, args)
+	}
+	return rows
+}
+
+func TestMigratorScenarioParallelApply(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrator.migrationContext.SetApplierParallelism(4)
+	migrator.migrationContext.SetDMLBatchSize(5)
+	fake.expect(`^(replace|update|delete) /\* gh-ost`).withDelay(time.Millisecond)
+
+	// Interleaved inserts, updates and deletes on a few overlapping rows, including
+	// updates moving rows onto other ids, followed by updates on the moved rows
+	random := rand.New(rand.NewSource(1))
+	expected := make(map[int]int)
+	var dmlEvents []*binlog.BinlogDMLEvent
+	for i := 0; i < 500; i++ {
+		id := random.Intn(16)
+		itemId, exists := expected[id]
+		switch {
+		case !exists:
+			expected[id] = i
+			dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{id, i}))
+		case i%5 == 0:
+			delete(expected, id)
+			dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.DeleteDML, []interface{}{id, itemId}, nil))
+		case i%3 == 0:
+			newId := random.Intn(16)
+			if _, taken := expected[newId]; taken {
+				continue
+			}
+			delete(expected, id)
+			expected[newId] = -i
+			dmlEvents = append(dmlEvents,
+				newFakeDMLEvent(binlog.UpdateDML, []interface{}{id, itemId}, []interface{}{newId, i}),
+				newFakeDMLEvent(binlog.UpdateDML, []interface{}{newId, i}, []interface{}{newId, -i}),
+			)
+		default:
+			expected[id] = i
+			dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.UpdateDML, []interface{}{id, itemId}, []interface{}{id, i}))
+		}
+	}
+
+	for start := 0; start < len(dmlEvents); start += 20 {
+		require.NoError(t, migrator.applyDMLEvents(dmlEvents[start:min(start+20, len(dmlEvents))]))
+	}
+	require.Equal(t, int64(len(dmlEvents)), migrator.migrationContext.TotalDMLEventsApplied)
+	require.Equal(t, expected, replayFakeStatements(t, fake.executedStatements()))
+	// Workers used connections of their own
+	require.Greater(t, fake.countQueries(`^SET /\* gh-ost \*/ SESSION time_zone`), len(dmlEvents)/20)
+}
+
+func TestMigratorScenarioParallelApplyResized(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	fake.expect(`^replace /\* gh-ost`)
+	insert := func(id int) []*binlog.BinlogDMLEvent {
+		return []*binlog.BinlogDMLEvent{newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{id, id})}
+	}
+
+	require.NoError(t, migrator.applyDMLEvents(insert(1)))
+	require.Equal(t, mysql.MaxDBPoolConnections, migrator.applier.db.Stats().MaxOpenConnections)
+
+	// As the applier-parallelism command does
+	migrator.migrationContext.SetApplierParallelism(8)
+	require.NoError(t, migrator.applyDMLEvents(insert(2)))
+	require.Equal(t, mysql.MaxDBPoolConnections+8, migrator.applier.db.Stats().MaxOpenConnections)
+
+	migrator.migrationContext.SetApplierParallelism(1)
+	require.NoError(t, migrator.applyDMLEvents(insert(3)))
+	require.Equal(t, mysql.MaxDBPoolConnections, migrator.applier.db.Stats().MaxOpenConnections)
+	require.Equal(t, 3, fake.countQueries(`^replace /\* gh-ost`))
+}
+
+func BenchmarkMigratorApplyDMLEvents(b *testing.B) {
+	for _, parallelism := range []int64{1, 4, 16} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			migrator, fake := newFakeMigrator(b)
+			migrator.migrationContext.SetApplierParallelism(parallelism)
+			// A round trip to a remote server
+			fake.expect(`^(replace|update|delete) /\* gh-ost`).withDelay(500 * time.Microsecond)
+
+			dmlEvents := make([]*binlog.BinlogDMLEvent, 0, 1000)
+			for id := 0; id < cap(dmlEvents); id++ {
+				dmlEvents = append(dmlEvents, newFakeDMLEvent(binlog.InsertDML, nil, []interface{}{id, id}))
+			}
+			// As many events as onApplyEventStruct() takes off the queue at once
+			roundSize := int(migrator.migrationContext.DMLBatchSize * parallelism)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for start := 0; start < len(dmlEvents); start += roundSize {
+					if err := migrator.applyDMLEvents(dmlEvents[start:min(start+roundSize, len(dmlEvents))]); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -6,8 +6,10 @@
 package logic
 
 import (
+	"bytes"
 	"context"
 	gosql "database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -217,6 +219,153 @@ func TestMigratorGetProgressPercent(t *testing.T) {
 	}
 }
 
+func TestMigratorGetLastProgressTime(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	require.True(t, migrator.getLastProgressTime().IsZero())
+
+	migrationContext.MarkRowCopyProgress()
+	rowCopyTime := migrationContext.GetLastRowCopyProgressTime()
+	require.Equal(t, rowCopyTime, migrator.getLastProgressTime())
+
+	// heartbeats do not count while rows are still being copied
+	heartbeatTime := rowCopyTime.Add(time.Minute)
+	migrationContext.SetLastHeartbeatOnChangelogTime(heartbeatTime)
+	require.Equal(t, rowCopyTime, migrator.getLastProgressTime())
+
+	// nor while there is a backlog of events to apply
+	atomic.StoreInt64(&migrator.rowCopyCompleteFlag, 1)
+	writeFunc := tableWriteFunc(func() error { return nil })
+	migrator.applyEventsQueue <- newApplyEventStructByFunc(&writeFunc)
+	require.Equal(t, rowCopyTime, migrator.getLastProgressTime())
+
+	<-migrator.applyEventsQueue
+	require.Equal(t, heartbeatTime, migrator.getLastProgressTime())
+}
+
+func TestMigratorGetApplierLag(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	require.Equal(t, time.Duration(0), migrator.getApplierLag())
+
+	// the applier lags as much as the oldest unapplied event, by gh-ost's clock, however long idle before
+	migrationContext.SetLastHeartbeatOnChangelogTime(time.Now().Add(-time.Minute))
+	migrationContext.SetBinlogClockSkew(time.Hour)
+	migrationContext.MarkBinlogEventQueued(time.Now().Add(time.Hour - 10*time.Second))
+	migrationContext.MarkBinlogEventQueued(time.Now().Add(time.Hour - 2*time.Second))
+	require.InDelta(t, 10, migrator.getApplierLag().Seconds(), 0.5)
+
+	migrationContext.MarkBinlogEventsApplied(1)
+	require.InDelta(t, 2, migrator.getApplierLag().Seconds(), 0.5)
+
+	migrationContext.MarkBinlogEventsApplied(1)
+	require.Equal(t, time.Duration(0), migrator.getApplierLag())
+}
+
+func TestMigratorReportSummary(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.OriginalTableName = "tablename"
+	migrationContext.TotalRowsCopied = 123
+	migrationContext.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	migrationContext.StartBinlogCoordinates = mysql.NewFileBinlogCoordinates("mysql-bin.000001", 4)
+	migrationContext.RowCopyCompleteBinlogCoordinates = mysql.NewFileBinlogCoordinates("mysql-bin.000002", 1024)
+	migrator := NewMigrator(migrationContext, "1.2.3")
+
+	migrator.reportSummary()
+	content, err := os.ReadFile(migrationContext.SummaryFile)
+	require.NoError(t, err)
+
+	var summary migrationSummary
+	require.NoError(t, json.Unmarshal(content, &summary))
+	require.Equal(t, "test", summary.DatabaseName)
+	require.Equal(t, int64(123), summary.RowsCopied)
+	require.Equal(t, "mysql-bin.000001:4", summary.StartBinlogCoordinates)
+	require.Equal(t, "mysql-bin.000002:1024", summary.RowCopyCompleteBinlogCoordinates)
+	require.Equal(t, "", summary.CutOverBinlogCoordinates)
+}
+
+func TestMigratorShouldValidateAssumedMaster(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.InspectorConnectionConfig.Key = mysql.InstanceKey{Hostname: "replica", Port: 3306}
+	migrationContext.ApplierConnectionConfig.Key = mysql.InstanceKey{Hostname: "master", Port: 3306}
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	require.False(t, migrator.shouldValidateAssumedMaster())
+
+	migrationContext.AssumeMasterHostname = "master:3306"
+	require.True(t, migrator.shouldValidateAssumedMaster())
+
+	migrationContext.MigrateOnReplica = true
+	require.False(t, migrator.shouldValidateAssumedMaster())
+	migrationContext.MigrateOnReplica = false
+
+	// assumed master is the inspected server itself
+	migrationContext.ApplierConnectionConfig.Key = mysql.InstanceKey{Hostname: "replica", Port: 3306}
+	require.False(t, migrator.shouldValidateAssumedMaster())
+}
+
+func TestMigratorIsStallExempt(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	require.False(t, migrator.isStallExempt())
+
+	migrationContext.SetThrottled(true, "test", base.NoThrottleReasonHint)
+	require.True(t, migrator.isStallExempt())
+	migrationContext.SetThrottled(false, "", base.NoThrottleReasonHint)
+	require.False(t, migrator.isStallExempt())
+
+	atomic.StoreInt64(&migrationContext.IsPostponingCutOver, 1)
+	require.True(t, migrator.isStallExempt())
+}
+
+func TestMigratorGetStatusSnapshot(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.OriginalTableName = "gh_ost_test"
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	migrationContext.TotalRowsCopied = 250
+	migrationContext.RowsEstimate = 1000
+	migrationContext.SetProgressPct(25.0)
+
+	status := migrator.getStatusSnapshot()
+	require.Equal(t, "test", status.DatabaseName)
+	require.Equal(t, "gh_ost_test", status.TableName)
+	require.Equal(t, "_gh_ost_test_gho", status.GhostTableName)
+	require.Equal(t, "migrating", status.State)
+	require.Equal(t, int64(250), status.RowsCopied)
+	require.Equal(t, int64(1000), status.RowsEstimate)
+	require.Equal(t, 25.0, status.ProgressPct)
+	require.Equal(t, "", status.BinlogCoordinates)
+	require.False(t, status.Stalled)
+	require.False(t, status.Backpressured)
+
+	migrationContext.SetThrottled(true, "lag", base.NoThrottleReasonHint)
+	atomic.StoreInt64(&migrator.stalledFlag, 1)
+	atomic.StoreInt64(&migrationContext.BacklogMemoryBytes, 4096)
+	atomic.StoreInt64(&migrationContext.IsBackpressuredFlag, 1)
+	status = migrator.getStatusSnapshot()
+	require.Equal(t, "throttled, lag", status.State)
+	require.True(t, status.Throttled)
+	require.Equal(t, "lag", status.ThrottleReason)
+	require.True(t, status.Stalled)
+	require.Equal(t, int64(4096), status.BacklogMemoryBytes)
+	require.True(t, status.Backpressured)
+}
+
+func TestMigratorPrintStallDiagnostics(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	migrationContext.MarkDMLApplyProgress()
+
+	var buf bytes.Buffer
+	migrator.printStallDiagnostics(&buf)
+	output := buf.String()
+	require.Contains(t, output, "# Row copy: complete: false; iteration: 0; rows copied: 0; last copied: never")
+	require.Contains(t, output, "# Streamer: coordinates: n/a; last heartbeat read: never")
+	require.Contains(t, output, "# Goroutines:")
+	require.Contains(t, output, "printStallDiagnostics")
+}
+
 func TestMigratorGetMigrationStateAndETA(t *testing.T) {
 	migrationContext := base.NewMigrationContext()
 	migrator := NewMigrator(migrationContext, "1.2.3")
@@ -269,6 +418,122 @@ func TestMigratorGetMigrationStateAndETA(t *testing.T) {
 		require.Equal(t, "due", eta)
 		require.Equal(t, "0s", etaDuration.String())
 	}
+	{
+		atomic.StoreInt64(&migrationContext.IsPostponingCutOver, 0)
+		atomic.StoreInt64(&migrationContext.IsQueuedFlag, 1)
+		state, _, _ := migrator.getMigrationStateAndETA(123456)
+		require.Equal(t, "queued", state)
+	}
+	{
+		atomic.StoreInt64(&migrationContext.IsQueuedFlag, 0)
+		atomic.StoreInt64(&migrationContext.IsWarmingUpFlag, 1)
+		atomic.StoreInt64(&migrationContext.WarmUpIndexesTotal, 3)
+		atomic.StoreInt64(&migrationContext.WarmUpIndexesDone, 1)
+		state, _, _ := migrator.getMigrationStateAndETA(123456)
+		require.Equal(t, "warming up ghost table, 1/3 indexes", state)
+	}
+	{
+		atomic.StoreInt64(&migrationContext.IsWarmingUpFlag, 0)
+		atomic.StoreInt64(&migrationContext.IsBackpressuredFlag, 1)
+		atomic.StoreInt64(&migrationContext.BacklogMemoryBytes, 300*1024*1024)
+		state, _, _ := migrator.getMigrationStateAndETA(123456)
+		require.Equal(t, "backpressured, backlog memory 300MB", state)
+	}
+	{
+		atomic.StoreInt64(&migrationContext.IsBackpressuredFlag, 0)
+		atomic.StoreInt64(&migrationContext.IsPostponingCutOver, 1)
+		migrationContext.StartTime = now.Add(-2 * time.Hour)
+		migrationContext.SetMaxRuntime(time.Hour)
+		state, eta, _ := migrator.getMigrationStateAndETA(123456)
+		require.Equal(t, "postponing cut-over, max-runtime exceeded", state)
+		require.Equal(t, "due", eta)
+	}
+}
+
+func TestFormatRuntimeRemaining(t *testing.T) {
+	require.Equal(t, "1h30m0s", formatRuntimeRemaining(90*time.Minute))
+	require.Equal(t, "exceeded", formatRuntimeRemaining(0))
+	require.Equal(t, "exceeded", formatRuntimeRemaining(-time.Minute))
+}
+
+func TestMigratorShouldBackpressure(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+	const mb = 1024 * 1024
+
+	// disabled
+	require.False(t, migrator.shouldBackpressure(1000*mb, 1000*mb, false))
+
+	migrationContext.MaxBacklogMemoryMB = 100
+	require.False(t, migrator.shouldBackpressure(99*mb, 1000*mb, false))
+	require.True(t, migrator.shouldBackpressure(100*mb, 0, false))
+	// resumes only below 80% of the ceiling
+	require.True(t, migrator.shouldBackpressure(90*mb, 0, true))
+	require.False(t, migrator.shouldBackpressure(79*mb, 0, true))
+
+	migrationContext.MaxBacklogMemoryMB = 0
+	migrationContext.MaxMemoryMB = 1000
+	require.False(t, migrator.shouldBackpressure(1*mb, 999*mb, false))
+	require.True(t, migrator.shouldBackpressure(1*mb, 1000*mb, false))
+	require.True(t, migrator.shouldBackpressure(1*mb, 900*mb, true))
+	require.False(t, migrator.shouldBackpressure(1*mb, 700*mb, true))
+	// nothing buffered: pausing the reader frees nothing
+	require.False(t, migrator.shouldBackpressure(0, 2000*mb, false))
+	require.False(t, migrator.shouldBackpressure(0, 2000*mb, true))
+}
+
+func TestMigratorTerminate(t *testing.T) {
+	t.Run("aborts", func(t *testing.T) {
+		migrationContext := base.NewMigrationContext()
+		migrator := NewMigrator(migrationContext, "1.2.3")
+		go migrator.Terminate()
+		err := <-migrationContext.PanicAbort
+		require.ErrorIs(t, err, ErrTerminated)
+	})
+
+	t.Run("after cut-over", func(t *testing.T) {
+		migrationContext := base.NewMigrationContext()
+		migrator := NewMigrator(migrationContext, "1.2.3")
+		atomic.StoreInt64(&migrationContext.CutOverCompleteFlag, 1)
+		migrator.Terminate()
+	})
+}
+
+func TestMigratorPrintStatusNotAvailable(t *testing.T) {
+	migrator := NewMigrator(base.NewMigrationContext(), "1.2.3")
+	// no applier, inspector or streamer to report on yet
+	require.NotPanics(t, func() {
+		migrator.PrintStatus(ForcePrintStatusAndHintRule)
+	})
+}
+
+func TestMigratorPrintPendingStatus(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.StartTime = time.Now()
+	migrator := NewMigrator(migrationContext, "1.2.3")
+
+	var status bytes.Buffer
+	migrator.printPendingStatus(&status)
+	require.Contains(t, status.String(), "State: starting;")
+
+	atomic.StoreInt64(&migrationContext.IsQueuedFlag, 1)
+	status.Reset()
+	migrator.printPendingStatus(&status)
+	require.Contains(t, status.String(), "State: queued;")
+}
+
+func TestCountMigrationsAhead(t *testing.T) {
+	registrations := []*MigrationRegistration{
+		{Id: 1, State: MigrationRegistrationRunning},
+		{Id: 2, State: MigrationRegistrationQueued},
+		{Id: 3, State: MigrationRegistrationQueued},
+		{Id: 4, State: MigrationRegistrationRunning},
+	}
+	require.Equal(t, 2, countMigrationsAhead(registrations, 2))
+	// queued migrations registered later do not count
+	require.Equal(t, 3, countMigrationsAhead(registrations, 3))
+	require.Equal(t, 1, countMigrationsAhead(registrations, 1))
+	require.Equal(t, 0, countMigrationsAhead(nil, 1))
 }
 
 func TestMigratorShouldPrintStatus(t *testing.T) {
@@ -384,6 +649,265 @@ func (suite *MigratorTestSuite) TestMigrateEmpty() {
 	suite.Require().Equal("_testing_del", tableName)
 }
 
+func (suite *MigratorTestSuite) TestMigrateInplaceIndexDDL() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(64), KEY name_idx (name))", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, 'a'), (2, 'b'), (3, 'c')", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.AttemptInplaceIndexDDL = true
+
+	migrationContext.AlterStatement = "ADD UNIQUE KEY name_uidx (name), DROP KEY name_idx"
+	migrationContext.AlterStatementOptions = migrationContext.AlterStatement
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+
+	err = migrator.Migrate()
+	suite.Require().NoError(err)
+
+	// Verify the indexes were changed on the original table
+	var tableName, createTableSQL string
+	//nolint:execinquery
+	err = suite.db.QueryRow("SHOW CREATE TABLE "+getTestTableName()).Scan(&tableName, &createTableSQL)
+	suite.Require().NoError(err)
+	suite.Require().Equal("CREATE TABLE `testing` (\n  `id` int NOT NULL,\n  `name` varchar(64) DEFAULT NULL,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `name_uidx` (`name`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci", createTableSQL)
+
+	// Verify the table was not swapped
+	//nolint:execinquery
+	err = suite.db.QueryRow("SHOW TABLES IN test LIKE '_testing_del'").Scan(&tableName)
+	suite.Require().Equal(gosql.ErrNoRows, err)
+	//nolint:execinquery
+	err = suite.db.QueryRow("SHOW TABLES IN test LIKE '_testing_gh_'").Scan(&tableName)
+	suite.Require().Equal(gosql.ErrNoRows, err)
+}
+
+func (suite *MigratorTestSuite) TestMigrateNarrowedColumn() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(64), status ENUM('active','deleted'))", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, 'short', 'active'), (2, 'a rather long name', 'deleted')", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.AlterStatementOptions = "MODIFY name VARCHAR(8), MODIFY status ENUM('active')"
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	err = migrator.Migrate()
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "--allow-lossy-migration")
+	suite.Require().Equal([]string{
+		"name varchar(64)->varchar(8): 1 rows do not fit",
+		"status enum('active','deleted')->enum('active'): 1 rows do not fit",
+	}, migrationContext.NarrowedColumnsFindings)
+}
+
+func (suite *MigratorTestSuite) TestMigrateNullableUniqueKey() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (a INT NULL, b INT NULL, v VARCHAR(16), UNIQUE KEY ab_uidx (a, b))", getTestTableName()))
+	suite.Require().NoError(err)
+	// NULLs at the start, in the middle and at the end of the key space
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s VALUES
+		(NULL, 1, 'n1'), (NULL, 2, 'n2'), (NULL, NULL, 'nn'),
+		(1, 1, 'r1'), (2, NULL, 'r2n'), (2, 2, 'r2'), (3, 3, 'r3'), (4, NULL, 'r4n'),
+		(5, 5, 'r5'), (6, 6, 'r6'), (7, 7, 'r7'), (8, 8, 'r8'), (9, NULL, 'r9n')`, getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.NullableUniqueKeyAllowed = true
+	migrationContext.ChunkSize = 10
+	migrationContext.AlterStatementOptions = "ENGINE=InnoDB"
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	suite.Require().NoError(migrator.Migrate())
+
+	checksumQuery := "SELECT COUNT(*), SUM(CRC32(CONCAT_WS(',', IFNULL(a, 'null'), IFNULL(b, 'null'), v))) FROM %s"
+	var oldCount, newCount, oldChecksum, newChecksum int64
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf(checksumQuery, getTestOldTableName())).Scan(&oldCount, &oldChecksum))
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf(checksumQuery, getTestTableName())).Scan(&newCount, &newChecksum))
+	suite.Require().Equal(int64(13), newCount)
+	suite.Require().Equal(oldCount, newCount)
+	suite.Require().Equal(oldChecksum, newChecksum)
+}
+
+func (suite *MigratorTestSuite) TestMigrateNullableUniqueKeyDuplicates() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (a INT NULL, b INT NOT NULL, UNIQUE KEY ab_uidx (a, b))", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (NULL, 1), (NULL, 1), (1, 1)", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.NullableUniqueKeyAllowed = true
+	migrationContext.AlterStatementOptions = "ENGINE=InnoDB"
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	err = migrator.Migrate()
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "cannot tell such rows apart")
+}
+
+func (suite *MigratorTestSuite) TestMigratePartialUniqueKey() {
+	ctx := context.Background()
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	for _, uniqueKey := range []string{"UNIQUE KEY email_uidx (email(20))", "UNIQUE KEY email_uidx ((lower(email)))"} {
+		_, err := suite.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+getTestTableName())
+		suite.Require().NoError(err)
+		_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (email VARCHAR(64) NOT NULL, name VARCHAR(64), %s)", getTestTableName(), uniqueKey))
+		suite.Require().NoError(err)
+
+		migrationContext := newTestMigrationContext()
+		migrationContext.ApplierConnectionConfig = connectionConfig
+		migrationContext.InspectorConnectionConfig = connectionConfig
+		migrationContext.SetConnectionConfig("innodb")
+		migrationContext.InitiallyDropOldTable = true
+		migrationContext.InitiallyDropGhostTable = true
+		migrationContext.AlterStatementOptions = "ENGINE=InnoDB"
+
+		migrator := NewMigrator(migrationContext, "0.0.0")
+		err = migrator.Migrate()
+		suite.Require().Error(err, uniqueKey)
+		suite.Require().Contains(err.Error(), "email_uidx indexed by column prefixes or expressions", uniqueKey)
+	}
+}
+
+func (suite *MigratorTestSuite) TestMigratePartitionedTable() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %s (id INT NOT NULL, name VARCHAR(64), PRIMARY KEY (id))
+		PARTITION BY RANGE COLUMNS (id) (
+			PARTITION p0 VALUES LESS THAN (100),
+			PARTITION p1 VALUES LESS THAN (200),
+			PARTITION p2 VALUES LESS THAN (300),
+			PARTITION p3 VALUES LESS THAN (MAXVALUE)
+		)`, getTestTableName()))
+	suite.Require().NoError(err)
+	// p2 is left empty
+	for _, id := range []int{1, 5, 99, 100, 150, 199, 300, 301, 1000} {
+		_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%d, 'name%d')", getTestTableName(), id, id))
+		suite.Require().NoError(err)
+	}
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.ChunkSize = 10
+	migrationContext.AlterStatementOptions = "ADD COLUMN foobar varchar(255)"
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	suite.Require().NoError(migrator.Migrate())
+	suite.Require().Equal([]string{"p0", "p1", "p2", "p3"}, migrationContext.IterationPartitions)
+
+	var count int64
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", getTestTableName())).Scan(&count))
+	suite.Require().Equal(int64(9), count)
+
+	var tableName, createTableSQL string
+	//nolint:execinquery
+	suite.Require().NoError(suite.db.QueryRow("SHOW CREATE TABLE "+getTestTableName()).Scan(&tableName, &createTableSQL))
+	suite.Require().Contains(createTableSQL, "PARTITION BY RANGE")
+}
+
+func (suite *MigratorTestSuite) TestMigrateColumnTransforms() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, email VARCHAR(64), ssn VARCHAR(16))", getTestTableName()))
+	suite.Require().NoError(err)
+	for id := 1; id <= 25; id++ {
+		_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%d, 'user%d@example.com', '123-45-%04d')", getTestTableName(), id, id, id))
+		suite.Require().NoError(err)
+	}
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.ChunkSize = 10
+	migrationContext.AlterStatementOptions = "ADD COLUMN email_hash CHAR(64)"
+	migrationContext.ColumnTransforms = []*sql.ColumnTransform{
+		{Column: "ssn", Expression: "NULL"},
+		{Column: "email_hash", Expression: "SHA2(email, 256)"},
+	}
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	suite.Require().NoError(migrator.Migrate())
+
+	var count, ssnCount, emailHashCount int64
+	suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*), COUNT(ssn), SUM(email_hash = SHA2(email, 256)) FROM %s", getTestTableName())).Scan(&count, &ssnCount, &emailHashCount))
+	suite.Require().Equal(int64(25), count)
+	suite.Require().Equal(int64(0), ssnCount)
+	suite.Require().Equal(int64(25), emailHashCount)
+}
+
+func (suite *MigratorTestSuite) TestMigrateColumnTransformInvalidExpression() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, email VARCHAR(64))", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.AlterStatementOptions = "ADD COLUMN email_hash CHAR(64)"
+	migrationContext.ColumnTransforms = []*sql.ColumnTransform{
+		{Column: "email_hash", Expression: "SHA2(no_such_column, 256)"},
+	}
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	err = migrator.Migrate()
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "Invalid expression for transformed column")
+}
+
 func (suite *MigratorTestSuite) TestCopierIntPK() {
 	ctx := context.Background()
 
@@ -807,3 +1331,226 @@ func (suite *MigratorTestSuite) TestRevert() {
 func TestMigrator(t *testing.T) {
 	suite.Run(t, new(MigratorTestSuite))
 }
+
+func (suite *MigratorTestSuite) TestValidateAssumedMaster() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY)", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	migrator.applier = NewApplier(migrationContext)
+	defer migrator.applier.Teardown()
+	suite.Require().NoError(migrator.applier.InitDBConnections())
+	suite.Require().NoError(migrator.applier.CreateChangelogTable())
+	defer migrator.applier.DropChangelogTable()
+	migrator.inspector = NewInspector(migrationContext)
+	defer migrator.inspector.Teardown()
+	suite.Require().NoError(migrator.inspector.InitDBConnections())
+
+	// the probe is written and read back on the same server
+	suite.Require().NoError(migrator.validateAssumedMaster())
+
+	_, err = suite.db.ExecContext(ctx, "SET GLOBAL read_only = ON")
+	suite.Require().NoError(err)
+	defer func() {
+		_, err := suite.db.ExecContext(ctx, "SET GLOBAL read_only = OFF")
+		suite.Assert().NoError(err)
+	}()
+	err = migrator.validateAssumedMaster()
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "is read_only")
+}
+
+func (suite *MigratorTestSuite) TestCleanupArtifacts() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY)", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY)", getTestGhostTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY) COMMENT='%s'", getTestOldTableName(), atomicCutOverMagicHint))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.Cleanup = true
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	migrator.applier = NewApplier(migrationContext)
+	defer migrator.applier.Teardown()
+	suite.Require().NoError(migrator.applier.InitDBConnections())
+	suite.Require().NoError(migrator.applier.CreateChangelogTable())
+	defer migrator.applier.DropChangelogTable()
+	migrator.inspector = NewInspector(migrationContext)
+	defer migrator.inspector.Teardown()
+	suite.Require().NoError(migrator.inspector.InitDBConnections())
+
+	suite.Require().NoError(migrator.validateArtifactsNotInUse())
+	artifacts, err := migrator.findMigrationArtifacts()
+	suite.Require().NoError(err)
+	suite.Require().Len(artifacts, 3)
+	for _, artifact := range artifacts {
+		suite.Require().Empty(artifact.keepReason, artifact.tableName)
+	}
+
+	// an old table which is not the cut-over magic table is kept, unless --ok-to-drop-table
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s COMMENT=''", getTestOldTableName()))
+	suite.Require().NoError(err)
+	artifacts, err = migrator.findMigrationArtifacts()
+	suite.Require().NoError(err)
+	suite.Require().Equal(migrationContext.GetOldTableName(), artifacts[2].tableName)
+	suite.Require().Contains(artifacts[2].keepReason, "--ok-to-drop-table")
+
+	// a heartbeating migration is running
+	_, err = migrator.applier.WriteChangelog("heartbeat", time.Now().Format(time.RFC3339Nano))
+	suite.Require().NoError(err)
+	err = migrator.validateArtifactsNotInUse()
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "a migration seems to be running")
+}
+
+func (suite *MigratorTestSuite) TestWaitForMigrationSlot() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY)", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.MaxConcurrentMigrations = 1
+	migrationContext.CoordinationTable = "_gh_ost_migrations"
+	migrationContext.CoordinationStaleSeconds = 60
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	migrator.hooksExecutor = NewHooksExecutor(migrationContext)
+	migrator.applier = NewApplier(migrationContext)
+	defer migrator.applier.Teardown()
+	suite.Require().NoError(migrator.applier.InitDBConnections())
+	suite.Require().NoError(migrator.applier.CreateCoordinationTable())
+	defer migrator.applier.dropTable(migrationContext.DatabaseName, migrationContext.CoordinationTable)
+
+	// another migration is already running
+	otherId, err := migrator.applier.RegisterMigration()
+	suite.Require().NoError(err)
+	suite.Require().NoError(migrator.applier.SetMigrationRegistrationState(otherId, MigrationRegistrationRunning))
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- migrator.waitForMigrationSlot()
+	}()
+	defer atomic.StoreInt64(&migrator.finishedMigrating, 1)
+	suite.Require().Eventually(func() bool {
+		return atomic.LoadInt64(&migrationContext.IsQueuedFlag) == 1
+	}, 5*time.Second, 100*time.Millisecond)
+
+	var queue bytes.Buffer
+	suite.Require().NoError(migrator.printMigrationQueue(&queue))
+	suite.Require().Contains(queue.String(), "state: running")
+	suite.Require().Contains(queue.String(), "state: queued; heartbeat")
+	suite.Require().Contains(queue.String(), "(this migration)")
+
+	suite.Require().NoError(migrator.applier.DeregisterMigration(otherId))
+	select {
+	case err := <-waitErr:
+		suite.Require().NoError(err)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("migration was not dequeued")
+	}
+	suite.Require().Equal(int64(0), atomic.LoadInt64(&migrationContext.IsQueuedFlag))
+
+	registrations, err := migrator.applier.ReadMigrationRegistrations()
+	suite.Require().NoError(err)
+	suite.Require().Len(registrations, 1)
+	suite.Require().Equal(MigrationRegistrationRunning, registrations[0].State)
+
+	migrator.deregisterMigration()
+	registrations, err = migrator.applier.ReadMigrationRegistrations()
+	suite.Require().NoError(err)
+	suite.Require().Empty(registrations)
+}
+
+func (suite *MigratorTestSuite) TestMigrateWarmUp() {
+	ctx := context.Background()
+
+	_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(64), KEY name_idx (name), FULLTEXT KEY name_ft (name))", getTestTableName()))
+	suite.Require().NoError(err)
+	_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, 'a'), (2, 'b'), (3, 'c')", getTestTableName()))
+	suite.Require().NoError(err)
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	migrationContext := newTestMigrationContext()
+	migrationContext.ApplierConnectionConfig = connectionConfig
+	migrationContext.InspectorConnectionConfig = connectionConfig
+	migrationContext.SetConnectionConfig("innodb")
+	migrationContext.InitiallyDropOldTable = true
+	migrationContext.AlterStatementOptions = "ADD KEY id_name_idx (id, name)"
+	migrationContext.WarmUpSeconds = 10
+
+	migrator := NewMigrator(migrationContext, "0.0.0")
+	suite.Require().NoError(migrator.Migrate())
+
+	// the fulltext key is not scanned
+	suite.Require().Equal(int64(3), atomic.LoadInt64(&migrationContext.WarmUpIndexesTotal))
+	suite.Require().Equal(int64(3), atomic.LoadInt64(&migrationContext.WarmUpIndexesDone))
+	suite.Require().Equal(int64(0), atomic.LoadInt64(&migrationContext.IsWarmingUpFlag))
+}
+
+func (suite *MigratorTestSuite) TestMigrateExoticIdentifiers() {
+	ctx := context.Background()
+
+	connectionConfig, err := getTestConnectionConfig(ctx, suite.mysqlContainer)
+	suite.Require().NoError(err)
+
+	for _, tableName := range []string{"order", "1st$table", "tab`le"} {
+		suite.Run(tableName, func() {
+			quotedTableName := fmt.Sprintf("%s.%s", sql.QuoteIdentifier(testMysqlDatabase), sql.QuoteIdentifier(tableName))
+			defer suite.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+quotedTableName)
+
+			_, err := suite.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (`key` INT PRIMARY KEY, `group` VARCHAR(32), `col``tick` INT, `$price` DECIMAL(10,2), `1st` INT, UNIQUE KEY `order` (`group`))", quotedTableName))
+			suite.Require().NoError(err)
+			_, err = suite.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1, 'a', 10, 1.50, 100), (2, 'b', 20, 2.50, 200), (3, 'c', 30, 3.50, 300)", quotedTableName))
+			suite.Require().NoError(err)
+
+			migrationContext := newTestMigrationContext()
+			migrationContext.ApplierConnectionConfig = connectionConfig
+			migrationContext.InspectorConnectionConfig = connectionConfig
+			migrationContext.SetConnectionConfig("innodb")
+			migrationContext.OriginalTableName = tableName
+			migrationContext.InitiallyDropOldTable = true
+			migrationContext.OkToDropTable = true
+			migrationContext.AlterStatementOptions = "ADD COLUMN `desc` INT, ADD KEY `select` (`1st`)"
+
+			migrator := NewMigrator(migrationContext, "0.0.0")
+			suite.Require().NoError(migrator.Migrate())
+
+			var count, colTickSum, firstSum int64
+			var priceSum, groups string
+			suite.Require().NoError(suite.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*), SUM(`col``tick`), SUM(`$price`), SUM(`1st`), GROUP_CONCAT(`group` ORDER BY `key`) FROM %s WHERE `desc` IS NULL", quotedTableName)).Scan(&count, &colTickSum, &priceSum, &firstSum, &groups))
+			suite.Require().Equal(int64(3), count)
+			suite.Require().Equal(int64(60), colTickSum)
+			suite.Require().Equal("7.50", priceSum)
+			suite.Require().Equal(int64(600), firstSum)
+			suite.Require().Equal("a,b,c", groups)
+		})
+	}
+}
@@ -0,0 +1,349 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/gh-ost/go/binlog"
+	"github.com/github/gh-ost/go/sql"
+)
+
+var (
+	// observeStatusInterval is how often --observe logs what it has observed so far
+	observeStatusInterval = time.Minute
+	// observeChunkSampleInterval is how often --observe times reading a chunk of the original table, at
+	// points across the key range
+	observeChunkSampleInterval = 10 * time.Second
+)
+
+// observation accumulates what --observe watches: the rows written to the original table, by binary
+// log event type, and the time reading a chunk of the original table takes
+type observation struct {
+	mutex     sync.Mutex
+	startTime time.Time
+	// uniqueKey is the key chunks are read by
+	uniqueKey *sql.UniqueKey
+
+	rowEvents              map[binlog.EventDML]int64
+	secondRowEvents        int64
+	peakRowEventsPerSecond int64
+
+	chunkReads         int64
+	chunkReadTotalTime time.Duration
+	chunkReadMaxTime   time.Duration
+
+	// chunkSampleRangeMin and chunkSampleRangeMax bound the first column of an integer key, whose chunk
+	// reads start at random points in between. Chunk reads of other keys walk the key, each following on
+	// the previous one, and start over past the end of the table.
+	chunkSampleRangeMin  *big.Int
+	chunkSampleRangeMax  *big.Int
+	chunkSampleNextStart []interface{}
+	random               *rand.Rand
+}
+
+// observationReport is what --observe reports upon exit, in the log and in --report-file
+type observationReport struct {
+	ObservedSeconds        float64            `json:"observed_seconds"`
+	RowEventsPerSecond     map[string]float64 `json:"row_events_per_second"`
+	PeakRowEventsPerSecond int64              `json:"peak_row_events_per_second"`
+	UniqueKey              string             `json:"unique_key"`
+	ChunkSize              int64              `json:"chunk_size"`
+	ChunkReads             int64              `json:"chunk_reads"`
+	AverageChunkReadSecs   float64            `json:"average_chunk_read_seconds"`
+	MaxChunkReadSecs       float64            `json:"max_chunk_read_seconds"`
+	// ProjectedRowCopyLowerBoundSecs is the time reading the estimated rows takes at the average chunk read
+	// time, with --nice-ratio, and without throttling. Row copy takes longer, as it also writes the ghost table.
+	ProjectedRowCopyLowerBoundSecs float64 `json:"projected_row_copy_lower_bound_seconds"`
+	// ExpectedPeakApplierLagSeconds is the time binary log events queue up behind the slowest chunk, plus
+	// the time the busiest second's rows take to apply at the per-row chunk read time
+	ExpectedPeakApplierLagSeconds float64 `json:"expected_peak_applier_lag_seconds"`
+	Throttled                     bool    `json:"throttled"`
+	ThrottleReason                string  `json:"throttle_reason,omitempty"`
+}
+
+func newObservation(uniqueKey *sql.UniqueKey) *observation {
+	return &observation{
+		startTime: time.Now(),
+		uniqueKey: uniqueKey,
+		rowEvents: make(map[binlog.EventDML]int64),
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// onRowEvent counts a row written to the original table
+func (this *observation) onRowEvent(dml binlog.EventDML) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.rowEvents[dml]++
+	this.secondRowEvents++
+}
+
+// tick closes the current second of row events, keeping track of the busiest second. It is to be
+// called once per second.
+func (this *observation) tick() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.secondRowEvents > this.peakRowEventsPerSecond {
+		this.peakRowEventsPerSecond = this.secondRowEvents
+	}
+	this.secondRowEvents = 0
+}
+
+// setChunkSampleRange has chunk reads start at random values of the key's first column, between the key's
+// given minimum and maximum values. It applies to integer keys, and is otherwise ignored.
+func (this *observation) setChunkSampleRange(minValues, maxValues *sql.ColumnValues) {
+	if !this.uniqueKey.IsInteger() || minValues == nil || maxValues == nil {
+		return
+	}
+	rangeMin, minOk := parseObservedInteger(minValues.AbstractValues()[0])
+	rangeMax, maxOk := parseObservedInteger(maxValues.AbstractValues()[0])
+	if !minOk || !maxOk || rangeMax.Cmp(rangeMin) < 0 {
+		return
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.chunkSampleRangeMin, this.chunkSampleRangeMax = rangeMin, rangeMax
+}
+
+// parseObservedInteger parses an integer key value as read from the server
+func parseObservedInteger(value interface{}) (*big.Int, bool) {
+	if bytes, ok := value.([]byte); ok {
+		return new(big.Int).SetString(string(bytes), 10)
+	}
+	return new(big.Int).SetString(fmt.Sprint(value), 10)
+}
+
+// nextChunkSampleStart returns the values of the key's leading columns the next chunk read starts at, or
+// nil for the table's first row
+func (this *observation) nextChunkSampleStart() []interface{} {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.chunkSampleRangeMin == nil {
+		return this.chunkSampleNextStart
+	}
+	span := new(big.Int).Sub(this.chunkSampleRangeMax, this.chunkSampleRangeMin)
+	start := new(big.Int).Rand(this.random, span.Add(span, big.NewInt(1)))
+	start.Add(start, this.chunkSampleRangeMin)
+	if start.IsInt64() {
+		return []interface{}{start.Int64()}
+	}
+	return []interface{}{start.Uint64()}
+}
+
+// onChunkRead accounts for the time reading a chunk took. lastValues are the key values of the chunk's
+// last row, nil if the chunk reached the end of the table.
+func (this *observation) onChunkRead(duration time.Duration, lastValues *sql.ColumnValues) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.chunkSampleNextStart = nil
+	if lastValues != nil {
+		this.chunkSampleNextStart = lastValues.AbstractValues()
+	}
+	this.chunkReads++
+	this.chunkReadTotalTime += duration
+	if duration > this.chunkReadMaxTime {
+		this.chunkReadMaxTime = duration
+	}
+}
+
+// report projects the migration of given estimated rows from what's been observed
+func (this *observation) report(rowsEstimate, chunkSize int64, niceRatio float64) *observationReport {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	elapsed := time.Since(this.startTime)
+	report := &observationReport{
+		ObservedSeconds:        elapsed.Seconds(),
+		RowEventsPerSecond:     make(map[string]float64),
+		PeakRowEventsPerSecond: this.peakRowEventsPerSecond,
+		UniqueKey:              this.uniqueKey.Name,
+		ChunkSize:              chunkSize,
+		ChunkReads:             this.chunkReads,
+		MaxChunkReadSecs:       this.chunkReadMaxTime.Seconds(),
+	}
+	for _, dml := range []binlog.EventDML{binlog.InsertDML, binlog.UpdateDML, binlog.DeleteDML} {
+		report.RowEventsPerSecond[strings.ToLower(string(dml))] = float64(this.rowEvents[dml]) / math.Max(elapsed.Seconds(), 1)
+	}
+	if this.chunkReads > 0 && chunkSize > 0 {
+		averageChunkReadTime := this.chunkReadTotalTime.Seconds() / float64(this.chunkReads)
+		chunks := math.Ceil(float64(rowsEstimate) / float64(chunkSize))
+		report.AverageChunkReadSecs = averageChunkReadTime
+		report.ProjectedRowCopyLowerBoundSecs = chunks * averageChunkReadTime * (1 + niceRatio)
+		report.ExpectedPeakApplierLagSeconds = this.chunkReadMaxTime.Seconds() + float64(this.peakRowEventsPerSecond)*averageChunkReadTime/float64(chunkSize)
+	}
+	return report
+}
+
+func (this *observationReport) String() string {
+	return fmt.Sprintf("%.0fs observed; row events/s: insert %.1f, update %.1f, delete %.1f, peak %d; chunk read (%d rows): avg %.3fs, max %.3fs over %d samples; projected row copy: at least %s (reads only); expected peak applier lag: %.1fs; throttled: %t %s",
+		this.ObservedSeconds,
+		this.RowEventsPerSecond["insert"], this.RowEventsPerSecond["update"], this.RowEventsPerSecond["delete"], this.PeakRowEventsPerSecond,
+		this.ChunkSize, this.AverageChunkReadSecs, this.MaxChunkReadSecs, this.ChunkReads,
+		time.Duration(this.ProjectedRowCopyLowerBoundSecs*float64(time.Second)).Round(time.Second),
+		this.ExpectedPeakApplierLagSeconds,
+		this.Throttled, this.ThrottleReason,
+	)
+}
+
+// Observe runs the preflight checks of a migration and then, creating nothing, watches the binary log
+// events on the original table and the throttler for --observe-duration, timing chunk reads on the way.
+// It then reports the write rate and projections for the migration.
+func (this *Migrator) Observe() (err error) {
+	defer this.reportPanic()
+	this.log.Infof("Observing %s.%s for %+v", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName), this.migrationContext.ObserveDuration)
+	this.migrationContext.StartTime = time.Now()
+	if this.migrationContext.Hostname, err = os.Hostname(); err != nil {
+		return err
+	}
+	if err := this.parser.ParseAlterStatement(this.migrationContext.AlterStatement); err != nil {
+		return err
+	}
+	if err := this.validateAlterStatement(); err != nil {
+		return err
+	}
+	defer this.teardown()
+
+	if err := this.initiateInspector(); err != nil {
+		return err
+	}
+	uniqueKey, err := this.electObservedUniqueKey()
+	if err != nil {
+		return err
+	}
+	this.applier = NewApplier(this.migrationContext)
+	if err := this.applier.InitDBConnections(); err != nil {
+		return err
+	}
+	artifacts, err := this.findMigrationArtifacts()
+	if err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		this.log.Warningf("%s.%s exists, created %+v ago. The migration would refuse to run unless it's dropped first; see --cleanup",
+			sql.EscapeName(artifact.databaseName), sql.EscapeName(artifact.tableName), artifact.age)
+	}
+
+	this.observation = newObservation(uniqueKey)
+	if err := this.initiateObservedStreaming(); err != nil {
+		return err
+	}
+	this.initiateThrottler()
+	go this.observeChunkReads()
+	go this.observeTicker()
+
+	timer := time.NewTimer(this.migrationContext.ObserveDuration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case err := <-this.migrationContext.PanicAbort:
+		if !errors.Is(err, ErrTerminated) {
+			return err
+		}
+		this.log.Infof("Observation terminated")
+	}
+	this.log.Infof("Observed %s.%s: %s", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName), this.observationReport())
+	return nil
+}
+
+// electObservedUniqueKey elects the unique key of the original table the migration would likely iterate,
+// not knowing the ghost table's keys
+func (this *Migrator) electObservedUniqueKey() (*sql.UniqueKey, error) {
+	candidateUniqueKeys := []*sql.UniqueKey{}
+	for _, uniqueKey := range this.migrationContext.OriginalTableUniqueKeys {
+		if partialUniqueKeyRefusal(uniqueKey) == "" {
+			candidateUniqueKeys = append(candidateUniqueKeys, uniqueKey)
+		}
+	}
+	uniqueKey, reason, err := this.inspector.electUniqueKey(candidateUniqueKeys)
+	if err != nil {
+		return nil, err
+	}
+	if uniqueKey == nil {
+		return nil, fmt.Errorf("No unique key of %s can be used for iteration. Bailing out", sql.EscapeName(this.migrationContext.OriginalTableName))
+	}
+	this.log.Infof("Migration would likely iterate %s (%s)", uniqueKey.Name, reason)
+	return uniqueKey, nil
+}
+
+// initiateObservedStreaming begins streaming binary log events, counting those on the original table
+func (this *Migrator) initiateObservedStreaming() error {
+	this.eventsStreamer = NewEventsStreamer(this.migrationContext)
+	if err := this.eventsStreamer.InitDBConnections(); err != nil {
+		return err
+	}
+	this.migrationContext.StartBinlogCoordinates = this.eventsStreamer.GetCurrentBinlogCoordinates()
+	if err := this.eventsStreamer.AddListener(
+		false,
+		this.migrationContext.DatabaseName,
+		this.migrationContext.OriginalTableName,
+		func(dmlEntry *binlog.BinlogEntry) error {
+			this.observation.onRowEvent(dmlEntry.DmlEvent.DML)
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+	go func() {
+		if err := this.eventsStreamer.StreamEvents(this.canStopStreaming); err != nil && atomic.LoadInt64(&this.finishedMigrating) == 0 {
+			this.migrationContext.PanicAbort <- err
+		}
+	}()
+	return nil
+}
+
+// observeChunkReads times reading a chunk of the original table every so often, until done observing
+func (this *Migrator) observeChunkReads() {
+	if this.observation.uniqueKey.IsInteger() {
+		if minValues, maxValues, err := this.applier.ReadUniqueKeyRangeValues(this.observation.uniqueKey); err != nil {
+			this.log.Errore(err)
+		} else {
+			this.observation.setChunkSampleRange(minValues, maxValues)
+		}
+	}
+	for atomic.LoadInt64(&this.finishedMigrating) == 0 {
+		if lastValues, duration, err := this.applier.ReadChunkSample(this.observation.uniqueKey, this.observation.nextChunkSampleStart()); err != nil {
+			this.log.Errore(err)
+		} else {
+			this.observation.onChunkRead(duration, lastValues)
+		}
+		time.Sleep(observeChunkSampleInterval)
+	}
+}
+
+// observeTicker closes each second of row events, and logs the observation every so often
+func (this *Migrator) observeTicker() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastStatusTime := time.Now()
+	for range ticker.C {
+		if atomic.LoadInt64(&this.finishedMigrating) > 0 {
+			return
+		}
+		this.observation.tick()
+		if time.Since(lastStatusTime) >= observeStatusInterval {
+			this.log.Infof("Observing: %s", this.observationReport())
+			lastStatusTime = time.Now()
+		}
+	}
+}
+
+// observationReport reports the observation so far, with the throttler's current state
+func (this *Migrator) observationReport() *observationReport {
+	rowsEstimate := atomic.LoadInt64(&this.migrationContext.RowsEstimate)
+	report := this.observation.report(rowsEstimate, atomic.LoadInt64(&this.migrationContext.ChunkSize), this.migrationContext.GetNiceRatio())
+	report.Throttled, report.ThrottleReason, _ = this.migrationContext.IsThrottled()
+	return report
+}
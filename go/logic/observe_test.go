@@ -0,0 +1,128 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/github/gh-ost/go/binlog"
+	"github.com/github/gh-ost/go/sql"
+)
+
+func TestObservationReport(t *testing.T) {
+	uniqueKey := &sql.UniqueKey{Name: "PRIMARY", Columns: *sql.NewColumnList([]string{"id"})}
+
+	t.Run("no chunk reads", func(t *testing.T) {
+		observation := newObservation(uniqueKey)
+		report := observation.report(1000000, 1000, 0)
+		require.Equal(t, "PRIMARY", report.UniqueKey)
+		require.Equal(t, int64(0), report.ChunkReads)
+		require.Zero(t, report.ProjectedRowCopyLowerBoundSecs)
+		require.Zero(t, report.ExpectedPeakApplierLagSeconds)
+		require.Equal(t, map[string]float64{"insert": 0, "update": 0, "delete": 0}, report.RowEventsPerSecond)
+	})
+
+	t.Run("projections", func(t *testing.T) {
+		observation := newObservation(uniqueKey)
+		observation.startTime = time.Now().Add(-10 * time.Second)
+		for i := 0; i < 30; i++ {
+			observation.onRowEvent(binlog.InsertDML)
+		}
+		for i := 0; i < 10; i++ {
+			observation.onRowEvent(binlog.UpdateDML)
+		}
+		observation.tick()
+		for i := 0; i < 20; i++ {
+			observation.onRowEvent(binlog.DeleteDML)
+		}
+		observation.tick()
+		observation.onChunkRead(100*time.Millisecond, nil)
+		observation.onChunkRead(300*time.Millisecond, nil)
+
+		report := observation.report(1000000, 1000, 0.5)
+		require.InDelta(t, 10, report.ObservedSeconds, 1)
+		require.InDelta(t, 3, report.RowEventsPerSecond["insert"], 0.5)
+		require.InDelta(t, 1, report.RowEventsPerSecond["update"], 0.5)
+		require.InDelta(t, 2, report.RowEventsPerSecond["delete"], 0.5)
+		require.Equal(t, int64(40), report.PeakRowEventsPerSecond)
+		require.Equal(t, int64(2), report.ChunkReads)
+		require.InDelta(t, 0.2, report.AverageChunkReadSecs, 0.0001)
+		require.InDelta(t, 0.3, report.MaxChunkReadSecs, 0.0001)
+		// 1000 chunks of 0.2s, with a 0.5 nice ratio
+		require.InDelta(t, 300, report.ProjectedRowCopyLowerBoundSecs, 0.0001)
+		// The slowest chunk, then 40 rows at 0.2ms each
+		require.InDelta(t, 0.308, report.ExpectedPeakApplierLagSeconds, 0.0001)
+		require.Contains(t, report.String(), "projected row copy: at least 5m0s (reads only)")
+	})
+}
+
+func TestObservationChunkSampleStart(t *testing.T) {
+	t.Run("integer key", func(t *testing.T) {
+		columns := sql.NewColumnList([]string{"id"})
+		columns.GetColumn("id").MySQLType = "bigint unsigned"
+		observation := newObservation(&sql.UniqueKey{Name: "PRIMARY", Columns: *columns})
+		require.Nil(t, observation.nextChunkSampleStart())
+
+		observation.setChunkSampleRange(sql.ToColumnValues([]interface{}{[]byte("1000")}), sql.ToColumnValues([]interface{}{[]byte("1999")}))
+		starts := map[int64]bool{}
+		for i := 0; i < 100; i++ {
+			start := observation.nextChunkSampleStart()
+			require.Len(t, start, 1)
+			require.GreaterOrEqual(t, start[0], int64(1000))
+			require.LessOrEqual(t, start[0], int64(1999))
+			starts[start[0].(int64)] = true
+		}
+		require.Greater(t, len(starts), 1)
+
+		// Beyond the signed range
+		observation.setChunkSampleRange(sql.ToColumnValues([]interface{}{[]byte("18446744073709551615")}), sql.ToColumnValues([]interface{}{[]byte("18446744073709551615")}))
+		require.Equal(t, []interface{}{uint64(18446744073709551615)}, observation.nextChunkSampleStart())
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		columns := sql.NewColumnList([]string{"uuid"})
+		columns.GetColumn("uuid").MySQLType = "varchar(36)"
+		observation := newObservation(&sql.UniqueKey{Name: "uuid_uidx", Columns: *columns})
+		observation.setChunkSampleRange(sql.ToColumnValues([]interface{}{[]byte("a")}), sql.ToColumnValues([]interface{}{[]byte("z")}))
+		require.Nil(t, observation.nextChunkSampleStart())
+
+		// Walks the key, and starts over past the end of the table
+		observation.onChunkRead(time.Millisecond, sql.ToColumnValues([]interface{}{[]byte("m")}))
+		require.Equal(t, []interface{}{[]byte("m")}, observation.nextChunkSampleStart())
+		observation.onChunkRead(time.Millisecond, nil)
+		require.Nil(t, observation.nextChunkSampleStart())
+	})
+}
+
+func TestApplierObserve(t *testing.T) {
+	migrator, fake := newFakeMigrator(t)
+	migrator.migrationContext.Observe = true
+	migrator.migrationContext.ChunkSize = 2
+	migrator.migrationContext.OriginalTableColumns = sql.NewColumnList([]string{"id", "item_id"})
+	fake.expect("^select /\\* gh-ost `test`.`testing` \\*/ `id`, `item_id`").returnRows([]string{"id", "item_id"}, []driver.Value{int64(1), int64(42)}, []driver.Value{int64(2), int64(42)})
+
+	lastValues, duration, err := migrator.applier.ReadChunkSample(migrator.migrationContext.UniqueKey, nil)
+	require.NoError(t, err)
+	require.Greater(t, duration, time.Duration(0))
+	require.Equal(t, []interface{}{int64(2)}, lastValues.AbstractValues())
+	require.Equal(t, 1, fake.countQueries("force index \\(PRIMARY\\)\\s+order by\\s+`id` asc\\s+limit 2$"))
+
+	// A chunk short of the chunk size reaches the end of the table
+	migrator.migrationContext.ChunkSize = 100
+	lastValues, _, err = migrator.applier.ReadChunkSample(migrator.migrationContext.UniqueKey, []interface{}{int64(1)})
+	require.NoError(t, err)
+	require.Nil(t, lastValues)
+	require.Equal(t, 1, fake.countQueries("force index \\(PRIMARY\\)\\s+where \\(\\(`id` > \\?\\) or \\(\\(`id` = \\?\\)\\)\\)\\s+order by\\s+`id` asc\\s+limit 100$"))
+
+	// With no changelog table, throttle state and the like are not written
+	_, err = migrator.applier.WriteAndLogChangelog("throttle", "max-load")
+	require.NoError(t, err)
+	require.Equal(t, 0, fake.countQueries(`insert /\* gh-ost \*/`))
+}
@@ -0,0 +1,166 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/gh-ost/go/mysql"
+	"github.com/github/gh-ost/go/sql"
+)
+
+// replicaPromotionPlan is written to --replica-promotion-plan-file upon cut-over with --migrate-on-replica,
+// for promoting the replica the table was migrated on
+type replicaPromotionPlan struct {
+	DatabaseName   string    `json:"database_name"`
+	TableName      string    `json:"table_name"`
+	OldTableName   string    `json:"old_table_name"`
+	TableStructure string    `json:"table_structure"`
+	Replica        string    `json:"replica"`
+	Master         string    `json:"master"`
+	GTID           bool      `json:"gtid"`
+	CutOverTime    time.Time `json:"cut_over_time"`
+	// CutOverBinlogCoordinates are in the replica's own binary logs
+	CutOverBinlogCoordinates string `json:"cut_over_binlog_coordinates"`
+	// ReplicationStopped is set once replication is stopped upon the 'promote' command. Until then,
+	// the replica keeps replicating, and the coordinates below are as of the time the plan is written.
+	ReplicationStopped bool      `json:"replication_stopped"`
+	CoordinatesTime    time.Time `json:"coordinates_time"`
+	// ReplicaBinlogCoordinates are the replica's own binary log coordinates
+	ReplicaBinlogCoordinates string `json:"replica_binlog_coordinates"`
+	// MasterExecutedBinlogCoordinates are the master's binary log coordinates executed by the replica
+	MasterExecutedBinlogCoordinates string `json:"master_executed_binlog_coordinates"`
+	// RepointCommands re-point another replica of the master onto the promoted replica
+	RepointCommands []string `json:"repoint_commands"`
+}
+
+// buildRepointCommands returns the commands that re-point another replica of the master onto the promoted
+// replica. With GTID these rely on auto positioning. Otherwise the other replica first executes up to where
+// the promoted replica executed, which is an exact position only once the promoted replica stopped replicating.
+func buildRepointCommands(dbVersion string, replicaKey mysql.InstanceKey, gtid bool, replicaCoordinates, masterExecutedCoordinates mysql.BinlogCoordinates) []string {
+	replica := mysql.ReplicaTermFor(dbVersion, "slave")
+	source, changeSource := "MASTER", "CHANGE MASTER TO"
+	if replica == "replica" {
+		source, changeSource = "SOURCE", "CHANGE REPLICATION SOURCE TO"
+	}
+	stopReplica := fmt.Sprintf("STOP %s", strings.ToUpper(replica))
+	startReplica := fmt.Sprintf("START %s", strings.ToUpper(replica))
+	if gtid {
+		return []string{
+			stopReplica,
+			fmt.Sprintf("%s %s_HOST=%s, %s_PORT=%d, %s_AUTO_POSITION=1", changeSource, source, sql.QuoteLiteral(replicaKey.Hostname), source, replicaKey.Port, source),
+			startReplica,
+		}
+	}
+	replicaFileCoordinates, ok := replicaCoordinates.(*mysql.FileBinlogCoordinates)
+	if !ok {
+		return nil
+	}
+	masterFileCoordinates, ok := masterExecutedCoordinates.(*mysql.FileBinlogCoordinates)
+	if !ok {
+		return nil
+	}
+	return []string{
+		stopReplica,
+		fmt.Sprintf("%s SQL_THREAD UNTIL %s_LOG_FILE=%s, %s_LOG_POS=%d", startReplica, source, sql.QuoteLiteral(masterFileCoordinates.LogFile), source, masterFileCoordinates.LogPos),
+		fmt.Sprintf("SELECT %s_POS_WAIT(%s, %d)", source, sql.QuoteLiteral(masterFileCoordinates.LogFile), masterFileCoordinates.LogPos),
+		stopReplica,
+		fmt.Sprintf("%s %s_HOST=%s, %s_PORT=%d, %s_LOG_FILE=%s, %s_LOG_POS=%d", changeSource, source, sql.QuoteLiteral(replicaKey.Hostname), source, replicaKey.Port, source, sql.QuoteLiteral(replicaFileCoordinates.LogFile), source, replicaFileCoordinates.LogPos),
+		startReplica,
+	}
+}
+
+// newReplicaPromotionPlan reads the replica's current coordinates into a promotion plan
+func (this *Migrator) newReplicaPromotionPlan(replicationStopped bool) (*replicaPromotionPlan, error) {
+	dbVersion := this.migrationContext.ApplierMySQLVersion
+	replicaCoordinates, err := mysql.GetSelfBinlogCoordinates(dbVersion, this.applier.db, this.migrationContext.UseGTIDs)
+	if err != nil {
+		return nil, err
+	}
+	_, masterExecutedCoordinates, err := mysql.GetReplicationBinlogCoordinates(dbVersion, this.applier.db, this.migrationContext.UseGTIDs)
+	if err != nil {
+		return nil, err
+	}
+	tableStructure, err := this.inspector.showCreateTable(this.migrationContext.DatabaseName, this.migrationContext.OriginalTableName)
+	if err != nil {
+		return nil, err
+	}
+	replicaKey := this.migrationContext.ApplierConnectionConfig.Key
+	plan := &replicaPromotionPlan{
+		DatabaseName:                    this.migrationContext.DatabaseName,
+		TableName:                       this.migrationContext.OriginalTableName,
+		OldTableName:                    this.migrationContext.GetOldTableName(),
+		TableStructure:                  tableStructure,
+		Replica:                         replicaKey.String(),
+		GTID:                            this.migrationContext.UseGTIDs,
+		CutOverTime:                     this.migrationContext.RenameTablesEndTime,
+		CutOverBinlogCoordinates:        displayBinlogCoordinates(this.migrationContext.CutOverBinlogCoordinates),
+		ReplicationStopped:              replicationStopped,
+		CoordinatesTime:                 time.Now(),
+		ReplicaBinlogCoordinates:        displayBinlogCoordinates(replicaCoordinates),
+		MasterExecutedBinlogCoordinates: displayBinlogCoordinates(masterExecutedCoordinates),
+		RepointCommands:                 buildRepointCommands(dbVersion, replicaKey, this.migrationContext.UseGTIDs, replicaCoordinates, masterExecutedCoordinates),
+	}
+	if this.replicationMasterKey != nil {
+		plan.Master = this.replicationMasterKey.String()
+	}
+	return plan, nil
+}
+
+// writeReplicaPromotionPlan writes --replica-promotion-plan-file, if given
+func (this *Migrator) writeReplicaPromotionPlan(replicationStopped bool) error {
+	if this.migrationContext.ReplicaPromotionPlanFile == "" {
+		return nil
+	}
+	plan, err := this.newReplicaPromotionPlan(replicationStopped)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(this.migrationContext.ReplicaPromotionPlanFile, append(content, '\n')); err != nil {
+		return err
+	}
+	this.log.Infof("Wrote replica promotion plan to %s; replica at %s, master executed up to %s", this.migrationContext.ReplicaPromotionPlanFile, plan.ReplicaBinlogCoordinates, plan.MasterExecutedBinlogCoordinates)
+	return nil
+}
+
+// prepareReplicaPromotion follows a cut-over with --migrate-on-replica: it writes the promotion plan and runs
+// the on-replica-cut-over hook. With --await-replica-promotion it then waits for the 'promote' command, while
+// replication keeps applying the master's writes onto the migrated table, and stops replication once commanded.
+func (this *Migrator) prepareReplicaPromotion() error {
+	if err := this.writeReplicaPromotionPlan(false); err != nil {
+		return err
+	}
+	if err := this.hooksExecutor.onReplicaCutOver(); err != nil {
+		return err
+	}
+	if !this.migrationContext.AwaitReplicaPromotion {
+		return nil
+	}
+
+	this.log.Infof("Replicating into %s.%s; awaiting the 'promote' command", sql.EscapeName(this.migrationContext.DatabaseName), sql.EscapeName(this.migrationContext.OriginalTableName))
+	atomic.StoreInt64(&this.migrationContext.IsAwaitingReplicaPromotionFlag, 1)
+	this.sleepWhileTrue(func() (bool, error) {
+		return atomic.LoadInt64(&this.migrationContext.UserCommandedPromoteFlag) == 0, nil
+	})
+	atomic.StoreInt64(&this.migrationContext.IsAwaitingReplicaPromotionFlag, 0)
+
+	this.log.Infof("Promotion commanded; stopping replication")
+	if err := this.retryOperation(this.applier.StopReplication); err != nil {
+		return err
+	}
+	if err := this.writeReplicaPromotionPlan(true); err != nil {
+		return err
+	}
+	return this.hooksExecutor.onReplicaPromotion()
+}
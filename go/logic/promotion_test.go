@@ -0,0 +1,57 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/github/gh-ost/go/mysql"
+)
+
+func TestBuildRepointCommands(t *testing.T) {
+	replicaKey := mysql.InstanceKey{Hostname: "replica1", Port: 3306}
+
+	t.Run("gtid", func(t *testing.T) {
+		require.Equal(t, []string{
+			"STOP SLAVE",
+			"CHANGE MASTER TO MASTER_HOST='replica1', MASTER_PORT=3306, MASTER_AUTO_POSITION=1",
+			"START SLAVE",
+		}, buildRepointCommands("8.0.40", replicaKey, true, nil, nil))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		replicaCoordinates := mysql.NewFileBinlogCoordinates("replica-bin.000012", 4567)
+		masterCoordinates := mysql.NewFileBinlogCoordinates("master-bin.000034", 8910)
+		require.Equal(t, []string{
+			"STOP REPLICA",
+			"START REPLICA SQL_THREAD UNTIL SOURCE_LOG_FILE='master-bin.000034', SOURCE_LOG_POS=8910",
+			"SELECT SOURCE_POS_WAIT('master-bin.000034', 8910)",
+			"STOP REPLICA",
+			"CHANGE REPLICATION SOURCE TO SOURCE_HOST='replica1', SOURCE_PORT=3306, SOURCE_LOG_FILE='replica-bin.000012', SOURCE_LOG_POS=4567",
+			"START REPLICA",
+		}, buildRepointCommands("8.4.3", replicaKey, false, replicaCoordinates, masterCoordinates))
+	})
+
+	t.Run("escaped", func(t *testing.T) {
+		replicaKey := mysql.InstanceKey{Hostname: `replica'1\`, Port: 3306}
+		replicaCoordinates := mysql.NewFileBinlogCoordinates("replica'bin.000012", 4567)
+		masterCoordinates := mysql.NewFileBinlogCoordinates("master'bin.000034", 8910)
+		require.Equal(t, []string{
+			"STOP SLAVE",
+			"START SLAVE SQL_THREAD UNTIL MASTER_LOG_FILE='master''bin.000034', MASTER_LOG_POS=8910",
+			"SELECT MASTER_POS_WAIT('master''bin.000034', 8910)",
+			"STOP SLAVE",
+			`CHANGE MASTER TO MASTER_HOST='replica''1\\', MASTER_PORT=3306, MASTER_LOG_FILE='replica''bin.000012', MASTER_LOG_POS=4567`,
+			"START SLAVE",
+		}, buildRepointCommands("5.7.44", replicaKey, false, replicaCoordinates, masterCoordinates))
+	})
+
+	t.Run("file coordinates missing", func(t *testing.T) {
+		require.Nil(t, buildRepointCommands("8.0.40", replicaKey, false, mysql.NewFileBinlogCoordinates("replica-bin.000012", 4567), nil))
+	})
+}
@@ -0,0 +1,208 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+// Outcomes of a migration, as reported in --report-file
+const (
+	reportOutcomeSuccess = "success"
+	reportOutcomeFailure = "failure"
+	// reportOutcomeAborted is a panic-abort: critical-load, a panic flag file, exhausted retries or --max-runtime
+	reportOutcomeAborted = "aborted"
+	// reportOutcomePanic is a Go panic
+	reportOutcomePanic = "panic"
+)
+
+const redactedFlagValue = "<redacted>"
+
+type migrationReportTimings struct {
+	ElapsedSeconds          float64 `json:"elapsed_seconds"`
+	RowCopySeconds          float64 `json:"row_copy_seconds"`
+	BinlogCatchUpSeconds    float64 `json:"binlog_catch_up_seconds"`
+	PostponedCutOverSeconds float64 `json:"postponed_cut_over_seconds"`
+	CutOverLockSeconds      float64 `json:"cut_over_lock_seconds"`
+}
+
+// migrationReport is written to --report-file upon exit, however the migration ended
+type migrationReport struct {
+	Version        string    `json:"version"`
+	DatabaseName   string    `json:"database_name"`
+	TableName      string    `json:"table_name"`
+	AlterStatement string    `json:"alter_statement"`
+	Revert         bool      `json:"revert"`
+	Observe        bool      `json:"observe"`
+	DryRun         bool      `json:"dry_run"`
+	Outcome        string    `json:"outcome"`
+	FailureClass   string    `json:"failure_class,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+
+	Timings          migrationReportTimings      `json:"timings"`
+	RowsCopied       int64                       `json:"rows_copied"`
+	RowsEstimate     int64                       `json:"rows_estimate"`
+	DMLEventsApplied int64                       `json:"dml_events_applied"`
+	Stats            base.MigrationStatsSnapshot `json:"stats"`
+
+	StartBinlogCoordinates           string `json:"start_binlog_coordinates"`
+	RowCopyCompleteBinlogCoordinates string `json:"row_copy_complete_binlog_coordinates"`
+	CutOverBinlogCoordinates         string `json:"cut_over_binlog_coordinates"`
+	TimeZone                         string `json:"time_zone"`
+
+	Configuration map[string]string `json:"configuration"`
+	Hooks         []hookInvocation  `json:"hooks"`
+
+	Observation *observationReport `json:"observation,omitempty"`
+}
+
+// WriteReport writes --report-file, given the error the migration ended with, if any. Of all exit paths,
+// only the first to write the report does.
+func (this *Migrator) WriteReport(err error) {
+	outcome := reportOutcomeSuccess
+	if err != nil {
+		outcome = reportOutcomeFailure
+	}
+	this.writeReport(outcome, err)
+}
+
+// reportPanic writes --report-file upon a Go panic, and then resumes panicking. It is to be deferred.
+func (this *Migrator) reportPanic() {
+	if r := recover(); r != nil {
+		this.writeReport(reportOutcomePanic, fmt.Errorf("%+v", r))
+		panic(r)
+	}
+}
+
+func (this *Migrator) writeReport(outcome string, err error) {
+	if this.migrationContext.ReportFile == "" {
+		return
+	}
+	this.reportOnce.Do(func() {
+		content, err := json.MarshalIndent(this.newReport(outcome, err), "", "  ")
+		if err != nil {
+			this.log.Errore(err)
+			return
+		}
+		if err := writeFileAtomically(this.migrationContext.ReportFile, append(content, '\n')); err != nil {
+			this.log.Errorf("Failed to write --report-file %s: %+v", this.migrationContext.ReportFile, err)
+			return
+		}
+		this.log.Infof("Wrote report to %s", this.migrationContext.ReportFile)
+	})
+}
+
+func (this *Migrator) newReport(outcome string, err error) *migrationReport {
+	report := &migrationReport{
+		Version:        this.appVersion,
+		DatabaseName:   this.migrationContext.DatabaseName,
+		TableName:      this.migrationContext.OriginalTableName,
+		AlterStatement: this.migrationContext.AlterStatement,
+		Revert:         this.migrationContext.Revert,
+		Observe:        this.migrationContext.Observe,
+		DryRun:         this.migrationContext.Noop,
+		Outcome:        outcome,
+		StartTime:      this.migrationContext.StartTime,
+		EndTime:        time.Now(),
+		Timings: migrationReportTimings{
+			RowCopySeconds:          this.migrationContext.ElapsedRowCopyTime().Seconds(),
+			BinlogCatchUpSeconds:    this.migrationContext.GetBinlogCatchUpTime().Seconds(),
+			PostponedCutOverSeconds: this.migrationContext.GetPostponedCutOverTime().Seconds(),
+			CutOverLockSeconds:      this.migrationContext.GetCutOverLockDuration().Seconds(),
+		},
+		RowsCopied:                       this.migrationContext.GetTotalRowsCopied(),
+		RowsEstimate:                     atomic.LoadInt64(&this.migrationContext.RowsEstimate) + atomic.LoadInt64(&this.migrationContext.RowsDeltaEstimate),
+		DMLEventsApplied:                 atomic.LoadInt64(&this.migrationContext.TotalDMLEventsApplied),
+		Stats:                            this.migrationContext.Stats.GetSnapshot(),
+		StartBinlogCoordinates:           displayBinlogCoordinates(this.migrationContext.StartBinlogCoordinates),
+		RowCopyCompleteBinlogCoordinates: displayBinlogCoordinates(this.migrationContext.RowCopyCompleteBinlogCoordinates),
+		CutOverBinlogCoordinates:         displayBinlogCoordinates(this.migrationContext.CutOverBinlogCoordinates),
+		TimeZone:                         this.migrationContext.ApplierTimeZone,
+		Configuration:                    redactFlags(this.migrationContext.CommandLineFlags),
+		Hooks:                            this.hooksExecutor.getInvocations(),
+	}
+	if !report.StartTime.IsZero() {
+		report.Timings.ElapsedSeconds = report.EndTime.Sub(report.StartTime).Seconds()
+	}
+	if this.observation != nil {
+		report.Observation = this.observationReport()
+	}
+	if err != nil {
+		report.FailureClass = this.failureClass(err)
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// failureClass returns what failed the migration: exceeding --max-runtime, SIGTERM, a row count mismatch at cut-over,
+// or else the phase it was in
+func (this *Migrator) failureClass(err error) string {
+	switch {
+	case errors.Is(err, ErrMaxRuntimeExceeded):
+		return "max-runtime-exceeded"
+	case errors.Is(err, ErrTerminated):
+		return "terminated"
+	case errors.Is(err, ErrRowCountMismatch):
+		return "row-count-mismatch"
+	case atomic.LoadInt64(&this.migrationContext.CutOverCompleteFlag) > 0:
+		return "after-cut-over"
+	case atomic.LoadInt64(&this.rowCopyCompleteFlag) > 0:
+		return "cut-over"
+	case this.migrationContext.ElapsedRowCopyTime() > 0:
+		return "row-copy"
+	}
+	return "before-row-copy"
+}
+
+// redactFlags returns given command line flags, with the values of passwords and tokens redacted
+func redactFlags(flags map[string]string) map[string]string {
+	redacted := make(map[string]string, len(flags))
+	for name, value := range flags {
+		if value != "" && (strings.Contains(name, "password") || strings.Contains(name, "token")) {
+			value = redactedFlagValue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// writeFileAtomically writes a temporary file next to given path, and renames it onto the path, so that
+// the path never holds a partially written file
+func writeFileAtomically(path string, content []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Once renamed, there is nothing left to remove
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
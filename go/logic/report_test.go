@@ -0,0 +1,166 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/github/gh-ost/go/base"
+)
+
+func newReportingMigrator(t *testing.T) *Migrator {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.DatabaseName = "test"
+	migrationContext.OriginalTableName = "tablename"
+	migrationContext.AlterStatement = "ADD COLUMN c INT"
+	migrationContext.ReportFile = filepath.Join(t.TempDir(), "report.json")
+	migrationContext.CommandLineFlags = map[string]string{
+		"chunk-size":      "1000",
+		"password":        "secret",
+		"master-password": "",
+	}
+	migrationContext.StartTime = time.Now().Add(-time.Minute)
+	return NewMigrator(migrationContext, "1.2.3")
+}
+
+func readReport(t *testing.T, migrator *Migrator) *migrationReport {
+	content, err := os.ReadFile(migrator.migrationContext.ReportFile)
+	require.NoError(t, err)
+	report := &migrationReport{}
+	require.NoError(t, json.Unmarshal(content, report))
+	return report
+}
+
+func TestMigratorWriteReport(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		migrationContext := migrator.migrationContext
+		migrationContext.TotalRowsCopied = 900
+		migrationContext.RowsEstimate = 1000
+		migrationContext.AddBinlogCatchUpTime(2 * time.Second)
+		migrationContext.AddPostponedCutOverTime(time.Hour)
+		migrationContext.LockTablesStartTime = time.Now()
+		migrationContext.RenameTablesEndTime = migrationContext.LockTablesStartTime.Add(300 * time.Millisecond)
+		migrationContext.Stats.MarkRetry()
+		migrationContext.Stats.AddThrottledTime("lag", 5*time.Second)
+		migrationContext.ApplierTimeZone = "+05:30"
+		migrator.hooksExecutor.recordInvocation(onSuccess, "/hooks/gh-ost-on-success-notify", time.Now(), nil)
+
+		migrator.WriteReport(nil)
+		report := readReport(t, migrator)
+		require.Equal(t, "1.2.3", report.Version)
+		require.Equal(t, "tablename", report.TableName)
+		require.Equal(t, reportOutcomeSuccess, report.Outcome)
+		require.Empty(t, report.FailureClass)
+		require.Empty(t, report.Error)
+		require.InDelta(t, 60, report.Timings.ElapsedSeconds, 5)
+		require.Equal(t, 2.0, report.Timings.BinlogCatchUpSeconds)
+		require.Equal(t, 3600.0, report.Timings.PostponedCutOverSeconds)
+		require.Equal(t, 0.3, report.Timings.CutOverLockSeconds)
+		require.Equal(t, int64(900), report.RowsCopied)
+		require.Equal(t, int64(1000), report.RowsEstimate)
+		require.Equal(t, int64(1), report.Stats.Retries)
+		require.Equal(t, map[string]float64{"lag": 5}, report.Stats.ThrottledSecondsByReason)
+		require.Equal(t, "+05:30", report.TimeZone)
+		require.Equal(t, map[string]string{
+			"chunk-size":      "1000",
+			"password":        redactedFlagValue,
+			"master-password": "",
+		}, report.Configuration)
+		require.Len(t, report.Hooks, 1)
+		require.Equal(t, onSuccess, report.Hooks[0].Hook)
+		require.Empty(t, report.Hooks[0].Error)
+
+		// only the report remains, no temporary files
+		entries, err := os.ReadDir(filepath.Dir(migrationContext.ReportFile))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		migrator.migrationContext.MarkRowCopyStartTime()
+		atomic.StoreInt64(&migrator.rowCopyCompleteFlag, 1)
+		migrator.hooksExecutor.recordInvocation(onFailure, "/hooks/gh-ost-on-failure-page", time.Now(), errors.New("exit status 1"))
+
+		migrator.WriteReport(errors.New("Timeout while waiting for events up to lock"))
+		report := readReport(t, migrator)
+		require.Equal(t, reportOutcomeFailure, report.Outcome)
+		require.Equal(t, "cut-over", report.FailureClass)
+		require.Equal(t, "Timeout while waiting for events up to lock", report.Error)
+		require.Equal(t, "exit status 1", report.Hooks[0].Error)
+
+		// the first exit path to report wins
+		migrator.WriteReport(nil)
+		require.Equal(t, reportOutcomeFailure, readReport(t, migrator).Outcome)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		migrator.writeReport(reportOutcomeAborted, ErrMaxRuntimeExceeded)
+		report := readReport(t, migrator)
+		require.Equal(t, reportOutcomeAborted, report.Outcome)
+		require.Equal(t, "max-runtime-exceeded", report.FailureClass)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		require.PanicsWithValue(t, "boom", func() {
+			defer migrator.reportPanic()
+			panic("boom")
+		})
+		report := readReport(t, migrator)
+		require.Equal(t, reportOutcomePanic, report.Outcome)
+		require.Equal(t, "before-row-copy", report.FailureClass)
+		require.Equal(t, "boom", report.Error)
+	})
+
+	t.Run("replaces existing report", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		require.NoError(t, os.WriteFile(migrator.migrationContext.ReportFile, []byte("not a report"), 0644))
+		migrator.WriteReport(nil)
+		require.Equal(t, reportOutcomeSuccess, readReport(t, migrator).Outcome)
+	})
+
+	t.Run("unwritable", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		migrator.migrationContext.ReportFile = filepath.Join(t.TempDir(), "does", "not", "exist", "report.json")
+		migrator.WriteReport(nil)
+		require.NoFileExists(t, migrator.migrationContext.ReportFile)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		migrator := newReportingMigrator(t)
+		reportFile := migrator.migrationContext.ReportFile
+		migrator.migrationContext.ReportFile = ""
+		migrator.WriteReport(nil)
+		require.NoFileExists(t, reportFile)
+	})
+}
+
+func TestMigratorFailureClass(t *testing.T) {
+	migrator := newReportingMigrator(t)
+	err := errors.New("failed")
+	require.Equal(t, "before-row-copy", migrator.failureClass(err))
+	migrator.migrationContext.MarkRowCopyStartTime()
+	require.Equal(t, "row-copy", migrator.failureClass(err))
+	atomic.StoreInt64(&migrator.rowCopyCompleteFlag, 1)
+	require.Equal(t, "cut-over", migrator.failureClass(err))
+	atomic.StoreInt64(&migrator.migrationContext.CutOverCompleteFlag, 1)
+	require.Equal(t, "after-cut-over", migrator.failureClass(err))
+	require.Equal(t, "max-runtime-exceeded", migrator.failureClass(ErrMaxRuntimeExceeded))
+	require.Equal(t, "terminated", migrator.failureClass(ErrTerminated))
+	require.Equal(t, "row-count-mismatch", migrator.failureClass(fmt.Errorf("%w: 3 rows on `t`, 2 rows on `_t_gho`", ErrRowCountMismatch)))
+}
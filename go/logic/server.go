@@ -9,14 +9,18 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -29,25 +33,67 @@ var (
 	ErrCPUProfilingBadOption  = errors.New("unrecognized cpu profiling option")
 	ErrCPUProfilingInProgress = errors.New("cpu profiling already in progress")
 	defaultCPUProfileDuration = time.Second * 30
+	statusHTTPTimeout         = time.Second * 5
 )
 
 type printStatusFunc func(PrintStatusRule, io.Writer)
+type statusSnapshotFunc func() *MigrationStatus
+type printQueueFunc func(io.Writer) error
 
-// Server listens for requests on a socket file or via TCP
+// MigrationStatus is the status document served by the HTTP status endpoint
+type MigrationStatus struct {
+	DatabaseName          string  `json:"database"`
+	TableName             string  `json:"table"`
+	GhostTableName        string  `json:"ghost_table"`
+	State                 string  `json:"state"`
+	RowsCopied            int64   `json:"rows_copied"`
+	RowsEstimate          int64   `json:"rows_estimate"`
+	ProgressPct           float64 `json:"progress_pct"`
+	RowCopyComplete       bool    `json:"row_copy_complete"`
+	EventsApplied         int64   `json:"events_applied"`
+	Backlog               int     `json:"backlog"`
+	BacklogCapacity       int     `json:"backlog_capacity"`
+	BacklogMemoryBytes    int64   `json:"backlog_memory_bytes"`
+	Backpressured         bool    `json:"backpressured"`
+	ElapsedSeconds        int64   `json:"elapsed_seconds"`
+	RowCopyElapsedSeconds int64   `json:"row_copy_elapsed_seconds"`
+	ETASeconds            int64   `json:"eta_seconds"`
+	BinlogCoordinates     string  `json:"binlog_coordinates"`
+	LagSeconds            float64 `json:"lag_seconds"`
+	HeartbeatLagSeconds   float64 `json:"heartbeat_lag_seconds"`
+	ApplierLagSeconds     float64 `json:"applier_lag_seconds"`
+	MaxApplierLagSeconds  float64 `json:"max_applier_lag_seconds"`
+	Throttled             bool    `json:"throttled"`
+	ThrottleReason        string  `json:"throttle_reason"`
+	PostponingCutOver     bool    `json:"postponing_cut_over"`
+	Stalled               bool    `json:"stalled"`
+
+	Stats base.MigrationStatsSnapshot `json:"stats"`
+}
+
+// Server listens for requests on a socket file or via TCP, and optionally serves status over HTTP
 type Server struct {
 	migrationContext *base.MigrationContext
+	log              base.Logger
 	unixListener     net.Listener
 	tcpListener      net.Listener
+	httpListener     net.Listener
+	httpServer       *http.Server
 	hooksExecutor    *HooksExecutor
 	printStatus      printStatusFunc
+	statusSnapshot   statusSnapshotFunc
+	printQueue       printQueueFunc
 	isCPUProfiling   int64
 }
 
-func NewServer(migrationContext *base.MigrationContext, hooksExecutor *HooksExecutor, printStatus printStatusFunc) *Server {
+func NewServer(migrationContext *base.MigrationContext, hooksExecutor *HooksExecutor, printStatus printStatusFunc, statusSnapshot statusSnapshotFunc, printQueue printQueueFunc) *Server {
 	return &Server{
 		migrationContext: migrationContext,
+		log:              migrationContext.NewComponentLogger(base.ServerLogComponent),
 		hooksExecutor:    hooksExecutor,
 		printStatus:      printStatus,
+		statusSnapshot:   statusSnapshot,
+		printQueue:       printQueue,
 	}
 }
 
@@ -95,7 +141,7 @@ func (this *Server) runCPUProfile(args string) (io.Reader, error) {
 
 	time.Sleep(duration)
 	pprof.StopCPUProfile()
-	this.migrationContext.Log.Infof("Captured %d byte runtime/pprof CPU profile (gzip=%v)", buf.Len(), useGzip)
+	this.log.Infof("Captured %d byte runtime/pprof CPU profile (gzip=%v)", buf.Len(), useGzip)
 	return &buf, nil
 }
 
@@ -104,7 +150,7 @@ func (this *Server) createPostponeCutOverFlagFile(filePath string) (err error) {
 		if err := base.TouchFile(filePath); err != nil {
 			return fmt.Errorf("Failed to create postpone cut-over flag file %s: %w", filePath, err)
 		}
-		this.migrationContext.Log.Infof("Created postpone-cut-over-flag-file: %s", filePath)
+		this.log.Infof("Created postpone-cut-over-flag-file: %s", filePath)
 	}
 	return nil
 }
@@ -120,12 +166,12 @@ func (this *Server) BindSocketFile() (err error) {
 	if err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Listening on unix socket file: %s", this.migrationContext.ServeSocketFile)
+	this.log.Infof("Listening on unix socket file: %s", this.migrationContext.ServeSocketFile)
 	return nil
 }
 
 func (this *Server) RemoveSocketFile() (err error) {
-	this.migrationContext.Log.Infof("Removing socket file: %s", this.migrationContext.ServeSocketFile)
+	this.log.Infof("Removing socket file: %s", this.migrationContext.ServeSocketFile)
 	return os.Remove(this.migrationContext.ServeSocketFile)
 }
 
@@ -137,17 +183,75 @@ func (this *Server) BindTCPPort() (err error) {
 	if err != nil {
 		return err
 	}
-	this.migrationContext.Log.Infof("Listening on tcp port: %d", this.migrationContext.ServeTCPPort)
+	this.log.Infof("Listening on tcp port: %d", this.migrationContext.ServeTCPPort)
+	return nil
+}
+
+// BindStatusHTTP listens on --status-listen, if configured
+func (this *Server) BindStatusHTTP() (err error) {
+	if this.migrationContext.StatusListenAddress == "" {
+		return nil
+	}
+	this.httpListener, err = net.Listen("tcp", this.migrationContext.StatusListenAddress)
+	if err != nil {
+		return err
+	}
+	this.httpServer = &http.Server{
+		Handler:           this.statusHTTPHandler(),
+		ReadHeaderTimeout: statusHTTPTimeout,
+		WriteTimeout:      statusHTTPTimeout,
+	}
+	this.log.Infof("Serving HTTP status on: %s", this.httpListener.Addr())
 	return nil
 }
 
+// ShutdownStatusHTTP gracefully stops the HTTP status server, letting in-flight requests complete
+func (this *Server) ShutdownStatusHTTP() (err error) {
+	if this.httpServer == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusHTTPTimeout)
+	defer cancel()
+	return this.httpServer.Shutdown(ctx)
+}
+
+// statusHTTPHandler serves the read-only status endpoints:
+// - /status: the JSON status document
+// - /healthz: 200 while the migration is progressing, 503 when stalled
+// - /progress: the progress percent, in plain text
+func (this *Server) statusHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(this.statusSnapshot()); err != nil {
+			this.log.Errore(err)
+		}
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		status := this.statusSnapshot()
+		switch {
+		case status.Stalled:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "stalled")
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	})
+	mux.HandleFunc("GET /progress", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%.1f\n", this.statusSnapshot().ProgressPct)
+	})
+	return mux
+}
+
 // Serve begins listening & serving on whichever device was configured
 func (this *Server) Serve() (err error) {
 	go func() {
 		for {
 			conn, err := this.unixListener.Accept()
 			if err != nil {
-				this.migrationContext.Log.Errore(err)
+				this.log.Errore(err)
 			}
 			go this.handleConnection(conn)
 		}
@@ -159,11 +263,19 @@ func (this *Server) Serve() (err error) {
 		for {
 			conn, err := this.tcpListener.Accept()
 			if err != nil {
-				this.migrationContext.Log.Errore(err)
+				this.log.Errore(err)
 			}
 			go this.handleConnection(conn)
 		}
 	}()
+	go func() {
+		if this.httpServer == nil {
+			return
+		}
+		if err := this.httpServer.Serve(this.httpListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			this.log.Errore(err)
+		}
+	}()
 
 	return nil
 }
@@ -189,7 +301,35 @@ func (this *Server) onServerCommand(command string, writer *bufio.Writer) (err e
 	} else {
 		fmt.Fprintf(writer, "%s\n", err.Error())
 	}
-	return this.migrationContext.Log.Errore(err)
+	return this.log.Errore(err)
+}
+
+// printStats prints a breakdown of the work done by the migration
+func (this *Server) printStats(writer io.Writer) {
+	stats := this.migrationContext.Stats.GetSnapshot()
+	fmt.Fprintf(writer, "# Applied: inserts: %d; updates: %d (%d modifying the unique key); deletes: %d\n",
+		stats.InsertsApplied, stats.UpdatesApplied, stats.UniqueKeyUpdatesApplied, stats.DeletesApplied,
+	)
+	fmt.Fprintf(writer, "# Chunks copied: %d; duration avg: %.1fms, p50: %.1fms, p95: %.1fms, p99: %.1fms\n",
+		stats.ChunksCopied, stats.ChunkCopyAvgMillis, stats.ChunkCopyP50Millis, stats.ChunkCopyP95Millis, stats.ChunkCopyP99Millis,
+	)
+	chunkSizes := make([]int64, 0, len(stats.RowsCopiedByChunkSize))
+	for chunkSize := range stats.RowsCopiedByChunkSize {
+		chunkSizes = append(chunkSizes, chunkSize)
+	}
+	sort.Slice(chunkSizes, func(i, j int) bool { return chunkSizes[i] < chunkSizes[j] })
+	for _, chunkSize := range chunkSizes {
+		fmt.Fprintf(writer, "# Rows copied with chunk-size %d: %d\n", chunkSize, stats.RowsCopiedByChunkSize[chunkSize])
+	}
+	throttleReasons := make([]string, 0, len(stats.ThrottledSecondsByReason))
+	for reason := range stats.ThrottledSecondsByReason {
+		throttleReasons = append(throttleReasons, reason)
+	}
+	sort.Strings(throttleReasons)
+	for _, reason := range throttleReasons {
+		fmt.Fprintf(writer, "# Throttled by %s: %.1fs\n", reason, stats.ThrottledSecondsByReason[reason])
+	}
+	fmt.Fprintf(writer, "# Retries: %d\n", stats.Retries)
 }
 
 // applyServerCommand parses and executes commands by user
@@ -220,13 +360,19 @@ cpu-profile=<options>                # Print a base64-encoded runtime/pprof CPU
 coordinates                          # Print the currently inspected coordinates
 applier                              # Print the hostname of the applier
 inspector                            # Print the hostname of the inspector
+stats                                # Print a breakdown of applied binlog events, chunk copies, throttling and retries
+queue                                # Print the live migrations registered for coordination (see --max-concurrent-migrations)
 chunk-size=<newsize>                 # Set a new chunk-size
 dml-batch-size=<newsize>             # Set a new dml-batch-size
+applier-parallelism=<workers>        # Set a new number of applier workers applying binlog events
+chunk-copy-optimizer-hints=<hints>   # Set new optimizer hints for the rowcopy SELECT, without the enclosing /*+ */ (empty to clear)
 nice-ratio=<ratio>                   # Set a new nice-ratio, immediate sleep after each row-copy operation, float (examples: 0 is aggressive, 0.7 adds 70% runtime, 1.0 doubles runtime, 2.0 triples runtime, ...)
 critical-load=<load>                 # Set a new set of max-load thresholds
 max-lag-millis=<max-lag>             # Set a new replication lag threshold
+max-runtime=<duration>               # Set a new migration deadline, relative to the migration start time, e.g. '8h' (0 disables)
 replication-lag-query=<query>        # Set a new query that determines replication lag (no quotes)
 max-load=<load>                      # Set a new set of max-load thresholds
+log-levels=<levels>                  # Override the log level of components, comma delimited component=level (level 'default' removes an override)
 throttle-query=<query>               # Set a new throttle-query (no quotes)
 throttle-http=<URL>                  # Set a new throttle URL
 throttle-control-replicas=<replicas> # Set a new comma delimited list of throttle control replicas
@@ -234,6 +380,8 @@ throttle                             # Force throttling
 no-throttle                          # End forced throttling (other throttling may still apply)
 postpone-cut-over-flag-file=<path>   # Postpone the cut-over phase, writing a cut over flag file to the given path
 unpostpone                           # Bail out a cut-over postpone; proceed to cut-over
+skip-warm-up                         # Skip or stop warming up the ghost table before cut-over
+promote                              # With --await-replica-promotion, stop replication on the replica migrated on, and complete
 panic                                # panic and quit without cleanup
 help                                 # This message
 - use '?' (question mark) as argument to get info rather than set. e.g. "max-load=?" will just print out current max-load.
@@ -257,6 +405,21 @@ help                                 # This message
 			}
 			return NoPrintStatusRule, fmt.Errorf("coordinates are read-only")
 		}
+	case "stats":
+		{
+			if argIsQuestion || arg == "" {
+				this.printStats(writer)
+				return NoPrintStatusRule, nil
+			}
+			return NoPrintStatusRule, fmt.Errorf("stats are read-only")
+		}
+	case "queue":
+		{
+			if argIsQuestion || arg == "" {
+				return NoPrintStatusRule, this.printQueue(writer)
+			}
+			return NoPrintStatusRule, fmt.Errorf("queue is read-only")
+		}
 	case "applier":
 		if this.migrationContext.ApplierConnectionConfig != nil && this.migrationContext.ApplierConnectionConfig.ImpliedKey != nil {
 			fmt.Fprintf(writer, "Host: %s, Version: %s\n",
@@ -294,11 +457,41 @@ help                                 # This message
 			}
 			if dmlBatchSize, err := strconv.Atoi(arg); err != nil {
 				return NoPrintStatusRule, err
+			} else if dmlBatchSize < 1 || dmlBatchSize > base.MaxEventsBatchSize {
+				return NoPrintStatusRule, fmt.Errorf("dml-batch-size must be between 1 and %d", base.MaxEventsBatchSize)
 			} else {
 				this.migrationContext.SetDMLBatchSize(int64(dmlBatchSize))
 				return ForcePrintStatusAndHintRule, nil
 			}
 		}
+	case "applier-parallelism":
+		{
+			if argIsQuestion {
+				fmt.Fprintf(writer, "%+v\n", this.migrationContext.GetApplierParallelism())
+				return NoPrintStatusRule, nil
+			}
+			if applierParallelism, err := strconv.Atoi(arg); err != nil {
+				return NoPrintStatusRule, err
+			} else if applierParallelism < 1 || applierParallelism > base.MaxApplierParallelism {
+				return NoPrintStatusRule, fmt.Errorf("applier-parallelism must be between 1 and %d", base.MaxApplierParallelism)
+			} else if restriction := this.migrationContext.GetApplierParallelismRestriction(); restriction != "" && applierParallelism > 1 {
+				return NoPrintStatusRule, fmt.Errorf("applier-parallelism is restricted to a single worker: %s", restriction)
+			} else {
+				this.migrationContext.SetApplierParallelism(int64(applierParallelism))
+				return ForcePrintStatusAndHintRule, nil
+			}
+		}
+	case "chunk-copy-optimizer-hints":
+		{
+			if argIsQuestion {
+				fmt.Fprintf(writer, "%s\n", this.migrationContext.GetChunkCopyOptimizerHints())
+				return NoPrintStatusRule, nil
+			}
+			if err := this.migrationContext.SetChunkCopyOptimizerHints(arg); err != nil {
+				return NoPrintStatusRule, err
+			}
+			return ForcePrintStatusAndHintRule, nil
+		}
 	case "max-lag-millis":
 		{
 			if argIsQuestion {
@@ -312,6 +505,27 @@ help                                 # This message
 				return ForcePrintStatusAndHintRule, nil
 			}
 		}
+	case "max-runtime":
+		{
+			if argIsQuestion {
+				maxRuntime := this.migrationContext.GetMaxRuntime()
+				if remaining, ok := this.migrationContext.GetRuntimeRemaining(); ok {
+					fmt.Fprintf(writer, "%+v; remaining: %s\n", maxRuntime, formatRuntimeRemaining(remaining))
+				} else {
+					fmt.Fprintf(writer, "%+v\n", maxRuntime)
+				}
+				return NoPrintStatusRule, nil
+			}
+			maxRuntime, err := time.ParseDuration(arg)
+			if err != nil {
+				return NoPrintStatusRule, err
+			}
+			if maxRuntime < 0 {
+				return NoPrintStatusRule, fmt.Errorf("max-runtime must be >= 0")
+			}
+			this.migrationContext.SetMaxRuntime(maxRuntime)
+			return ForcePrintStatusAndHintRule, nil
+		}
 	case "replication-lag-query":
 		{
 			return NoPrintStatusRule, fmt.Errorf("replication-lag-query is deprecated. gh-ost uses an internal, subsecond resolution query")
@@ -341,6 +555,17 @@ help                                 # This message
 			}
 			return ForcePrintStatusAndHintRule, nil
 		}
+	case "log-levels":
+		{
+			if argIsQuestion {
+				fmt.Fprintf(writer, "%s\n", this.migrationContext.LogLevels.String())
+				return NoPrintStatusRule, nil
+			}
+			if err := this.migrationContext.SetComponentLogLevels(arg); err != nil {
+				return NoPrintStatusRule, err
+			}
+			return NoPrintStatusRule, nil
+		}
 	case "critical-load":
 		{
 			if argIsQuestion {
@@ -430,6 +655,10 @@ help                                 # This message
 				err := fmt.Errorf("User commanded 'unpostpone' on %s, but migrated table is %s; ignoring request.", arg, this.migrationContext.OriginalTableName)
 				return NoPrintStatusRule, err
 			}
+			if this.migrationContext.IsPastDeadline() {
+				err := fmt.Errorf("User commanded 'unpostpone', but the migration exceeded --max-runtime; extend it via 'max-runtime=<duration>' to allow cut-over")
+				return NoPrintStatusRule, err
+			}
 			if atomic.LoadInt64(&this.migrationContext.IsPostponingCutOver) > 0 {
 				atomic.StoreInt64(&this.migrationContext.UserCommandedUnpostponeFlag, 1)
 				fmt.Fprintf(writer, "Unpostponed\n")
@@ -438,6 +667,31 @@ help                                 # This message
 			fmt.Fprintf(writer, "You may only invoke this when gh-ost is actively postponing migration. At this time it is not.\n")
 			return NoPrintStatusRule, nil
 		}
+	case "promote":
+		{
+			if arg != "" && arg != this.migrationContext.OriginalTableName {
+				// User explicitly provided table name. This is a courtesy protection mechanism
+				err := fmt.Errorf("User commanded 'promote' on %s, but migrated table is %s; ignoring request.", arg, this.migrationContext.OriginalTableName)
+				return NoPrintStatusRule, err
+			}
+			if atomic.LoadInt64(&this.migrationContext.IsAwaitingReplicaPromotionFlag) > 0 {
+				atomic.StoreInt64(&this.migrationContext.UserCommandedPromoteFlag, 1)
+				fmt.Fprintf(writer, "Promoting\n")
+				return NoPrintStatusRule, nil
+			}
+			fmt.Fprintf(writer, "You may only invoke this when gh-ost is awaiting replica promotion (--await-replica-promotion). At this time it is not.\n")
+			return NoPrintStatusRule, nil
+		}
+	case "skip-warm-up":
+		{
+			atomic.StoreInt64(&this.migrationContext.UserCommandedSkipWarmUpFlag, 1)
+			if atomic.LoadInt64(&this.migrationContext.IsWarmingUpFlag) > 0 {
+				fmt.Fprintf(writer, "Warm-up stopped\n")
+				return ForcePrintStatusAndHintRule, nil
+			}
+			fmt.Fprintf(writer, "Warm-up will be skipped\n")
+			return NoPrintStatusRule, nil
+		}
 	case "panic":
 		{
 			if arg == "" && this.migrationContext.ForceNamedPanicCommand {
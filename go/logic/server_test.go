@@ -1,6 +1,11 @@
 package logic
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"testing"
@@ -35,10 +40,7 @@ func TestServerRunCPUProfile(t *testing.T) {
 	})
 
 	t.Run("success", func(t *testing.T) {
-		s := &Server{
-			isCPUProfiling:   0,
-			migrationContext: base.NewMigrationContext(),
-		}
+		s := NewServer(base.NewMigrationContext(), nil, nil, nil, nil)
 		defaultCPUProfileDuration = time.Millisecond * 10
 		profile, err := s.runCPUProfile("")
 		require.NoError(t, err)
@@ -47,10 +49,7 @@ func TestServerRunCPUProfile(t *testing.T) {
 	})
 
 	t.Run("success with block", func(t *testing.T) {
-		s := &Server{
-			isCPUProfiling:   0,
-			migrationContext: base.NewMigrationContext(),
-		}
+		s := NewServer(base.NewMigrationContext(), nil, nil, nil, nil)
 		profile, err := s.runCPUProfile("10ms,block")
 		require.NoError(t, err)
 		require.NotNil(t, profile)
@@ -58,10 +57,7 @@ func TestServerRunCPUProfile(t *testing.T) {
 	})
 
 	t.Run("success with block and gzip", func(t *testing.T) {
-		s := &Server{
-			isCPUProfiling:   0,
-			migrationContext: base.NewMigrationContext(),
-		}
+		s := NewServer(base.NewMigrationContext(), nil, nil, nil, nil)
 		profile, err := s.runCPUProfile("10ms,block,gzip")
 		require.NoError(t, err)
 		require.NotNil(t, profile)
@@ -73,9 +69,7 @@ func TestServerCreatePostponeCutOverFlagFile(t *testing.T) {
 	t.Parallel()
 
 	t.Run("success", func(t *testing.T) {
-		s := &Server{
-			migrationContext: base.NewMigrationContext(),
-		}
+		s := NewServer(base.NewMigrationContext(), nil, nil, nil, nil)
 		dir, err := os.MkdirTemp("", "gh-ost-test-")
 		require.NoError(t, err)
 		defer os.RemoveAll(dir)
@@ -88,9 +82,7 @@ func TestServerCreatePostponeCutOverFlagFile(t *testing.T) {
 	})
 
 	t.Run("file already exists", func(t *testing.T) {
-		s := &Server{
-			migrationContext: base.NewMigrationContext(),
-		}
+		s := NewServer(base.NewMigrationContext(), nil, nil, nil, nil)
 		dir, err := os.MkdirTemp("", "gh-ost-test-")
 		require.NoError(t, err)
 
@@ -103,3 +95,242 @@ func TestServerCreatePostponeCutOverFlagFile(t *testing.T) {
 		require.FileExists(t, filePath)
 	})
 }
+
+func TestServerApplyMaxRuntimeCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	s := &Server{
+		migrationContext: migrationContext,
+		hooksExecutor:    NewHooksExecutor(migrationContext),
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+
+	rule, err := s.applyServerCommand("max-runtime=6h", writer)
+	require.NoError(t, err)
+	require.EqualValues(t, ForcePrintStatusAndHintRule, rule)
+	require.Equal(t, 6*time.Hour, migrationContext.GetMaxRuntime())
+
+	_, err = s.applyServerCommand("max-runtime=?", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "6h0m0s; remaining: 6h0m0s\n", buf.String())
+
+	_, err = s.applyServerCommand("max-runtime=-1h", writer)
+	require.Error(t, err)
+	_, err = s.applyServerCommand("max-runtime=soon", writer)
+	require.Error(t, err)
+	require.Equal(t, 6*time.Hour, migrationContext.GetMaxRuntime())
+
+	migrationContext.StartTime = time.Now().Add(-7 * time.Hour)
+	_, err = s.applyServerCommand("unpostpone", writer)
+	require.ErrorContains(t, err, "exceeded --max-runtime")
+
+	_, err = s.applyServerCommand("max-runtime=0", writer)
+	require.NoError(t, err)
+	require.False(t, migrationContext.IsPastDeadline())
+}
+
+func TestServerApplyDMLBatchSizeCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	s := &Server{
+		migrationContext: migrationContext,
+		hooksExecutor:    NewHooksExecutor(migrationContext),
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+
+	rule, err := s.applyServerCommand("dml-batch-size=50", writer)
+	require.NoError(t, err)
+	require.EqualValues(t, ForcePrintStatusAndHintRule, rule)
+	require.Equal(t, int64(50), migrationContext.DMLBatchSize)
+
+	_, err = s.applyServerCommand("dml-batch-size=0", writer)
+	require.ErrorContains(t, err, "between 1 and 1000")
+	_, err = s.applyServerCommand("dml-batch-size=1001", writer)
+	require.ErrorContains(t, err, "between 1 and 1000")
+	_, err = s.applyServerCommand("dml-batch-size=many", writer)
+	require.Error(t, err)
+	require.Equal(t, int64(50), migrationContext.DMLBatchSize)
+
+	_, err = s.applyServerCommand("dml-batch-size=?", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "50\n", buf.String())
+}
+
+func TestServerApplyApplierParallelismCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	s := &Server{
+		migrationContext: migrationContext,
+		hooksExecutor:    NewHooksExecutor(migrationContext),
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+
+	rule, err := s.applyServerCommand("applier-parallelism=8", writer)
+	require.NoError(t, err)
+	require.EqualValues(t, ForcePrintStatusAndHintRule, rule)
+	require.Equal(t, int64(8), migrationContext.GetApplierParallelism())
+
+	_, err = s.applyServerCommand("applier-parallelism=0", writer)
+	require.ErrorContains(t, err, "between 1 and 64")
+	_, err = s.applyServerCommand("applier-parallelism=65", writer)
+	require.ErrorContains(t, err, "between 1 and 64")
+	_, err = s.applyServerCommand("applier-parallelism=many", writer)
+	require.Error(t, err)
+	require.Equal(t, int64(8), migrationContext.GetApplierParallelism())
+
+	_, err = s.applyServerCommand("applier-parallelism=?", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "8\n", buf.String())
+
+	migrationContext.RestrictApplierParallelism("ghost table has 2 unique keys")
+	_, err = s.applyServerCommand("applier-parallelism=4", writer)
+	require.ErrorContains(t, err, "ghost table has 2 unique keys")
+	require.Equal(t, int64(1), migrationContext.GetApplierParallelism())
+	_, err = s.applyServerCommand("applier-parallelism=1", writer)
+	require.NoError(t, err)
+}
+
+func TestServerApplyLogLevelsCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	s := &Server{
+		migrationContext: migrationContext,
+		hooksExecutor:    NewHooksExecutor(migrationContext),
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+
+	rule, err := s.applyServerCommand("log-levels=applier=debug,throttler=error", writer)
+	require.NoError(t, err)
+	require.EqualValues(t, NoPrintStatusRule, rule)
+
+	_, err = s.applyServerCommand("log-levels=copier=debug", writer)
+	require.ErrorContains(t, err, "Unknown log component")
+
+	_, err = s.applyServerCommand("log-levels=?", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "general=info,applier=debug,throttler=error\n", buf.String())
+}
+
+func TestServerApplyPromoteCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.OriginalTableName = "tbl"
+	s := NewServer(migrationContext, NewHooksExecutor(migrationContext), nil, nil, nil)
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+
+	_, err := s.applyServerCommand("promote", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Contains(t, buf.String(), "awaiting replica promotion")
+	require.Zero(t, migrationContext.UserCommandedPromoteFlag)
+
+	migrationContext.IsAwaitingReplicaPromotionFlag = 1
+	_, err = s.applyServerCommand("promote=other", writer)
+	require.Error(t, err)
+	require.Zero(t, migrationContext.UserCommandedPromoteFlag)
+
+	_, err = s.applyServerCommand("promote=tbl", writer)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), migrationContext.UserCommandedPromoteFlag)
+}
+
+func TestServerApplyStatsCommand(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	s := &Server{
+		migrationContext: migrationContext,
+		hooksExecutor:    NewHooksExecutor(migrationContext),
+	}
+	migrationContext.Stats.AddAppliedDML(5, 3, 1, 2)
+	migrationContext.Stats.MarkChunkCopied(2000, 1500, 20*time.Millisecond)
+	migrationContext.Stats.MarkChunkCopied(1000, 1000, 10*time.Millisecond)
+	migrationContext.Stats.AddThrottledTime("max-load", 1500*time.Millisecond)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	_, err := s.applyServerCommand("stats", writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, `# Applied: inserts: 5; updates: 3 (2 modifying the unique key); deletes: 1
+# Chunks copied: 2; duration avg: 15.0ms, p50: 10.0ms, p95: 20.0ms, p99: 20.0ms
+# Rows copied with chunk-size 1000: 1000
+# Rows copied with chunk-size 2000: 1500
+# Throttled by max-load: 1.5s
+# Retries: 0
+`, buf.String())
+
+	_, err = s.applyServerCommand("stats=1", writer)
+	require.Error(t, err)
+}
+
+func TestServerStatusHTTPHandler(t *testing.T) {
+	status := &MigrationStatus{
+		DatabaseName: "test",
+		TableName:    "gh_ost_test",
+		State:        "migrating",
+		ProgressPct:  42.25,
+	}
+	s := &Server{
+		migrationContext: base.NewMigrationContext(),
+		statusSnapshot:   func() *MigrationStatus { return status },
+	}
+	handler := s.statusHTTPHandler()
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	t.Run("status", func(t *testing.T) {
+		recorder := get("/status")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		var decoded MigrationStatus
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &decoded))
+		require.Equal(t, *status, decoded)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		recorder := get("/progress")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "42.2\n", recorder.Body.String())
+	})
+
+	t.Run("healthz", func(t *testing.T) {
+		recorder := get("/healthz")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "ok\n", recorder.Body.String())
+
+		status.Stalled = true
+		recorder = get("/healthz")
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Equal(t, "stalled\n", recorder.Body.String())
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/status", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	})
+}
+
+func TestServerStatusHTTPShutdown(t *testing.T) {
+	migrationContext := base.NewMigrationContext()
+	migrationContext.StatusListenAddress = "127.0.0.1:0"
+	s := NewServer(migrationContext, nil, nil, func() *MigrationStatus { return &MigrationStatus{} }, nil)
+	require.NoError(t, s.BindStatusHTTP())
+	go s.httpServer.Serve(s.httpListener)
+
+	url := "http://" + s.httpListener.Addr().String() + "/healthz"
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, s.ShutdownStatusHTTP())
+	_, err = http.Get(url)
+	require.Error(t, err)
+}
@@ -39,24 +39,31 @@ type EventsStreamer struct {
 	db                       *gosql.DB
 	dbVersion                string
 	migrationContext         *base.MigrationContext
+	log                      base.Logger
 	initialBinlogCoordinates mysql.BinlogCoordinates
 	listeners                [](*BinlogEventListener)
 	listenersMutex           *sync.Mutex
 	eventsChannel            chan *binlog.BinlogEntry
-	binlogReader             *binlog.GoMySQLReader
+	binlogReader             binlog.BinlogReader
+	newBinlogReader          func() binlog.BinlogReader
 	name                     string
 }
 
 func NewEventsStreamer(migrationContext *base.MigrationContext) *EventsStreamer {
-	return &EventsStreamer{
-		connectionConfig:         migrationContext.InspectorConnectionConfig,
+	streamer := &EventsStreamer{
+		connectionConfig:         migrationContext.GetBinlogConnectionConfig(),
 		migrationContext:         migrationContext,
+		log:                      migrationContext.NewComponentLogger(base.StreamerLogComponent),
 		listeners:                [](*BinlogEventListener){},
 		listenersMutex:           &sync.Mutex{},
 		eventsChannel:            make(chan *binlog.BinlogEntry, EventsChannelBufferSize),
 		name:                     "streamer",
 		initialBinlogCoordinates: migrationContext.InitialStreamerCoords,
 	}
+	streamer.newBinlogReader = func() binlog.BinlogReader {
+		return binlog.NewGoMySQLReader(migrationContext, streamer.hasListener)
+	}
+	return streamer
 }
 
 // AddListener registers a new listener for binlog events, on a per-table basis
@@ -81,6 +88,20 @@ func (this *EventsStreamer) AddListener(
 	return nil
 }
 
+// hasListener tells whether any listener is registered for changes on given table. The binlog reader
+// does not decode row events of other tables.
+func (this *EventsStreamer) hasListener(databaseName string, tableName string) bool {
+	this.listenersMutex.Lock()
+	defer this.listenersMutex.Unlock()
+
+	for _, listener := range this.listeners {
+		if strings.EqualFold(listener.databaseName, databaseName) && strings.EqualFold(listener.tableName, tableName) {
+			return true
+		}
+	}
+	return false
+}
+
 // notifyListeners will notify relevant listeners with given DML event. Only
 // listeners registered for changes on the table on which the DML operates are notified.
 func (this *EventsStreamer) notifyListeners(binlogEntry *binlog.BinlogEntry) {
@@ -112,6 +133,9 @@ func (this *EventsStreamer) InitDBConnections() (err error) {
 	}
 	version, err := base.ValidateConnection(this.db, this.connectionConfig, this.migrationContext, this.name)
 	if err != nil {
+		if this.migrationContext.ProxyCompat {
+			return fmt.Errorf("Streaming binary logs requires a direct connection to the backend, but cannot connect to --binlog-host %s: %w", this.connectionConfig.Key.String(), err)
+		}
 		return err
 	}
 	this.dbVersion = version
@@ -129,11 +153,11 @@ func (this *EventsStreamer) InitDBConnections() (err error) {
 
 // initBinlogReader creates and connects the reader: we hook up to a MySQL server as a replica
 func (this *EventsStreamer) initBinlogReader(binlogCoordinates mysql.BinlogCoordinates) error {
-	goMySQLReader := binlog.NewGoMySQLReader(this.migrationContext)
-	if err := goMySQLReader.ConnectBinlogStreamer(binlogCoordinates); err != nil {
+	binlogReader := this.newBinlogReader()
+	if err := binlogReader.ConnectBinlogStreamer(binlogCoordinates); err != nil {
 		return err
 	}
-	this.binlogReader = goMySQLReader
+	this.binlogReader = binlogReader
 	return nil
 }
 
@@ -169,7 +193,7 @@ func (this *EventsStreamer) readCurrentBinlogCoordinates() error {
 	if !foundMasterStatus {
 		return fmt.Errorf("Got no results from SHOW %s. Bailing out", strings.ToUpper(binaryLogStatusTerm))
 	}
-	this.migrationContext.Log.Debugf("Streamer binlog coordinates: %+v", this.initialBinlogCoordinates)
+	this.log.Debugf("Streamer binlog coordinates: %+v", this.initialBinlogCoordinates)
 	return nil
 }
 
@@ -198,7 +222,7 @@ func (this *EventsStreamer) StreamEvents(canStopStreaming func() bool) error {
 				return nil
 			}
 
-			this.migrationContext.Log.Infof("StreamEvents encountered unexpected error: %+v", err)
+			this.log.Infof("StreamEvents encountered unexpected error: %+v", err)
 			this.migrationContext.MarkPointOfInterest()
 			time.Sleep(ReconnectStreamerSleepSeconds * time.Second)
 
@@ -208,8 +232,8 @@ func (this *EventsStreamer) StreamEvents(canStopStreaming func() bool) error {
 			}
 
 			// Reposition at same coordinates
-			if this.binlogReader.LastTrxCoords != nil {
-				reconnectCoords = this.binlogReader.LastTrxCoords.Clone()
+			if lastTrxCoords := this.binlogReader.GetLastTrxCoordinates(); lastTrxCoords != nil {
+				reconnectCoords = lastTrxCoords.Clone()
 			} else {
 				reconnectCoords = this.initialBinlogCoordinates.Clone()
 			}
@@ -219,7 +243,7 @@ func (this *EventsStreamer) StreamEvents(canStopStreaming func() bool) error {
 				successiveFailures = 0
 			}
 
-			this.migrationContext.Log.Infof("Reconnecting EventsStreamer... Will resume at %+v", reconnectCoords)
+			this.log.Infof("Reconnecting EventsStreamer... Will resume at %+v", reconnectCoords)
 			_ = this.binlogReader.Close()
 			if err := this.initBinlogReader(reconnectCoords); err != nil {
 				return err
@@ -230,7 +254,7 @@ func (this *EventsStreamer) StreamEvents(canStopStreaming func() bool) error {
 
 func (this *EventsStreamer) Close() (err error) {
 	err = this.binlogReader.Close()
-	this.migrationContext.Log.Infof("Closed streamer connection. err=%+v", err)
+	this.log.Infof("Closed streamer connection. err=%+v", err)
 	return err
 }
 
@@ -8,7 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/github/gh-ost/go/base"
 	"github.com/github/gh-ost/go/binlog"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mysql"
@@ -261,6 +263,20 @@ func (suite *EventsStreamerTestSuite) TestStreamEventsAutomaticallyReconnects()
 	suite.Require().Len(dmlEvents, 3)
 }
 
+func TestEventsStreamerHasListener(t *testing.T) {
+	streamer := NewEventsStreamer(base.NewMigrationContext())
+	require.False(t, streamer.hasListener("test", "testing"))
+
+	onDmlEvent := func(*binlog.BinlogEntry) error { return nil }
+	require.NoError(t, streamer.AddListener(false, "test", "testing", onDmlEvent))
+	require.NoError(t, streamer.AddListener(false, "test", "_testing_ghc", onDmlEvent))
+	require.True(t, streamer.hasListener("test", "testing"))
+	require.True(t, streamer.hasListener("TEST", "Testing"))
+	require.True(t, streamer.hasListener("test", "_testing_ghc"))
+	require.False(t, streamer.hasListener("test", "_testing_gho"))
+	require.False(t, streamer.hasListener("other", "testing"))
+}
+
 func TestEventsStreamer(t *testing.T) {
 	suite.Run(t, new(EventsStreamerTestSuite))
 }
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -45,6 +46,7 @@ const frenoMagicHint = "freno"
 type Throttler struct {
 	appVersion        string
 	migrationContext  *base.MigrationContext
+	log               base.Logger
 	applier           *Applier
 	httpClient        *http.Client
 	httpClientTimeout time.Duration
@@ -56,6 +58,7 @@ func NewThrottler(migrationContext *base.MigrationContext, applier *Applier, ins
 	return &Throttler{
 		appVersion:        appVersion,
 		migrationContext:  migrationContext,
+		log:               migrationContext.NewComponentLogger(base.ThrottlerLogComponent),
 		applier:           applier,
 		httpClient:        &http.Client{},
 		httpClientTimeout: time.Duration(migrationContext.ThrottleHTTPTimeoutMillis) * time.Millisecond,
@@ -75,6 +78,29 @@ func (this *Throttler) throttleHttpMessage(statusCode int) string {
 	return fmt.Sprintf("http=%d", statusCode)
 }
 
+// throttleReasonKind returns the kind of a throttle reason, without the measurements it mentions, e.g. "lag"
+// for "lag=2.5s"
+func throttleReasonKind(reason string) string {
+	switch {
+	case strings.HasPrefix(reason, "critical-load-hibernate"), reason == "leaving hibernation":
+		return "critical-load-hibernate"
+	case strings.HasPrefix(reason, "lag="):
+		return "lag"
+	case strings.Contains(reason, " replica-lag="):
+		return "control-replica-lag"
+	case strings.HasPrefix(reason, "max-load "):
+		return "max-load"
+	case strings.HasPrefix(reason, "http="), strings.Contains(reason, "(http="):
+		return "http"
+	case reason == "commanded by user":
+		return "user-command"
+	case reason == "flag-file", reason == "throttle-query":
+		return reason
+	}
+	// Errors collecting metrics
+	return "error"
+}
+
 // shouldThrottle performs checks to see whether we should currently be throttling.
 // It merely observes the metrics collected by other components, it does not issue
 // its own metric collection.
@@ -116,20 +142,40 @@ func (this *Throttler) shouldThrottle() (result bool, reason string, reasonHint
 	return false, "", base.NoThrottleReasonHint
 }
 
-// parseChangelogHeartbeat parses a string timestamp and deduces replication lag
-func parseChangelogHeartbeat(heartbeatValue string) (lag time.Duration, err error) {
-	heartbeatTime, err := time.Parse(time.RFC3339Nano, heartbeatValue)
+// formatChangelogHeartbeat returns a changelog heartbeat value: the time the heartbeat is written at,
+// followed by its sequence number
+func formatChangelogHeartbeat(heartbeatTime time.Time, sequence int64) string {
+	return fmt.Sprintf("%s;%d", heartbeatTime.Format(time.RFC3339Nano), sequence)
+}
+
+// parseChangelogHeartbeat parses a changelog heartbeat value. Values written by earlier versions have
+// no sequence number, reported as 0.
+func parseChangelogHeartbeat(heartbeatValue string) (heartbeatTime time.Time, sequence int64, err error) {
+	timeToken, sequenceToken, hasSequence := strings.Cut(heartbeatValue, ";")
+	if heartbeatTime, err = time.Parse(time.RFC3339Nano, timeToken); err != nil {
+		return heartbeatTime, sequence, err
+	}
+	if hasSequence {
+		if sequence, err = strconv.ParseInt(sequenceToken, 10, 64); err != nil {
+			return heartbeatTime, sequence, fmt.Errorf("Invalid heartbeat sequence in %s: %w", heartbeatValue, err)
+		}
+	}
+	return heartbeatTime, sequence, nil
+}
+
+// heartbeatLag deduces replication lag from the latest changelog heartbeat value read on a server
+func (this *Throttler) heartbeatLag(heartbeatValue string) (lag time.Duration, err error) {
+	heartbeatTime, sequence, err := parseChangelogHeartbeat(heartbeatValue)
 	if err != nil {
 		return lag, err
 	}
-	lag = time.Since(heartbeatTime)
-	return lag, nil
+	return this.migrationContext.GetHeartbeatLag(sequence, heartbeatTime), nil
 }
 
-// parseChangelogHeartbeat parses a string timestamp and deduces replication lag
+// parseChangelogHeartbeat parses a heartbeat value and deduces replication lag
 func (this *Throttler) parseChangelogHeartbeat(heartbeatValue string) (err error) {
-	if lag, err := parseChangelogHeartbeat(heartbeatValue); err != nil {
-		return this.migrationContext.Log.Errore(err)
+	if lag, err := this.heartbeatLag(heartbeatValue); err != nil {
+		return this.log.Errore(err)
 	} else {
 		atomic.StoreInt64(&this.migrationContext.CurrentLag, int64(lag))
 		return nil
@@ -146,18 +192,19 @@ func (this *Throttler) collectReplicationLag(firstThrottlingCollected chan<- boo
 			return nil
 		}
 
-		if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica {
+		if this.migrationContext.TestOnReplica || this.migrationContext.MigrateOnReplica || this.migrationContext.Observe {
 			// when running on replica, the heartbeat injection is also done on the replica.
 			// This means we will always get a good heartbeat value.
 			// When running on replica, we should instead check the `SHOW SLAVE STATUS` output.
+			// With --observe, there is no changelog table to heartbeat on.
 			if lag, err := mysql.GetReplicationLagFromSlaveStatus(this.inspector.dbVersion, this.inspector.informationSchemaDb); err != nil {
-				return this.migrationContext.Log.Errore(err)
+				return this.log.Errore(err)
 			} else {
 				atomic.StoreInt64(&this.migrationContext.CurrentLag, int64(lag))
 			}
 		} else {
 			if heartbeatValue, err := this.inspector.readChangelogState("heartbeat"); err != nil {
-				return this.migrationContext.Log.Errore(err)
+				return this.log.Errore(err)
 			} else {
 				this.parseChangelogHeartbeat(heartbeatValue)
 			}
@@ -187,7 +234,7 @@ func (this *Throttler) collectControlReplicasLag() {
 	replicationLagQuery := fmt.Sprintf(`
 		select value from %s.%s where hint = 'heartbeat' and id <= 255
 		`,
-		sql.EscapeName(this.migrationContext.DatabaseName),
+		sql.EscapeName(this.migrationContext.GetChangelogDatabaseName()),
 		sql.EscapeName(this.migrationContext.GetChangelogTableName()),
 	)
 
@@ -199,12 +246,16 @@ func (this *Throttler) collectControlReplicasLag() {
 		if err != nil {
 			return lag, err
 		}
+		if this.migrationContext.Observe {
+			// No changelog table, and so no heartbeat, with --observe
+			return mysql.GetReplicationLagFromSlaveStatus(this.inspector.dbVersion, db)
+		}
 
 		if err := db.QueryRow(replicationLagQuery).Scan(&heartbeatValue); err != nil {
 			return lag, err
 		}
 
-		lag, err = parseChangelogHeartbeat(heartbeatValue)
+		lag, err = this.heartbeatLag(heartbeatValue)
 		return lag, err
 	}
 
@@ -375,7 +426,7 @@ func (this *Throttler) collectGeneralThrottleMetrics() error {
 		hibernateDuration := time.Duration(this.migrationContext.CriticalLoadHibernateSeconds) * time.Second
 		hibernateUntilTime := time.Now().Add(hibernateDuration)
 		atomic.StoreInt64(&this.migrationContext.HibernateUntil, hibernateUntilTime.UnixNano())
-		this.migrationContext.Log.Errorf("critical-load met: %s=%d, >=%d. Will hibernate for the duration of %+v, until %+v", variableName, value, threshold, hibernateDuration, hibernateUntilTime)
+		this.log.Errorf("critical-load met: %s=%d, >=%d. Will hibernate for the duration of %+v, until %+v", variableName, value, threshold, hibernateDuration, hibernateUntilTime)
 		go func() {
 			time.Sleep(hibernateDuration)
 			this.migrationContext.SetThrottleGeneralCheckResult(base.NewThrottleCheckResult(true, "leaving hibernation", base.LeavingHibernationThrottleReasonHint))
@@ -388,7 +439,7 @@ func (this *Throttler) collectGeneralThrottleMetrics() error {
 		this.migrationContext.PanicAbort <- fmt.Errorf("critical-load met: %s=%d, >=%d", variableName, value, threshold)
 	}
 	if criticalLoadMet && this.migrationContext.CriticalLoadIntervalMilliseconds > 0 {
-		this.migrationContext.Log.Errorf("critical-load met once: %s=%d, >=%d. Will check again in %d millis", variableName, value, threshold, this.migrationContext.CriticalLoadIntervalMilliseconds)
+		this.log.Errorf("critical-load met once: %s=%d, >=%d. Will check again in %d millis", variableName, value, threshold, this.migrationContext.CriticalLoadIntervalMilliseconds)
 		go func() {
 			timer := time.NewTimer(time.Millisecond * time.Duration(this.migrationContext.CriticalLoadIntervalMilliseconds))
 			<-timer.C
@@ -462,8 +513,14 @@ func (this *Throttler) initiateThrottlerCollection(firstThrottlingCollected chan
 
 // initiateThrottlerChecks initiates the throttle ticker and sets the basic behavior of throttling.
 func (this *Throttler) initiateThrottlerChecks() {
+	lastCheckTime := time.Now()
 	throttlerFunction := func() {
 		alreadyThrottling, currentReason, _ := this.migrationContext.IsThrottled()
+		now := time.Now()
+		if alreadyThrottling {
+			this.migrationContext.Stats.AddThrottledTime(throttleReasonKind(currentReason), now.Sub(lastCheckTime))
+		}
+		lastCheckTime = now
 		shouldThrottle, throttleReason, throttleReasonHint := this.shouldThrottle()
 		if shouldThrottle && !alreadyThrottling {
 			// New throttling
@@ -506,6 +563,6 @@ func (this *Throttler) throttle(onThrottled func()) {
 }
 
 func (this *Throttler) Teardown() {
-	this.migrationContext.Log.Debugf("Tearing down...")
+	this.log.Debugf("Tearing down...")
 	atomic.StoreInt64(&this.finishedMigrating, 1)
 }
@@ -0,0 +1,52 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChangelogHeartbeat(t *testing.T) {
+	heartbeatTime := time.Date(2025, 3, 4, 5, 6, 7, 123456789, time.UTC)
+
+	parsedTime, sequence, err := parseChangelogHeartbeat(formatChangelogHeartbeat(heartbeatTime, 42))
+	require.NoError(t, err)
+	require.True(t, heartbeatTime.Equal(parsedTime))
+	require.Equal(t, int64(42), sequence)
+
+	// written by earlier versions
+	parsedTime, sequence, err = parseChangelogHeartbeat(heartbeatTime.Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	require.True(t, heartbeatTime.Equal(parsedTime))
+	require.Equal(t, int64(0), sequence)
+
+	_, _, err = parseChangelogHeartbeat("2025-03-04T05:06:07Z;x")
+	require.Error(t, err)
+	_, _, err = parseChangelogHeartbeat("yesterday")
+	require.Error(t, err)
+}
+
+func TestThrottleReasonKind(t *testing.T) {
+	reasonKinds := map[string]string{
+		"lag=2.500000s": "lag",
+		"replica.example.com:3306 replica-lag=3.000000s":    "control-replica-lag",
+		"max-load Threads_running=120 >= 100":               "max-load",
+		"http=429":                                          "http",
+		"Too many requests (http=429)":                      "http",
+		"commanded by user":                                 "user-command",
+		"flag-file":                                         "flag-file",
+		"throttle-query":                                    "throttle-query",
+		"critical-load-hibernate until 2025-03-04 05:06:07": "critical-load-hibernate",
+		"leaving hibernation":                               "critical-load-hibernate",
+		"Threads_running connection refused":                "error",
+	}
+	for reason, kind := range reasonKinds {
+		require.Equal(t, kind, throttleReasonKind(reason), reason)
+	}
+}
@@ -76,6 +76,15 @@ func TestBinlogCoordinates(t *testing.T) {
 	require.True(t, c9.SmallerThanOrEquals(&c10))
 }
 
+func TestGTIDBinlogCoordinatesContainsServerUUID(t *testing.T) {
+	coords, err := NewGTIDBinlogCoordinates("3E11FA47-71CA-11E1-9E33-C80AA9429562:1-23,7f80fa47-ff33-71a1-ae01-b80cc7823548:100")
+	require.NoError(t, err)
+	require.True(t, coords.ContainsServerUUID("3e11fa47-71ca-11e1-9e33-c80aa9429562"))
+	require.True(t, coords.ContainsServerUUID("7F80FA47-FF33-71A1-AE01-B80CC7823548"))
+	require.False(t, coords.ContainsServerUUID("08dc06d7-c27c-11ea-b204-e4434b77a5ce"))
+	require.False(t, (&GTIDBinlogCoordinates{}).ContainsServerUUID("3e11fa47-71ca-11e1-9e33-c80aa9429562"))
+}
+
 func TestBinlogCoordinatesAsKey(t *testing.T) {
 	m := make(map[BinlogCoordinates]bool)
 
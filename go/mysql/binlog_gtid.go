@@ -6,6 +6,8 @@
 package mysql
 
 import (
+	"strings"
+
 	gomysql "github.com/go-mysql-org/go-mysql/mysql"
 )
 
@@ -50,6 +52,15 @@ func (this *GTIDBinlogCoordinates) Equals(other BinlogCoordinates) bool {
 	return this.GTIDSet.Equal(otherCoords.GTIDSet)
 }
 
+// ContainsServerUUID returns true if the GTID set includes transactions originating on the server with given server_uuid.
+func (this *GTIDBinlogCoordinates) ContainsServerUUID(serverUUID string) bool {
+	if this.IsEmpty() {
+		return false
+	}
+	_, found := this.GTIDSet.Sets[strings.ToLower(serverUUID)]
+	return found
+}
+
 // IsEmpty returns true if the GTID set is empty.
 func (this *GTIDBinlogCoordinates) IsEmpty() bool {
 	return this.GTIDSet == nil
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strings"
 
@@ -31,6 +32,8 @@ type ConnectionConfig struct {
 	Timeout              float64
 	TransactionIsolation string
 	Charset              string
+	// TimeZone is the session time_zone, if set; otherwise sessions use the server's default
+	TimeZone string
 }
 
 func NewConnectionConfig() *ConnectionConfig {
@@ -51,6 +54,7 @@ func (this *ConnectionConfig) DuplicateCredentials(key InstanceKey) *ConnectionC
 		Timeout:              this.Timeout,
 		TransactionIsolation: this.TransactionIsolation,
 		Charset:              this.Charset,
+		TimeZone:             this.TimeZone,
 	}
 
 	if this.tlsConfig != nil {
@@ -162,6 +166,10 @@ func (this *ConnectionConfig) GetDBUri(databaseName string) string {
 		fmt.Sprintf("readTimeout=%fs", this.Timeout),
 		fmt.Sprintf("writeTimeout=%fs", this.Timeout),
 	}
+	if this.TimeZone != "" {
+		// e.g. a '+' offset must be escaped
+		connectionParams = append(connectionParams, fmt.Sprintf("time_zone=%s", url.QueryEscape(fmt.Sprintf("%q", this.TimeZone))))
+	}
 
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", this.User, this.Password, hostname, this.Key.Port, databaseName, strings.Join(connectionParams, "&"))
 }
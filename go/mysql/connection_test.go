@@ -9,6 +9,7 @@ import (
 	"crypto/tls"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/openark/golib/log"
 	"github.com/stretchr/testify/require"
 )
@@ -44,6 +45,7 @@ func TestDuplicateCredentials(t *testing.T) {
 	}
 	c.TransactionIsolation = transactionIsolation
 	c.Charset = "utf8mb4"
+	c.TimeZone = "+00:00"
 
 	dup := c.DuplicateCredentials(InstanceKey{Hostname: "otherhost", Port: 3310})
 	require.Equal(t, "otherhost", dup.Key.Hostname)
@@ -58,6 +60,7 @@ func TestDuplicateCredentials(t *testing.T) {
 	require.Equal(t, c.tlsConfig.InsecureSkipVerify, dup.tlsConfig.InsecureSkipVerify)
 	require.Equal(t, c.TransactionIsolation, dup.TransactionIsolation)
 	require.Equal(t, c.Charset, dup.Charset)
+	require.Equal(t, c.TimeZone, dup.TimeZone)
 }
 
 func TestDuplicate(t *testing.T) {
@@ -93,6 +96,23 @@ func TestGetDBUri(t *testing.T) {
 	require.Equal(t, `gromit:penguin@tcp(myhost:3306)/test?autocommit=true&interpolateParams=true&charset=utf8mb4,utf8,latin1&tls=false&transaction_isolation="REPEATABLE-READ"&timeout=1.234500s&readTimeout=1.234500s&writeTimeout=1.234500s`, uri)
 }
 
+func TestGetDBUriWithTimeZone(t *testing.T) {
+	c := NewConnectionConfig()
+	c.Key = InstanceKey{Hostname: "myhost", Port: 3306}
+	c.User = "gromit"
+	c.Password = "penguin"
+	c.Timeout = 1.2345
+	c.TransactionIsolation = transactionIsolation
+	c.Charset = "utf8mb4,utf8,latin1"
+	c.TimeZone = "+05:30"
+
+	uri := c.GetDBUri("test")
+	require.Equal(t, `gromit:penguin@tcp(myhost:3306)/test?autocommit=true&interpolateParams=true&charset=utf8mb4,utf8,latin1&tls=false&transaction_isolation="REPEATABLE-READ"&timeout=1.234500s&readTimeout=1.234500s&writeTimeout=1.234500s&time_zone=%22%2B05%3A30%22`, uri)
+	dsn, err := mysql.ParseDSN(uri)
+	require.NoError(t, err)
+	require.Equal(t, `"+05:30"`, dsn.Params["time_zone"])
+}
+
 func TestGetDBUriWithTLSSetup(t *testing.T) {
 	c := NewConnectionConfig()
 	c.Key = InstanceKey{Hostname: "myhost", Port: 3306}
@@ -7,6 +7,7 @@ package mysql
 
 import (
 	gosql "database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/github/gh-ost/go/sql"
 
+	drivermysql "github.com/go-sql-driver/mysql"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
 )
@@ -23,6 +25,25 @@ const (
 	MaxDBPoolConnections = 3
 )
 
+const (
+	// ER_NO_SUCH_TABLE: Table doesn't exist
+	noSuchTableErrorNumber = 1146
+	// ER_QUERY_TIMEOUT: Query execution was interrupted, maximum statement execution time exceeded
+	queryTimeoutErrorNumber = 3024
+	// ER_LOCK_WAIT_TIMEOUT: Lock wait timeout exceeded
+	lockWaitTimeoutErrorNumber = 1205
+	// ER_ALTER_OPERATION_NOT_SUPPORTED: ALGORITHM/LOCK is not supported
+	alterOperationNotSupportedErrorNumber = 1845
+	// ER_ALTER_OPERATION_NOT_SUPPORTED_REASON: ALGORITHM/LOCK is not supported, with a reason
+	alterOperationNotSupportedReasonErrorNumber = 1846
+	// ER_UNKNOWN_STMT_HANDLER: Unknown prepared statement handler
+	unknownStatementHandlerErrorNumber = 1243
+	// ER_NEED_REPREPARE: Prepared statement needs to be re-prepared
+	needReprepareErrorNumber = 1615
+	// ER_WARN_DEPRECATED_SYNTAX: syntax is deprecated and will be removed in a future release
+	deprecatedSyntaxWarningNumber = 1287
+)
+
 type ReplicationLagResult struct {
 	Key InstanceKey
 	Lag time.Duration
@@ -240,17 +261,58 @@ func GetTableColumns(db *gosql.DB, databaseName, tableName string) (*sql.ColumnL
 	return sql.NewColumnList(columnNames), sql.NewColumnList(virtualColumnNames), nil
 }
 
+// IsQueryTimeoutError checks whether given error is that of a query interrupted due to MAX_EXECUTION_TIME
+func IsQueryTimeoutError(err error) bool {
+	var mysqlErr *drivermysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == queryTimeoutErrorNumber
+}
+
+// IsAlterRefusedError checks whether given error is that of an ALTER the server refused to run with the requested
+// ALGORITHM/LOCK, or could not start for waiting on a lock. Either way the ALTER did not change the table.
+func IsAlterRefusedError(err error) bool {
+	var mysqlErr *drivermysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case alterOperationNotSupportedErrorNumber, alterOperationNotSupportedReasonErrorNumber, lockWaitTimeoutErrorNumber:
+		return true
+	}
+	return false
+}
+
+// IsNoSuchTableError checks whether given error is that of a query on a table that does not exist
+func IsNoSuchTableError(err error) bool {
+	var mysqlErr *drivermysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == noSuchTableErrorNumber
+}
+
+// IsStaleStatementError checks whether given error is that of executing a prepared statement the server
+// no longer holds, or can no longer run as prepared. The statement's query is unaffected, and can run as text.
+func IsStaleStatementError(err error) bool {
+	var mysqlErr *drivermysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == unknownStatementHandlerErrorNumber || mysqlErr.Number == needReprepareErrorNumber
+}
+
+// IsDeprecatedSyntaxWarning checks whether given `show warnings` code is that of deprecated syntax
+func IsDeprecatedSyntaxWarning(code int) bool {
+	return code == deprecatedSyntaxWarningNumber
+}
+
 // Kill executes a KILL QUERY by connection id
 func Kill(db *gosql.DB, connectionID string) error {
-	_, err := db.Exec(`KILL QUERY %s`, connectionID)
+	_, err := db.Exec(fmt.Sprintf(`KILL QUERY %s`, connectionID))
 	return err
 }
 
 // GetTriggers reads trigger list from given table
 func GetTriggers(db *gosql.DB, databaseName, tableName string) (triggers []Trigger, err error) {
-	query := fmt.Sprintf(`select trigger_name as name, event_manipulation as event, action_statement as statement, action_timing as timing
-	from information_schema.triggers 
-	where trigger_schema = '%s' and event_object_table = '%s'`, databaseName, tableName)
+	query := `select trigger_name as name, event_manipulation as event, action_statement as statement, action_timing as timing
+	from information_schema.triggers
+	where trigger_schema = ? and event_object_table = ?`
 
 	err = sqlutils.QueryRowsMap(db, query, func(rowMap sqlutils.RowMap) error {
 		triggers = append(triggers, Trigger{
@@ -260,7 +322,7 @@ func GetTriggers(db *gosql.DB, databaseName, tableName string) (triggers []Trigg
 			Timing:    rowMap.GetString("timing"),
 		})
 		return nil
-	})
+	}, databaseName, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,18 @@ import (
 
 type ValueComparisonSign string
 
+// CopyConflictStrategy is how row copy treats a copied row conflicting with a row already on the ghost table
+type CopyConflictStrategy string
+
+const (
+	// CopyConflictIgnore keeps the ghost table's row (insert ignore)
+	CopyConflictIgnore CopyConflictStrategy = "ignore"
+	// CopyConflictReplace deletes the conflicting ghost table rows, and inserts the copied row (replace)
+	CopyConflictReplace CopyConflictStrategy = "replace"
+	// CopyConflictUpsert updates the conflicting ghost table row with the copied row (insert ... on duplicate key update)
+	CopyConflictUpsert CopyConflictStrategy = "upsert"
+)
+
 const (
 	LessThanComparisonSign            ValueComparisonSign = "<"
 	LessThanOrEqualsComparisonSign    ValueComparisonSign = "<="
@@ -20,17 +32,44 @@ const (
 	GreaterThanOrEqualsComparisonSign ValueComparisonSign = ">="
 	GreaterThanComparisonSign         ValueComparisonSign = ">"
 	NotEqualsComparisonSign           ValueComparisonSign = "!="
+	NullSafeEqualsComparisonSign      ValueComparisonSign = "<=>"
 	MaxColumnNameLength                                   = 64
 )
 
-// EscapeName will escape a db/table/column/... name by wrapping with backticks.
-// It is not fool proof. I'm just trying to do the right thing here, not solving
-// SQL injection issues, which should be irrelevant for this tool.
-func EscapeName(name string) string {
+// QuoteIdentifier quotes a db/table/column/... name with backticks, doubling any embedded backtick.
+// This is the single place where gh-ost quotes identifiers, and any name is safe to quote:
+// reserved words such as `order`, names with backticks, dollar signs, leading digits, etc.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// UnquoteIdentifier returns the name a user quoted with backticks (or double quotes), as in an ALTER statement.
+// A name that is not quoted is returned as is.
+func UnquoteIdentifier(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, "`") && strings.HasSuffix(name, "`") {
+		return strings.ReplaceAll(name[1:len(name)-1], "``", "`")
+	}
 	if unquoted, err := strconv.Unquote(name); err == nil {
-		name = unquoted
+		return unquoted
 	}
-	return fmt.Sprintf("`%s`", name)
+	return name
+}
+
+// EscapeName quotes a db/table/column/... name with QuoteIdentifier, first unquoting it if it is already quoted.
+func EscapeName(name string) string {
+	return QuoteIdentifier(UnquoteIdentifier(name))
+}
+
+// sanitizeComment makes a value safe to interpolate within a /* ... */ comment, which a `*/` in,
+// say, a table name would otherwise terminate
+func sanitizeComment(value string) string {
+	return strings.ReplaceAll(value, "*/", "* /")
+}
+
+// QuoteLiteral quotes a string as a single quoted SQL literal, escaping quotes and backslashes
+func QuoteLiteral(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
 
 // TruncateColumnName truncates a name so it can be used as a MySQL
@@ -74,6 +113,30 @@ func buildPreparedValues(length int) []string {
 	return values
 }
 
+// buildTransformedValues returns the ghost table columns to write and their values, given the mapped
+// shared columns and their values. A transformed column's value is replaced by its expression;
+// transformed columns which are not shared are appended.
+func buildTransformedValues(mappedSharedColumns, values []string, columnTransforms []*ColumnTransform) ([]string, []string) {
+	columns := duplicateNames(mappedSharedColumns)
+	values = duplicateNames(values)
+	for _, transform := range columnTransforms {
+		expression := fmt.Sprintf("(%s)", transform.Expression)
+		isSharedColumn := false
+		for i, column := range columns {
+			if strings.EqualFold(column, transform.Column) {
+				values[i] = expression
+				isSharedColumn = true
+				break
+			}
+		}
+		if !isSharedColumn {
+			columns = append(columns, transform.Column)
+			values = append(values, expression)
+		}
+	}
+	return columns, values
+}
+
 func duplicateNames(names []string) []string {
 	duplicate := make([]string, len(names))
 	copy(duplicate, names)
@@ -92,6 +155,12 @@ func BuildValueComparison(column string, value string, comparisonSign ValueCompa
 }
 
 func BuildEqualsComparison(columns []string, values []string) (result string, err error) {
+	return buildEqualsComparison(columns, values, nil)
+}
+
+// buildEqualsComparison compares columns with the null-safe `<=>` operator where nullSafe is set,
+// so that NULL values in a (nullable) unique key still match their row.
+func buildEqualsComparison(columns []string, values []string, nullSafe []bool) (result string, err error) {
 	if len(columns) == 0 {
 		return "", fmt.Errorf("Got 0 columns in GetEqualsComparison")
 	}
@@ -101,7 +170,11 @@ func BuildEqualsComparison(columns []string, values []string) (result string, er
 	comparisons := []string{}
 	for i, column := range columns {
 		value := values[i]
-		comparison, err := BuildValueComparison(column, value, EqualsComparisonSign)
+		comparisonSign := EqualsComparisonSign
+		if i < len(nullSafe) && nullSafe[i] {
+			comparisonSign = NullSafeEqualsComparisonSign
+		}
+		comparison, err := BuildValueComparison(column, value, comparisonSign)
 		if err != nil {
 			return "", err
 		}
@@ -117,6 +190,56 @@ func BuildEqualsPreparedComparison(columns []string) (result string, err error)
 	return BuildEqualsComparison(columns, values)
 }
 
+// buildNullSafeEqualsPreparedComparison is like BuildEqualsPreparedComparison, using `<=>` for nullable columns,
+// and casting values of columns whose type the ALTER changes to their ghost table type
+func buildNullSafeEqualsPreparedComparison(columns *ColumnList) (result string, err error) {
+	values := buildPreparedValues(columns.Len())
+	for i, column := range columns.Columns() {
+		if column.ghostCast != "" {
+			values[i] = column.ghostCast
+		}
+	}
+	return buildEqualsComparison(columns.Names(), values, columns.nullableFlags())
+}
+
+// BuildGhostCastPreparedValue returns the prepared value by which a unique key column's values are compared
+// on the ghost table, given the column as typed on the ghost table: the value cast to the column's type,
+// so that the comparison uses the ghost table's index. Returns an empty string for types not cast to.
+func BuildGhostCastPreparedValue(ghostColumn *Column) string {
+	mysqlType := strings.ToLower(ghostColumn.MySQLType)
+	typeName, typeArgs := mysqlType, ""
+	if i := strings.IndexAny(mysqlType, "( "); i >= 0 {
+		typeName = mysqlType[:i]
+	}
+	if i, j := strings.Index(mysqlType, "("), strings.Index(mysqlType, ")"); i >= 0 && j > i {
+		typeArgs = mysqlType[i : j+1]
+	}
+	switch typeName {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		if strings.Contains(mysqlType, "unsigned") {
+			return "cast(? as unsigned)"
+		}
+		return "cast(? as signed)"
+	case "decimal", "numeric":
+		return fmt.Sprintf("cast(? as decimal%s)", typeArgs)
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		if ghostColumn.Charset == "" || ghostColumn.Collation == "" {
+			return "cast(? as char)"
+		}
+		return fmt.Sprintf("cast(? as char character set %s) collate %s", ghostColumn.Charset, ghostColumn.Collation)
+	case "binary":
+		// pads the value to the column's length, as stored
+		return fmt.Sprintf("cast(? as binary%s)", typeArgs)
+	case "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "cast(? as binary)"
+	case "date":
+		return "cast(? as date)"
+	case "datetime", "time":
+		return fmt.Sprintf("cast(? as %s%s)", typeName, typeArgs)
+	}
+	return ""
+}
+
 // It holds the prepared query statement so it doesn't need to be recreated every time.
 type CheckpointInsertQueryBuilder struct {
 	uniqueKeyColumns  *ColumnList
@@ -143,10 +266,12 @@ func NewCheckpointQueryBuilder(databaseName, tableName string, uniqueKeyColumns
 		into %s.%s
 			(gh_ost_chk_timestamp, gh_ost_chk_coords, gh_ost_chk_iteration,
 			 gh_ost_rows_copied, gh_ost_dml_applied, gh_ost_is_cutover,
+			 gh_ost_chk_partition_index,
   			 %s, %s)
 		values
 			(unix_timestamp(now()), ?, ?,
 			 ?, ?, ?,
+			 ?,
 			 %s, %s)`,
 		databaseName, tableName,
 		strings.Join(minUniqueColNames, ", "),
@@ -201,6 +326,14 @@ func BuildSetPreparedClause(columns *ColumnList) (result string, err error) {
 }
 
 func BuildRangeComparison(columns []string, values []string, args []interface{}, comparisonSign ValueComparisonSign) (result string, explodedArgs []interface{}, err error) {
+	return buildRangeComparison(columns, nil, nil, values, args, comparisonSign)
+}
+
+// buildRangeComparison builds a lexicographic comparison of columns against values. It is NULL-aware
+// for nullable columns and NULL args, treating NULL as smaller than any value, which is consistent
+// with MySQL's ordering of NULLs in an ascending index. Comparison signs refer to index order: the
+// value comparison of a descending index part is reversed.
+func buildRangeComparison(columns []string, nullable []bool, descending []bool, values []string, args []interface{}, comparisonSign ValueComparisonSign) (result string, explodedArgs []interface{}, err error) {
 	if len(columns) == 0 {
 		return "", explodedArgs, fmt.Errorf("Got 0 columns in GetRangeComparison")
 	}
@@ -219,31 +352,39 @@ func BuildRangeComparison(columns []string, values []string, args []interface{},
 		comparisonSign = GreaterThanComparisonSign
 		includeEquals = true
 	}
+	nullSafe := make([]bool, len(columns))
+	for i := range columns {
+		nullSafe[i] = (i < len(nullable) && nullable[i]) || args[i] == nil
+	}
 	comparisons := []string{}
 
 	for i, column := range columns {
 		value := values[i]
-		rangeComparison, err := BuildValueComparison(column, value, comparisonSign)
+		columnComparisonSign := comparisonSign
+		if i < len(descending) && descending[i] {
+			columnComparisonSign = reverseComparisonSign(comparisonSign)
+		}
+		rangeComparison, rangeArgs, err := buildNullAwareValueComparison(column, value, args[i], nullSafe[i], columnComparisonSign)
 		if err != nil {
 			return "", explodedArgs, err
 		}
 		if i > 0 {
-			equalitiesComparison, err := BuildEqualsComparison(columns[0:i], values[0:i])
+			equalitiesComparison, err := buildEqualsComparison(columns[0:i], values[0:i], nullSafe[0:i])
 			if err != nil {
 				return "", explodedArgs, err
 			}
 			comparison := fmt.Sprintf("(%s AND %s)", equalitiesComparison, rangeComparison)
 			comparisons = append(comparisons, comparison)
 			explodedArgs = append(explodedArgs, args[0:i]...)
-			explodedArgs = append(explodedArgs, args[i])
+			explodedArgs = append(explodedArgs, rangeArgs...)
 		} else {
 			comparisons = append(comparisons, rangeComparison)
-			explodedArgs = append(explodedArgs, args[i])
+			explodedArgs = append(explodedArgs, rangeArgs...)
 		}
 	}
 
 	if includeEquals {
-		comparison, err := BuildEqualsComparison(columns, values)
+		comparison, err := buildEqualsComparison(columns, values, nullSafe)
 		if err != nil {
 			return "", explodedArgs, err
 		}
@@ -255,37 +396,156 @@ func BuildRangeComparison(columns []string, values []string, args []interface{},
 	return result, explodedArgs, nil
 }
 
+// reverseComparisonSign returns the strict comparison sign for the reversed order
+func reverseComparisonSign(comparisonSign ValueComparisonSign) ValueComparisonSign {
+	switch comparisonSign {
+	case GreaterThanComparisonSign:
+		return LessThanComparisonSign
+	case LessThanComparisonSign:
+		return GreaterThanComparisonSign
+	}
+	return comparisonSign
+}
+
+// buildNullAwareValueComparison builds a strict (< or >) comparison where NULL is the smallest value:
+// any non-NULL value is greater than NULL, and a NULL value is smaller than any non-NULL value.
+func buildNullAwareValueComparison(column string, value string, arg interface{}, nullable bool, comparisonSign ValueComparisonSign) (result string, args []interface{}, err error) {
+	if !nullable {
+		result, err = BuildValueComparison(column, value, comparisonSign)
+		return result, []interface{}{arg}, err
+	}
+	switch comparisonSign {
+	case GreaterThanComparisonSign:
+		if arg == nil {
+			return fmt.Sprintf("(%s is not null)", EscapeName(column)), nil, nil
+		}
+	case LessThanComparisonSign:
+		if arg != nil {
+			result, err = BuildValueComparison(column, value, comparisonSign)
+			if err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprintf("(%s or (%s is null))", result, EscapeName(column)), []interface{}{arg}, nil
+		}
+	}
+	result, err = BuildValueComparison(column, value, comparisonSign)
+	return result, []interface{}{arg}, err
+}
+
 func BuildRangePreparedComparison(columns *ColumnList, args []interface{}, comparisonSign ValueComparisonSign) (result string, explodedArgs []interface{}, err error) {
 	values := buildColumnsPreparedValues(columns)
-	return BuildRangeComparison(columns.Names(), values, args, comparisonSign)
+	return buildRangeComparison(columns.Names(), columns.nullableFlags(), columns.descendingFlags(), values, args, comparisonSign)
+}
+
+// buildOptimizerHintsComment wraps given optimizer hints in a /*+ ... */ comment, to follow a
+// SELECT keyword. It returns an empty string if there are no hints.
+func buildOptimizerHintsComment(hints ...string) string {
+	nonEmptyHints := []string{}
+	for _, hint := range hints {
+		if hint = strings.TrimSpace(hint); hint != "" {
+			nonEmptyHints = append(nonEmptyHints, hint)
+		}
+	}
+	if len(nonEmptyHints) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/*+ %s */", strings.Join(nonEmptyHints, " "))
+}
+
+// buildMaxExecutionTimeHint returns a MAX_EXECUTION_TIME optimizer hint, or an empty string if
+// given time is not positive
+func buildMaxExecutionTimeHint(maxExecutionTimeMillis int64) string {
+	if maxExecutionTimeMillis <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("MAX_EXECUTION_TIME(%d)", maxExecutionTimeMillis)
+}
+
+// ValidateOptimizerHints verifies user supplied optimizer hints can be safely wrapped in a /*+ ... */ comment
+func ValidateOptimizerHints(hints string) error {
+	if strings.Contains(hints, "/*") || strings.Contains(hints, "*/") {
+		return fmt.Errorf("Optimizer hints must not contain comment delimiters: %s", hints)
+	}
+	return nil
+}
+
+// BuildCountRowsQuery builds a query counting all rows of a table, limited to given execution time, if positive
+func BuildCountRowsQuery(databaseName, tableName string, maxExecutionTimeMillis int64) string {
+	return fmt.Sprintf(`select %s /* gh-ost */ count(*) as count_rows from %s.%s`,
+		buildOptimizerHintsComment(buildMaxExecutionTimeHint(maxExecutionTimeMillis)),
+		EscapeName(databaseName), EscapeName(tableName))
 }
 
-func BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName string, sharedColumns []string, mappedSharedColumns []string, uniqueKey string, uniqueKeyColumns *ColumnList, rangeStartValues, rangeEndValues []string, rangeStartArgs, rangeEndArgs []interface{}, includeRangeStartValues bool, transactionalTable bool, noWait bool) (result string, explodedArgs []interface{}, err error) {
+// BuildWarmUpIndexQuery builds a query scanning a whole index of a table, limited to given execution time, if positive
+func BuildWarmUpIndexQuery(databaseName, tableName, indexName string, maxExecutionTimeMillis int64) string {
+	return fmt.Sprintf(`select %s /* gh-ost */ count(*) as count_rows from %s.%s force index (%s)`,
+		buildOptimizerHintsComment(buildMaxExecutionTimeHint(maxExecutionTimeMillis)),
+		EscapeName(databaseName), EscapeName(tableName), EscapeName(indexName))
+}
+
+// buildPartitionClause returns an explicit PARTITION clause restricting a query to given partition,
+// or an empty string if no partition is given
+func buildPartitionClause(partitionName string) string {
+	if partitionName == "" {
+		return ""
+	}
+	return fmt.Sprintf("partition (%s)", EscapeName(partitionName))
+}
+
+// buildForceIndexClause returns a FORCE INDEX clause for given index, or an empty string if no index
+// is given, as for a full row key
+func buildForceIndexClause(indexName string) string {
+	if indexName == "" {
+		return ""
+	}
+	return fmt.Sprintf("force index (%s)", indexName)
+}
+
+func BuildRangeInsertQuery(databaseName, originalTableName, ghostDatabaseName, ghostTableName string, sharedColumns []string, mappedSharedColumns []string, uniqueKey string, uniqueKeyColumns *ColumnList, rangeStartValues, rangeEndValues []string, rangeStartArgs, rangeEndArgs []interface{}, includeRangeStartValues bool, transactionalTable bool, noWait bool, partitionName string, optimizerHints string, columnTransforms []*ColumnTransform, conflictStrategy CopyConflictStrategy) (result string, explodedArgs []interface{}, err error) {
 	if len(sharedColumns) == 0 {
 		return "", explodedArgs, fmt.Errorf("Got 0 shared columns in BuildRangeInsertQuery")
 	}
 	databaseName = EscapeName(databaseName)
 	originalTableName = EscapeName(originalTableName)
+	ghostDatabaseName = EscapeName(ghostDatabaseName)
 	ghostTableName = EscapeName(ghostTableName)
 
-	mappedSharedColumns = duplicateNames(mappedSharedColumns)
+	sharedColumns = duplicateNames(sharedColumns)
+	for i := range sharedColumns {
+		sharedColumns[i] = EscapeName(sharedColumns[i])
+	}
+	mappedSharedColumns, sharedColumns = buildTransformedValues(mappedSharedColumns, sharedColumns, columnTransforms)
+	sharedColumnsListing := strings.Join(sharedColumns, ", ")
+
 	for i := range mappedSharedColumns {
 		mappedSharedColumns[i] = EscapeName(mappedSharedColumns[i])
 	}
 	mappedSharedColumnsListing := strings.Join(mappedSharedColumns, ", ")
 
-	sharedColumns = duplicateNames(sharedColumns)
-	for i := range sharedColumns {
-		sharedColumns[i] = EscapeName(sharedColumns[i])
+	insertStatement, ignoreClause, onDuplicateKeyClause := "insert", "", ""
+	switch conflictStrategy {
+	case CopyConflictIgnore:
+		ignoreClause = "ignore"
+	case CopyConflictReplace:
+		insertStatement = "replace"
+	case CopyConflictUpsert:
+		updates := make([]string, len(mappedSharedColumns))
+		for i, column := range mappedSharedColumns {
+			updates[i] = fmt.Sprintf("%s=values(%s)", column, column)
+		}
+		onDuplicateKeyClause = fmt.Sprintf("on duplicate key update %s", strings.Join(updates, ", "))
+	default:
+		return "", explodedArgs, fmt.Errorf("Unknown copy conflict strategy in BuildRangeInsertQuery: %s", conflictStrategy)
 	}
-	sharedColumnsListing := strings.Join(sharedColumns, ", ")
 
-	uniqueKey = EscapeName(uniqueKey)
+	if uniqueKey != "" {
+		uniqueKey = EscapeName(uniqueKey)
+	}
 	var minRangeComparisonSign ValueComparisonSign = GreaterThanComparisonSign
 	if includeRangeStartValues {
 		minRangeComparisonSign = GreaterThanOrEqualsComparisonSign
 	}
-	rangeStartComparison, rangeExplodedArgs, err := BuildRangeComparison(uniqueKeyColumns.Names(), rangeStartValues, rangeStartArgs, minRangeComparisonSign)
+	rangeStartComparison, rangeExplodedArgs, err := buildRangeComparison(uniqueKeyColumns.Names(), uniqueKeyColumns.nullableFlags(), uniqueKeyColumns.descendingFlags(), rangeStartValues, rangeStartArgs, minRangeComparisonSign)
 	if err != nil {
 		return "", explodedArgs, err
 	}
@@ -298,38 +558,39 @@ func BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName strin
 			transactionalClause = "lock in share mode"
 		}
 	}
-	rangeEndComparison, rangeExplodedArgs, err := BuildRangeComparison(uniqueKeyColumns.Names(), rangeEndValues, rangeEndArgs, LessThanOrEqualsComparisonSign)
+	rangeEndComparison, rangeExplodedArgs, err := buildRangeComparison(uniqueKeyColumns.Names(), uniqueKeyColumns.nullableFlags(), uniqueKeyColumns.descendingFlags(), rangeEndValues, rangeEndArgs, LessThanOrEqualsComparisonSign)
 	if err != nil {
 		return "", explodedArgs, err
 	}
 	explodedArgs = append(explodedArgs, rangeExplodedArgs...)
 	result = fmt.Sprintf(`
-		insert /* gh-ost %s.%s */ ignore
+		%s /* gh-ost %s.%s */ %s
 		into
 			%s.%s
 			(%s)
 		(
-			select %s
+			select %s %s
 			from
-				%s.%s
-			force index (%s)
+				%s.%s %s
+			%s
 			where
 				(%s and %s)
 				%s
-		)`,
-		databaseName, originalTableName, databaseName, ghostTableName, mappedSharedColumnsListing,
-		sharedColumnsListing, databaseName, originalTableName, uniqueKey,
-		rangeStartComparison, rangeEndComparison, transactionalClause)
+		)
+		%s`,
+		insertStatement, sanitizeComment(databaseName), sanitizeComment(originalTableName), ignoreClause, ghostDatabaseName, ghostTableName, mappedSharedColumnsListing,
+		buildOptimizerHintsComment(optimizerHints), sharedColumnsListing, databaseName, originalTableName, buildPartitionClause(partitionName), buildForceIndexClause(uniqueKey),
+		rangeStartComparison, rangeEndComparison, transactionalClause, onDuplicateKeyClause)
 	return result, explodedArgs, nil
 }
 
-func BuildRangeInsertPreparedQuery(databaseName, originalTableName, ghostTableName string, sharedColumns []string, mappedSharedColumns []string, uniqueKey string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, includeRangeStartValues bool, transactionalTable bool, noWait bool) (result string, explodedArgs []interface{}, err error) {
+func BuildRangeInsertPreparedQuery(databaseName, originalTableName, ghostDatabaseName, ghostTableName string, sharedColumns []string, mappedSharedColumns []string, uniqueKey string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, includeRangeStartValues bool, transactionalTable bool, noWait bool, partitionName string, optimizerHints string, columnTransforms []*ColumnTransform, conflictStrategy CopyConflictStrategy) (result string, explodedArgs []interface{}, err error) {
 	rangeStartValues := buildColumnsPreparedValues(uniqueKeyColumns)
 	rangeEndValues := buildColumnsPreparedValues(uniqueKeyColumns)
-	return BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, includeRangeStartValues, transactionalTable, noWait)
+	return BuildRangeInsertQuery(databaseName, originalTableName, ghostDatabaseName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, includeRangeStartValues, transactionalTable, noWait, partitionName, optimizerHints, columnTransforms, conflictStrategy)
 }
 
-func BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, tableName string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, chunkSize int64, includeRangeStartValues bool, hint string) (result string, explodedArgs []interface{}, err error) {
+func BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, tableName string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, chunkSize int64, includeRangeStartValues bool, hint string, partitionName string, maxExecutionTimeMillis int64) (result string, explodedArgs []interface{}, err error) {
 	if uniqueKeyColumns.Len() == 0 {
 		return "", explodedArgs, fmt.Errorf("Got 0 columns in BuildUniqueKeyRangeEndPreparedQuery")
 	}
@@ -355,26 +616,22 @@ func BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, tableName string
 	uniqueKeyColumnAscending := make([]string, len(uniqueKeyColumnNames))
 	for i, column := range uniqueKeyColumns.Columns() {
 		uniqueKeyColumnNames[i] = EscapeName(uniqueKeyColumnNames[i])
-		if column.Type == EnumColumnType {
-			uniqueKeyColumnAscending[i] = fmt.Sprintf("concat(%s) asc", uniqueKeyColumnNames[i])
-		} else {
-			uniqueKeyColumnAscending[i] = fmt.Sprintf("%s asc", uniqueKeyColumnNames[i])
-		}
+		uniqueKeyColumnAscending[i] = buildUniqueKeyColumnOrder(column, uniqueKeyColumnNames[i], "asc")
 	}
 	result = fmt.Sprintf(`
-		select /* gh-ost %s.%s %s */
+		select %s /* gh-ost %s.%s %s */
 			%s
 		from
-			%s.%s
+			%s.%s %s
 		where
 			%s and %s
 		order by
 			%s
 		limit 1
 		offset %d`,
-		databaseName, tableName, hint,
+		buildOptimizerHintsComment(buildMaxExecutionTimeHint(maxExecutionTimeMillis)), sanitizeComment(databaseName), sanitizeComment(tableName), sanitizeComment(hint),
 		strings.Join(uniqueKeyColumnNames, ", "),
-		databaseName, tableName,
+		databaseName, tableName, buildPartitionClause(partitionName),
 		rangeStartComparison, rangeEndComparison,
 		strings.Join(uniqueKeyColumnAscending, ", "),
 		(chunkSize - 1),
@@ -382,7 +639,7 @@ func BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, tableName string
 	return result, explodedArgs, nil
 }
 
-func BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, tableName string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, chunkSize int64, includeRangeStartValues bool, hint string) (result string, explodedArgs []interface{}, err error) {
+func BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, tableName string, uniqueKeyColumns *ColumnList, rangeStartArgs, rangeEndArgs []interface{}, chunkSize int64, includeRangeStartValues bool, hint string, partitionName string, maxExecutionTimeMillis int64) (result string, explodedArgs []interface{}, err error) {
 	if uniqueKeyColumns.Len() == 0 {
 		return "", explodedArgs, fmt.Errorf("Got 0 columns in BuildUniqueKeyRangeEndPreparedQuery")
 	}
@@ -409,21 +666,16 @@ func BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, tableName str
 	uniqueKeyColumnDescending := make([]string, len(uniqueKeyColumnNames))
 	for i, column := range uniqueKeyColumns.Columns() {
 		uniqueKeyColumnNames[i] = EscapeName(uniqueKeyColumnNames[i])
-		if column.Type == EnumColumnType {
-			uniqueKeyColumnAscending[i] = fmt.Sprintf("concat(%s) asc", uniqueKeyColumnNames[i])
-			uniqueKeyColumnDescending[i] = fmt.Sprintf("concat(%s) desc", uniqueKeyColumnNames[i])
-		} else {
-			uniqueKeyColumnAscending[i] = fmt.Sprintf("%s asc", uniqueKeyColumnNames[i])
-			uniqueKeyColumnDescending[i] = fmt.Sprintf("%s desc", uniqueKeyColumnNames[i])
-		}
+		uniqueKeyColumnAscending[i] = buildUniqueKeyColumnOrder(column, uniqueKeyColumnNames[i], "asc")
+		uniqueKeyColumnDescending[i] = buildUniqueKeyColumnOrder(column, uniqueKeyColumnNames[i], "desc")
 	}
 	result = fmt.Sprintf(`
-		select /* gh-ost %s.%s %s */ %s
+		select %s /* gh-ost %s.%s %s */ %s
 		from (
 			select
 				%s
 			from
-				%s.%s
+				%s.%s %s
 			where
 				%s and %s
 			order by
@@ -433,8 +685,8 @@ func BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, tableName str
 		order by
 			%s
 		limit 1`,
-		databaseName, tableName, hint, strings.Join(uniqueKeyColumnNames, ", "),
-		strings.Join(uniqueKeyColumnNames, ", "), databaseName, tableName,
+		buildOptimizerHintsComment(buildMaxExecutionTimeHint(maxExecutionTimeMillis)), sanitizeComment(databaseName), sanitizeComment(tableName), sanitizeComment(hint), strings.Join(uniqueKeyColumnNames, ", "),
+		strings.Join(uniqueKeyColumnNames, ", "), databaseName, tableName, buildPartitionClause(partitionName),
 		rangeStartComparison, rangeEndComparison,
 		strings.Join(uniqueKeyColumnAscending, ", "), chunkSize,
 		strings.Join(uniqueKeyColumnDescending, ", "),
@@ -442,6 +694,22 @@ func BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, tableName str
 	return result, explodedArgs, nil
 }
 
+// buildUniqueKeyColumnOrder builds the ORDER BY term of an (escaped) unique key column, where order
+// ("asc" or "desc") refers to index order: a descending index part is ordered the other way around.
+func buildUniqueKeyColumnOrder(column Column, escapedColumnName string, order string) string {
+	if column.IsDescending {
+		if order == "asc" {
+			order = "desc"
+		} else {
+			order = "asc"
+		}
+	}
+	if column.Type == EnumColumnType {
+		return fmt.Sprintf("concat(%s) %s", escapedColumnName, order)
+	}
+	return fmt.Sprintf("%s %s", escapedColumnName, order)
+}
+
 func BuildUniqueKeyMinValuesPreparedQuery(databaseName, tableName string, uniqueKey *UniqueKey) (string, error) {
 	return buildUniqueKeyMinMaxValuesPreparedQuery(databaseName, tableName, uniqueKey, "asc")
 }
@@ -461,27 +729,186 @@ func buildUniqueKeyMinMaxValuesPreparedQuery(databaseName, tableName string, uni
 	uniqueKeyColumnOrder := make([]string, len(uniqueKeyColumnNames))
 	for i, column := range uniqueKey.Columns.Columns() {
 		uniqueKeyColumnNames[i] = EscapeName(uniqueKeyColumnNames[i])
-		if column.Type == EnumColumnType {
-			uniqueKeyColumnOrder[i] = fmt.Sprintf("concat(%s) %s", uniqueKeyColumnNames[i], order)
-		} else {
-			uniqueKeyColumnOrder[i] = fmt.Sprintf("%s %s", uniqueKeyColumnNames[i], order)
-		}
+		uniqueKeyColumnOrder[i] = buildUniqueKeyColumnOrder(column, uniqueKeyColumnNames[i], order)
 	}
 	query := fmt.Sprintf(`
 		select /* gh-ost %s.%s */ %s
 		from
 			%s.%s
-		force index (%s)
+		%s
 		order by
 			%s
 		limit 1`,
-		databaseName, tableName, strings.Join(uniqueKeyColumnNames, ", "),
-		databaseName, tableName, uniqueKey.Name,
+		sanitizeComment(databaseName), sanitizeComment(tableName), strings.Join(uniqueKeyColumnNames, ", "),
+		databaseName, tableName, buildForceIndexClause(uniqueKey.IndexName()),
 		strings.Join(uniqueKeyColumnOrder, ", "),
 	)
 	return query, nil
 }
 
+// BuildChunkSamplePreparedQuery builds a query reading a chunk of a table's rows in unique key order, the
+// way row copy reads a chunk, though without locking the rows read. The chunk starts at given values of the
+// key's leading columns, inclusive, or at the table's first row if none are given.
+func BuildChunkSamplePreparedQuery(databaseName, tableName string, columns *ColumnList, uniqueKey *UniqueKey, startArgs []interface{}, chunkSize int64) (result string, explodedArgs []interface{}, err error) {
+	if uniqueKey.Columns.Len() == 0 {
+		return "", explodedArgs, fmt.Errorf("Got 0 columns in BuildChunkSamplePreparedQuery")
+	}
+	if len(startArgs) > uniqueKey.Columns.Len() {
+		return "", explodedArgs, fmt.Errorf("Got %d columns but %d start args in BuildChunkSamplePreparedQuery", uniqueKey.Columns.Len(), len(startArgs))
+	}
+	databaseName = EscapeName(databaseName)
+	tableName = EscapeName(tableName)
+
+	columnNames := duplicateNames(columns.Names())
+	for i := range columnNames {
+		columnNames[i] = EscapeName(columnNames[i])
+	}
+	uniqueKeyColumnOrder := make([]string, uniqueKey.Columns.Len())
+	for i, column := range uniqueKey.Columns.Columns() {
+		uniqueKeyColumnOrder[i] = buildUniqueKeyColumnOrder(column, EscapeName(column.Name), "asc")
+	}
+	whereClause := ""
+	if n := len(startArgs); n > 0 {
+		startComparison, startExplodedArgs, err := buildRangeComparison(uniqueKey.Columns.Names()[:n], uniqueKey.Columns.nullableFlags()[:n], uniqueKey.Columns.descendingFlags()[:n],
+			buildColumnsPreparedValues(&uniqueKey.Columns)[:n], startArgs, GreaterThanOrEqualsComparisonSign)
+		if err != nil {
+			return "", explodedArgs, err
+		}
+		whereClause = fmt.Sprintf("where %s", startComparison)
+		explodedArgs = append(explodedArgs, startExplodedArgs...)
+	}
+	result = fmt.Sprintf(`
+		select /* gh-ost %s.%s */ %s
+		from
+			%s.%s
+		%s
+		%s
+		order by
+			%s
+		limit %d`,
+		sanitizeComment(databaseName), sanitizeComment(tableName), strings.Join(columnNames, ", "),
+		databaseName, tableName, buildForceIndexClause(uniqueKey.IndexName()),
+		whereClause,
+		strings.Join(uniqueKeyColumnOrder, ", "),
+		chunkSize,
+	)
+	return result, explodedArgs, nil
+}
+
+// BuildNarrowingColumnCountQuery builds a query counting rows matching given narrowing condition on
+// given column, as returned by BuildNarrowingColumnCondition. Only the first scanLimit rows of the
+// table are read, and counting stops at given limit.
+func BuildNarrowingColumnCountQuery(databaseName, tableName, columnName, condition string, scanLimit, limit int64) (string, error) {
+	if condition == "" {
+		return "", fmt.Errorf("Got empty condition in BuildNarrowingColumnCountQuery")
+	}
+	databaseName = EscapeName(databaseName)
+	tableName = EscapeName(tableName)
+	query := fmt.Sprintf(`
+		select /* gh-ost %s.%s */ count(*)
+		from (
+			select 1
+			from (
+				select %s
+				from
+					%s.%s
+				limit %d
+			) narrowing_sample
+			where
+				%s
+			limit %d
+		) narrowing_rows`,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
+		EscapeName(columnName),
+		databaseName, tableName,
+		scanLimit,
+		condition,
+		limit,
+	)
+	return query, nil
+}
+
+// BuildNullableUniqueKeyDuplicatesQuery builds a query listing (up to limit) key values which
+// contain NULLs and are shared by more than one row. MySQL permits such duplicates in a unique key,
+// but gh-ost cannot tell these rows apart.
+func BuildNullableUniqueKeyDuplicatesQuery(databaseName, tableName string, uniqueKey *UniqueKey, limit int64) (string, error) {
+	if uniqueKey.Columns.Len() == 0 {
+		return "", fmt.Errorf("Got 0 columns in BuildNullableUniqueKeyDuplicatesQuery")
+	}
+	databaseName = EscapeName(databaseName)
+	tableName = EscapeName(tableName)
+
+	uniqueKeyColumnNames := duplicateNames(uniqueKey.Columns.Names())
+	isNullConditions := []string{}
+	for i, column := range uniqueKey.Columns.Columns() {
+		uniqueKeyColumnNames[i] = EscapeName(uniqueKeyColumnNames[i])
+		if column.Nullable {
+			isNullConditions = append(isNullConditions, fmt.Sprintf("%s is null", uniqueKeyColumnNames[i]))
+		}
+	}
+	if len(isNullConditions) == 0 {
+		return "", fmt.Errorf("Unique key %s has no nullable columns in BuildNullableUniqueKeyDuplicatesQuery", uniqueKey.Name)
+	}
+	indexName := uniqueKey.IndexName()
+	if indexName != "" {
+		indexName = EscapeName(indexName)
+	}
+	query := fmt.Sprintf(`
+		select /* gh-ost %s.%s */ %s, count(*) as duplicates_count
+		from
+			%s.%s
+		%s
+		where
+			%s
+		group by
+			%s
+		having
+			count(*) > 1
+		limit %d`,
+		sanitizeComment(databaseName), sanitizeComment(tableName), strings.Join(uniqueKeyColumnNames, ", "),
+		databaseName, tableName,
+		buildForceIndexClause(indexName),
+		strings.Join(isNullConditions, " or "),
+		strings.Join(uniqueKeyColumnNames, ", "),
+		limit,
+	)
+	return query, nil
+}
+
+// BuildFullRowDuplicatesQuery builds a query counting rows (up to limit) whose values, in all given
+// columns, are shared with another row. A table without PRIMARY nor UNIQUE key may hold such rows,
+// which gh-ost cannot tell apart.
+func BuildFullRowDuplicatesQuery(databaseName, tableName string, columns *ColumnList, limit int64) (string, error) {
+	if columns.Len() == 0 {
+		return "", fmt.Errorf("Got 0 columns in BuildFullRowDuplicatesQuery")
+	}
+	databaseName = EscapeName(databaseName)
+	tableName = EscapeName(tableName)
+
+	columnNames := duplicateNames(columns.Names())
+	for i := range columnNames {
+		columnNames[i] = EscapeName(columnNames[i])
+	}
+	query := fmt.Sprintf(`
+		select /* gh-ost %s.%s */ count(*)
+		from (
+			select 1
+			from
+				%s.%s
+			group by
+				%s
+			having
+				count(*) > 1
+			limit %d
+		) duplicate_rows`,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
+		databaseName, tableName,
+		strings.Join(columnNames, ", "),
+		limit,
+	)
+	return query, nil
+}
+
 // DMLDeleteQueryBuilder can build DELETE queries for DML events.
 // It holds the prepared query statement so it doesn't need to be recreated every time.
 type DMLDeleteQueryBuilder struct {
@@ -499,7 +926,7 @@ func NewDMLDeleteQueryBuilder(databaseName, tableName string, tableColumns, uniq
 	}
 	databaseName = EscapeName(databaseName)
 	tableName = EscapeName(tableName)
-	equalsComparison, err := BuildEqualsPreparedComparison(uniqueKeyColumns.Names())
+	equalsComparison, err := buildNullSafeEqualsPreparedComparison(uniqueKeyColumns)
 	if err != nil {
 		return nil, err
 	}
@@ -510,7 +937,7 @@ func NewDMLDeleteQueryBuilder(databaseName, tableName string, tableColumns, uniq
 			%s.%s
 		where
 			%s`,
-		databaseName, tableName,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
 		databaseName, tableName,
 		equalsComparison,
 	)
@@ -527,16 +954,20 @@ func NewDMLDeleteQueryBuilder(databaseName, tableName string, tableColumns, uniq
 // It returns the query string and the unique key arguments array.
 // Returns an error if the number of arguments is not equal to the number of table columns.
 func (b *DMLDeleteQueryBuilder) BuildQuery(args []interface{}) (string, []interface{}, error) {
+	return b.AppendQueryArgs(make([]interface{}, 0, b.uniqueKeyColumns.Len()), args)
+}
+
+// AppendQueryArgs is like BuildQuery, but appends the unique key arguments to dst
+// so that callers may reuse an argument buffer across events.
+func (b *DMLDeleteQueryBuilder) AppendQueryArgs(dst, args []interface{}) (string, []interface{}, error) {
 	if len(args) != b.tableColumns.Len() {
-		return "", nil, fmt.Errorf("args count differs from table column count in BuildDMLDeleteQuery")
+		return "", dst, fmt.Errorf("args count differs from table column count in BuildDMLDeleteQuery")
 	}
-	uniqueKeyArgs := make([]interface{}, 0, b.uniqueKeyColumns.Len())
 	for _, column := range b.uniqueKeyColumns.Columns() {
 		tableOrdinal := b.tableColumns.Ordinals[column.Name]
-		arg := column.convertArg(args[tableOrdinal], true)
-		uniqueKeyArgs = append(uniqueKeyArgs, arg)
+		dst = append(dst, column.convertArg(args[tableOrdinal], true))
 	}
-	return b.preparedStatement, uniqueKeyArgs, nil
+	return b.preparedStatement, dst, nil
 }
 
 // DMLInsertQueryBuilder can build INSERT queries for DML events.
@@ -544,13 +975,17 @@ func (b *DMLDeleteQueryBuilder) BuildQuery(args []interface{}) (string, []interf
 type DMLInsertQueryBuilder struct {
 	tableColumns, sharedColumns *ColumnList
 	preparedStatement           string
+	// hasColumnTransforms is set when the statement selects from a derived table of the full row
+	hasColumnTransforms bool
 }
 
 // NewDMLInsertQueryBuilder creates a new DMLInsertQueryBuilder.
 // It prepares the INSERT query statement.
 // Returns an error if no shared columns are given, the shared columns are not a subset of the table columns,
 // or the prepared statement cannot be built.
-func NewDMLInsertQueryBuilder(databaseName, tableName string, tableColumns, sharedColumns, mappedSharedColumns *ColumnList) (*DMLInsertQueryBuilder, error) {
+// With column transforms, the row's values are bound into a derived table, which the transform expressions
+// may reference by the original table's column names.
+func NewDMLInsertQueryBuilder(databaseName, tableName string, tableColumns, sharedColumns, mappedSharedColumns *ColumnList, columnTransforms []*ColumnTransform) (*DMLInsertQueryBuilder, error) {
 	if !sharedColumns.IsSubsetOf(tableColumns) {
 		return nil, fmt.Errorf("shared columns is not a subset of table columns in NewDMLInsertQueryBuilder")
 	}
@@ -565,6 +1000,10 @@ func NewDMLInsertQueryBuilder(databaseName, tableName string, tableColumns, shar
 	}
 	preparedValues := buildColumnsPreparedValues(mappedSharedColumns)
 
+	if len(columnTransforms) > 0 {
+		return newDMLInsertTransformQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, mappedSharedColumns, preparedValues, columnTransforms), nil
+	}
+
 	stmt := fmt.Sprintf(`
 		replace /* gh-ost %s.%s */
 		into
@@ -572,7 +1011,7 @@ func NewDMLInsertQueryBuilder(databaseName, tableName string, tableColumns, shar
 			(%s)
 		values
 			(%s)`,
-		databaseName, tableName,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
 		databaseName, tableName,
 		strings.Join(mappedSharedColumnNames, ", "),
 		strings.Join(preparedValues, ", "),
@@ -585,20 +1024,77 @@ func NewDMLInsertQueryBuilder(databaseName, tableName string, tableColumns, shar
 	}, nil
 }
 
+func newDMLInsertTransformQueryBuilder(databaseName, tableName string, tableColumns, sharedColumns, mappedSharedColumns *ColumnList, preparedValues []string, columnTransforms []*ColumnTransform) *DMLInsertQueryBuilder {
+	rowValues := make([]string, 0, tableColumns.Len())
+	for _, column := range tableColumns.Columns() {
+		token := "?"
+		if ordinal, ok := sharedColumns.Ordinals[column.Name]; ok {
+			token = preparedValues[ordinal]
+		}
+		rowValues = append(rowValues, fmt.Sprintf("%s as %s", token, EscapeName(column.Name)))
+	}
+	sharedColumnNames := duplicateNames(sharedColumns.Names())
+	for i := range sharedColumnNames {
+		sharedColumnNames[i] = EscapeName(sharedColumnNames[i])
+	}
+	columnNames, values := buildTransformedValues(mappedSharedColumns.Names(), sharedColumnNames, columnTransforms)
+	for i := range columnNames {
+		columnNames[i] = EscapeName(columnNames[i])
+	}
+
+	stmt := fmt.Sprintf(`
+		replace /* gh-ost %s.%s */
+		into
+			%s.%s
+			(%s)
+		select
+			%s
+		from
+			(select %s) as gh_ost_row`,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
+		databaseName, tableName,
+		strings.Join(columnNames, ", "),
+		strings.Join(values, ", "),
+		strings.Join(rowValues, ", "),
+	)
+
+	return &DMLInsertQueryBuilder{
+		tableColumns:        tableColumns,
+		sharedColumns:       sharedColumns,
+		preparedStatement:   stmt,
+		hasColumnTransforms: true,
+	}
+}
+
 // BuildQuery builds the arguments array for a DML event INSERT query.
 // It returns the query string and the shared arguments array.
 // Returns an error if the number of arguments differs from the number of table columns.
 func (b *DMLInsertQueryBuilder) BuildQuery(args []interface{}) (string, []interface{}, error) {
+	return b.AppendQueryArgs(make([]interface{}, 0, b.sharedColumns.Len()), args)
+}
+
+// AppendQueryArgs is like BuildQuery, but appends the shared arguments to dst
+// so that callers may reuse an argument buffer across events.
+func (b *DMLInsertQueryBuilder) AppendQueryArgs(dst, args []interface{}) (string, []interface{}, error) {
 	if len(args) != b.tableColumns.Len() {
-		return "", nil, fmt.Errorf("args count differs from table column count in BuildDMLInsertQuery")
+		return "", dst, fmt.Errorf("args count differs from table column count in BuildDMLInsertQuery")
+	}
+	if b.hasColumnTransforms {
+		// the full row is bound, converted as shared columns are where applicable
+		for i, column := range b.tableColumns.Columns() {
+			if sharedColumn := b.sharedColumns.GetColumn(column.Name); sharedColumn != nil {
+				dst = append(dst, sharedColumn.convertArg(args[i], false))
+			} else {
+				dst = append(dst, column.convertArg(args[i], false))
+			}
+		}
+		return b.preparedStatement, dst, nil
 	}
-	sharedArgs := make([]interface{}, 0, b.sharedColumns.Len())
 	for _, column := range b.sharedColumns.Columns() {
 		tableOrdinal := b.tableColumns.Ordinals[column.Name]
-		arg := column.convertArg(args[tableOrdinal], false)
-		sharedArgs = append(sharedArgs, arg)
+		dst = append(dst, column.convertArg(args[tableOrdinal], false))
 	}
-	return b.preparedStatement, sharedArgs, nil
+	return b.preparedStatement, dst, nil
 }
 
 // DMLUpdateQueryBuilder can build UPDATE queries for DML events.
@@ -635,7 +1131,7 @@ func NewDMLUpdateQueryBuilder(databaseName, tableName string, tableColumns, shar
 		return nil, err
 	}
 
-	equalsComparison, err := BuildEqualsPreparedComparison(uniqueKeyColumns.Names())
+	equalsComparison, err := buildNullSafeEqualsPreparedComparison(uniqueKeyColumns)
 	if err != nil {
 		return nil, err
 	}
@@ -646,7 +1142,7 @@ func NewDMLUpdateQueryBuilder(databaseName, tableName string, tableColumns, shar
 			%s
 		where
 			%s`,
-		databaseName, tableName,
+		sanitizeComment(databaseName), sanitizeComment(tableName),
 		databaseName, tableName,
 		setClause,
 		equalsComparison,
@@ -662,19 +1158,22 @@ func NewDMLUpdateQueryBuilder(databaseName, tableName string, tableColumns, shar
 // BuildQuery builds the arguments array for a DML event UPDATE query.
 // It returns the query string, the shared arguments array, and the unique key arguments array.
 func (b *DMLUpdateQueryBuilder) BuildQuery(valueArgs, whereArgs []interface{}) (string, []interface{}, []interface{}, error) {
-	sharedArgs := make([]interface{}, 0, b.sharedColumns.Len())
+	query, args, err := b.AppendQueryArgs(make([]interface{}, 0, b.sharedColumns.Len()+b.uniqueKeyColumns.Len()), valueArgs, whereArgs)
+	sharedArgs := args[:b.sharedColumns.Len():b.sharedColumns.Len()]
+	uniqueKeyArgs := args[b.sharedColumns.Len():]
+	return query, sharedArgs, uniqueKeyArgs, err
+}
+
+// AppendQueryArgs is like BuildQuery, but appends the shared arguments followed by
+// the unique key arguments to dst, so that callers may reuse an argument buffer across events.
+func (b *DMLUpdateQueryBuilder) AppendQueryArgs(dst, valueArgs, whereArgs []interface{}) (string, []interface{}, error) {
 	for _, column := range b.sharedColumns.Columns() {
 		tableOrdinal := b.tableColumns.Ordinals[column.Name]
-		arg := column.convertArg(valueArgs[tableOrdinal], false)
-		sharedArgs = append(sharedArgs, arg)
+		dst = append(dst, column.convertArg(valueArgs[tableOrdinal], false))
 	}
-
-	uniqueKeyArgs := make([]interface{}, 0, b.uniqueKeyColumns.Len())
 	for _, column := range b.uniqueKeyColumns.Columns() {
 		tableOrdinal := b.tableColumns.Ordinals[column.Name]
-		arg := column.convertArg(whereArgs[tableOrdinal], true)
-		uniqueKeyArgs = append(uniqueKeyArgs, arg)
+		dst = append(dst, column.convertArg(whereArgs[tableOrdinal], true))
 	}
-
-	return b.preparedStatement, sharedArgs, uniqueKeyArgs, nil
+	return b.preparedStatement, dst, nil
 }
@@ -38,6 +38,43 @@ func TestEscapeName(t *testing.T) {
 	}
 }
 
+func TestBuildQueryCommentSanitized(t *testing.T) {
+	require.Equal(t, "`tbl* /x`", sanitizeComment("`tbl*/x`"))
+
+	uniqueKey := &UniqueKey{Name: "PRIMARY", Columns: *NewColumnList([]string{"id"})}
+	query, err := BuildUniqueKeyMinValuesPreparedQuery("my*/db", "tbl*/", uniqueKey)
+	require.NoError(t, err)
+	require.Equal(t, "select /* gh-ost my* /db.tbl* / */ id from my*/db.tbl*/ force index (PRIMARY) order by id asc limit 1", normalizeQuery(query))
+
+	builder, err := NewDMLDeleteQueryBuilder("mydb", "tbl*/", NewColumnList([]string{"id", "name"}), &uniqueKey.Columns)
+	require.NoError(t, err)
+	query, _, err = builder.BuildQuery([]interface{}{3, "a"})
+	require.NoError(t, err)
+	require.Contains(t, normalizeQuery(query), "delete /* gh-ost mydb.tbl* / */ from mydb.tbl*/")
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, "`order`", QuoteIdentifier("order"))
+	require.Equal(t, "`col``tick`", QuoteIdentifier("col`tick"))
+	require.Equal(t, "`$1st`", QuoteIdentifier("$1st"))
+	require.Equal(t, "`\"quoted\"`", QuoteIdentifier(`"quoted"`))
+	require.Equal(t, "`col``tick`", EscapeName("col`tick"))
+	require.Equal(t, "`col``tick`", EscapeName("`col``tick`"))
+}
+
+func TestUnquoteIdentifier(t *testing.T) {
+	require.Equal(t, "order", UnquoteIdentifier("order"))
+	require.Equal(t, "order", UnquoteIdentifier("`order`"))
+	require.Equal(t, "col`tick", UnquoteIdentifier("`col``tick`"))
+	require.Equal(t, "order", UnquoteIdentifier(`"order"`))
+	require.Equal(t, "`", UnquoteIdentifier("`"))
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	require.Equal(t, "'my_table'", QuoteLiteral("my_table"))
+	require.Equal(t, `'it''s \\ here'`, QuoteLiteral(`it's \ here`))
+}
+
 func TestBuildEqualsComparison(t *testing.T) {
 	{
 		columns := []string{"c1"}
@@ -158,6 +195,46 @@ func TestBuildRangeComparison(t *testing.T) {
 	}
 }
 
+func TestBuildRangePreparedComparisonNullable(t *testing.T) {
+	columns := NewColumnList([]string{"c1", "c2"})
+	columns.GetColumn("c1").Nullable = true
+	{
+		// range start within the NULLs at the beginning of the key space
+		comparison, explodedArgs, err := BuildRangePreparedComparison(columns, []interface{}{nil, 17}, GreaterThanComparisonSign)
+		require.NoError(t, err)
+		require.Equal(t, "((`c1` is not null) or (((`c1` <=> ?)) AND (`c2` > ?)))", comparison)
+		require.Equal(t, []interface{}{nil, 17}, explodedArgs)
+	}
+	{
+		comparison, explodedArgs, err := BuildRangePreparedComparison(columns, []interface{}{3, 17}, GreaterThanComparisonSign)
+		require.NoError(t, err)
+		require.Equal(t, "((`c1` > ?) or (((`c1` <=> ?)) AND (`c2` > ?)))", comparison)
+		require.Equal(t, []interface{}{3, 3, 17}, explodedArgs)
+	}
+	{
+		// NULLs sort before any value, and so are included by a non-NULL range end
+		comparison, explodedArgs, err := BuildRangePreparedComparison(columns, []interface{}{3, 17}, LessThanOrEqualsComparisonSign)
+		require.NoError(t, err)
+		require.Equal(t, "(((`c1` < ?) or (`c1` is null)) or (((`c1` <=> ?)) AND (`c2` < ?)) or ((`c1` <=> ?) and (`c2` = ?)))", comparison)
+		require.Equal(t, []interface{}{3, 3, 17, 3, 17}, explodedArgs)
+	}
+	{
+		comparison, explodedArgs, err := BuildRangePreparedComparison(columns, []interface{}{nil, 17}, LessThanOrEqualsComparisonSign)
+		require.NoError(t, err)
+		require.Equal(t, "((`c1` < ?) or (((`c1` <=> ?)) AND (`c2` < ?)) or ((`c1` <=> ?) and (`c2` = ?)))", comparison)
+		require.Equal(t, []interface{}{nil, nil, 17, nil, 17}, explodedArgs)
+	}
+	{
+		// NULL in the middle of the key space: second column
+		columns := NewColumnList([]string{"c1", "c2"})
+		columns.GetColumn("c2").Nullable = true
+		comparison, explodedArgs, err := BuildRangePreparedComparison(columns, []interface{}{3, nil}, GreaterThanOrEqualsComparisonSign)
+		require.NoError(t, err)
+		require.Equal(t, "((`c1` > ?) or (((`c1` = ?)) AND (`c2` is not null)) or ((`c1` = ?) and (`c2` <=> ?)))", comparison)
+		require.Equal(t, []interface{}{3, 3, 3, nil}, explodedArgs)
+	}
+}
+
 func TestBuildRangeInsertQuery(t *testing.T) {
 	databaseName := "mydb"
 	originalTableName := "tbl"
@@ -171,7 +248,7 @@ func TestBuildRangeInsertQuery(t *testing.T) {
 		rangeStartArgs := []interface{}{3}
 		rangeEndArgs := []interface{}{103}
 
-		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true)
+		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true, "", "", nil, CopyConflictIgnore)
 		require.NoError(t, err)
 		expected := `
 			insert /* gh-ost mydb.tbl */ ignore
@@ -199,7 +276,7 @@ func TestBuildRangeInsertQuery(t *testing.T) {
 		rangeStartArgs := []interface{}{3, 17}
 		rangeEndArgs := []interface{}{103, 117}
 
-		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true)
+		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true, "", "", nil, CopyConflictIgnore)
 		require.NoError(t, err)
 		expected := `
 			insert /* gh-ost mydb.tbl */ ignore
@@ -241,7 +318,7 @@ func TestBuildRangeInsertQueryRenameMap(t *testing.T) {
 		rangeStartArgs := []interface{}{3}
 		rangeEndArgs := []interface{}{103}
 
-		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true)
+		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true, "", "", nil, CopyConflictIgnore)
 		require.NoError(t, err)
 		expected := `
 			insert /* gh-ost mydb.tbl */ ignore
@@ -270,7 +347,7 @@ func TestBuildRangeInsertQueryRenameMap(t *testing.T) {
 		rangeStartArgs := []interface{}{3, 17}
 		rangeEndArgs := []interface{}{103, 117}
 
-		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true)
+		query, explodedArgs, err := BuildRangeInsertQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, mappedSharedColumns, uniqueKey, uniqueKeyColumns, rangeStartValues, rangeEndValues, rangeStartArgs, rangeEndArgs, true, true, true, "", "", nil, CopyConflictIgnore)
 		require.NoError(t, err)
 		expected := `
 			insert /* gh-ost mydb.tbl */ ignore
@@ -294,6 +371,33 @@ func TestBuildRangeInsertQueryRenameMap(t *testing.T) {
 	}
 }
 
+func TestBuildRangeInsertQueryWithColumnTransforms(t *testing.T) {
+	sharedColumns := []string{"id", "email", "ssn"}
+	uniqueKeyColumns := NewColumnList([]string{"id"})
+	columnTransforms := []*ColumnTransform{
+		{Column: "ssn", Expression: "NULL"},
+		{Column: "email_hash", Expression: "SHA2(email, 256)"},
+	}
+	query, explodedArgs, err := BuildRangeInsertQuery("mydb", "tbl", "mydb", "ghost", sharedColumns, sharedColumns, "PRIMARY", uniqueKeyColumns, []string{"@v1s"}, []string{"@v1e"}, []interface{}{3}, []interface{}{103}, true, false, false, "", "", columnTransforms, CopyConflictIgnore)
+	require.NoError(t, err)
+	expected := `
+		insert /* gh-ost mydb.tbl */ ignore
+		into
+			mydb.ghost
+			(id, email, ssn, email_hash)
+		(
+			select id, email, (NULL), (SHA2(email, 256))
+			from
+				mydb.tbl
+			force index (PRIMARY)
+			where
+				(((id > @v1s) or ((id = @v1s)))
+				and ((id < @v1e) or ((id = @v1e))))
+		)`
+	require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	require.Equal(t, []interface{}{3, 3, 103, 103}, explodedArgs)
+}
+
 func TestBuildRangeInsertPreparedQuery(t *testing.T) {
 	databaseName := "mydb"
 	originalTableName := "tbl"
@@ -305,7 +409,7 @@ func TestBuildRangeInsertPreparedQuery(t *testing.T) {
 		rangeStartArgs := []interface{}{3, 17}
 		rangeEndArgs := []interface{}{103, 117}
 
-		query, explodedArgs, err := BuildRangeInsertPreparedQuery(databaseName, originalTableName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, true, true, true)
+		query, explodedArgs, err := BuildRangeInsertPreparedQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, true, true, true, "", "", nil, CopyConflictIgnore)
 		require.NoError(t, err)
 		expected := `
 			insert /* gh-ost mydb.tbl */ ignore
@@ -323,6 +427,76 @@ func TestBuildRangeInsertPreparedQuery(t *testing.T) {
 		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
 		require.Equal(t, []interface{}{3, 3, 17, 3, 17, 103, 103, 117, 103, 117}, explodedArgs)
 	}
+	{
+		uniqueKey := "PRIMARY"
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		rangeStartArgs := []interface{}{3}
+		rangeEndArgs := []interface{}{103}
+
+		query, explodedArgs, err := BuildRangeInsertPreparedQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, sharedColumns, uniqueKey, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, false, true, false, "p20240101", "", nil, CopyConflictIgnore)
+		require.NoError(t, err)
+		expected := `
+			insert /* gh-ost mydb.tbl */ ignore
+			into
+				mydb.ghost
+				(id, name, position)
+			(
+				select id, name, position
+				from
+					mydb.tbl partition (p20240101)
+				force index (PRIMARY)
+				where (((id > ?)) and ((id < ?) or ((id = ?))))
+				lock in share mode
+			)`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 103, 103}, explodedArgs)
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		query, _, err := BuildRangeInsertPreparedQuery(databaseName, originalTableName, databaseName, ghostTableName, sharedColumns, sharedColumns, "PRIMARY", uniqueKeyColumns, []interface{}{3}, []interface{}{103}, false, false, false, "", "NO_RANGE_OPTIMIZATION(tbl PRIMARY)", nil, CopyConflictIgnore)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "( select /*+ NO_RANGE_OPTIMIZATION(tbl PRIMARY) */ id, name, position from mydb.tbl force index (PRIMARY)")
+	}
+}
+
+func TestBuildRangeInsertQueryGhostDatabase(t *testing.T) {
+	sharedColumns := []string{"id", "name"}
+	uniqueKeyColumns := NewColumnList([]string{"id"})
+	query, _, err := BuildRangeInsertQuery("mydb", "tbl", "scratch", "ghost", sharedColumns, sharedColumns, "PRIMARY", uniqueKeyColumns, []string{"@v1s"}, []string{"@v1e"}, []interface{}{3}, []interface{}{103}, true, false, false, "", "", nil, CopyConflictIgnore)
+	require.NoError(t, err)
+	require.Contains(t, normalizeQuery(query), "into scratch.ghost (id, name) ( select id, name from mydb.tbl force index (PRIMARY)")
+}
+
+func TestBuildRangeInsertQueryCopyConflictStrategy(t *testing.T) {
+	sharedColumns := []string{"id", "name"}
+	mappedSharedColumns := []string{"id", "full_name"}
+	uniqueKeyColumns := NewColumnList([]string{"id"})
+	buildQuery := func(conflictStrategy CopyConflictStrategy) (string, error) {
+		query, _, err := BuildRangeInsertQuery("mydb", "tbl", "mydb", "ghost", sharedColumns, mappedSharedColumns, "PRIMARY", uniqueKeyColumns, []string{"@v1s"}, []string{"@v1e"}, []interface{}{3}, []interface{}{103}, true, true, false, "", "", nil, conflictStrategy)
+		return normalizeQuery(query), err
+	}
+	{
+		query, err := buildQuery(CopyConflictIgnore)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(query, "insert /* gh-ost mydb.tbl */ ignore into mydb.ghost (id, full_name)"))
+		require.True(t, strings.HasSuffix(query, "lock in share mode )"))
+	}
+	{
+		query, err := buildQuery(CopyConflictReplace)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(query, "replace /* gh-ost mydb.tbl */ into mydb.ghost (id, full_name)"))
+		require.True(t, strings.HasSuffix(query, "lock in share mode )"))
+	}
+	{
+		query, err := buildQuery(CopyConflictUpsert)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(query, "insert /* gh-ost mydb.tbl */ into mydb.ghost (id, full_name)"))
+		require.True(t, strings.HasSuffix(query, "lock in share mode ) on duplicate key update id=values(id), full_name=values(full_name)"))
+	}
+	{
+		_, err := buildQuery("overwrite")
+		require.Error(t, err)
+	}
 }
 
 func TestBuildUniqueKeyRangeEndPreparedQueryViaOffset(t *testing.T) {
@@ -334,7 +508,7 @@ func TestBuildUniqueKeyRangeEndPreparedQueryViaOffset(t *testing.T) {
 		rangeStartArgs := []interface{}{3, 17}
 		rangeEndArgs := []interface{}{103, 117}
 
-		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, false, "test")
+		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, false, "test", "", 0)
 		require.NoError(t, err)
 		expected := `
 			select /* gh-ost mydb.tbl test */
@@ -350,6 +524,41 @@ func TestBuildUniqueKeyRangeEndPreparedQueryViaOffset(t *testing.T) {
 		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
 		require.Equal(t, []interface{}{3, 3, 17, 103, 103, 117, 103, 117}, explodedArgs)
 	}
+	{
+		// mixed-direction key: (created_at DESC, id)
+		uniqueKeyColumns := NewColumnList([]string{"created_at", "id"})
+		uniqueKeyColumns.GetColumn("created_at").IsDescending = true
+		rangeStartArgs := []interface{}{3, 17}
+		rangeEndArgs := []interface{}{103, 117}
+
+		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, false, "test", "", 0)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl test */
+				created_at, id
+			from
+				mydb.tbl
+			where
+				((created_at < ?) or (((created_at = ?)) AND (id > ?))) and ((created_at > ?) or (((created_at = ?)) AND (id < ?)) or ((created_at = ?) and (id = ?)))
+			order by
+				created_at desc, id asc
+			limit 1
+			offset 499`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 3, 17, 103, 103, 117, 103, 117}, explodedArgs)
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		query, _, err := BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, originalTableName, uniqueKeyColumns, []interface{}{3}, []interface{}{103}, chunkSize, false, "test", "p1", 0)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "from mydb.tbl partition (p1) where")
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		query, _, err := BuildUniqueKeyRangeEndPreparedQueryViaOffset(databaseName, originalTableName, uniqueKeyColumns, []interface{}{3}, []interface{}{103}, chunkSize, false, "test", "", 2000)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(normalizeQuery(query), "select /*+ MAX_EXECUTION_TIME(2000) */ /* gh-ost mydb.tbl test */ id from"))
+	}
 }
 
 func TestBuildUniqueKeyRangeEndPreparedQueryViaTemptable(t *testing.T) {
@@ -361,7 +570,7 @@ func TestBuildUniqueKeyRangeEndPreparedQueryViaTemptable(t *testing.T) {
 		rangeStartArgs := []interface{}{3, 17}
 		rangeEndArgs := []interface{}{103, 117}
 
-		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, false, "test")
+		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, false, "test", "", 0)
 		require.NoError(t, err)
 		expected := `
 			select /* gh-ost mydb.tbl test */
@@ -382,6 +591,61 @@ func TestBuildUniqueKeyRangeEndPreparedQueryViaTemptable(t *testing.T) {
 		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
 		require.Equal(t, []interface{}{3, 3, 17, 103, 103, 117, 103, 117}, explodedArgs)
 	}
+	{
+		// mixed-direction key: (created_at DESC, id)
+		uniqueKeyColumns := NewColumnList([]string{"created_at", "id"})
+		uniqueKeyColumns.GetColumn("created_at").IsDescending = true
+		rangeStartArgs := []interface{}{3, 17}
+		rangeEndArgs := []interface{}{103, 117}
+
+		query, explodedArgs, err := BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, originalTableName, uniqueKeyColumns, rangeStartArgs, rangeEndArgs, chunkSize, true, "test", "", 0)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl test */
+				created_at, id
+			from (
+				select
+					created_at, id
+				from
+					mydb.tbl
+				where ((created_at < ?) or (((created_at = ?)) AND (id > ?)) or ((created_at = ?) and (id = ?))) and ((created_at > ?) or (((created_at = ?)) AND (id < ?)) or ((created_at = ?) and (id = ?)))
+				order by
+					created_at desc, id asc
+				limit 500
+			) select_osc_chunk
+			order by
+				created_at asc, id desc
+			limit 1`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 3, 17, 3, 17, 103, 103, 117, 103, 117}, explodedArgs)
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		query, _, err := BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, originalTableName, uniqueKeyColumns, []interface{}{3}, []interface{}{103}, chunkSize, false, "test", "p1", 0)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "from mydb.tbl partition (p1) where")
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"id"})
+		query, _, err := BuildUniqueKeyRangeEndPreparedQueryViaTemptable(databaseName, originalTableName, uniqueKeyColumns, []interface{}{3}, []interface{}{103}, chunkSize, false, "test", "", 2000)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(normalizeQuery(query), "select /*+ MAX_EXECUTION_TIME(2000) */ /* gh-ost mydb.tbl test */ id from ( select id from"))
+	}
+}
+
+func TestBuildCountRowsQuery(t *testing.T) {
+	require.Equal(t, "select /* gh-ost */ count(*) as count_rows from mydb.tbl", normalizeQuery(BuildCountRowsQuery("mydb", "tbl", 0)))
+	require.Equal(t, "select /*+ MAX_EXECUTION_TIME(500) */ /* gh-ost */ count(*) as count_rows from mydb.tbl", normalizeQuery(BuildCountRowsQuery("mydb", "tbl", 500)))
+}
+
+func TestBuildWarmUpIndexQuery(t *testing.T) {
+	require.Equal(t, "select /* gh-ost */ count(*) as count_rows from mydb._tbl_gho force index (PRIMARY)", normalizeQuery(BuildWarmUpIndexQuery("mydb", "_tbl_gho", "PRIMARY", 0)))
+	require.Equal(t, "select /*+ MAX_EXECUTION_TIME(500) */ /* gh-ost */ count(*) as count_rows from mydb._tbl_gho force index (name_idx)", normalizeQuery(BuildWarmUpIndexQuery("mydb", "_tbl_gho", "name_idx", 500)))
+}
+
+func TestValidateOptimizerHints(t *testing.T) {
+	require.NoError(t, ValidateOptimizerHints("INDEX(tbl idx) BKA(tbl)"))
+	require.Error(t, ValidateOptimizerHints("BKA(tbl) */ sleep(1) /*"))
 }
 
 func TestBuildUniqueKeyMinValuesPreparedQuery(t *testing.T) {
@@ -417,6 +681,67 @@ func TestBuildUniqueKeyMinValuesPreparedQuery(t *testing.T) {
 		`
 		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
 	}
+	{
+		// mixed-direction key: (created_at DESC, id)
+		uniqueKeyColumns := NewColumnList([]string{"created_at", "id"})
+		uniqueKeyColumns.GetColumn("created_at").IsDescending = true
+		uniqueKey := &UniqueKey{Name: "created_id_uidx", Columns: *uniqueKeyColumns}
+
+		query, err := BuildUniqueKeyMinValuesPreparedQuery(databaseName, originalTableName, uniqueKey)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "order by created_at desc, id asc")
+
+		query, err = BuildUniqueKeyMaxValuesPreparedQuery(databaseName, originalTableName, uniqueKey)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "order by created_at asc, id desc")
+	}
+}
+
+func TestBuildChunkSamplePreparedQuery(t *testing.T) {
+	columns := NewColumnList([]string{"id", "created_at", "name"})
+	{
+		uniqueKey := &UniqueKey{Name: "PRIMARY", Columns: *NewColumnList([]string{"id"})}
+		query, explodedArgs, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, uniqueKey, nil, 1000)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl */ id, created_at, name
+			  from
+			    mydb.tbl
+			  force index (PRIMARY)
+			  order by
+			    id asc
+			  limit 1000
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Empty(t, explodedArgs)
+	}
+	{
+		uniqueKey := &UniqueKey{Name: "PRIMARY", Columns: *NewColumnList([]string{"id"})}
+		query, explodedArgs, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, uniqueKey, []interface{}{500}, 1000)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "force index (PRIMARY) where ((id > ?) or ((id = ?))) order by id asc limit 1000")
+		require.Equal(t, []interface{}{500, 500}, explodedArgs)
+	}
+	{
+		uniqueKeyColumns := NewColumnList([]string{"created_at", "id"})
+		uniqueKeyColumns.GetColumn("created_at").IsDescending = true
+		uniqueKey := &UniqueKey{Name: "created_id_uidx", Columns: *uniqueKeyColumns}
+		query, _, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, uniqueKey, nil, 100)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "force index (created_id_uidx) order by created_at desc, id asc limit 100")
+
+		// Starting at a prefix of the key
+		query, explodedArgs, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, uniqueKey, []interface{}{"2025-01-01"}, 100)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "force index (created_id_uidx) where ((created_at < ?) or ((created_at = ?))) order by created_at desc, id asc limit 100")
+		require.Equal(t, []interface{}{"2025-01-01", "2025-01-01"}, explodedArgs)
+	}
+	{
+		_, _, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, &UniqueKey{Name: "PRIMARY"}, nil, 100)
+		require.Error(t, err)
+		_, _, err = BuildChunkSamplePreparedQuery("mydb", "tbl", columns, &UniqueKey{Name: "PRIMARY", Columns: *NewColumnList([]string{"id"})}, []interface{}{1, 2}, 100)
+		require.Error(t, err)
+	}
 }
 
 func TestBuildDMLDeleteQuery(t *testing.T) {
@@ -486,6 +811,19 @@ func TestBuildDMLDeleteQuery(t *testing.T) {
 	}
 }
 
+func TestBuildDMLDeleteQueryExoticIdentifiers(t *testing.T) {
+	tableColumns := NewColumnList([]string{"order", "col`tick", "$1st"})
+	uniqueKeyColumns := NewColumnList([]string{"order", "col`tick"})
+	builder, err := NewDMLDeleteQueryBuilder("my`db", "group", tableColumns, uniqueKeyColumns)
+	require.NoError(t, err)
+
+	query, uniqueKeyArgs, err := builder.BuildQuery([]interface{}{1, "a", 2})
+	require.NoError(t, err)
+	require.Contains(t, query, "`my``db`.`group`")
+	require.Contains(t, query, "((`order` = ?) and (`col``tick` = ?))")
+	require.Equal(t, []interface{}{1, "a"}, uniqueKeyArgs)
+}
+
 func TestBuildDMLDeleteQuerySignedUnsigned(t *testing.T) {
 	databaseName := "mydb"
 	tableName := "tbl"
@@ -526,6 +864,48 @@ func TestBuildDMLDeleteQuerySignedUnsigned(t *testing.T) {
 	}
 }
 
+func TestBuildDMLDeleteQueryGhostCast(t *testing.T) {
+	databaseName := "mydb"
+	tableName := "tbl"
+	tableColumns := NewColumnList([]string{"id", "name", "rank", "position", "age"})
+	uniqueKeyColumns := NewColumnList([]string{"name", "position"})
+	uniqueKeyColumns.SetGhostCast("position", "cast(? as signed)")
+	builder, err := NewDMLDeleteQueryBuilder(databaseName, tableName, tableColumns, uniqueKeyColumns)
+	require.NoError(t, err)
+	args := []interface{}{3, "testname", "first", 17, 23}
+	query, uniqueKeyArgs, err := builder.BuildQuery(args)
+	require.NoError(t, err)
+	expected := `
+		delete /* gh-ost mydb.tbl */
+			from
+				mydb.tbl
+			where
+				((name = ?) and (position = cast(? as signed)))
+	`
+	require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	require.Equal(t, []interface{}{"testname", 17}, uniqueKeyArgs)
+}
+
+func TestBuildGhostCastPreparedValue(t *testing.T) {
+	tests := []struct {
+		column   Column
+		expected string
+	}{
+		{Column{MySQLType: "int(11)"}, "cast(? as signed)"},
+		{Column{MySQLType: "bigint unsigned"}, "cast(? as unsigned)"},
+		{Column{MySQLType: "decimal(20,4)"}, "cast(? as decimal(20,4))"},
+		{Column{MySQLType: "varchar(191)", Charset: "utf8mb4", Collation: "utf8mb4_0900_ai_ci"}, "cast(? as char character set utf8mb4) collate utf8mb4_0900_ai_ci"},
+		{Column{MySQLType: "binary(16)"}, "cast(? as binary(16))"},
+		{Column{MySQLType: "varbinary(255)"}, "cast(? as binary)"},
+		{Column{MySQLType: "datetime(6)"}, "cast(? as datetime(6))"},
+		{Column{MySQLType: "date"}, "cast(? as date)"},
+		{Column{MySQLType: "enum('a','b')"}, ""},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, BuildGhostCastPreparedValue(&test.column), test.column.MySQLType)
+	}
+}
+
 func TestBuildDMLInsertQuery(t *testing.T) {
 	databaseName := "mydb"
 	tableName := "tbl"
@@ -533,7 +913,7 @@ func TestBuildDMLInsertQuery(t *testing.T) {
 	args := []interface{}{3, "testname", "first", 17, 23}
 	{
 		sharedColumns := NewColumnList([]string{"id", "name", "position", "age"})
-		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.NoError(t, err)
 		query, sharedArgs, err := builder.BuildQuery(args)
 		require.NoError(t, err)
@@ -549,7 +929,7 @@ func TestBuildDMLInsertQuery(t *testing.T) {
 	}
 	{
 		sharedColumns := NewColumnList([]string{"position", "name", "age", "id"})
-		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.NoError(t, err)
 		query, sharedArgs, err := builder.BuildQuery(args)
 		require.NoError(t, err)
@@ -565,16 +945,42 @@ func TestBuildDMLInsertQuery(t *testing.T) {
 	}
 	{
 		sharedColumns := NewColumnList([]string{"position", "name", "surprise", "id"})
-		_, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		_, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.Error(t, err)
 	}
 	{
 		sharedColumns := NewColumnList([]string{})
-		_, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		_, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.Error(t, err)
 	}
 }
 
+func TestBuildDMLInsertQueryWithColumnTransforms(t *testing.T) {
+	databaseName := "mydb"
+	tableName := "tbl"
+	tableColumns := NewColumnList([]string{"id", "email", "ssn", "legacy"})
+	sharedColumns := NewColumnList([]string{"id", "email", "ssn"})
+	columnTransforms := []*ColumnTransform{
+		{Column: "SSN", Expression: "NULL"},
+		{Column: "email_hash", Expression: "SHA2(email, 256)"},
+	}
+	builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, columnTransforms)
+	require.NoError(t, err)
+	query, args, err := builder.BuildQuery([]interface{}{3, "user@example.com", "123-45-6789", 7})
+	require.NoError(t, err)
+	expected := `
+		replace /* gh-ost mydb.tbl */
+			into mydb.tbl
+				(id, email, ssn, email_hash)
+			select
+				id, email, (NULL), (SHA2(email, 256))
+			from
+				(select ? as id, ? as email, ? as ssn, ? as legacy) as gh_ost_row
+	`
+	require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	require.Equal(t, []interface{}{3, "user@example.com", "123-45-6789", 7}, args)
+}
+
 func TestBuildDMLInsertQuerySignedUnsigned(t *testing.T) {
 	databaseName := "mydb"
 	tableName := "tbl"
@@ -584,7 +990,7 @@ func TestBuildDMLInsertQuerySignedUnsigned(t *testing.T) {
 		// testing signed
 		args := []interface{}{3, "testname", "first", int8(-1), 23}
 		sharedColumns := NewColumnList([]string{"id", "name", "position", "age"})
-		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.NoError(t, err)
 		query, sharedArgs, err := builder.BuildQuery(args)
 		require.NoError(t, err)
@@ -602,7 +1008,7 @@ func TestBuildDMLInsertQuerySignedUnsigned(t *testing.T) {
 		// testing unsigned
 		args := []interface{}{3, "testname", "first", int8(-1), 23}
 		sharedColumns.SetUnsigned("position")
-		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.NoError(t, err)
 		query, sharedArgs, err := builder.BuildQuery(args)
 		require.NoError(t, err)
@@ -620,7 +1026,7 @@ func TestBuildDMLInsertQuerySignedUnsigned(t *testing.T) {
 		// testing unsigned
 		args := []interface{}{3, "testname", "first", int32(-1), 23}
 		sharedColumns.SetUnsigned("position")
-		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns)
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
 		require.NoError(t, err)
 		query, sharedArgs, err := builder.BuildQuery(args)
 		require.NoError(t, err)
@@ -747,6 +1153,180 @@ func TestBuildDMLUpdateQuery(t *testing.T) {
 	}
 }
 
+func TestBuildDMLQueriesWithNullableUniqueKeyColumn(t *testing.T) {
+	databaseName := "mydb"
+	tableName := "tbl"
+	tableColumns := NewColumnList([]string{"id", "name", "position"})
+	sharedColumns := NewColumnList([]string{"id", "name", "position"})
+	uniqueKeyColumns := NewColumnList([]string{"name", "position"})
+	uniqueKeyColumns.GetColumn("name").Nullable = true
+	args := []interface{}{3, nil, 17}
+	{
+		builder, err := NewDMLDeleteQueryBuilder(databaseName, tableName, tableColumns, uniqueKeyColumns)
+		require.NoError(t, err)
+		query, uniqueKeyArgs, err := builder.BuildQuery(args)
+		require.NoError(t, err)
+		expected := `
+			delete /* gh-ost mydb.tbl */
+				from mydb.tbl
+				where
+					((name <=> ?) and (position = ?))
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{nil, 17}, uniqueKeyArgs)
+	}
+	{
+		builder, err := NewDMLUpdateQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, uniqueKeyColumns)
+		require.NoError(t, err)
+		query, _, uniqueKeyArgs, err := builder.BuildQuery(args, args)
+		require.NoError(t, err)
+		expected := `
+			update /* gh-ost mydb.tbl */
+			  mydb.tbl
+					set id=?, name=?, position=?
+				where
+					((name <=> ?) and (position = ?))
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{nil, 17}, uniqueKeyArgs)
+	}
+}
+
+func TestBuildNullableUniqueKeyDuplicatesQuery(t *testing.T) {
+	uniqueKey := &UniqueKey{Name: "name_position_uidx", Columns: *NewColumnList([]string{"name", "position"})}
+	{
+		_, err := BuildNullableUniqueKeyDuplicatesQuery("mydb", "tbl", uniqueKey, 10)
+		require.Error(t, err)
+	}
+	{
+		uniqueKey.Columns.GetColumn("name").Nullable = true
+		query, err := BuildNullableUniqueKeyDuplicatesQuery("mydb", "tbl", uniqueKey, 10)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl */ name, position, count(*) as duplicates_count
+			from mydb.tbl
+			force index (name_position_uidx)
+			where name is null
+			group by name, position
+			having count(*) > 1
+			limit 10
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	}
+}
+
+func TestBuildFullRowUniqueKeyQueries(t *testing.T) {
+	columns := NewColumnList([]string{"name", "position"})
+	columns.GetColumn("position").Nullable = true
+	uniqueKey := NewFullRowUniqueKey(columns)
+	{
+		query, err := BuildUniqueKeyMinValuesPreparedQuery("mydb", "tbl", uniqueKey)
+		require.NoError(t, err)
+		require.Equal(t, "select /* gh-ost mydb.tbl */ name, position from mydb.tbl order by name asc, position asc limit 1", normalizeQuery(query))
+	}
+	{
+		query, _, err := BuildChunkSamplePreparedQuery("mydb", "tbl", columns, uniqueKey, nil, 100)
+		require.NoError(t, err)
+		require.Equal(t, "select /* gh-ost mydb.tbl */ name, position from mydb.tbl order by name asc, position asc limit 100", normalizeQuery(query))
+	}
+	{
+		query, err := BuildNullableUniqueKeyDuplicatesQuery("mydb", "tbl", uniqueKey, 10)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "from mydb.tbl where position is null")
+	}
+	{
+		query, _, err := BuildRangeInsertPreparedQuery("mydb", "tbl", "mydb", "ghost", columns.Names(), columns.Names(), uniqueKey.IndexName(), &uniqueKey.Columns, []interface{}{"a", 1}, []interface{}{"z", 9}, true, false, false, "", "", nil, CopyConflictIgnore)
+		require.NoError(t, err)
+		require.Contains(t, normalizeQuery(query), "( select name, position from mydb.tbl where")
+		require.NotContains(t, query, "force index")
+	}
+}
+
+func TestBuildFullRowDuplicatesQuery(t *testing.T) {
+	{
+		query, err := BuildFullRowDuplicatesQuery("mydb", "tbl", NewColumnList([]string{"name", "position"}), 1)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl */ count(*)
+			from (
+				select 1
+				from mydb.tbl
+				group by name, position
+				having count(*) > 1
+				limit 1
+			) duplicate_rows
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	}
+	{
+		_, err := BuildFullRowDuplicatesQuery("mydb", "tbl", NewColumnList([]string{}), 1)
+		require.Error(t, err)
+	}
+}
+
+func TestBuildDMLQueriesWithGeneratedUniqueKeyColumn(t *testing.T) {
+	databaseName := "mydb"
+	tableName := "tbl"
+	// "total" is a generated column: not shared (MySQL computes it), yet part of the unique key
+	tableColumns := NewColumnList([]string{"id", "a", "b", "total"})
+	sharedColumns := NewColumnList([]string{"id", "a", "b"})
+	uniqueKeyColumns := NewColumnList([]string{"total", "id"})
+	uniqueKeyColumns.GetColumn("total").IsVirtual = true
+	valueArgs := []interface{}{3, 10, 20, 30}
+	whereArgs := []interface{}{3, 1, 2, 3}
+	{
+		builder, err := NewDMLUpdateQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, uniqueKeyColumns)
+		require.NoError(t, err)
+		query, sharedArgs, uniqueKeyArgs, err := builder.BuildQuery(valueArgs, whereArgs)
+		require.NoError(t, err)
+		expected := `
+			update /* gh-ost mydb.tbl */
+			  mydb.tbl
+					set id=?, a=?, b=?
+				where
+					((total = ?) and (id = ?))
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 10, 20}, sharedArgs)
+		require.Equal(t, []interface{}{3, 3}, uniqueKeyArgs)
+	}
+	{
+		builder, err := NewDMLDeleteQueryBuilder(databaseName, tableName, tableColumns, uniqueKeyColumns)
+		require.NoError(t, err)
+		query, uniqueKeyArgs, err := builder.BuildQuery(whereArgs)
+		require.NoError(t, err)
+		expected := `
+			delete /* gh-ost mydb.tbl */
+				from mydb.tbl
+				where
+					((total = ?) and (id = ?))
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 3}, uniqueKeyArgs)
+	}
+	{
+		builder, err := NewDMLInsertQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, nil)
+		require.NoError(t, err)
+		query, sharedArgs, err := builder.BuildQuery(valueArgs)
+		require.NoError(t, err)
+		expected := `
+			replace /* gh-ost mydb.tbl */
+				into mydb.tbl
+					(id, a, b)
+				values
+					(?, ?, ?)
+		`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+		require.Equal(t, []interface{}{3, 10, 20}, sharedArgs)
+	}
+	{
+		// a unique key column which is neither shared nor generated cannot be resolved
+		uniqueKeyColumns := NewColumnList([]string{"total", "id"})
+		_, err := NewDMLUpdateQueryBuilder(databaseName, tableName, tableColumns, sharedColumns, sharedColumns, uniqueKeyColumns)
+		require.Error(t, err)
+	}
+}
+
 func TestBuildDMLUpdateQuerySignedUnsigned(t *testing.T) {
 	databaseName := "mydb"
 	tableName := "tbl"
@@ -804,11 +1384,13 @@ func TestCheckpointQueryBuilder(t *testing.T) {
 		insert /* gh-ost */ into mydb._tbl_ghk
 		(gh_ost_chk_timestamp, gh_ost_chk_coords, gh_ost_chk_iteration,
 		 gh_ost_rows_copied, gh_ost_dml_applied, gh_ost_is_cutover,
+		 gh_ost_chk_partition_index,
 		 name_min, position_min, my_very_long_column_that_is_64_utf8_characters_long_很长很长很长很长_min,
 		 name_max, position_max, my_very_long_column_that_is_64_utf8_characters_long_很长很长很长很长_max)
 		values
 		(unix_timestamp(now()), ?, ?,
 			 ?, ?, ?,
+			 ?,
 			 ?, ?, ?,
 			 ?, ?, ?)
     `
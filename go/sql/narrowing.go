@@ -0,0 +1,183 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	lengthColumnTypeRegexp  = regexp.MustCompile(`^(char|varchar|binary|varbinary)[(]([0-9]+)[)]`)
+	integerColumnTypeRegexp = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\b`)
+	setValuesRegexp         = regexp.MustCompile("^set[(](.*)[)]$")
+)
+
+// max lengths of the TEXT/BLOB family, which have no explicit length in their column type
+var implicitColumnTypeLengths = map[string]uint64{
+	"tinytext":   255,
+	"text":       65535,
+	"mediumtext": 16777215,
+	"longtext":   4294967295,
+	"tinyblob":   255,
+	"blob":       65535,
+	"mediumblob": 16777215,
+	"longblob":   4294967295,
+}
+
+type integerRange struct {
+	min int64
+	max uint64
+}
+
+var signedIntegerRanges = map[string]integerRange{
+	"tinyint":   {min: -128, max: 127},
+	"smallint":  {min: -32768, max: 32767},
+	"mediumint": {min: -8388608, max: 8388607},
+	"int":       {min: -2147483648, max: 2147483647},
+	"bigint":    {min: -9223372036854775808, max: 9223372036854775807},
+}
+
+var unsignedIntegerRanges = map[string]integerRange{
+	"tinyint":   {min: 0, max: 255},
+	"smallint":  {min: 0, max: 65535},
+	"mediumint": {min: 0, max: 16777215},
+	"int":       {min: 0, max: 4294967295},
+	"bigint":    {min: 0, max: 18446744073709551615},
+}
+
+// parseLengthColumnType returns the max length of a character or binary column type,
+// and whether the length is measured in bytes (binary types) rather than characters.
+func parseLengthColumnType(columnType string) (length uint64, isBinary bool, ok bool) {
+	if submatch := lengthColumnTypeRegexp.FindStringSubmatch(columnType); len(submatch) > 0 {
+		length, err := strconv.ParseUint(submatch[2], 10, 64)
+		if err != nil {
+			return 0, false, false
+		}
+		return length, strings.HasSuffix(submatch[1], "binary"), true
+	}
+	if length, ok := implicitColumnTypeLengths[columnType]; ok {
+		return length, strings.HasSuffix(columnType, "blob"), true
+	}
+	return 0, false, false
+}
+
+func parseIntegerColumnType(columnType string) (result integerRange, ok bool) {
+	submatch := integerColumnTypeRegexp.FindStringSubmatch(columnType)
+	if len(submatch) == 0 {
+		return result, false
+	}
+	if strings.Contains(columnType, "unsigned") {
+		return unsignedIntegerRanges[submatch[1]], true
+	}
+	return signedIntegerRanges[submatch[1]], true
+}
+
+// ParseEnumOrSetMembers returns the quoted members of an ENUM or SET column type,
+// e.g. `enum('a','b')` returns `'a'`, `'b'`.
+func ParseEnumOrSetMembers(columnType string) (members []string) {
+	var values string
+	if submatch := enumValuesRegexp.FindStringSubmatch(columnType); len(submatch) > 0 {
+		values = submatch[1]
+	} else if submatch := setValuesRegexp.FindStringSubmatch(columnType); len(submatch) > 0 {
+		values = submatch[1]
+	} else {
+		return members
+	}
+	var member strings.Builder
+	inQuote := false
+	for i := 0; i < len(values); i++ {
+		c := values[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(values) && values[i+1] == '\'':
+			// escaped quote within member
+			member.WriteString("''")
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+			member.WriteByte(c)
+		case c == ',' && !inQuote:
+			members = append(members, member.String())
+			member.Reset()
+		default:
+			member.WriteByte(c)
+		}
+	}
+	if member.Len() > 0 {
+		members = append(members, member.String())
+	}
+	return members
+}
+
+// BuildNarrowingColumnCondition returns a condition matching the rows whose value in given
+// column would not fit after the column type changes from fromType to toType.
+// An empty result means the change is not known to be narrowing.
+func BuildNarrowingColumnCondition(columnName, fromType, toType string) string {
+	fromType = strings.TrimSpace(fromType)
+	toType = strings.TrimSpace(toType)
+	if fromType == toType {
+		return ""
+	}
+	columnName = EscapeName(columnName)
+	// keywords are matched case insensitively; ENUM/SET members keep their case
+	lowerFromType := strings.ToLower(fromType)
+	lowerToType := strings.ToLower(toType)
+
+	if fromLength, fromIsBinary, ok := parseLengthColumnType(lowerFromType); ok {
+		toLength, toIsBinary, ok := parseLengthColumnType(lowerToType)
+		if !ok || fromIsBinary != toIsBinary || toLength >= fromLength {
+			return ""
+		}
+		if toIsBinary {
+			return fmt.Sprintf("length(%s) > %d", columnName, toLength)
+		}
+		return fmt.Sprintf("char_length(%s) > %d", columnName, toLength)
+	}
+
+	if fromRange, ok := parseIntegerColumnType(lowerFromType); ok {
+		toRange, ok := parseIntegerColumnType(lowerToType)
+		if !ok {
+			return ""
+		}
+		conditions := []string{}
+		if fromRange.min < toRange.min {
+			conditions = append(conditions, fmt.Sprintf("%s < %d", columnName, toRange.min))
+		}
+		if fromRange.max > toRange.max {
+			conditions = append(conditions, fmt.Sprintf("%s > %d", columnName, toRange.max))
+		}
+		return strings.Join(conditions, " or ")
+	}
+
+	isEnum := strings.HasPrefix(lowerFromType, "enum") && strings.HasPrefix(lowerToType, "enum")
+	isSet := strings.HasPrefix(lowerFromType, "set") && strings.HasPrefix(lowerToType, "set")
+	if isEnum || isSet {
+		toMembers := make(map[string]bool)
+		for _, member := range ParseEnumOrSetMembers(toType) {
+			toMembers[member] = true
+		}
+		removedMembers := []string{}
+		for _, member := range ParseEnumOrSetMembers(fromType) {
+			if !toMembers[member] {
+				removedMembers = append(removedMembers, member)
+			}
+		}
+		if len(removedMembers) == 0 {
+			return ""
+		}
+		if isEnum {
+			return fmt.Sprintf("%s in (%s)", columnName, strings.Join(removedMembers, ", "))
+		}
+		conditions := make([]string, len(removedMembers))
+		for i, member := range removedMembers {
+			conditions[i] = fmt.Sprintf("find_in_set(%s, %s) > 0", member, columnName)
+		}
+		return strings.Join(conditions, " or ")
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright 2025 GitHub Inc.
+	 See https://github.com/github/gh-ost/blob/master/LICENSE
+*/
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnumOrSetMembers(t *testing.T) {
+	require.Equal(t, []string{"'a'", "'b'"}, ParseEnumOrSetMembers("enum('a','b')"))
+	require.Equal(t, []string{"'a,b'", "'it''s'"}, ParseEnumOrSetMembers("set('a,b','it''s')"))
+	require.Nil(t, ParseEnumOrSetMembers("varchar(10)"))
+}
+
+func TestBuildNarrowingColumnCondition(t *testing.T) {
+	{
+		require.Equal(t, "", BuildNarrowingColumnCondition("name", "varchar(10)", "varchar(10)"))
+		require.Equal(t, "", BuildNarrowingColumnCondition("name", "varchar(10)", "varchar(20)"))
+		require.Equal(t, "char_length(`name`) > 10", BuildNarrowingColumnCondition("name", "varchar(100)", "varchar(10)"))
+		require.Equal(t, "char_length(`name`) > 255", BuildNarrowingColumnCondition("name", "text", "varchar(255)"))
+		require.Equal(t, "length(`data`) > 16", BuildNarrowingColumnCondition("data", "varbinary(32)", "binary(16)"))
+		require.Equal(t, "", BuildNarrowingColumnCondition("data", "varbinary(32)", "varchar(16)"))
+	}
+	{
+		require.Equal(t, "", BuildNarrowingColumnCondition("id", "smallint", "int"))
+		require.Equal(t, "`id` < -32768 or `id` > 32767", BuildNarrowingColumnCondition("id", "int", "smallint"))
+		require.Equal(t, "`id` > 2147483647", BuildNarrowingColumnCondition("id", "int unsigned", "int"))
+		require.Equal(t, "`id` < 0", BuildNarrowingColumnCondition("id", "int(11)", "int(10) unsigned"))
+		require.Equal(t, "", BuildNarrowingColumnCondition("id", "int", "bigint"))
+	}
+	{
+		require.Equal(t, "", BuildNarrowingColumnCondition("e", "enum('a','b')", "enum('a','b','c')"))
+		require.Equal(t, "`e` in ('b', 'c')", BuildNarrowingColumnCondition("e", "enum('a','b','c')", "enum('a')"))
+		require.Equal(t, "`e` in ('B')", BuildNarrowingColumnCondition("e", "enum('A','B')", "enum('A','b')"))
+		require.Equal(t, "find_in_set('b', `s`) > 0", BuildNarrowingColumnCondition("s", "set('a','b')", "set('a')"))
+	}
+}
+
+func TestBuildNarrowingColumnCountQuery(t *testing.T) {
+	{
+		query, err := BuildNarrowingColumnCountQuery("mydb", "tbl", "name", "char_length(`name`) > 10", 100000, 1000)
+		require.NoError(t, err)
+		expected := `
+			select /* gh-ost mydb.tbl */ count(*)
+			from (
+				select 1 from (
+					select name from mydb.tbl limit 100000
+				) narrowing_sample
+				where char_length(name) > 10 limit 1000
+			) narrowing_rows`
+		require.Equal(t, normalizeQuery(expected), normalizeQuery(query))
+	}
+	{
+		_, err := BuildNarrowingColumnCountQuery("mydb", "tbl", "name", "", 100000, 1000)
+		require.Error(t, err)
+	}
+}
@@ -7,7 +7,6 @@ package sql
 
 import (
 	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -15,6 +14,7 @@ var (
 	sanitizeQuotesRegexp                 = regexp.MustCompile("('[^']*')")
 	renameColumnRegexp                   = regexp.MustCompile(`(?i)\bchange\s+(column\s+|)([\S]+)\s+([\S]+)\s+`)
 	dropColumnRegexp                     = regexp.MustCompile(`(?i)\bdrop\s+(column\s+|)([\S]+)$`)
+	modifyColumnRegexp                   = regexp.MustCompile(`(?i)\bmodify\s+(column\s+|)([\S]+)\s+`)
 	renameTableRegexp                    = regexp.MustCompile(`(?i)\brename\s+(to|as)\s+`)
 	autoIncrementRegexp                  = regexp.MustCompile(`(?i)\bauto_increment[\s]*=[\s]*([0-9]+)`)
 	alterTableExplicitSchemaTableRegexps = []*regexp.Regexp{
@@ -33,12 +33,14 @@ var (
 		// ALTER TABLE tbl something
 		regexp.MustCompile(`(?i)\balter\s+table\s+([\S]+)\s+(.*$)`),
 	}
-	enumValuesRegexp = regexp.MustCompile("^enum[(](.*)[)]$")
+	enumValuesRegexp          = regexp.MustCompile("^enum[(](.*)[)]$")
+	indexOnlyAlterTokenRegexp = regexp.MustCompile(`(?i)^(add\s+((unique|fulltext|spatial)\s+)?(index|key)|drop\s+(index|key))\b`)
 )
 
 type AlterTableParser struct {
 	columnRenameMap        map[string]string
 	droppedColumns         map[string]bool
+	modifiedColumns        map[string]bool
 	isRenameTable          bool
 	isAutoIncrementDefined bool
 
@@ -53,6 +55,7 @@ func NewAlterTableParser() *AlterTableParser {
 	return &AlterTableParser{
 		columnRenameMap: make(map[string]string),
 		droppedColumns:  make(map[string]bool),
+		modifiedColumns: make(map[string]bool),
 	}
 }
 
@@ -100,23 +103,22 @@ func (this *AlterTableParser) parseAlterToken(alterToken string) {
 		// rename
 		allStringSubmatch := renameColumnRegexp.FindAllStringSubmatch(alterToken, -1)
 		for _, submatch := range allStringSubmatch {
-			if unquoted, err := strconv.Unquote(submatch[2]); err == nil {
-				submatch[2] = unquoted
-			}
-			if unquoted, err := strconv.Unquote(submatch[3]); err == nil {
-				submatch[3] = unquoted
-			}
-			this.columnRenameMap[submatch[2]] = submatch[3]
+			this.columnRenameMap[UnquoteIdentifier(submatch[2])] = UnquoteIdentifier(submatch[3])
+			this.modifiedColumns[UnquoteIdentifier(submatch[2])] = true
+		}
+	}
+	{
+		// modify
+		allStringSubmatch := modifyColumnRegexp.FindAllStringSubmatch(alterToken, -1)
+		for _, submatch := range allStringSubmatch {
+			this.modifiedColumns[UnquoteIdentifier(submatch[2])] = true
 		}
 	}
 	{
 		// drop
 		allStringSubmatch := dropColumnRegexp.FindAllStringSubmatch(alterToken, -1)
 		for _, submatch := range allStringSubmatch {
-			if unquoted, err := strconv.Unquote(submatch[2]); err == nil {
-				submatch[2] = unquoted
-			}
-			this.droppedColumns[submatch[2]] = true
+			this.droppedColumns[UnquoteIdentifier(submatch[2])] = true
 		}
 	}
 	{
@@ -176,10 +178,28 @@ func (this *AlterTableParser) DroppedColumnsMap() map[string]bool {
 	return this.droppedColumns
 }
 
+// ModifiedColumnsMap returns the columns redefined via MODIFY or CHANGE, by their original names
+func (this *AlterTableParser) ModifiedColumnsMap() map[string]bool {
+	return this.modifiedColumns
+}
+
 func (this *AlterTableParser) IsRenameTable() bool {
 	return this.isRenameTable
 }
 
+// IsIndexOnly returns true when the ALTER statement does nothing but add and/or drop indexes
+func (this *AlterTableParser) IsIndexOnly() bool {
+	if len(this.alterTokens) == 0 {
+		return false
+	}
+	for _, alterToken := range this.alterTokens {
+		if !indexOnlyAlterTokenRegexp.MatchString(alterToken) {
+			return false
+		}
+	}
+	return true
+}
+
 func (this *AlterTableParser) IsAutoIncrementDefined() bool {
 	return this.isAutoIncrementDefined
 }
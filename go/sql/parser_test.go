@@ -184,6 +184,41 @@ func TestParseAlterStatementDroppedColumns(t *testing.T) {
 		require.Len(t, parser.droppedColumns, 1)
 		require.True(t, parser.droppedColumns["b"])
 	}
+	{
+		parser := NewAlterTableParser()
+		statement := "drop column `order`, drop column `col``tick`, drop `$1st`"
+		err := parser.ParseAlterStatement(statement)
+		require.NoError(t, err)
+		require.Len(t, parser.droppedColumns, 3)
+		require.True(t, parser.droppedColumns["order"])
+		require.True(t, parser.droppedColumns["col`tick"])
+		require.True(t, parser.droppedColumns["$1st"])
+	}
+}
+
+func TestParseAlterStatementModifiedColumns(t *testing.T) {
+	{
+		parser := NewAlterTableParser()
+		statement := "modify column id bigint unsigned not null, change `uuid` `uuid` varchar(64) not null, change column c d int, add column e int, drop column f"
+		err := parser.ParseAlterStatement(statement)
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"id": true, "uuid": true, "c": true}, parser.ModifiedColumnsMap())
+	}
+	{
+		parser := NewAlterTableParser()
+		statement := "modify `order` int not null default 0, alter column g set default 1, add key modify_idx(h)"
+		err := parser.ParseAlterStatement(statement)
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"order": true}, parser.ModifiedColumnsMap())
+	}
+}
+
+func TestParseAlterStatementRenameQuotedColumn(t *testing.T) {
+	parser := NewAlterTableParser()
+	statement := "change column `col``tick` `key` int"
+	err := parser.ParseAlterStatement(statement)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"col`tick": "key"}, parser.GetNonTrivialRenames())
 }
 
 func TestParseAlterStatementRenameTable(t *testing.T) {
@@ -318,6 +353,27 @@ func TestParseAlterStatementExplicitTable(t *testing.T) {
 	}
 }
 
+func TestParseAlterStatementIsIndexOnly(t *testing.T) {
+	statements := map[string]bool{
+		"add index idx_a (a)":                                true,
+		"ADD KEY `idx_a` (a), DROP KEY idx_b":                true,
+		"add unique key uk (a, b)":                           true,
+		"add fulltext index ft (c), drop index `key`":        true,
+		"drop index idx_a, add column i int":                 false,
+		"add primary key (id)":                               false,
+		"drop primary key":                                   false,
+		"add constraint fk foreign key (a) references t(id)": false,
+		"add keyword int":                                    false,
+		"engine=innodb":                                      false,
+	}
+	for statement, expected := range statements {
+		parser := NewAlterTableParser()
+		require.NoError(t, parser.ParseAlterStatement(statement))
+		require.Equal(t, expected, parser.IsIndexOnly(), statement)
+	}
+	require.False(t, NewAlterTableParser().IsIndexOnly())
+}
+
 func TestParseEnumValues(t *testing.T) {
 	{
 		s := "enum('red','green','blue','orange')"
@@ -9,10 +9,13 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+var partitionColumnRegexp = regexp.MustCompile("^[0-9a-zA-Z$_]+$")
+
 type ColumnType int
 
 const (
@@ -51,10 +54,20 @@ type Column struct {
 	// add Octet length for binary type, fix bytes with suffix "00" get clipped in mysql binlog.
 	// https://github.com/github/gh-ost/issues/909
 	BinaryOctetLength uint
+	// OctetLength is the maximum length in bytes of a character or binary string column
+	OctetLength       uint
 	charsetConversion *CharacterSetConversion
 	CharacterSetName  string
 	Nullable          bool
-	MySQLType         string
+	// HasDefault is set when inserts may omit the column: it has a default value or is auto_increment
+	HasDefault bool
+	MySQLType  string
+	Collation  string
+	// IsDescending applies to unique key columns, and marks a descending index part (MySQL 8.0)
+	IsDescending bool
+	// ghostCast applies to unique key columns whose type is changed by the ALTER, and is the prepared value
+	// their values are compared as on the ghost table, e.g. "cast(? as signed)"
+	ghostCast string
 }
 
 func (this *Column) convertArg(arg interface{}, isUniqueKeyColumn bool) interface{} {
@@ -119,6 +132,70 @@ func (this *Column) convertArg(arg interface{}, isUniqueKeyColumn bool) interfac
 	return arg
 }
 
+// KeyByteLength returns the number of bytes the column's values take in an index, by its MySQL type.
+// ok is false for types that may only be indexed by a prefix, such as TEXT and BLOB, or are unknown.
+func (this *Column) KeyByteLength() (length uint, ok bool) {
+	columnType := strings.ToLower(this.MySQLType)
+	var args []uint
+	if open := strings.Index(columnType, "("); open >= 0 {
+		if end := strings.Index(columnType[open:], ")"); end >= 0 {
+			for _, arg := range strings.Split(columnType[open+1:open+end], ",") {
+				if n, err := strconv.ParseUint(strings.TrimSpace(arg), 10, 32); err == nil {
+					args = append(args, uint(n))
+				}
+			}
+		}
+		columnType = columnType[:open]
+	}
+	columnType, _, _ = strings.Cut(columnType, " ")
+	arg := func(i int, defaultValue uint) uint {
+		if i < len(args) {
+			return args[i]
+		}
+		return defaultValue
+	}
+	// fractional seconds take one byte per two digits
+	fractionalSecondsLength := (arg(0, 0) + 1) / 2
+	switch columnType {
+	case "tinyint", "bool", "boolean", "year":
+		return 1, true
+	case "smallint":
+		return 2, true
+	case "mediumint", "date":
+		return 3, true
+	case "int", "integer", "float":
+		return 4, true
+	case "bigint", "double", "real":
+		return 8, true
+	case "decimal", "numeric":
+		return decimalByteLength(arg(0, 10)-arg(1, 0)) + decimalByteLength(arg(1, 0)), true
+	case "time":
+		return 3 + fractionalSecondsLength, true
+	case "datetime":
+		return 5 + fractionalSecondsLength, true
+	case "timestamp":
+		return 4 + fractionalSecondsLength, true
+	case "bit":
+		return (arg(0, 1) + 7) / 8, true
+	case "enum":
+		return 2, true
+	case "set":
+		return 8, true
+	case "char", "varchar", "binary", "varbinary":
+		if this.OctetLength > 0 {
+			return this.OctetLength, true
+		}
+		return this.BinaryOctetLength, this.BinaryOctetLength > 0
+	}
+	return 0, false
+}
+
+// decimalByteLength returns the storage of given number of DECIMAL digits: four bytes per nine digits,
+// and fewer for the remaining digits
+func decimalByteLength(digits uint) uint {
+	return digits/9*4 + [9]uint{0, 1, 1, 2, 2, 3, 3, 4, 4}[digits%9]
+}
+
 func NewColumns(names []string) []Column {
 	result := make([]Column, len(names))
 	for i := range names {
@@ -268,14 +345,62 @@ func (this *ColumnList) FilterBy(f func(Column) bool) *ColumnList {
 	return &ColumnList{Ordinals: this.Ordinals, columns: filteredCols}
 }
 
+// nullableFlags returns, per column, whether the column is nullable
+func (this *ColumnList) nullableFlags() []bool {
+	flags := make([]bool, len(this.columns))
+	for i := range this.columns {
+		flags[i] = this.columns[i].Nullable
+	}
+	return flags
+}
+
+// descendingFlags returns, per column, whether the column is a descending index part
+func (this *ColumnList) descendingFlags() []bool {
+	flags := make([]bool, len(this.columns))
+	for i := range this.columns {
+		flags[i] = this.columns[i].IsDescending
+	}
+	return flags
+}
+
 func (this *ColumnList) Len() int {
 	return len(this.columns)
 }
 
+// SetGhostCast has values of given unique key column compared on the ghost table as given prepared value
+func (this *ColumnList) SetGhostCast(columnName string, preparedValue string) {
+	this.GetColumn(columnName).ghostCast = preparedValue
+}
+
 func (this *ColumnList) SetCharsetConversion(columnName string, fromCharset string, toCharset string) {
 	this.GetColumn(columnName).charsetConversion = &CharacterSetConversion{FromCharset: fromCharset, ToCharset: toCharset}
 }
 
+// ColumnTransform rewrites the values written to a ghost table column with an SQL expression,
+// evaluated against the original table's row
+type ColumnTransform struct {
+	Column     string
+	Expression string
+}
+
+// ParseColumnTransform parses a `target_col=SQL_EXPRESSION` transform
+func ParseColumnTransform(transform string) (*ColumnTransform, error) {
+	tokens := strings.SplitN(transform, "=", 2)
+	if len(tokens) != 2 {
+		return nil, fmt.Errorf("Invalid column transform %q; expected target_col=SQL_EXPRESSION", transform)
+	}
+	column := strings.TrimSpace(tokens[0])
+	expression := strings.TrimSpace(tokens[1])
+	if column == "" || expression == "" {
+		return nil, fmt.Errorf("Invalid column transform %q; expected target_col=SQL_EXPRESSION", transform)
+	}
+	return &ColumnTransform{Column: column, Expression: expression}, nil
+}
+
+func (this *ColumnTransform) String() string {
+	return fmt.Sprintf("%s=%s", this.Column, this.Expression)
+}
+
 // UniqueKey is the combination of a key's name and columns
 type UniqueKey struct {
 	Name             string
@@ -283,6 +408,42 @@ type UniqueKey struct {
 	Columns          ColumnList
 	HasNullable      bool
 	IsAutoIncrement  bool
+	// HasPrefixPart is set when some column is indexed by a prefix of its values, e.g. `email(20)`
+	HasPrefixPart bool
+	// HasFunctionalPart is set when some key part is an expression, e.g. `(lower(email))`. Such parts
+	// are not listed in Columns.
+	HasFunctionalPart bool
+	// IsFullRow is set for the key made of all of a keyless table's columns, see NewFullRowUniqueKey.
+	// No index of the original table backs it.
+	IsFullRow bool
+}
+
+const (
+	// MaxUniqueKeyColumns is the number of columns a MySQL index may have at most
+	MaxUniqueKeyColumns = 16
+	// MaxUniqueKeyByteLength is the length in bytes an InnoDB index may have at most
+	MaxUniqueKeyByteLength = 3072
+)
+
+// FullRowUniqueKeyName names the full row key, and the temporary unique index backing it on the ghost table
+const FullRowUniqueKeyName = "_gh_ost_full_row"
+
+// NewFullRowUniqueKey returns a key made of all given columns of a table that has no PRIMARY nor
+// UNIQUE key, identifying a row by its values. Nullability is read off the columns.
+func NewFullRowUniqueKey(columns *ColumnList) *UniqueKey {
+	uniqueKey := &UniqueKey{
+		Name:             FullRowUniqueKeyName,
+		NameInGhostTable: FullRowUniqueKeyName,
+		Columns:          *NewColumnList(columns.Names()),
+		IsFullRow:        true,
+	}
+	for i, column := range columns.Columns() {
+		uniqueKey.Columns.columns[i] = column
+		if column.Nullable {
+			uniqueKey.HasNullable = true
+		}
+	}
+	return uniqueKey
 }
 
 // IsPrimary checks if this unique key is primary
@@ -290,6 +451,39 @@ func (this *UniqueKey) IsPrimary() bool {
 	return this.Name == "PRIMARY"
 }
 
+// IsInteger checks whether all columns of this key are of integer types. Such keys are compact,
+// and typically monotonic, which makes for good locality when iterating the table.
+func (this *UniqueKey) IsInteger() bool {
+	for _, column := range this.Columns.Columns() {
+		if !integerColumnTypeRegexp.MatchString(strings.ToLower(column.MySQLType)) {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyByteLength returns the number of bytes the key's values take in an index, see Column.KeyByteLength().
+// ok is false if any column's length is unknown.
+func (this *UniqueKey) KeyByteLength() (length uint, ok bool) {
+	for _, column := range this.Columns.Columns() {
+		columnLength, ok := column.KeyByteLength()
+		if !ok {
+			return 0, false
+		}
+		length += columnLength
+	}
+	return length, true
+}
+
+// IndexName returns the name of the original table's index backing this key, or an empty string
+// for a full row key
+func (this *UniqueKey) IndexName() string {
+	if this.IsFullRow {
+		return ""
+	}
+	return this.Name
+}
+
 func (this *UniqueKey) Len() int {
 	return this.Columns.Len()
 }
@@ -302,6 +496,50 @@ func (this *UniqueKey) String() string {
 	return fmt.Sprintf("%s: %s; has nullable: %+v", description, this.Columns.Names(), this.HasNullable)
 }
 
+// TablePartitioning describes a table's partitioning, as found in information_schema.partitions
+type TablePartitioning struct {
+	Method           string
+	Expression       string
+	Partitions       []string
+	HasSubpartitions bool
+}
+
+// Columns returns the partitioning columns, or nil if the partitioning expression is anything
+// other than a plain list of columns (e.g. a function of a column)
+func (this *TablePartitioning) Columns() (columns []string) {
+	for _, token := range strings.Split(this.Expression, ",") {
+		column := strings.Trim(strings.TrimSpace(token), "`")
+		if !partitionColumnRegexp.MatchString(column) {
+			return nil
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// IsAlignedWith checks whether iterating given unique key partition by partition, in order of
+// partitions, visits rows in the key's order. This holds for RANGE partitioning by plain columns,
+// where either the partitioning columns are a prefix of the key, or the key is a prefix of them.
+func (this *TablePartitioning) IsAlignedWith(uniqueKey *UniqueKey) bool {
+	if this.HasSubpartitions || len(this.Partitions) == 0 {
+		return false
+	}
+	if method := strings.ToUpper(this.Method); method != "RANGE" && method != "RANGE COLUMNS" {
+		return false
+	}
+	partitionColumns := this.Columns()
+	if len(partitionColumns) == 0 {
+		return false
+	}
+	keyColumns := uniqueKey.Columns.Columns()
+	for i := 0; i < len(partitionColumns) && i < len(keyColumns); i++ {
+		if !strings.EqualFold(partitionColumns[i], keyColumns[i].Name) || keyColumns[i].IsDescending {
+			return false
+		}
+	}
+	return true
+}
+
 type ColumnValues struct {
 	abstractValues []interface{}
 	ValuesPointers []interface{}
@@ -65,3 +65,116 @@ func TestConvertArgCharsetDecoding(t *testing.T) {
 	str := col.convertArg(latin1Bytes, false)
 	require.Equal(t, "Garçon !", str)
 }
+
+func TestTablePartitioningIsAlignedWith(t *testing.T) {
+	uniqueKey := &UniqueKey{Name: "created_id_uidx", Columns: *NewColumnList([]string{"created_at", "id"})}
+	{
+		partitioning := &TablePartitioning{Method: "RANGE COLUMNS", Expression: "`created_at`", Partitions: []string{"p0", "p1"}}
+		require.Equal(t, []string{"created_at"}, partitioning.Columns())
+		require.True(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		partitioning := &TablePartitioning{Method: "RANGE COLUMNS", Expression: "`created_at`,`id`,`other`", Partitions: []string{"p0", "p1"}}
+		require.True(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		partitioning := &TablePartitioning{Method: "RANGE", Expression: "to_days(`created_at`)", Partitions: []string{"p0", "p1"}}
+		require.Nil(t, partitioning.Columns())
+		require.False(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		partitioning := &TablePartitioning{Method: "RANGE", Expression: "`id`", Partitions: []string{"p0", "p1"}}
+		require.False(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		partitioning := &TablePartitioning{Method: "LIST COLUMNS", Expression: "`created_at`", Partitions: []string{"p0", "p1"}}
+		require.False(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		partitioning := &TablePartitioning{Method: "RANGE COLUMNS", Expression: "`created_at`", Partitions: []string{"p0", "p1"}, HasSubpartitions: true}
+		require.False(t, partitioning.IsAlignedWith(uniqueKey))
+	}
+	{
+		descendingKey := &UniqueKey{Name: "created_id_uidx", Columns: *NewColumnList([]string{"created_at", "id"})}
+		descendingKey.Columns.GetColumn("created_at").IsDescending = true
+		partitioning := &TablePartitioning{Method: "RANGE COLUMNS", Expression: "`created_at`", Partitions: []string{"p0", "p1"}}
+		require.False(t, partitioning.IsAlignedWith(descendingKey))
+	}
+}
+
+func TestNewFullRowUniqueKey(t *testing.T) {
+	columns := NewColumnList([]string{"name", "position"})
+	columns.GetColumn("name").MySQLType = "varchar(64)"
+	uniqueKey := NewFullRowUniqueKey(columns)
+	require.True(t, uniqueKey.IsFullRow)
+	require.False(t, uniqueKey.HasNullable)
+	require.Equal(t, []string{"name", "position"}, uniqueKey.Columns.Names())
+	require.Equal(t, "varchar(64)", uniqueKey.Columns.GetColumn("name").MySQLType)
+	require.Equal(t, FullRowUniqueKeyName, uniqueKey.NameInGhostTable)
+	require.Empty(t, uniqueKey.IndexName())
+
+	columns.GetColumn("position").Nullable = true
+	require.True(t, NewFullRowUniqueKey(columns).HasNullable)
+
+	require.Equal(t, "PRIMARY", (&UniqueKey{Name: "PRIMARY"}).IndexName())
+}
+
+func TestColumnKeyByteLength(t *testing.T) {
+	keyByteLength := func(mysqlType string, octetLength uint) (uint, bool) {
+		column := &Column{MySQLType: mysqlType, OctetLength: octetLength}
+		return column.KeyByteLength()
+	}
+	for mysqlType, expected := range map[string]uint{
+		"tinyint(1)":          1,
+		"int(10) unsigned":    4,
+		"bigint":              8,
+		"double":              8,
+		"decimal(10,2)":       5,
+		"decimal(20,0)":       9,
+		"datetime":            5,
+		"datetime(6)":         8,
+		"timestamp(3)":        6,
+		"bit(9)":              2,
+		"enum('a','b')":       2,
+		"varbinary(16)":       16,
+		"varchar(255)":        1020,
+		"char(10)":            40,
+		"mediumint(8) signed": 3,
+	} {
+		length, ok := keyByteLength(mysqlType, map[string]uint{"varbinary(16)": 16, "varchar(255)": 1020, "char(10)": 40}[mysqlType])
+		require.True(t, ok, mysqlType)
+		require.Equal(t, expected, length, mysqlType)
+	}
+	for _, mysqlType := range []string{"text", "mediumblob", "json", "geometry", "varchar(16)"} {
+		_, ok := keyByteLength(mysqlType, 0)
+		require.False(t, ok, mysqlType)
+	}
+
+	uniqueKey := &UniqueKey{Columns: *NewColumnList([]string{"id", "name"})}
+	uniqueKey.Columns.GetColumn("id").MySQLType = "int"
+	uniqueKey.Columns.GetColumn("name").MySQLType = "varchar(10)"
+	uniqueKey.Columns.GetColumn("name").OctetLength = 40
+	length, ok := uniqueKey.KeyByteLength()
+	require.True(t, ok)
+	require.Equal(t, uint(44), length)
+	uniqueKey.Columns.GetColumn("name").MySQLType = "text"
+	_, ok = uniqueKey.KeyByteLength()
+	require.False(t, ok)
+}
+
+func TestParseColumnTransform(t *testing.T) {
+	transform, err := ParseColumnTransform("email_hash = SHA2(email, 256)")
+	require.NoError(t, err)
+	require.Equal(t, "email_hash", transform.Column)
+	require.Equal(t, "SHA2(email, 256)", transform.Expression)
+
+	transform, err = ParseColumnTransform("flag=IF(a=1, 'y', 'n')")
+	require.NoError(t, err)
+	require.Equal(t, "flag", transform.Column)
+	require.Equal(t, "IF(a=1, 'y', 'n')", transform.Expression)
+
+	for _, invalid := range []string{"", "email_hash", "=SHA2(email, 256)", "ssn="} {
+		_, err = ParseColumnTransform(invalid)
+		require.Error(t, err, invalid)
+	}
+}
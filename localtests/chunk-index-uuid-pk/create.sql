@@ -0,0 +1,29 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  uuid char(36) not null,
+  seq bigint unsigned not null,
+  code varchar(16) not null,
+  i int not null,
+  primary key(uuid),
+  unique key seq_uidx(seq),
+  unique key code_uidx(code)
+);
+
+insert into gh_ost_test values (uuid(), 1, 'c1', 11);
+insert into gh_ost_test values (uuid(), 2, 'c2', 13);
+insert into gh_ost_test values (uuid(), 3, 'c3', 17);
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test select uuid(), max(seq) + 1, concat('c', max(seq) + 1), 19 from gh_ost_test;
+  update gh_ost_test set i=i+1 where seq % 2 = 0;
+  delete from gh_ost_test where seq % 11 = 0;
+end ;;
@@ -0,0 +1,27 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  id int not null,
+  created_at datetime not null,
+  i int not null,
+  unique key created_id_uidx(created_at desc, id)
+);
+
+insert into gh_ost_test values (1, '2020-01-01 00:00:00', 11);
+insert into gh_ost_test values (2, '2020-01-01 00:00:00', 13);
+insert into gh_ost_test values (3, '2020-01-02 00:00:00', 17);
+insert into gh_ost_test values (4, '2019-12-31 00:00:00', 19);
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test select max(id) + 1, now() - interval (max(id) % 5) day, 23 from gh_ost_test;
+  update gh_ost_test set i=i+1 where id % 2 = 0;
+  delete from gh_ost_test where id % 7 = 0;
+end ;;
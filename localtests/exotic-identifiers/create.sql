@@ -0,0 +1,27 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  `key` int auto_increment,
+  `order` int not null,
+  `group` varchar(32) not null,
+  `col``tick` varchar(32),
+  `$price` decimal(10,2),
+  `1st` int,
+  primary key(`key`),
+  unique key `unique` (`order`, `group`)
+) auto_increment=1;
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test values (null, 11, uuid(), 'tick', 1.25, 1);
+  insert into gh_ost_test values (null, 13, uuid(), 'tock', 2.50, 2);
+  update gh_ost_test set `col``tick`='updated', `$price`=`$price`+1, `1st`=`1st`+1 where `key`=last_insert_id();
+  delete from gh_ost_test where `key`=last_insert_id()-1;
+end ;;
@@ -0,0 +1,23 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  id int auto_increment,
+  i int not null,
+  scratch varchar(32) not null default 'none',
+  notes text,
+  primary key(id)
+) auto_increment=1;
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test values (null, 11, 'scratch', 'some notes');
+  insert into gh_ost_test values (null, 13, 'scratch', 'more notes');
+  update gh_ost_test set i=i+1, scratch='updated', notes='updated notes' where id=last_insert_id();
+end ;;
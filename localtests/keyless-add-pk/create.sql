@@ -0,0 +1,25 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  i int not null,
+  name varchar(64) not null,
+  key i_idx(i)
+) auto_increment=1;
+
+insert into gh_ost_test values (11, uuid());
+insert into gh_ost_test values (13, uuid());
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test values (11, uuid());
+  insert into gh_ost_test values (13, uuid());
+  update gh_ost_test set i=i+1 where i=13 order by name limit 1;
+  delete from gh_ost_test where i=14 order by name limit 1;
+end ;;
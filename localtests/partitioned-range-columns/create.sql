@@ -0,0 +1,30 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  id int auto_increment,
+  i int not null,
+  ts timestamp,
+  primary key(id)
+) auto_increment=1
+partition by range columns (id) (
+  partition p0 values less than (5),
+  partition p1 values less than (10),
+  partition p2 values less than (50),
+  partition p3 values less than (maxvalue)
+);
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test values (null, 11, now());
+  insert into gh_ost_test values (null, 13, now());
+  insert into gh_ost_test values (null, 17, now());
+  update gh_ost_test set i=i+1 where id % 3 = 0;
+  delete from gh_ost_test where id % 7 = 0;
+end ;;
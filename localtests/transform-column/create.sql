@@ -0,0 +1,22 @@
+drop table if exists gh_ost_test;
+create table gh_ost_test (
+  id int auto_increment,
+  email varchar(64) not null,
+  ssn varchar(16),
+  primary key(id)
+) auto_increment=1;
+
+drop event if exists gh_ost_test;
+delimiter ;;
+create event gh_ost_test
+  on schedule every 1 second
+  starts current_timestamp
+  ends current_timestamp + interval 60 second
+  on completion not preserve
+  enable
+  do
+begin
+  insert into gh_ost_test values (null, concat('user', rand(), '@example.com'), '123-45-6789');
+  insert into gh_ost_test values (null, concat('user', rand(), '@example.com'), '987-65-4321');
+  update gh_ost_test set email=concat('updated', rand(), '@example.com') where id=last_insert_id();
+end ;;